@@ -0,0 +1,81 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// nodeBaseImagesAnnotationKey is the annotation of the nodes with the comma-separated images their node image
+// ships with, e.g. as captured by the bootstrap of a managed node pool when the node joins
+const nodeBaseImagesAnnotationKey = "kubefledged.io/base-images"
+
+// normalizedImageSet returns the set of the images normalized with the default registry
+func normalizedImageSet(imageList []string, defaultRegistry string) map[string]bool {
+	set := make(map[string]bool, len(imageList))
+	for _, image := range imageList {
+		if image = strings.TrimSpace(image); image != "" {
+			set[images.NormalizeImage(image, defaultRegistry)] = true
+		}
+	}
+	return set
+}
+
+// isBaseImage returns true if the image is one of the base images of the node: the images of --node-base-images
+// or of its base images annotation. The images are compared normalized e.g. nginx is docker.io/library/nginx:latest
+func (c *Controller) isBaseImage(node *corev1.Node, image string) bool {
+	annotation := node.Annotations[nodeBaseImagesAnnotationKey]
+	if len(c.baseImages) == 0 && annotation == "" {
+		return false
+	}
+	normalized := images.NormalizeImage(image, c.defaultImageRegistry)
+	if c.baseImages[normalized] {
+		return true
+	}
+	return annotation != "" && normalizedImageSet(strings.Split(annotation, ","), c.defaultImageRegistry)[normalized]
+}
+
+// withoutBaseImages returns the images of the list which are not base images of the node. The node already
+// ships with its base images, so they are neither pulled nor deleted
+func (c *Controller) withoutBaseImages(node *corev1.Node, imageList []string) []string {
+	var filtered []string
+	for _, image := range imageList {
+		if c.isBaseImage(node, image) {
+			glog.V(4).Infof("Skipping base image %s of node %s", image, node.Name)
+			continue
+		}
+		filtered = append(filtered, image)
+	}
+	return filtered
+}
+
+// withoutBaseImageWork returns the image work requests whose image is not a base image of their node
+func (c *Controller) withoutBaseImageWork(iwrs []images.ImageWorkRequest) []images.ImageWorkRequest {
+	var filtered []images.ImageWorkRequest
+	for _, iwr := range iwrs {
+		if c.isBaseImage(iwr.Node, iwr.Image) {
+			glog.V(4).Infof("Skipping base image %s of node %s", iwr.Image, iwr.Node.Name)
+			continue
+		}
+		filtered = append(filtered, iwr)
+	}
+	return filtered
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWithoutBaseImages(t *testing.T) {
+	controller, _, _ := newTestController(fakeclientset.NewSimpleClientset(), &kubefledgedclientsetfake.Clientset{})
+	controller.defaultImageRegistry = "docker.io"
+	node1 := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	node2 := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2",
+		Annotations: map[string]string{nodeBaseImagesAnnotationKey: "docker.io/library/foo:v1, registry.k8s.io/pause:3.9"}}}
+	tests := []struct {
+		name           string
+		nodeBaseImages []string
+		node           *corev1.Node
+		imageList      []string
+		expected       []string
+	}{
+		{name: "#1: No base images", nodeBaseImages: nil, node: node1,
+			imageList: []string{"foo:v1", "bar:v1"}, expected: []string{"foo:v1", "bar:v1"}},
+		{name: "#2: Base images of the flag", nodeBaseImages: []string{"docker.io/library/bar:v1"}, node: node1,
+			imageList: []string{"foo:v1", "bar:v1"}, expected: []string{"foo:v1"}},
+		{name: "#3: Base images of the node annotation", nodeBaseImages: nil, node: node2,
+			imageList: []string{"foo:v1", "bar:v1", "registry.k8s.io/pause:3.9"}, expected: []string{"bar:v1"}},
+		{name: "#4: Base images of the flag and the node annotation", nodeBaseImages: []string{"bar"}, node: node2,
+			imageList: []string{"foo:v1", "bar:latest", "bar:v1"}, expected: []string{"bar:v1"}},
+		{name: "#5: Base images of the node annotation on another node", nodeBaseImages: nil, node: node1,
+			imageList: []string{"foo:v1"}, expected: []string{"foo:v1"}},
+	}
+	for _, test := range tests {
+		controller.baseImages = normalizedImageSet(test.nodeBaseImages, controller.defaultImageRegistry)
+		if actual := controller.withoutBaseImages(test.node, test.imageList); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Test: %s failed: expectedImages=%v, actualImages=%v", test.name, test.expected, actual)
+		}
+	}
+
+	controller.baseImages = normalizedImageSet([]string{"foo:v1"}, controller.defaultImageRegistry)
+	iwrs := []images.ImageWorkRequest{{Image: "foo:v1", Node: node1}, {Image: "bar:v1", Node: node1}, {Image: "pause:3.9", Node: node2}}
+	if actual := controller.withoutBaseImageWork(iwrs); len(actual) != 2 || actual[0].Image != "bar:v1" || actual[1].Image != "pause:3.9" {
+		t.Errorf("Test: expectedWork=[bar:v1 pause:3.9], actualWork=%v", actual)
+	}
+}
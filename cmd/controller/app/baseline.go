@@ -0,0 +1,104 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/golang/glog"
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// baselineImageCacheName is the name of the image cache, in the namespace of the controller,
+// which caches the baseline images on all the nodes
+const baselineImageCacheName = "kubefledged-baseline"
+
+// baselineImageCache creates or updates the image cache of the baseline images. It is left
+// untouched when no baseline images are configured
+func (c *Controller) baselineImageCache() error {
+	if len(c.baselineImages) == 0 {
+		return nil
+	}
+	spec := v1alpha2.ImageCacheSpec{
+		CacheSpec: []v1alpha2.CacheSpecImages{{Images: c.baselineImages}},
+	}
+	imageCaches := c.kubefledgedclientset.KubefledgedV1alpha2().ImageCaches(c.fledgedNameSpace)
+	imageCache, err := imageCaches.Get(context.TODO(), baselineImageCacheName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		imageCache = &v1alpha2.ImageCache{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      baselineImageCacheName,
+				Namespace: c.fledgedNameSpace,
+				Labels:    map[string]string{"app": "kubefledged", "kubefledged": "kubefledged-baseline"},
+			},
+			Spec: spec,
+		}
+		if selector, err := labels.Parse(c.imageCacheLabelSelector); err == nil && !selector.Matches(labels.Set(imageCache.Labels)) {
+			glog.Warningf("Baseline image cache does not match imagecache label selector %q and won't be processed", c.imageCacheLabelSelector)
+		}
+		if _, err := imageCaches.Create(context.TODO(), imageCache, metav1.CreateOptions{}); err != nil {
+			glog.Errorf("Error creating baseline image cache: %v", err)
+			return err
+		}
+		glog.Infof("Baseline image cache(%s) created with %d images", baselineImageCacheName, len(c.baselineImages))
+		return nil
+	}
+	if err != nil {
+		glog.Errorf("Error getting baseline image cache: %v", err)
+		return err
+	}
+	if reflect.DeepEqual(imageCache.Spec, spec) {
+		glog.Info("Baseline image cache is up to date")
+		return nil
+	}
+	imageCache.Spec = spec
+	if _, err := imageCaches.Update(context.TODO(), imageCache, metav1.UpdateOptions{}); err != nil {
+		glog.Errorf("Error updating baseline image cache: %v", err)
+		return err
+	}
+	glog.Infof("Baseline image cache(%s) updated with %d images", baselineImageCacheName, len(c.baselineImages))
+	return nil
+}
+
+// isBaselineImageCache returns true if the image cache is the image cache of the baseline images
+func (c *Controller) isBaselineImageCache(imageCache *v1alpha2.ImageCache) bool {
+	return imageCache.Namespace == c.fledgedNameSpace && imageCache.Name == baselineImageCacheName
+}
+
+// withoutImages returns the image lists without the given images
+func withoutImages(imageLists [][]string, excluded []string) [][]string {
+	if len(excluded) == 0 {
+		return imageLists
+	}
+	exclude := make(map[string]bool, len(excluded))
+	for _, image := range excluded {
+		exclude[image] = true
+	}
+	filtered := make([][]string, len(imageLists))
+	for k, imageList := range imageLists {
+		for _, image := range imageList {
+			if !exclude[image] {
+				filtered[k] = append(filtered[k], image)
+			}
+		}
+	}
+	return filtered
+}
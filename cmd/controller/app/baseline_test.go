@@ -0,0 +1,83 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestBaselineImageCache(t *testing.T) {
+	existing := &v1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{Name: baselineImageCacheName, Namespace: fledgedNameSpace},
+		Spec: v1alpha2.ImageCacheSpec{
+			CacheSpec: []v1alpha2.CacheSpecImages{{Images: []string{"foo"}}},
+		},
+	}
+	tests := []struct {
+		name           string
+		baselineImages []string
+		existing       []runtime.Object
+		expectedImages []string
+	}{
+		{name: "#1: No baseline images", baselineImages: nil, existing: nil, expectedImages: nil},
+		{name: "#2: Create baseline image cache", baselineImages: []string{"foo", "bar"}, existing: nil, expectedImages: []string{"foo", "bar"}},
+		{name: "#3: Update baseline image cache", baselineImages: []string{"foo", "bar"}, existing: []runtime.Object{existing.DeepCopy()}, expectedImages: []string{"foo", "bar"}},
+		{name: "#4: Baseline image cache up to date", baselineImages: []string{"foo"}, existing: []runtime.Object{existing.DeepCopy()}, expectedImages: []string{"foo"}},
+	}
+	for _, test := range tests {
+		fledgedclientset := kubefledgedclientsetfake.NewSimpleClientset(test.existing...)
+		controller, _, _ := newTestController(fakeclientset.NewSimpleClientset(), fledgedclientset)
+		controller.baselineImages = test.baselineImages
+		if err := controller.baselineImageCache(); err != nil {
+			t.Errorf("Test: %s failed: expectedError=nil, actualError=%v", test.name, err)
+			continue
+		}
+		imageCache, err := fledgedclientset.KubefledgedV1alpha2().ImageCaches(fledgedNameSpace).Get(context.TODO(), baselineImageCacheName, metav1.GetOptions{})
+		if test.expectedImages == nil {
+			if err == nil {
+				t.Errorf("Test: %s failed: baseline image cache created", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: %s failed: error getting baseline image cache: %v", test.name, err)
+			continue
+		}
+		if actual := imageCache.Spec.CacheSpec[0].Images; !reflect.DeepEqual(actual, test.expectedImages) {
+			t.Errorf("Test: %s failed: expectedImages=%v, actualImages=%v", test.name, test.expectedImages, actual)
+		}
+	}
+}
+
+func TestWithoutImages(t *testing.T) {
+	imageLists := [][]string{{"foo", "bar"}, {"baz"}}
+	if actual := withoutImages(imageLists, nil); !reflect.DeepEqual(actual, imageLists) {
+		t.Errorf("Test: no excluded images failed: expected=%v, actual=%v", imageLists, actual)
+	}
+	expected := [][]string{{"bar"}, nil}
+	if actual := withoutImages(imageLists, []string{"foo", "baz"}); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Test: excluded images failed: expected=%v, actual=%v", expected, actual)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"github.com/golang/glog"
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	listers "github.com/senthilrch/kube-fledged/pkg/client/listers/kubefledged/v1alpha2"
+	"github.com/senthilrch/kube-fledged/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// CacheHitReporter watches pods getting scheduled and counts the images of the pods
+// which were already cached on the node by an image cache
+type CacheHitReporter struct {
+	nodesLister       corelisters.NodeLister
+	imageCachesLister listers.ImageCacheLister
+}
+
+// NewCacheHitReporter returns a new cache hit reporter
+func NewCacheHitReporter(
+	podInformer coreinformers.PodInformer,
+	nodesLister corelisters.NodeLister,
+	imageCachesLister listers.ImageCacheLister) *CacheHitReporter {
+
+	reporter := &CacheHitReporter{
+		nodesLister:       nodesLister,
+		imageCachesLister: imageCachesLister,
+	}
+	podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, new interface{}) {
+			oldPod := old.(*corev1.Pod)
+			newPod := new.(*corev1.Pod)
+			// count the pod once, when it is bound to a node
+			if oldPod.Spec.NodeName == "" && newPod.Spec.NodeName != "" {
+				reporter.reportCacheHits(newPod)
+			}
+		},
+	})
+	return reporter
+}
+
+// reportCacheHits increments the cache hit counter of every image cache holding an image of the pod on the pod's node
+func (r *CacheHitReporter) reportCacheHits(pod *corev1.Pod) {
+	// ignore the jobs created by kube-fledged for pulling and deleting images
+	if pod.Labels["app"] == "kubefledged" {
+		return
+	}
+	node, err := r.nodesLister.Get(pod.Spec.NodeName)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	imageCaches, err := r.imageCachesLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for _, container := range containers {
+		for _, imageCache := range imageCaches {
+			if imageCachedOnNode(imageCache, container.Image, node) {
+				glog.V(4).Infof("Image %s of pod %s/%s cached on node %s by image cache %s/%s", container.Image,
+					pod.Namespace, pod.Name, node.Name, imageCache.Namespace, imageCache.Name)
+				metrics.CacheHits.WithLabelValues(imageCache.Namespace, imageCache.Name).Inc()
+			}
+		}
+	}
+}
+
+// imageCachedOnNode returns true if the image is listed in the image cache for the node
+// and the last pull of the image to the node did not fail
+func imageCachedOnNode(imageCache *v1alpha2.ImageCache, image string, node *corev1.Node) bool {
+	if imageCache.Status.Status != v1alpha2.ImageCacheActionStatusSucceeded &&
+		imageCache.Status.Status != v1alpha2.ImageCacheActionStatusFailed ||
+		imageCache.Status.Reason == v1alpha2.ImageCacheReasonImageCachePurge {
+		return false
+	}
+	for _, failure := range imageCache.Status.Failures[image] {
+		if failure.Node == node.Labels["kubernetes.io/hostname"] {
+			return false
+		}
+	}
+	for _, cacheSpec := range imageCache.Spec.CacheSpec {
+		if !labels.SelectorFromSet(cacheSpec.NodeSelector).Matches(labels.Set(node.Labels)) {
+			continue
+		}
+		for _, cachedImage := range cacheSpec.Images {
+			if cachedImage == image {
+				return true
+			}
+		}
+	}
+	return false
+}
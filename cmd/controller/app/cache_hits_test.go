@@ -0,0 +1,136 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	fledgedinformers "github.com/senthilrch/kube-fledged/pkg/client/informers/externalversions"
+	"github.com/senthilrch/kube-fledged/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReportCacheHits(t *testing.T) {
+	node := corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "fakenode",
+			Labels: map[string]string{"kubernetes.io/hostname": "fakenode", "foo": "bar"},
+		},
+	}
+	imageCache := v1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cachehits",
+			Namespace: fledgedNameSpace,
+		},
+		Spec: v1alpha2.ImageCacheSpec{
+			CacheSpec: []v1alpha2.CacheSpecImages{
+				{
+					Images:       []string{"foo:v1", "failed:v1"},
+					NodeSelector: map[string]string{"foo": "bar"},
+				},
+				{
+					Images:       []string{"othernodes:v1"},
+					NodeSelector: map[string]string{"foo": "baz"},
+				},
+			},
+		},
+		Status: v1alpha2.ImageCacheStatus{
+			Status: v1alpha2.ImageCacheActionStatusFailed,
+			Failures: map[string]v1alpha2.NodeReasonMessageList{
+				"failed:v1": {{Node: "fakenode", Reason: "ErrImagePull"}},
+			},
+		},
+	}
+	tests := []struct {
+		name         string
+		pod          corev1.Pod
+		expectedHits float64
+	}{
+		{
+			name: "#1: Image cached on the node",
+			pod: corev1.Pod{
+				Spec: corev1.PodSpec{NodeName: "fakenode", Containers: []corev1.Container{{Image: "foo:v1"}}},
+			},
+			expectedHits: 1,
+		},
+		{
+			name: "#2: Image pull to the node failed",
+			pod: corev1.Pod{
+				Spec: corev1.PodSpec{NodeName: "fakenode", Containers: []corev1.Container{{Image: "failed:v1"}}},
+			},
+			expectedHits: 0,
+		},
+		{
+			name: "#3: Image cached on other nodes only",
+			pod: corev1.Pod{
+				Spec: corev1.PodSpec{NodeName: "fakenode", Containers: []corev1.Container{{Image: "othernodes:v1"}}},
+			},
+			expectedHits: 0,
+		},
+		{
+			name: "#4: Image not in any image cache",
+			pod: corev1.Pod{
+				Spec: corev1.PodSpec{NodeName: "fakenode", Containers: []corev1.Container{{Image: "notcached:v1"}}},
+			},
+			expectedHits: 0,
+		},
+		{
+			name: "#5: Init container and container images cached",
+			pod: corev1.Pod{
+				Spec: corev1.PodSpec{
+					NodeName:       "fakenode",
+					InitContainers: []corev1.Container{{Image: "foo:v1"}},
+					Containers:     []corev1.Container{{Image: "foo:v1"}},
+				},
+			},
+			expectedHits: 2,
+		},
+		{
+			name: "#6: Pod of an image pull job",
+			pod: corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "kubefledged"}},
+				Spec:       corev1.PodSpec{NodeName: "fakenode", Containers: []corev1.Container{{Image: "foo:v1"}}},
+			},
+			expectedHits: 0,
+		},
+	}
+	for _, test := range tests {
+		fakekubeclientset := &fakeclientset.Clientset{}
+		fakefledgedclientset := &kubefledgedclientsetfake.Clientset{}
+		kubeInformerFactory := kubeinformers.NewSharedInformerFactory(fakekubeclientset, noResyncPeriodFunc())
+		fledgedInformerFactory := fledgedinformers.NewSharedInformerFactory(fakefledgedclientset, noResyncPeriodFunc())
+		nodeInformer := kubeInformerFactory.Core().V1().Nodes()
+		imageCacheInformer := fledgedInformerFactory.Kubefledged().V1alpha2().ImageCaches()
+		nodeInformer.Informer().GetIndexer().Add(&node)
+		imageCacheInformer.Informer().GetIndexer().Add(&imageCache)
+		reporter := NewCacheHitReporter(kubeInformerFactory.Core().V1().Pods(), nodeInformer.Lister(), imageCacheInformer.Lister())
+
+		metrics.CacheHits.Reset()
+		reporter.reportCacheHits(&test.pod)
+		hits := testutil.ToFloat64(metrics.CacheHits.WithLabelValues(fledgedNameSpace, "cachehits"))
+		if hits != test.expectedHits {
+			t.Errorf("Test: %s failed: expectedHits=%v, actualHits=%v", test.name, test.expectedHits, hits)
+		}
+	}
+	t.Logf("%d tests passed", len(tests))
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package app
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	"github.com/senthilrch/kube-fledged/pkg/metrics"
+)
+
+// Status label values of the kubefledged_cache_images_total metric
+const (
+	cacheImagesStatusCached = "cached"
+	cacheImagesStatusFailed = "failed"
+)
+
+// cacheCoverage is the image and node coverage of an image cache. An image is cached if it was pulled to
+// (or already present on) all its nodes. A node is covered if all the images meant for it were cached
+type cacheCoverage struct {
+	cachedImages int
+	failedImages int
+	nodesCovered int
+	nodes        int
+}
+
+// coverageOf returns the coverage of the image cache from the results of its latest reconcile, and whether
+// the reconcile was a purge
+func coverageOf(results map[string]images.ImageWorkResult) (coverage cacheCoverage, purged bool) {
+	failedImages := map[string]bool{}
+	allImages := map[string]bool{}
+	failedNodes := map[string]bool{}
+	allNodes := map[string]bool{}
+	for _, result := range results {
+		if result.ImageWorkRequest.WorkType == images.ImageCachePurge {
+			return cacheCoverage{}, true
+		}
+		// a deleted node is neither covered nor failed
+		if result.Status == images.ImageWorkResultStatusNodeDeleted {
+			continue
+		}
+		image := result.ImageWorkRequest.Image
+		node := result.ImageWorkRequest.Node.Labels["kubernetes.io/hostname"]
+		allImages[image] = true
+		allNodes[node] = true
+		if result.Status != images.ImageWorkResultStatusSucceeded && result.Status != images.ImageWorkResultStatusAlreadyPulled {
+			failedImages[image] = true
+			failedNodes[node] = true
+		}
+	}
+	return cacheCoverage{
+		cachedImages: len(allImages) - len(failedImages),
+		failedImages: len(failedImages),
+		nodesCovered: len(allNodes) - len(failedNodes),
+		nodes:        len(allNodes),
+	}, false
+}
+
+// recordCacheMetrics sets the image and node coverage gauges of the image cache from the results of
+// its latest reconcile. The gauges of a purged image cache are removed
+func recordCacheMetrics(cacheKey string, results map[string]images.ImageWorkResult) {
+	coverage, purged := coverageOf(results)
+	if purged {
+		deleteCacheMetrics(cacheKey)
+		return
+	}
+	metrics.CacheImages.WithLabelValues(cacheKey, cacheImagesStatusCached).Set(float64(coverage.cachedImages))
+	metrics.CacheImages.WithLabelValues(cacheKey, cacheImagesStatusFailed).Set(float64(coverage.failedImages))
+	metrics.CacheNodesCovered.WithLabelValues(cacheKey).Set(float64(coverage.nodesCovered))
+}
+
+// deleteCacheMetrics removes the gauges and the deadline timeouts counter of the image cache
+func deleteCacheMetrics(cacheKey string) {
+	metrics.CacheImages.DeletePartialMatch(prometheus.Labels{"cache": cacheKey})
+	metrics.CacheNodesCovered.DeleteLabelValues(cacheKey)
+	metrics.CacheStale.DeleteLabelValues(cacheKey)
+	metrics.DeadlineTimeouts.DeleteLabelValues(cacheKey)
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package app
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	"github.com/senthilrch/kube-fledged/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRecordCacheMetrics(t *testing.T) {
+	nodeOf := func(hostname string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"kubernetes.io/hostname": hostname}}}
+	}
+	result := func(image, hostname, status string, workType images.WorkType) images.ImageWorkResult {
+		return images.ImageWorkResult{
+			ImageWorkRequest: images.ImageWorkRequest{Image: image, Node: nodeOf(hostname), WorkType: workType},
+			Status:           status,
+		}
+	}
+	cacheKey := "kube-fledged/foo"
+	recordCacheMetrics(cacheKey, map[string]images.ImageWorkResult{
+		"job1": result("foo:v1", "node1", images.ImageWorkResultStatusSucceeded, images.ImageCacheCreate),
+		"job2": result("foo:v1", "node2", images.ImageWorkResultStatusAlreadyPulled, images.ImageCacheCreate),
+		"job3": result("bar:v1", "node1", images.ImageWorkResultStatusSucceeded, images.ImageCacheCreate),
+		"job4": result("bar:v1", "node2", images.ImageWorkResultStatusFailed, images.ImageCacheCreate),
+		"job5": result("baz:v1", "node3", images.ImageWorkResultStatusSucceeded, images.ImageCacheCreate),
+		"job6": result("baz:v1", "node4", images.ImageWorkResultStatusNodeDeleted, images.ImageCacheCreate),
+	})
+	if actual := testutil.ToFloat64(metrics.CacheImages.WithLabelValues(cacheKey, cacheImagesStatusCached)); actual != 2 {
+		t.Errorf("Test: cached images failed: expected=2, actual=%v", actual)
+	}
+	if actual := testutil.ToFloat64(metrics.CacheImages.WithLabelValues(cacheKey, cacheImagesStatusFailed)); actual != 1 {
+		t.Errorf("Test: failed images failed: expected=1, actual=%v", actual)
+	}
+	if actual := testutil.ToFloat64(metrics.CacheNodesCovered.WithLabelValues(cacheKey)); actual != 2 {
+		t.Errorf("Test: nodes covered failed: expected=2, actual=%v", actual)
+	}
+
+	// the gauges of a purged image cache are removed
+	recordCacheMetrics(cacheKey, map[string]images.ImageWorkResult{
+		"job7": result("foo:v1", "node1", images.ImageWorkResultStatusSucceeded, images.ImageCachePurge),
+	})
+	if count := metrics.CacheImages.DeletePartialMatch(prometheus.Labels{"cache": cacheKey}); count != 0 {
+		t.Errorf("Test: purge failed: expected no image gauges, actual=%d", count)
+	}
+	if metrics.CacheNodesCovered.DeleteLabelValues(cacheKey) {
+		t.Errorf("Test: purge failed: node gauge not removed")
+	}
+}
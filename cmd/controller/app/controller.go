@@ -18,8 +18,13 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang/glog"
@@ -29,16 +34,25 @@ import (
 	informers "github.com/senthilrch/kube-fledged/pkg/client/informers/externalversions/kubefledged/v1alpha2"
 	listers "github.com/senthilrch/kube-fledged/pkg/client/listers/kubefledged/v1alpha2"
 	"github.com/senthilrch/kube-fledged/pkg/images"
+	"github.com/senthilrch/kube-fledged/pkg/tracing"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	appsinformers "k8s.io/client-go/informers/apps/v1"
+	batchinformers "k8s.io/client-go/informers/batch/v1"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
@@ -48,6 +62,11 @@ import (
 const controllerAgentName = "kubefledged-controller"
 const imageCachePurgeAnnotationKey = "kubefledged.io/purge-imagecache"
 const imageCacheRefreshAnnotationKey = "kubefledged.io/refresh-imagecache"
+const imageCacheRefreshNowAnnotationKey = "kubefledged.io/refresh-now"
+const nodeSkipCacheAnnotationKey = "kubefledged.io/skip-cache"
+
+// reconcileRequeueDelay is the delay after which a reconcile held back by the active reconcile of its image cache is retried
+const reconcileRequeueDelay = 5 * time.Second
 
 const (
 	// SuccessSynced is used as part of the Event 'reason' when a ImageCache is synced
@@ -69,6 +88,15 @@ type Controller struct {
 	nodesSynced       cache.InformerSynced
 	imageCachesLister listers.ImageCacheLister
 	imageCachesSynced cache.InformerSynced
+	// configMapsLister is nil unless the ConfigMaps referenced in imagesFrom are watched
+	configMapsLister corelisters.ConfigMapLister
+	configMapsSynced cache.InformerSynced
+	// deploymentsLister and statefulSetsLister list the workloads opted in to caching. They are
+	// nil unless workload image caches are enabled
+	deploymentsLister  appslisters.DeploymentLister
+	deploymentsSynced  cache.InformerSynced
+	statefulSetsLister appslisters.StatefulSetLister
+	statefulSetsSynced cache.InformerSynced
 
 	// workqueue is a rate limited work queue. This is used to queue work to be
 	// processed instead of performing it as soon as a change happens. This
@@ -82,6 +110,100 @@ type Controller struct {
 	// Kubernetes API.
 	recorder                   record.EventRecorder
 	imageCacheRefreshFrequency time.Duration
+	imageCacheLabelSelector    string
+	baselineImages             []string
+	// updateDebounceWindow is how long an update waits in the workqueue, so that the updates
+	// of a burst are reconciled together. pendingUpdates has the keys of such waiting updates
+	updateDebounceWindow time.Duration
+	pendingUpdates       map[string]bool
+	pendingUpdatesLock   sync.Mutex
+	// resumedImageCaches has the keys of the image caches under processing when the controller stopped, whose
+	// jobs were adopted by the image manager. Their reconciles are resumed instead of being aborted
+	resumedImageCaches map[string]bool
+	// activeReconciles has the start time of the create, update, refresh or purge of each image cache
+	// whose image work is in flight. Another such work type of the image cache waits until the status
+	// of the active one is updated, or until reconcileTimeout has passed
+	activeReconciles     map[string]time.Time
+	activeReconcilesLock sync.Mutex
+	reconcileTimeout     time.Duration
+	// staleAfter is how long after its last successful refresh an image cache is stale. If 0, it is
+	// staleRefreshFactor times the refresh frequency of the image cache
+	staleAfter time.Duration
+	// lastProgress is the time, in unix nanoseconds, a worker last picked up or completed a work item.
+	// The watchdog checks it every watchdogWindow, if set
+	lastProgress   atomic.Int64
+	watchdogWindow time.Duration
+	watchdogCrash  bool
+	// listTags lists the tags of the repositories of the image lists
+	listTags func(repository string) ([]string, error)
+	// cronJobsLister lists the CronJobs ahead of whose runs image caches are pre-warmed. It is nil
+	// if pre-warming is disabled. preWarmedRuns has the scheduled run each image cache was last
+	// pre-warmed for, until it is purged. It is only accessed by the pre-warm worker
+	cronJobsLister batchlisters.CronJobLister
+	cronJobsSynced cache.InformerSynced
+	preWarmedRuns  map[string]time.Time
+	// startupDelay defers the first refresh of the image caches after the controller starts
+	startupDelay time.Duration
+	// statusConfigMap is the ConfigMap in the namespace of kubefledged to which the summaries of the image
+	// caches are written, if set. statusSummariesLoaded is set once the summaries the ConfigMap had when
+	// the controller started are loaded
+	statusConfigMap       string
+	statusSummaries       map[string]cacheStatusSummary
+	statusSummariesLoaded bool
+	statusSummariesLock   sync.Mutex
+	// supportedRuntimes are the container runtimes of the nodes images are cached on. Nodes with
+	// other runtimes are skipped. All runtimes are supported if it is empty
+	supportedRuntimes []string
+	// zoneBalancedPulls orders the nodes of an image list round-robin across their zones when queueing pulls
+	zoneBalancedPulls bool
+	// maintenanceKey is the key of the taint or annotation of nodes under maintenance, which are skipped
+	// until it is removed. No node is under maintenance if it is empty
+	maintenanceKey string
+	// refreshLeaseNamespace and refreshLeaseName are the Lease renewed after each refresh of the image caches.
+	// No lease is renewed if refreshLeaseName is empty
+	refreshLeaseNamespace string
+	refreshLeaseName      string
+	// lastRefreshes are the times at which the refresh worker last refreshed the image caches, by key
+	lastRefreshes map[string]time.Time
+	// quietHours are the time ranges in which the periodic refreshes and the pre-warms are deferred
+	quietHours QuietHours
+	// baseImages are the normalized images all the nodes ship with, which are neither pulled nor deleted.
+	// Images are normalized with defaultImageRegistry
+	baseImages           map[string]bool
+	defaultImageRegistry string
+	// watchNamespace is the namespace of the image caches and their jobs, or metav1.NamespaceAll if the
+	// controller watches all the namespaces
+	watchNamespace string
+	// imageHistory has the latest results of each image of the image caches on each of its nodes, to detect
+	// the images flapping between cached and failed
+	imageHistory     map[imageHistoryKey][]imageHistoryEntry
+	imageHistoryLock sync.Mutex
+	// maxImagesPerCache is the maximum no. of images of an image cache once its image lists are expanded with
+	// imagesFrom and repositories. Zero means there is no limit
+	maxImagesPerCache int
+}
+
+// ControllerConfig has the settings of the controller, which are the flags of kubefledged-controller of the
+// same names
+type ControllerConfig struct {
+	images.ImageManagerConfig
+	ImageCacheRefreshFrequency time.Duration
+	ImageCacheLabelSelector    string
+	BaselineImages             []string
+	UpdateDebounceWindow       time.Duration
+	WatchdogWindow             time.Duration
+	WatchdogCrash              bool
+	StartupDelay               time.Duration
+	StatusConfigMap            string
+	SupportedRuntimes          []string
+	ZoneBalancedPulls          bool
+	MaintenanceKey             string
+	RefreshLeaseNamespace      string
+	RefreshLeaseName           string
+	QuietHours                 QuietHours
+	NodeBaseImages             []string
+	StaleAfter                 time.Duration
+	MaxImagesPerCache          int
 }
 
 // NewController returns a new fledged controller
@@ -91,16 +213,11 @@ func NewController(
 	namespace string,
 	nodeInformer coreinformers.NodeInformer,
 	imageCacheInformer informers.ImageCacheInformer,
-	imageCacheRefreshFrequency time.Duration,
-	imagePullDeadlineDuration time.Duration,
-	criClientImage string,
-	busyboxImage string,
-	imagePullPolicy string,
-	serviceAccountName string,
-	imageDeleteJobHostNetwork bool,
-	jobPriorityClassName string,
-	canDeleteJob bool,
-	criSocketPath string) *Controller {
+	configMapInformer coreinformers.ConfigMapInformer,
+	deploymentInformer appsinformers.DeploymentInformer,
+	statefulSetInformer appsinformers.StatefulSetInformer,
+	cronJobInformer batchinformers.CronJobInformer,
+	config ControllerConfig) *Controller {
 
 	runtime.Must(fledgedscheme.AddToScheme(scheme.Scheme))
 	glog.V(4).Info("Creating event broadcaster")
@@ -120,13 +237,45 @@ func NewController(
 		workqueue:                  workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "ImageCaches"),
 		imageworkqueue:             workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "ImagePullerStatus"),
 		recorder:                   recorder,
-		imageCacheRefreshFrequency: imageCacheRefreshFrequency,
+		imageCacheRefreshFrequency: config.ImageCacheRefreshFrequency,
+		imageCacheLabelSelector:    config.ImageCacheLabelSelector,
+		baselineImages:             config.BaselineImages,
+		updateDebounceWindow:       config.UpdateDebounceWindow,
+		pendingUpdates:             map[string]bool{},
+		resumedImageCaches:         map[string]bool{},
+		activeReconciles:           map[string]time.Time{},
+		reconcileTimeout:           2 * config.ImagePullDeadlineDuration,
+		staleAfter:                 config.StaleAfter,
+		watchdogWindow:             config.WatchdogWindow,
+		watchdogCrash:              config.WatchdogCrash,
+		listTags:                   images.ListTags,
+		preWarmedRuns:              map[string]time.Time{},
+		startupDelay:               config.StartupDelay,
+		statusConfigMap:            config.StatusConfigMap,
+		statusSummaries:            map[string]cacheStatusSummary{},
+		supportedRuntimes:          config.SupportedRuntimes,
+		zoneBalancedPulls:          config.ZoneBalancedPulls,
+		watchNamespace:             config.WatchNamespace,
+		maintenanceKey:             config.MaintenanceKey,
+		refreshLeaseNamespace:      config.RefreshLeaseNamespace,
+		refreshLeaseName:           config.RefreshLeaseName,
+		lastRefreshes:              map[string]time.Time{},
+		quietHours:                 config.QuietHours,
+		baseImages:                 normalizedImageSet(config.NodeBaseImages, config.DefaultImageRegistry),
+		defaultImageRegistry:       config.DefaultImageRegistry,
+		imageHistory:               map[imageHistoryKey][]imageHistoryEntry{},
+		maxImagesPerCache:          config.MaxImagesPerCache,
+	}
+	if config.ImagePullDeadlineMax > config.ImagePullDeadlineDuration {
+		controller.reconcileTimeout = 2 * config.ImagePullDeadlineMax
+	}
+	if cronJobInformer != nil {
+		controller.cronJobsLister = cronJobInformer.Lister()
+		controller.cronJobsSynced = cronJobInformer.Informer().HasSynced
 	}
 
 	imageManager, _ := images.NewImageManager(controller.workqueue, controller.imageworkqueue,
-		controller.kubeclientset, controller.fledgedNameSpace, imagePullDeadlineDuration,
-		criClientImage, busyboxImage, imagePullPolicy, serviceAccountName, imageDeleteJobHostNetwork,
-		jobPriorityClassName, canDeleteJob, criSocketPath)
+		controller.kubeclientset, controller.fledgedNameSpace, controller.cachedImages, config.ImageManagerConfig)
 	controller.imageManager = imageManager
 
 	glog.Info("Setting up event handlers")
@@ -142,17 +291,160 @@ func NewController(
 			controller.enqueueImageCache(images.ImageCacheDelete, obj, nil)
 		},
 	})
+	// Set up event handlers for when workloads opted in to caching change
+	if deploymentInformer != nil && statefulSetInformer != nil {
+		controller.deploymentsLister = deploymentInformer.Lister()
+		controller.deploymentsSynced = deploymentInformer.Informer().HasSynced
+		controller.statefulSetsLister = statefulSetInformer.Lister()
+		controller.statefulSetsSynced = statefulSetInformer.Informer().HasSynced
+		workloadHandler := cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				controller.enqueueWorkload(nil, obj)
+			},
+			UpdateFunc: func(old, new interface{}) {
+				controller.enqueueWorkload(old, new)
+			},
+			DeleteFunc: func(obj interface{}) {
+				controller.enqueueWorkload(obj, nil)
+			},
+		}
+		deploymentInformer.Informer().AddEventHandler(workloadHandler)
+		statefulSetInformer.Informer().AddEventHandler(workloadHandler)
+	}
+	// Set up an event handler for when nodes are deleted e.g. on scale-down by the cluster autoscaler
+	nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, new interface{}) {
+			controller.imageManager.InvalidateNodeImages(new.(*corev1.Node).Name)
+			if controller.underMaintenance(old.(*corev1.Node)) && !controller.underMaintenance(new.(*corev1.Node)) {
+				controller.enqueueImageCachesAfterMaintenance(new.(*corev1.Node))
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			node, ok := obj.(*corev1.Node)
+			if !ok {
+				tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					return
+				}
+				if node, ok = tombstone.Obj.(*corev1.Node); !ok {
+					return
+				}
+			}
+			controller.imageManager.InvalidateNodeImages(node.Name)
+			for _, iwr := range controller.imageManager.HandleNodeDeletion(node) {
+				controller.imageManager.RetargetImageWork(iwr, controller.retargetNode(iwr))
+			}
+		},
+	})
+	// Set up an event handler for when ConfigMaps referenced in imagesFrom change
+	if configMapInformer != nil {
+		controller.configMapsLister = configMapInformer.Lister()
+		controller.configMapsSynced = configMapInformer.Informer().HasSynced
+		configMapInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(old, new interface{}) {
+				oldConfigMap := old.(*corev1.ConfigMap)
+				newConfigMap := new.(*corev1.ConfigMap)
+				if reflect.DeepEqual(oldConfigMap.Data, newConfigMap.Data) {
+					return
+				}
+				controller.enqueueImageCachesReferencingConfigMap(newConfigMap)
+			},
+		})
+	}
 	return controller
 }
 
 // PreFlightChecks performs pre-flight checks and actions before the controller is started
 func (c *Controller) PreFlightChecks() error {
+	if err := c.fledgedNamespaceCheck(); err != nil {
+		return err
+	}
+	if err := c.permissionsCheck(); err != nil {
+		return err
+	}
 	if err := c.danglingJobs(); err != nil {
 		return err
 	}
 	if err := c.danglingImageCaches(); err != nil {
 		return err
 	}
+	if err := c.baselineImageCache(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// fledgedNamespaceCheck returns an error if the namespace of kubefledged does not exist, and warns of the resource
+// quotas of the namespace which would reject the pods of the jobs pulling and deleting images. Quotas of pods or
+// jobs with no headroom left do, and so do quotas of compute resources, since the pods of the jobs request none
+func (c *Controller) fledgedNamespaceCheck() error {
+	_, err := c.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), c.fledgedNameSpace, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		glog.Errorf("Namespace %s of kubefledged not found: create it or set KUBEFLEDGED_NAMESPACE to an existing namespace", c.fledgedNameSpace)
+		return err
+	}
+	if err != nil {
+		glog.Warningf("Error getting namespace %s, skipping namespace check: %v", c.fledgedNameSpace, err)
+		return nil
+	}
+	quotas, err := c.kubeclientset.CoreV1().ResourceQuotas(c.fledgedNameSpace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		glog.Warningf("Error listing resource quotas of namespace %s, skipping quota check: %v", c.fledgedNameSpace, err)
+		return nil
+	}
+	for _, quota := range quotas.Items {
+		for name, hard := range quota.Status.Hard {
+			switch name {
+			case corev1.ResourcePods, "count/pods", "count/jobs.batch":
+				used := quota.Status.Used[name]
+				if used.Cmp(hard) >= 0 {
+					glog.Warningf("Resource quota %s of namespace %s has no %s left (used %s of %s): jobs pulling and deleting images in the namespace will fail to be created",
+						quota.Name, c.fledgedNameSpace, name, used.String(), hard.String())
+				}
+			case corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceRequestsCPU, corev1.ResourceRequestsMemory,
+				corev1.ResourceLimitsCPU, corev1.ResourceLimitsMemory:
+				glog.Warningf("Resource quota %s of namespace %s limits %s: the pods of jobs pulling and deleting images request no resources, and are rejected unless a LimitRange of the namespace sets default requests and limits",
+					quota.Name, c.fledgedNameSpace, name)
+			}
+		}
+	}
+	return nil
+}
+
+// preFlightPermissions are the permissions in the namespace of kubefledged without which image caches fail
+var preFlightPermissions = []authorizationv1.ResourceAttributes{
+	{Verb: "create", Group: "batch", Resource: "jobs"},
+	{Verb: "delete", Group: "batch", Resource: "jobs"},
+	{Verb: "list", Resource: "pods"},
+	{Verb: "list", Resource: "events"},
+}
+
+// permissionsCheck returns an error naming the permissions in the namespace of kubefledged which the controller
+// lacks, as per a SelfSubjectAccessReview of each of them. Permissions which could not be reviewed are skipped
+func (c *Controller) permissionsCheck() error {
+	var missing []string
+	for _, attributes := range preFlightPermissions {
+		attributes.Namespace = c.fledgedNameSpace
+		resource := attributes.Resource
+		if attributes.Group != "" {
+			resource += "." + attributes.Group
+		}
+		review, err := c.kubeclientset.AuthorizationV1().SelfSubjectAccessReviews().Create(context.TODO(),
+			&authorizationv1.SelfSubjectAccessReview{Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &attributes}},
+			metav1.CreateOptions{})
+		if err != nil {
+			glog.Warningf("Error reviewing permission to %s %s, skipping permission check: %v", attributes.Verb, resource, err)
+			continue
+		}
+		if !review.Status.Allowed {
+			missing = append(missing, attributes.Verb+" "+resource)
+		}
+	}
+	if len(missing) > 0 {
+		err := fmt.Errorf("kubefledged-controller is not permitted to %s in namespace %s", strings.Join(missing, ", "), c.fledgedNameSpace)
+		glog.Errorf("%v: grant the permissions to its service account in its ClusterRole", err)
+		return err
+	}
 	return nil
 }
 
@@ -163,7 +455,7 @@ func (c *Controller) danglingJobs() error {
 	labelSelector := labels.NewSelector()
 	labelSelector = labelSelector.Add(*appEqKubefledged, *kubefledgedEqImagemanager)
 
-	joblist, err := c.kubeclientset.BatchV1().Jobs("").List(context.TODO(), metav1.ListOptions{
+	joblist, err := c.kubeclientset.BatchV1().Jobs(c.watchNamespace).List(context.TODO(), metav1.ListOptions{
 		LabelSelector: labelSelector.String(),
 	})
 	if err != nil {
@@ -175,8 +467,43 @@ func (c *Controller) danglingJobs() error {
 		glog.Info("No dangling or stuck jobs found...")
 		return nil
 	}
+	var managedImageCaches map[string]bool
+	if c.imageCacheLabelSelector != "" {
+		// When an imagecache label selector is set, other controller instances may own
+		// the remaining jobs. Only delete jobs of image caches matching the selector.
+		imagecachelist, err := c.kubefledgedclientset.KubefledgedV1alpha2().ImageCaches(c.watchNamespace).List(context.TODO(), metav1.ListOptions{
+			LabelSelector: c.imageCacheLabelSelector,
+		})
+		if err != nil {
+			glog.Errorf("Error listing imagecaches: %v", err)
+			return err
+		}
+		managedImageCaches = map[string]bool{}
+		for _, imagecache := range imagecachelist.Items {
+			managedImageCaches[imagecache.Namespace+"/"+imagecache.Name] = true
+		}
+	}
+	var processingImageCaches map[string]bool
+	var adopted []batchv1.Job
 	deletePropagation := metav1.DeletePropagationBackground
 	for _, job := range joblist.Items {
+		key := job.Namespace + "/" + job.Labels["imagecache"]
+		if managedImageCaches != nil && !managedImageCaches[key] {
+			continue
+		}
+		// the pull and delete jobs of the reconciles under processing are adopted by their resumed reconciles
+		if images.IsResumableJob(&job) {
+			if processingImageCaches == nil {
+				if processingImageCaches, err = c.processingImageCaches(); err != nil {
+					return err
+				}
+			}
+			if processingImageCaches[key] {
+				adopted = append(adopted, job)
+				c.resumedImageCaches[key] = true
+				continue
+			}
+		}
 		err := c.kubeclientset.BatchV1().Jobs(job.Namespace).
 			Delete(context.TODO(), job.Name, metav1.DeleteOptions{PropagationPolicy: &deletePropagation})
 		if err != nil {
@@ -185,6 +512,7 @@ func (c *Controller) danglingJobs() error {
 		}
 		glog.Infof("Dangling Job(%s) deleted", job.Name)
 	}
+	c.imageManager.AdoptJobs(adopted)
 	return nil
 }
 
@@ -192,7 +520,9 @@ func (c *Controller) danglingJobs() error {
 // image caches will get refreshed in the next cycle
 func (c *Controller) danglingImageCaches() error {
 	dangling := false
-	imagecachelist, err := c.kubefledgedclientset.KubefledgedV1alpha2().ImageCaches("").List(context.TODO(), metav1.ListOptions{})
+	imagecachelist, err := c.kubefledgedclientset.KubefledgedV1alpha2().ImageCaches(c.watchNamespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: c.imageCacheLabelSelector,
+	})
 	if err != nil {
 		glog.Errorf("Error listing imagecaches: %v", err)
 		return err
@@ -210,6 +540,10 @@ func (c *Controller) danglingImageCaches() error {
 	}
 	for _, imagecache := range imagecachelist.Items {
 		if imagecache.Status.Status == v1alpha2.ImageCacheActionStatusProcessing {
+			if c.resumedImageCaches[imagecache.Namespace+"/"+imagecache.Name] {
+				c.resumeReconcile(&imagecache)
+				continue
+			}
 			status.StartTime = imagecache.Status.StartTime
 			err := c.updateImageCacheStatus(&imagecache, status)
 			if err != nil {
@@ -240,7 +574,17 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 	glog.Info("Starting kubefledged-controller")
 
 	// Wait for the caches to be synced before starting workers
-	if ok := cache.WaitForCacheSync(stopCh, c.nodesSynced, c.imageCachesSynced); !ok {
+	cachesSynced := []cache.InformerSynced{c.nodesSynced, c.imageCachesSynced}
+	if c.configMapsSynced != nil {
+		cachesSynced = append(cachesSynced, c.configMapsSynced)
+	}
+	if c.deploymentsSynced != nil && c.statefulSetsSynced != nil {
+		cachesSynced = append(cachesSynced, c.deploymentsSynced, c.statefulSetsSynced)
+	}
+	if c.cronJobsSynced != nil {
+		cachesSynced = append(cachesSynced, c.cronJobsSynced)
+	}
+	if ok := cache.WaitForCacheSync(stopCh, cachesSynced...); !ok {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 	glog.Info("Informer caches synched successfull")
@@ -251,9 +595,27 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 	}
 	glog.Info("Image cache worker started")
 
+	if c.watchdogWindow > 0 {
+		c.recordProgress()
+		go wait.Until(c.checkProgress, c.watchdogWindow, stopCh)
+		glog.Info("Watchdog started")
+	}
+
 	if c.imageCacheRefreshFrequency.Nanoseconds() != int64(0) {
-		go wait.Until(c.runRefreshWorker, c.imageCacheRefreshFrequency, stopCh)
-		glog.Info("Image cache refresh worker started")
+		go c.afterStartupDelay(stopCh, func() {
+			glog.Info("Image cache refresh worker started")
+			wait.Until(c.runRefreshWorker, c.refreshTick(), stopCh)
+		})
+	}
+
+	if c.imageCacheRefreshFrequency > 0 || c.staleAfter > 0 {
+		go wait.Until(c.runStaleWorker, staleCheckPeriod, stopCh)
+		glog.Info("Image cache stale worker started")
+	}
+
+	if c.cronJobsLister != nil {
+		go wait.Until(c.runPreWarmWorker, time.Minute, stopCh)
+		glog.Info("Image cache pre-warm worker started")
 	}
 
 	c.imageManager.Run(stopCh)
@@ -268,6 +630,19 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 	return nil
 }
 
+// afterStartupDelay runs fn once the startup delay has passed, unless stopCh is closed before
+func (c *Controller) afterStartupDelay(stopCh <-chan struct{}, fn func()) {
+	if c.startupDelay > 0 {
+		glog.Infof("Waiting %s after startup", c.startupDelay)
+		select {
+		case <-time.After(c.startupDelay):
+		case <-stopCh:
+			return
+		}
+	}
+	fn()
+}
+
 // enqueueImageCache takes a ImageCache resource and converts it into a namespace/name
 // string which is then put onto the work queue. This method should *not* be
 // passed resources of any type other than ImageCache.
@@ -309,10 +684,25 @@ func (c *Controller) enqueueImageCache(workType images.WorkType, old, new interf
 				break
 			}
 		}
-		if reflect.DeepEqual(newImageCache.Spec, oldImageCache.Spec) {
+		// refresh-now triggers a refresh whenever its value (e.g. a timestamp) is set or bumped
+		if refreshNow, exists := newImageCache.Annotations[imageCacheRefreshNowAnnotationKey]; exists {
+			if oldRefreshNow, exists := oldImageCache.Annotations[imageCacheRefreshNowAnnotationKey]; !exists || oldRefreshNow != refreshNow {
+				workType = images.ImageCacheRefresh
+				break
+			}
+		}
+		if reflect.DeepEqual(newImageCache.Spec, oldImageCache.Spec) || isGenerationReconciled(newImageCache) {
 			return false
 		}
 	case images.ImageCacheDelete:
+		if key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(old); err == nil {
+			deleteCacheMetrics(key)
+			c.imageHistoryLock.Lock()
+			c.deleteImageHistory(key)
+			c.imageHistoryLock.Unlock()
+			c.endReconcile(key)
+			go c.deleteStatusSummary(key)
+		}
 		return false
 
 	case images.ImageCacheRefresh:
@@ -330,11 +720,357 @@ func (c *Controller) enqueueImageCache(workType images.WorkType, old, new interf
 		wqKey.OldImageCache = oldImageCache
 	}
 
+	if workType == images.ImageCacheUpdate && c.updateDebounceWindow > 0 {
+		c.debounceUpdate(wqKey)
+		return true
+	}
+
 	c.workqueue.AddRateLimited(wqKey)
 	glog.V(4).Infof("enqueueImageCache::ImageCache resource queued for work type %s", workType)
 	return true
 }
 
+// debounceUpdate queues the update after the debounce window. Further updates of the image cache
+// within the window are coalesced into it: the reconcile uses the latest spec of the image cache
+// and compares it with the spec before the first update
+func (c *Controller) debounceUpdate(wqKey images.WorkQueueKey) {
+	c.pendingUpdatesLock.Lock()
+	defer c.pendingUpdatesLock.Unlock()
+	if c.pendingUpdates[wqKey.ObjKey] {
+		glog.V(4).Infof("Update of image cache %s coalesced with the pending update", wqKey.ObjKey)
+		return
+	}
+	c.pendingUpdates[wqKey.ObjKey] = true
+	c.workqueue.AddAfter(wqKey, c.updateDebounceWindow)
+	glog.V(4).Infof("enqueueImageCache::ImageCache resource queued for work type %s after %s", wqKey.WorkType, c.updateDebounceWindow)
+}
+
+// isReconcile returns true for the work types which create jobs for the images of an image cache
+func isReconcile(workType images.WorkType) bool {
+	switch workType {
+	case images.ImageCacheCreate, images.ImageCacheUpdate, images.ImageCacheRefresh, images.ImageCachePurge:
+		return true
+	}
+	return false
+}
+
+// startReconcile marks a reconcile of the image cache as active. It returns false if another reconcile
+// of the image cache is active. Other work types are never held back
+func (c *Controller) startReconcile(wqKey images.WorkQueueKey) bool {
+	if !isReconcile(wqKey.WorkType) {
+		return true
+	}
+	c.activeReconcilesLock.Lock()
+	defer c.activeReconcilesLock.Unlock()
+	if started, ok := c.activeReconciles[wqKey.ObjKey]; ok {
+		if time.Since(started) < c.reconcileTimeoutOf(wqKey.ObjKey) {
+			glog.V(4).Infof("Reconcile(%s) of image cache %s waits for the active reconcile", wqKey.WorkType, wqKey.ObjKey)
+			return false
+		}
+		// the status update of the active reconcile was lost
+		glog.Warningf("Active reconcile of image cache %s timed out", wqKey.ObjKey)
+	}
+	c.activeReconciles[wqKey.ObjKey] = time.Now()
+	return true
+}
+
+// reconcileTimeoutOf returns the duration after which an active reconcile of the image cache is considered
+// lost. It is twice the job deadline of the image cache, if set, or of the controller
+func (c *Controller) reconcileTimeoutOf(objKey string) time.Duration {
+	namespace, name, err := cache.SplitMetaNamespaceKey(objKey)
+	if err != nil {
+		return c.reconcileTimeout
+	}
+	imageCache, err := c.imageCachesLister.ImageCaches(namespace).Get(name)
+	if err != nil || imageCache.Spec.JobDeadlineSeconds == nil {
+		return c.reconcileTimeout
+	}
+	return 2 * time.Duration(*imageCache.Spec.JobDeadlineSeconds) * time.Second
+}
+
+// endReconcile marks the reconcile of the image cache as completed
+func (c *Controller) endReconcile(objKey string) {
+	c.activeReconcilesLock.Lock()
+	defer c.activeReconcilesLock.Unlock()
+	delete(c.activeReconciles, objKey)
+}
+
+// enqueueImageCachesReferencingConfigMap queues a refresh of the image caches
+// whose image lists are sourced from the given ConfigMap
+func (c *Controller) enqueueImageCachesReferencingConfigMap(configMap *corev1.ConfigMap) {
+	imageCaches, err := c.imageCachesLister.ImageCaches(configMap.Namespace).List(labels.Everything())
+	if err != nil {
+		glog.Errorf("Error in listing image caches: %v", err)
+		return
+	}
+	for i := range imageCaches {
+		if imageCaches[i].Status.Status == v1alpha2.ImageCacheActionStatusProcessing {
+			continue
+		}
+		for _, cacheSpecImages := range imageCaches[i].Spec.CacheSpec {
+			if cacheSpecImages.ImagesFrom != nil && cacheSpecImages.ImagesFrom.Name == configMap.Name {
+				glog.Infof("ConfigMap %s/%s changed, refreshing image cache %s", configMap.Namespace, configMap.Name, imageCaches[i].Name)
+				c.enqueueImageCache(images.ImageCacheRefresh, imageCaches[i], nil)
+				break
+			}
+		}
+	}
+}
+
+// errWorkloadRef is returned by imageListNodes when the workload referenced by the image list could not be read
+var errWorkloadRef = errors.New("error reading workload")
+
+// imageListNodes returns the nodes of an image list: the nodes matching its node selector which have not
+// opted out of caching, are not under maintenance and have a supported container runtime and, if it
+// references a workload, on which the pods of the workload can be scheduled
+func (c *Controller) imageListNodes(namespace string, cacheSpecImages v1alpha2.CacheSpecImages) ([]*corev1.Node, error) {
+	nodes, err := c.nodesLister.List(labels.Set(cacheSpecImages.NodeSelector).AsSelector())
+	if err != nil {
+		glog.Errorf("Error listing nodes using nodeselector %+v: %v", cacheSpecImages.NodeSelector, err)
+		return nil, err
+	}
+	nodes = c.filterSupportedRuntimeNodes(c.filterMaintenanceNodes(filterSkipCacheNodes(nodes)))
+	if len(cacheSpecImages.NodeNames) > 0 {
+		nodes = filterNodeNames(nodes, cacheSpecImages.NodeNames)
+	}
+	glog.V(4).Infof("No. of nodes in %+v is %d", cacheSpecImages.NodeSelector, len(nodes))
+	if cacheSpecImages.WorkloadRef == nil {
+		return nodes, nil
+	}
+	podSpec, err := c.workloadRefPodSpec(namespace, cacheSpecImages.WorkloadRef)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %v", errWorkloadRef, workloadRefString(cacheSpecImages.WorkloadRef), err)
+	}
+	nodes = feasibleNodes(podSpec, nodes)
+	glog.V(4).Infof("No. of nodes feasible for %s is %d", workloadRefString(cacheSpecImages.WorkloadRef), len(nodes))
+	return nodes, nil
+}
+
+// filterSkipCacheNodes removes the nodes which have opted out of caching using the skip-cache annotation
+func filterSkipCacheNodes(nodes []*corev1.Node) []*corev1.Node {
+	filtered := make([]*corev1.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if n.Annotations[nodeSkipCacheAnnotationKey] == "true" {
+			glog.V(4).Infof("Skipping node %s annotated with %s", n.Name, nodeSkipCacheAnnotationKey)
+			continue
+		}
+		filtered = append(filtered, n)
+	}
+	return filtered
+}
+
+// filterNodeNames keeps the nodes with the given names
+func filterNodeNames(nodes []*corev1.Node, names []string) []*corev1.Node {
+	named := make(map[string]bool, len(names))
+	for _, name := range names {
+		named[name] = true
+	}
+	filtered := make([]*corev1.Node, 0, len(names))
+	for _, n := range nodes {
+		if named[n.Name] {
+			filtered = append(filtered, n)
+		}
+	}
+	return filtered
+}
+
+// missingNodeNames returns the names listed in nodeNames of the image lists of the image cache
+// for which there is no node, in sorted order
+func (c *Controller) missingNodeNames(imageCache *v1alpha2.ImageCache) []string {
+	missing := map[string]bool{}
+	for _, i := range imageCache.Spec.CacheSpec {
+		for _, name := range i.NodeNames {
+			if _, err := c.nodesLister.Get(name); apierrors.IsNotFound(err) {
+				missing[name] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// withMissingNodes appends a note of the nodes listed in nodeNames which do not exist to the status message
+func withMissingNodes(message string, missing []string) string {
+	if len(missing) == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s. %s: %s", message, v1alpha2.ImageCacheMessageNodesNotFound, strings.Join(missing, ", "))
+}
+
+// retargetNode returns a ready node of the image list of a pull abandoned on a deleted node, to which the
+// image is not yet being pulled by its image cache. Nodes are tried in the order of their names. It is nil
+// if there is no such node
+func (c *Controller) retargetNode(iwr images.ImageWorkRequest) *corev1.Node {
+	cacheSpec := iwr.Imagecache.Spec.CacheSpec
+	if iwr.ImageList >= len(cacheSpec) {
+		return nil
+	}
+	nodes, err := c.imageListNodes(iwr.Imagecache.Namespace, cacheSpec[iwr.ImageList])
+	if err != nil {
+		glog.Errorf("Error listing nodes of image list %d of imagecache(%s): %v", iwr.ImageList, iwr.Imagecache.Name, err)
+		return nil
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	targeted := c.imageManager.NodesWithImageWork(iwr.Imagecache, iwr.Image)
+	for _, n := range nodes {
+		if n.Name == iwr.Node.Name || targeted[n.Name] || !images.IsNodeReady(n) {
+			continue
+		}
+		return n
+	}
+	return nil
+}
+
+// diffImages returns the images which are in newImages but not in oldImages (added)
+// and the images which are in oldImages but not in newImages (removed)
+func diffImages(oldImages, newImages []string) (added, removed []string) {
+	oldSet := make(map[string]bool, len(oldImages))
+	for _, image := range oldImages {
+		oldSet[image] = true
+	}
+	newSet := make(map[string]bool, len(newImages))
+	for _, image := range newImages {
+		newSet[image] = true
+		if !oldSet[image] {
+			added = append(added, image)
+		}
+	}
+	for _, image := range oldImages {
+		if !newSet[image] {
+			removed = append(removed, image)
+		}
+	}
+	return added, removed
+}
+
+// errRepositoryTags is returned by imagesOf when the tags of a repository of the image list could not be listed
+var errRepositoryTags = errors.New("error listing tags of repository")
+
+// imagesOf returns the images of an image list. Images listed in the ConfigMap referenced by imagesFrom,
+// and the tags of the repositories matching their patterns, are appended to the images specified in the list.
+func (c *Controller) imagesOf(namespace string, cacheSpecImages v1alpha2.CacheSpecImages) ([]string, error) {
+	if cacheSpecImages.ImagesFrom == nil && len(cacheSpecImages.Repositories) == 0 {
+		return cacheSpecImages.Images, nil
+	}
+	imageList := append([]string{}, cacheSpecImages.Images...)
+	appendImage := func(image string) {
+		for _, i := range imageList {
+			if i == image {
+				return
+			}
+		}
+		imageList = append(imageList, image)
+	}
+
+	if cacheSpecImages.ImagesFrom != nil {
+		data, err := c.imagesFromData(namespace, cacheSpecImages.ImagesFrom)
+		if err != nil {
+			return nil, err
+		}
+		for _, image := range parseImageList(data) {
+			appendImage(image)
+		}
+	}
+
+	// A registry which doesn't support listing tags is skipped, as retrying won't help
+	for _, r := range cacheSpecImages.Repositories {
+		tags, err := c.listTags(r.Repository)
+		if errors.Is(err, images.ErrTagsNotListable) {
+			glog.Warningf("Skipping repository %s: %v", r.Repository, err)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w %s: %v", errRepositoryTags, r.Repository, err)
+		}
+		repositoryImages, err := images.MatchTags(r.Repository, tags, r.IncludeTags, r.ExcludeTags)
+		if err != nil {
+			return nil, fmt.Errorf("%w %s: %v", errRepositoryTags, r.Repository, err)
+		}
+		for _, image := range repositoryImages {
+			appendImage(image)
+		}
+	}
+	return imageList, nil
+}
+
+// parseImageList returns the images of a newline-separated list of images, skipping blank lines and comments
+func parseImageList(data string) []string {
+	var imageList []string
+	for _, line := range strings.Split(data, "\n") {
+		image := strings.TrimSpace(line)
+		if image == "" || strings.HasPrefix(image, "#") {
+			continue
+		}
+		imageList = append(imageList, image)
+	}
+	return imageList
+}
+
+// cachedImages returns the images and repositories of all the image caches, for the cache budget of
+// the nodes. Image lists of ConfigMaps which cannot be read are left out
+func (c *Controller) cachedImages() (imageList, repositories []string) {
+	imageCaches, err := c.imageCachesLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("Error listing imagecaches: %v", err)
+		return nil, nil
+	}
+	for _, imageCache := range imageCaches {
+		for _, cacheSpecImages := range imageCache.Spec.CacheSpec {
+			imageList = append(imageList, cacheSpecImages.Images...)
+			if cacheSpecImages.ImagesFrom != nil {
+				if data, err := c.imagesFromData(imageCache.Namespace, cacheSpecImages.ImagesFrom); err == nil {
+					imageList = append(imageList, parseImageList(data)...)
+				}
+			}
+			for _, r := range cacheSpecImages.Repositories {
+				repositories = append(repositories, r.Repository)
+			}
+		}
+	}
+	return imageList, repositories
+}
+
+// countImages returns the no. of images of the image lists
+func countImages(imageLists [][]string) int {
+	noOfImages := 0
+	for _, imageList := range imageLists {
+		noOfImages += len(imageList)
+	}
+	return noOfImages
+}
+
+// imagesFromData returns the data of the key of the ConfigMap referenced by imagesFrom. It is empty
+// if the ConfigMap or the key is optional and not found. The ConfigMap is read from the api server
+// unless the ConfigMaps are watched
+func (c *Controller) imagesFromData(namespace string, imagesFrom *corev1.ConfigMapKeySelector) (string, error) {
+	optional := imagesFrom.Optional != nil && *imagesFrom.Optional
+	var configMap *corev1.ConfigMap
+	var err error
+	if c.configMapsLister != nil {
+		configMap, err = c.configMapsLister.ConfigMaps(namespace).Get(imagesFrom.Name)
+	} else {
+		configMap, err = c.kubeclientset.CoreV1().ConfigMaps(namespace).Get(context.TODO(), imagesFrom.Name, metav1.GetOptions{})
+	}
+	if err != nil {
+		if apierrors.IsNotFound(err) && optional {
+			return "", nil
+		}
+		return "", err
+	}
+	data, ok := configMap.Data[imagesFrom.Key]
+	if !ok {
+		if optional {
+			return "", nil
+		}
+		return "", fmt.Errorf("key %s not found in configmap %s", imagesFrom.Key, imagesFrom.Name)
+	}
+	return data, nil
+}
+
 // runWorker is a long-running function that will continually call the
 // processNextWorkItem function in order to read and process a message on the
 // workqueue.
@@ -352,6 +1088,8 @@ func (c *Controller) processNextWorkItem() bool {
 	if shutdown {
 		return false
 	}
+	c.recordProgress()
+	defer c.recordProgress()
 
 	// We wrap this block in a func so we can defer c.workqueue.Done.
 	err := func(obj interface{}) error {
@@ -377,9 +1115,27 @@ func (c *Controller) processNextWorkItem() bool {
 			runtime.HandleError(fmt.Errorf("unexpected type in workqueue: %#v", obj))
 			return nil
 		}
+		if !c.startReconcile(key) {
+			// the image work of another reconcile of the image cache is in flight. Creating jobs
+			// for the same images and nodes would race with it, so retry once it has completed
+			c.workqueue.Forget(obj)
+			c.workqueue.AddAfter(key, reconcileRequeueDelay)
+			return nil
+		}
+		if key.WorkType == images.ImageCacheUpdate {
+			// updates received from now on are not covered by this reconcile
+			c.pendingUpdatesLock.Lock()
+			delete(c.pendingUpdates, key.ObjKey)
+			c.pendingUpdatesLock.Unlock()
+		}
 		// Run the syncHandler, passing it the namespace/name string of the
-		// ImageCache resource to be synced.
-		if err := c.syncHandler(key); err != nil {
+		// ImageCache resource to be synced. Status updates are traced as part
+		// of the reconcile whose image work they report
+		ctx, span := tracing.Start(key.Parent.Context(), "Controller.processNextWorkItem",
+			tracing.CacheKey.String(key.ObjKey), tracing.WorkTypeKey.String(string(key.WorkType)))
+		err := c.syncHandler(ctx, key)
+		tracing.End(span, err)
+		if err != nil {
 			glog.Errorf("error syncing imagecache: %v", err.Error())
 			return fmt.Errorf("error syncing imagecache: %v", err.Error())
 		}
@@ -398,7 +1154,8 @@ func (c *Controller) processNextWorkItem() bool {
 	return true
 }
 
-// runRefreshWorker is resposible of refreshing the image cache
+// runRefreshWorker is resposible of refreshing the image cache. Each image cache is refreshed as per its
+// refresh frequency
 func (c *Controller) runRefreshWorker() {
 	// List the ImageCache resources
 	imageCaches, err := c.imageCachesLister.ImageCaches("").List(labels.Everything())
@@ -406,7 +1163,19 @@ func (c *Controller) runRefreshWorker() {
 		glog.Errorf("Error in listing image caches: %v", err)
 		return
 	}
+	now := time.Now()
+	quiet := c.quietHours.contains(now)
+	deferred := 0
+	refreshed := make(map[string]time.Time, len(imageCaches))
 	for i := range imageCaches {
+		key, err := cache.MetaNamespaceKeyFunc(imageCaches[i])
+		if err != nil {
+			continue
+		}
+		// The last refreshes of the image caches which still exist are kept
+		if last, ok := c.lastRefreshes[key]; ok {
+			refreshed[key] = last
+		}
 		// Do not refresh if status is not yet updated
 		if reflect.DeepEqual(imageCaches[i].Status, v1alpha2.ImageCacheStatus{}) {
 			continue
@@ -424,14 +1193,32 @@ func (c *Controller) runRefreshWorker() {
 		if imageCaches[i].Status.Reason == v1alpha2.ImageCacheReasonImageCachePurge {
 			continue
 		}
+		// Image caches pre-warmed ahead of the runs of a CronJob are refreshed by the pre-warm worker
+		if imageCaches[i].Spec.PreWarm != nil && c.cronJobsLister != nil {
+			continue
+		}
+		if !c.refreshDue(imageCaches[i], c.lastRefreshes[key], now) {
+			continue
+		}
+		// Refreshes due in quiet hours are made once they end
+		if quiet {
+			deferred++
+			continue
+		}
+		refreshed[key] = now
 		c.enqueueImageCache(images.ImageCacheRefresh, imageCaches[i], nil)
 	}
+	if deferred > 0 {
+		glog.V(4).Infof("Quiet hours: deferring the refresh of %d image cache(s)", deferred)
+	}
+	c.lastRefreshes = refreshed
+	c.renewRefreshLease(now)
 }
 
 // syncHandler compares the actual state with the desired, and attempts to
 // converge the two. It then updates the Status block of the ImageCache resource
 // with the current status of the resource.
-func (c *Controller) syncHandler(wqKey images.WorkQueueKey) error {
+func (c *Controller) syncHandler(ctx context.Context, wqKey images.WorkQueueKey) error {
 	status := &v1alpha2.ImageCacheStatus{
 		Failures: map[string]v1alpha2.NodeReasonMessageList{},
 	}
@@ -445,6 +1232,16 @@ func (c *Controller) syncHandler(wqKey images.WorkQueueKey) error {
 
 	glog.Infof("Starting to sync image cache %s(%s)", name, wqKey.WorkType)
 
+	// A reconcile stays active until its status update, unless it ends before queueing any image work
+	imageWorkQueued := false
+	if isReconcile(wqKey.WorkType) {
+		defer func() {
+			if !imageWorkQueued {
+				c.endReconcile(wqKey.ObjKey)
+			}
+		}()
+	}
+
 	switch wqKey.WorkType {
 	case images.ImageCacheCreate, images.ImageCacheUpdate, images.ImageCacheRefresh, images.ImageCachePurge:
 
@@ -459,6 +1256,12 @@ func (c *Controller) syncHandler(wqKey images.WorkQueueKey) error {
 			return err
 		}
 
+		// the spec of a queued update may already have been reconciled, e.g. by an update coalesced with it
+		if wqKey.WorkType == images.ImageCacheUpdate && isGenerationReconciled(imageCache) {
+			glog.Infof("Generation %d of imagecache(%s) already reconciled, skipping update", imageCache.Generation, name)
+			return nil
+		}
+
 		if wqKey.WorkType == images.ImageCacheUpdate && wqKey.OldImageCache == nil {
 			status.Status = v1alpha2.ImageCacheActionStatusFailed
 			status.Reason = v1alpha2.ImageCacheReasonOldImageCacheNotFound
@@ -474,18 +1277,122 @@ func (c *Controller) syncHandler(wqKey images.WorkQueueKey) error {
 
 		cacheSpec := imageCache.Spec.CacheSpec
 		glog.V(4).Infof("cacheSpec: %+v", cacheSpec)
-		var nodes []*corev1.Node
+
+		nodeLists := make([][]*corev1.Node, len(cacheSpec))
+		for k, i := range cacheSpec {
+			if nodeLists[k], err = c.imageListNodes(namespace, i); err != nil {
+				if !errors.Is(err, errWorkloadRef) {
+					return err
+				}
+				status.Status = v1alpha2.ImageCacheActionStatusFailed
+				status.Reason = v1alpha2.ImageCacheReasonWorkloadRefFailed
+				status.Message = v1alpha2.ImageCacheMessageWorkloadRefFailed
+
+				if err := c.updateImageCacheStatus(imageCache, status); err != nil {
+					glog.Errorf("Error updating imagecache status to %s: %v", status.Status, err)
+					return err
+				}
+				glog.Errorf("Error reading workload of imagecache(%s): %v", name, err)
+				return fmt.Errorf("%s: %v", status.Reason, err)
+			}
+			if c.zoneBalancedPulls {
+				nodeLists[k] = zoneBalanced(nodeLists[k])
+			}
+		}
+
+		imageLists := make([][]string, len(cacheSpec))
+		for k, i := range cacheSpec {
+			if imageLists[k], err = c.imagesOf(namespace, i); err != nil {
+				status.Status = v1alpha2.ImageCacheActionStatusFailed
+				status.Reason = v1alpha2.ImageCacheReasonImagesFromConfigMapFailed
+				status.Message = v1alpha2.ImageCacheMessageImagesFromConfigMapFailed
+				if errors.Is(err, errRepositoryTags) {
+					status.Reason = v1alpha2.ImageCacheReasonRepositoryTagsListFailed
+					status.Message = v1alpha2.ImageCacheMessageRepositoryTagsListFailed
+				}
+
+				if err := c.updateImageCacheStatus(imageCache, status); err != nil {
+					glog.Errorf("Error updating imagecache status to %s: %v", status.Status, err)
+					return err
+				}
+				glog.Errorf("Error reading images of imagecache(%s): %v", name, err)
+				return fmt.Errorf("%s: %v", status.Reason, err)
+			}
+		}
+
+		// The webhook server only counts the images listed in the spec, so the images read from ConfigMaps and
+		// the tags of repositories are counted here. A purge still deletes all the images
+		if noOfImages := countImages(imageLists); c.maxImagesPerCache > 0 && noOfImages > c.maxImagesPerCache &&
+			wqKey.WorkType != images.ImageCachePurge {
+			status.Status = v1alpha2.ImageCacheActionStatusFailed
+			status.Reason = v1alpha2.ImageCacheReasonTooManyImages
+			status.Message = fmt.Sprintf(v1alpha2.ImageCacheMessageTooManyImages, noOfImages, c.maxImagesPerCache)
+
+			if err := c.updateImageCacheStatus(imageCache, status); err != nil {
+				glog.Errorf("Error updating imagecache status to %s: %v", status.Status, err)
+				return err
+			}
+			glog.Errorf("%s: %s", status.Reason, status.Message)
+			return fmt.Errorf("%s: %s", status.Reason, status.Message)
+		}
+
+		// An update only pulls the images added to an image list and deletes the images removed from it
+		pullLists := imageLists
+		purgeLists := make([][]string, len(cacheSpec))
+		if wqKey.WorkType == images.ImageCacheUpdate {
+			pullLists = make([][]string, len(cacheSpec))
+			for k := range cacheSpec {
+				var oldImages []string
+				if k < len(wqKey.OldImageCache.Spec.CacheSpec) {
+					oldCacheSpecImages := wqKey.OldImageCache.Spec.CacheSpec[k]
+					if oldImages, err = c.imagesOf(namespace, oldCacheSpecImages); err != nil {
+						oldImages = oldCacheSpecImages.Images
+					}
+				}
+				pullLists[k], purgeLists[k] = diffImages(oldImages, imageLists[k])
+			}
+		}
+		// Baseline images are pulled to all the nodes by the baseline image cache. Other image caches
+		// neither pull them again nor delete them
+		if !c.isBaselineImageCache(imageCache) {
+			pullLists = withoutImages(pullLists, c.baselineImages)
+			purgeLists = withoutImages(purgeLists, c.baselineImages)
+		}
+		// In declarative mode, the images the image cache pulled which are no longer in its image lists are
+		// deleted too. A purge deletes all of them, so none are left cached
+		var staleImages []string
+		if isDeclarative(imageCache) {
+			desiredLists := imageLists
+			if !c.isBaselineImageCache(imageCache) {
+				desiredLists = withoutImages(imageLists, c.baselineImages)
+			}
+			staleImages = c.staleImages(imageCache, desiredLists, purgeLists)
+			status.CachedImages = []string{}
+			status.ShadowedDeletions = map[string][]string{}
+			if wqKey.WorkType != images.ImageCachePurge {
+				status.CachedImages = declarativeImages(desiredLists)
+			}
+			// Shadowed deletions are only logged and listed in the status. Their images are kept in the cached
+			// images, so that they are deleted by the first reconcile after the shadow ends
+			if len(staleImages) > 0 && isShadowed(imageCache, time.Now()) {
+				status.ShadowedDeletions = c.shadowDeletions(imageCache, nodeLists, staleImages)
+				status.CachedImages = declarativeImages([][]string{status.CachedImages, staleImages})
+				staleImages = nil
+			}
+		}
 
 		status.Status = v1alpha2.ImageCacheActionStatusProcessing
 
 		if wqKey.WorkType == images.ImageCacheCreate {
 			status.Reason = v1alpha2.ImageCacheReasonImageCacheCreate
 			status.Message = v1alpha2.ImageCacheMessagePullingImages
+			status.ObservedGeneration = imageCache.Generation
 		}
 
 		if wqKey.WorkType == images.ImageCacheUpdate {
 			status.Reason = v1alpha2.ImageCacheReasonImageCacheUpdate
 			status.Message = v1alpha2.ImageCacheMessageUpdatingCache
+			status.ObservedGeneration = imageCache.Generation
 		}
 
 		if wqKey.WorkType == images.ImageCacheRefresh {
@@ -497,6 +1404,11 @@ func (c *Controller) syncHandler(wqKey images.WorkQueueKey) error {
 			status.Reason = v1alpha2.ImageCacheReasonImageCachePurge
 			status.Message = v1alpha2.ImageCacheMessagePurgeCache
 		}
+		status.Message = withMissingNodes(status.Message, c.missingNodeNames(imageCache))
+		unsupportedRuntimeNodes := c.unsupportedRuntimeNodes(imageCache)
+		status.Message = withUnsupportedRuntimeNodes(status.Message, unsupportedRuntimeNodes)
+		status.Message = withMaintenanceNodes(status.Message, c.maintenanceNodes(imageCache))
+		status.Message = withShadowedDeletions(status.Message, status.ShadowedDeletions)
 
 		imageCache, err = c.kubefledgedclientset.KubefledgedV1alpha2().ImageCaches(namespace).Get(context.TODO(), name, metav1.GetOptions{})
 		if err != nil {
@@ -508,62 +1420,53 @@ func (c *Controller) syncHandler(wqKey images.WorkQueueKey) error {
 			glog.Errorf("Error updating imagecache status to %s: %v", status.Status, err)
 			return err
 		}
+		c.recordUnsupportedRuntimeEvent(imageCache, unsupportedRuntimeNodes)
 
-		for k, i := range cacheSpec {
-			if len(i.NodeSelector) > 0 {
-				if nodes, err = c.nodesLister.List(labels.Set(i.NodeSelector).AsSelector()); err != nil {
-					glog.Errorf("Error listing nodes using nodeselector %+v: %v", i.NodeSelector, err)
-					return err
-				}
-			} else {
-				if nodes, err = c.nodesLister.List(labels.Everything()); err != nil {
-					glog.Errorf("Error listing nodes using nodeselector labels.Everything(): %v", err)
-					return err
-				}
-			}
-			glog.V(4).Infof("No. of nodes in %+v is %d", i.NodeSelector, len(nodes))
-
-			for _, n := range nodes {
-				for m := range i.Images {
+		for k := range cacheSpec {
+			for _, n := range nodeLists[k] {
+				// the images the node ships with are neither pulled nor deleted
+				pullList := c.withoutBaseImages(n, pullLists[k])
+				for m := range pullList {
 					ipr := images.ImageWorkRequest{
-						Image:                   i.Images[m],
+						Image:                   pullList[m],
 						Node:                    n,
 						ContainerRuntimeVersion: n.Status.NodeInfo.ContainerRuntimeVersion,
+						ImageList:               k,
 						WorkType:                wqKey.WorkType,
 						Imagecache:              imageCache,
+						Parent:                  tracing.ParentOf(ctx),
 					}
 					c.imageworkqueue.AddRateLimited(ipr)
 				}
-				if wqKey.WorkType == images.ImageCacheUpdate {
-					for _, oldimage := range wqKey.OldImageCache.Spec.CacheSpec[k].Images {
-						matched := false
-						for _, newimage := range i.Images {
-							if oldimage == newimage {
-								matched = true
-								break
-							}
-						}
-						if !matched {
-							ipr := images.ImageWorkRequest{
-								Image:                   oldimage,
-								Node:                    n,
-								ContainerRuntimeVersion: n.Status.NodeInfo.ContainerRuntimeVersion,
-								WorkType:                images.ImageCachePurge,
-								Imagecache:              imageCache,
-							}
-							c.imageworkqueue.AddRateLimited(ipr)
-						}
+				for _, oldimage := range c.withoutBaseImages(n, purgeLists[k]) {
+					ipr := images.ImageWorkRequest{
+						Image:                   oldimage,
+						Node:                    n,
+						ContainerRuntimeVersion: n.Status.NodeInfo.ContainerRuntimeVersion,
+						ImageList:               k,
+						WorkType:                images.ImageCachePurge,
+						Imagecache:              imageCache,
+						Parent:                  tracing.ParentOf(ctx),
 					}
+					c.imageworkqueue.AddRateLimited(ipr)
 				}
 			}
 		}
+		c.queueStaleImagePurges(ctx, imageCache, nodeLists, staleImages)
 
 		// We add an empty image pull request to signal the image manager that all
 		// requests for this sync action have been placed in the imageworkqueue
-		c.imageworkqueue.AddRateLimited(images.ImageWorkRequest{WorkType: wqKey.WorkType, Imagecache: imageCache})
+		c.imageworkqueue.AddRateLimited(images.ImageWorkRequest{WorkType: wqKey.WorkType, Imagecache: imageCache, Parent: tracing.ParentOf(ctx)})
+		imageWorkQueued = true
+
+	case images.ImageCacheWorkloadSync:
+		if err := c.syncWorkloadImageCache(namespace); err != nil {
+			return err
+		}
 
 	case images.ImageCacheStatusUpdate:
 		glog.V(4).Infof("wqKey.Status = %+v", wqKey.Status)
+		c.endReconcile(wqKey.ObjKey)
 		// Finally, we update the status block of the ImageCache resource to reflect the
 		// current state of the world
 		// Get the ImageCache resource with this namespace/name
@@ -600,6 +1503,18 @@ func (c *Controller) syncHandler(wqKey images.WorkQueueKey) error {
 					status.Message = v1alpha2.ImageCacheMessageImagePullFailedForSomeImages
 				}
 			}
+			if v.Status == images.ImageWorkResultStatusSucceeded && v.Digest != "" {
+				if status.VerifiedDigests == nil {
+					status.VerifiedDigests = map[string]string{}
+				}
+				status.VerifiedDigests[v.ImageWorkRequest.Image] = v.Digest
+			}
+			if v.Status == images.ImageWorkResultStatusSucceeded && v.Mirror != "" {
+				if status.PulledFromMirrors == nil {
+					status.PulledFromMirrors = map[string]string{}
+				}
+				status.PulledFromMirrors[v.ImageWorkRequest.Image] = v.Mirror
+			}
 			if v.Status == images.ImageWorkResultStatusFailed || v.Status == images.ImageWorkResultStatusUnknown {
 				status.Failures[v.ImageWorkRequest.Image] = append(
 					status.Failures[v.ImageWorkRequest.Image], v1alpha2.NodeReasonMessage{
@@ -609,12 +1524,36 @@ func (c *Controller) syncHandler(wqKey images.WorkQueueKey) error {
 					})
 			}
 		}
+		applyFailurePolicy(imageCache.Spec.FailurePolicy, status, *wqKey.Status)
+
+		status.Message = withMissingNodes(status.Message, c.missingNodeNames(imageCache))
+		status.Message = withUnsupportedRuntimeNodes(status.Message, c.unsupportedRuntimeNodes(imageCache))
+		status.Message = withMaintenanceNodes(status.Message, c.maintenanceNodes(imageCache))
+		status.Message = withShadowedDeletions(status.Message, imageCache.Status.ShadowedDeletions)
+		coverage := coveragePercent(*wqKey.Status)
+		status.CoveragePercent = &coverage
+		if isSuccessfulRefresh(imageCache.Status.Reason, status.Status) {
+			refreshTime := metav1.Now()
+			status.LastSuccessfulRefreshTime = &refreshTime
+		}
 
 		err = c.updateImageCacheStatus(imageCache, status)
 		if err != nil {
 			glog.Errorf("Error updating ImageCache status: %v", err)
 			return err
 		}
+		recordCacheMetrics(wqKey.ObjKey, *wqKey.Status)
+		c.recordImageHistory(wqKey.ObjKey, *wqKey.Status, time.Now())
+		c.recordStatusSummary(wqKey.ObjKey, status, *wqKey.Status)
+		c.recordImageFailureEvents(imageCache, *wqKey.Status)
+		if wqKey.DeadlineExceeded {
+			c.recordDeadlineTimeout(wqKey.ObjKey, imageCache)
+		}
+		var duration time.Duration
+		if status.StartTime != nil {
+			duration = time.Since(status.StartTime.Time)
+		}
+		glog.Info(reconcileSummary(wqKey.ObjKey, *wqKey.Status, duration))
 
 		if imageCache.Status.Reason == v1alpha2.ImageCacheReasonImageCachePurge || imageCache.Status.Reason == v1alpha2.ImageCacheReasonImageCacheRefresh {
 			imageCache, err := c.kubefledgedclientset.KubefledgedV1alpha2().ImageCaches(namespace).Get(context.TODO(), name, metav1.GetOptions{})
@@ -629,20 +1568,28 @@ func (c *Controller) syncHandler(wqKey images.WorkQueueKey) error {
 				}
 			}
 			if imageCache.Status.Reason == v1alpha2.ImageCacheReasonImageCacheRefresh {
-				if _, ok := imageCache.Annotations[imageCacheRefreshAnnotationKey]; ok {
-					if err := c.removeAnnotation(imageCache, imageCacheRefreshAnnotationKey); err != nil {
-						glog.Errorf("Error removing Annotation %s from imagecache(%s): %v", imageCacheRefreshAnnotationKey, imageCache.Name, err)
+				var refreshAnnotationKeys []string
+				for _, annotationKey := range []string{imageCacheRefreshAnnotationKey, imageCacheRefreshNowAnnotationKey} {
+					if _, ok := imageCache.Annotations[annotationKey]; ok {
+						refreshAnnotationKeys = append(refreshAnnotationKeys, annotationKey)
+					}
+				}
+				if len(refreshAnnotationKeys) > 0 {
+					if err := c.removeAnnotation(imageCache, refreshAnnotationKeys...); err != nil {
+						glog.Errorf("Error removing Annotation %s from imagecache(%s): %v", strings.Join(refreshAnnotationKeys, ","), imageCache.Name, err)
 						return err
 					}
 				}
 			}
 		}
 
-		if status.Status == v1alpha2.ImageCacheActionStatusSucceeded || status.Status == v1alpha2.ImageCacheActioneNoImagesPulledOrDeleted {
+		if (status.Status == v1alpha2.ImageCacheActionStatusSucceeded || status.Status == v1alpha2.ImageCacheActioneNoImagesPulledOrDeleted) &&
+			len(status.Failures) == 0 {
 			c.recorder.Event(imageCache, corev1.EventTypeNormal, status.Reason, status.Message)
 		}
 
-		if status.Status == v1alpha2.ImageCacheActionStatusFailed {
+		// partial failures under the "partial" failure policy are reported as warnings too
+		if status.Status == v1alpha2.ImageCacheActionStatusFailed || len(status.Failures) > 0 {
 			c.recorder.Event(imageCache, corev1.EventTypeWarning, status.Reason, status.Message)
 		}
 	}
@@ -659,25 +1606,97 @@ func (c *Controller) updateImageCacheStatus(imageCache *v1alpha2.ImageCache, sta
 	// NEVER modify objects from the store. It's a read-only, local cache.
 	// You can use DeepCopy() to make a deep copy of original object and modify this copy
 	// Or create a copy manually for better performance
+	conditions := imageCacheCopy.Status.Conditions
+	cachedImages, shadowedDeletions := imageCacheCopy.Status.CachedImages, imageCacheCopy.Status.ShadowedDeletions
+	coverage, observedGeneration := imageCacheCopy.Status.CoveragePercent, imageCacheCopy.Status.ObservedGeneration
+	lastSuccessfulRefreshTime := imageCacheCopy.Status.LastSuccessfulRefreshTime
 	imageCacheCopy.Status = *status
+	imageCacheCopy.Status.Conditions = conditions
+	// the cached images and shadowed deletions are only recorded by the reconciles of image caches in declarative mode
+	if status.CachedImages == nil {
+		imageCacheCopy.Status.CachedImages = cachedImages
+	}
+	if status.ShadowedDeletions == nil {
+		imageCacheCopy.Status.ShadowedDeletions = shadowedDeletions
+	}
+	// the coverage is only computed once a reconcile completes, and is kept while the next one is processing
+	if status.CoveragePercent == nil {
+		imageCacheCopy.Status.CoveragePercent = coverage
+	}
+	// the observed generation is only advanced by the creates and updates, which reconcile the spec
+	if status.ObservedGeneration == 0 {
+		imageCacheCopy.Status.ObservedGeneration = observedGeneration
+	}
+	// the last successful refresh is only advanced by the reconciles pulling the images which succeed
+	if status.LastSuccessfulRefreshTime == nil {
+		imageCacheCopy.Status.LastSuccessfulRefreshTime = lastSuccessfulRefreshTime
+	}
+	setImageCacheConditions(&imageCacheCopy.Status, imageCacheCopy.Generation)
+	setCoverageCondition(&imageCacheCopy.Status, imageCacheCopy.Spec.MinCoveragePercent, imageCacheCopy.Generation)
+	setStaleCondition(&imageCacheCopy.Status, c.staleThreshold(imageCacheCopy), imageCacheCopy.Generation, time.Now())
 	if imageCacheCopy.Status.Status != v1alpha2.ImageCacheActionStatusProcessing {
 		completionTime := metav1.Now()
 		imageCacheCopy.Status.CompletionTime = &completionTime
 	}
-	// If the CustomResourceSubresources feature gate is not enabled,
-	// we must use Update instead of UpdateStatus to update the Status block of the ImageCache resource.
-	// UpdateStatus will not allow changes to the Spec of the resource,
+	// The status subresource of the ImageCache resource is enabled, so that the status updates do not
+	// advance its generation. UpdateStatus will not allow changes to the Spec of the resource,
 	// which is ideal for ensuring nothing other than resource status has been updated.
-	_, err = c.kubefledgedclientset.KubefledgedV1alpha2().ImageCaches(imageCache.Namespace).Update(context.TODO(), imageCacheCopy, metav1.UpdateOptions{})
+	_, err = c.kubefledgedclientset.KubefledgedV1alpha2().ImageCaches(imageCache.Namespace).UpdateStatus(context.TODO(), imageCacheCopy, metav1.UpdateOptions{})
 	return err
 }
 
-func (c *Controller) removeAnnotation(imageCache *v1alpha2.ImageCache, annotationKey string) error {
+// isGenerationReconciled returns true if the generation of the spec of the image cache was reconciled by
+// a create or update. Refreshes and purges reconcile the image cache regardless of its generation
+func isGenerationReconciled(imageCache *v1alpha2.ImageCache) bool {
+	return imageCache.Generation != 0 && imageCache.Generation <= imageCache.Status.ObservedGeneration
+}
+
+// setImageCacheConditions sets the Ready, Progressing and Degraded conditions from the status of the image cache.
+// The last transition time of a condition is retained if its status does not change
+func setImageCacheConditions(status *v1alpha2.ImageCacheStatus, generation int64) {
+	reason := status.Reason
+	if reason == "" {
+		reason = string(status.Status)
+	}
+	setCondition := func(conditionType string, conditionStatus metav1.ConditionStatus) {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               conditionType,
+			Status:             conditionStatus,
+			ObservedGeneration: generation,
+			Reason:             reason,
+			Message:            status.Message,
+		})
+	}
+	switch status.Status {
+	case v1alpha2.ImageCacheActionStatusProcessing:
+		setCondition(v1alpha2.ImageCacheConditionProgressing, metav1.ConditionTrue)
+		if meta.FindStatusCondition(status.Conditions, v1alpha2.ImageCacheConditionReady) == nil {
+			setCondition(v1alpha2.ImageCacheConditionReady, metav1.ConditionUnknown)
+		}
+	case v1alpha2.ImageCacheActionStatusSucceeded, v1alpha2.ImageCacheActioneNoImagesPulledOrDeleted:
+		setCondition(v1alpha2.ImageCacheConditionReady, metav1.ConditionTrue)
+		setCondition(v1alpha2.ImageCacheConditionProgressing, metav1.ConditionFalse)
+		// an image cache succeeding with failures under the "partial" failure policy is degraded
+		if len(status.Failures) > 0 {
+			setCondition(v1alpha2.ImageCacheConditionDegraded, metav1.ConditionTrue)
+		} else {
+			setCondition(v1alpha2.ImageCacheConditionDegraded, metav1.ConditionFalse)
+		}
+	case v1alpha2.ImageCacheActionStatusFailed, v1alpha2.ImageCacheActionStatusAborted:
+		setCondition(v1alpha2.ImageCacheConditionReady, metav1.ConditionFalse)
+		setCondition(v1alpha2.ImageCacheConditionProgressing, metav1.ConditionFalse)
+		setCondition(v1alpha2.ImageCacheConditionDegraded, metav1.ConditionTrue)
+	}
+}
+
+func (c *Controller) removeAnnotation(imageCache *v1alpha2.ImageCache, annotationKeys ...string) error {
 	imageCacheCopy := imageCache.DeepCopy()
-	delete(imageCacheCopy.Annotations, annotationKey)
+	for _, annotationKey := range annotationKeys {
+		delete(imageCacheCopy.Annotations, annotationKey)
+	}
 	_, err := c.kubefledgedclientset.KubefledgedV1alpha2().ImageCaches(imageCache.Namespace).Update(context.TODO(), imageCacheCopy, metav1.UpdateOptions{})
 	if err == nil {
-		glog.Infof("Annotation %s removed from imagecache(%s)", annotationKey, imageCache.Name)
+		glog.Infof("Annotation %s removed from imagecache(%s)", strings.Join(annotationKeys, ","), imageCache.Name)
 	}
 	return err
 }
@@ -17,7 +17,9 @@ limitations under the License.
 package app
 
 import (
+	"context"
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -28,11 +30,13 @@ import (
 	informers "github.com/senthilrch/kube-fledged/pkg/client/informers/externalversions"
 	kubefledgedinformers "github.com/senthilrch/kube-fledged/pkg/client/informers/externalversions/kubefledged/v1alpha2"
 	"github.com/senthilrch/kube-fledged/pkg/images"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	kubeinformers "k8s.io/client-go/informers"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -58,6 +62,7 @@ func newTestController(kubeclientset kubernetes.Interface, fledgedclientset clie
 	fledgedInformerFactory := informers.NewSharedInformerFactory(fledgedclientset, noResyncPeriodFunc())
 	nodeInformer := kubeInformerFactory.Core().V1().Nodes()
 	imagecacheInformer := fledgedInformerFactory.Kubefledged().V1alpha2().ImageCaches()
+	configMapInformer := kubeInformerFactory.Core().V1().ConfigMaps()
 	imageCacheRefreshFrequency := time.Second * 0
 	imagePullDeadlineDuration := time.Second * 5
 	criClientImage := "senthilrch/fledged-docker-client:latest"
@@ -68,6 +73,8 @@ func newTestController(kubeclientset kubernetes.Interface, fledgedclientset clie
 	jobPriorityClassName := "priority-class-kube-fledged"
 	canDelete := false
 	socketPath := ""
+	imageCacheLabelSelector := ""
+	imageDigestVerification := false
 
 	/* 	startInformers := true
 	   	if startInformers {
@@ -78,26 +85,47 @@ func newTestController(kubeclientset kubernetes.Interface, fledgedclientset clie
 	   	} */
 
 	controller := NewController(kubeclientset,
-		fledgedclientset, fledgedNameSpace, nodeInformer, imagecacheInformer,
-		imageCacheRefreshFrequency, imagePullDeadlineDuration, criClientImage,
-		busyboxImage, imagePullPolicy, serviceAccountName, imageDeleteJobHostNetwork,
-		jobPriorityClassName, canDelete, socketPath)
+		fledgedclientset, fledgedNameSpace, nodeInformer, imagecacheInformer, configMapInformer,
+		nil, nil, nil,
+		ControllerConfig{
+			ImageManagerConfig: images.ImageManagerConfig{
+				ImagePullDeadlineDuration: imagePullDeadlineDuration,
+				CRIClientImage:            criClientImage,
+				BusyboxImage:              busyboxImage,
+				ImagePullPolicy:           imagePullPolicy,
+				HelperImagePullPolicy:     "IfNotPresent",
+				ServiceAccountName:        serviceAccountName,
+				ImageDeleteJobHostNetwork: imageDeleteJobHostNetwork,
+				JobPriorityClassName:      jobPriorityClassName,
+				CanDeleteJob:              canDelete,
+				CRISocketPath:             socketPath,
+				ImageDigestVerification:   imageDigestVerification,
+			},
+			ImageCacheRefreshFrequency: imageCacheRefreshFrequency,
+			ImageCacheLabelSelector:    imageCacheLabelSelector,
+		})
 	controller.nodesSynced = func() bool { return true }
 	controller.imageCachesSynced = func() bool { return true }
+	controller.configMapsSynced = func() bool { return true }
 	return controller, nodeInformer, imagecacheInformer
 }
 
 func TestPreFlightChecks(t *testing.T) {
 	tests := []struct {
-		name                  string
-		jobList               *batchv1.JobList
-		jobListError          error
-		jobDeleteError        error
-		imageCacheList        *kubefledgedv1alpha2.ImageCacheList
-		imageCacheListError   error
-		imageCacheUpdateError error
-		expectErr             bool
-		errorString           string
+		name                    string
+		jobList                 *batchv1.JobList
+		jobListError            error
+		jobDeleteError          error
+		imageCacheList          *kubefledgedv1alpha2.ImageCacheList
+		imageCacheListError     error
+		imageCacheUpdateError   error
+		imageCacheLabelSelector string
+		watchNamespace          string
+		namespaceNotFound       bool
+		deniedVerb              string
+		expectedResumed         int
+		expectErr               bool
+		errorString             string
 	}{
 		{
 			name:                  "#1: No dangling jobs. No imagecaches",
@@ -224,10 +252,102 @@ func TestPreFlightChecks(t *testing.T) {
 			expectErr:             true,
 			errorString:           "Internal error occurred: fake error",
 		},
+		{
+			name: "#8: One dangling job of an imagecache not matching label selector. Job not deleted",
+			jobList: &batchv1.JobList{
+				Items: []batchv1.Job{
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "foo",
+							Labels: map[string]string{
+								"app":         "kubefledged",
+								"kubefledged": "kubefledged-image-manager",
+								"imagecache":  "bar",
+							},
+						},
+					},
+				},
+			},
+			jobListError:            nil,
+			jobDeleteError:          fmt.Errorf("fake error"),
+			imageCacheList:          &kubefledgedv1alpha2.ImageCacheList{Items: []kubefledgedv1alpha2.ImageCache{}},
+			imageCacheListError:     nil,
+			imageCacheUpdateError:   nil,
+			imageCacheLabelSelector: "team=foo",
+			expectErr:               false,
+			errorString:             "",
+		},
+		{
+			name:              "#9: Namespace of kubefledged not found",
+			namespaceNotFound: true,
+			expectErr:         true,
+			errorString:       "namespaces \"kube-fledged\" not found",
+		},
+		{
+			name:           "#10: Jobs and imagecaches listed in the watched namespace",
+			jobList:        &batchv1.JobList{Items: []batchv1.Job{}},
+			imageCacheList: &kubefledgedv1alpha2.ImageCacheList{Items: []kubefledgedv1alpha2.ImageCache{}},
+			watchNamespace: "team-a",
+			expectErr:      false,
+			errorString:    "",
+		},
+		{
+			name:        "#11: Not permitted to create jobs",
+			deniedVerb:  "create",
+			expectErr:   true,
+			errorString: "kubefledged-controller is not permitted to create jobs.batch in namespace kube-fledged",
+		},
+		{
+			name: "#12: One pull job of an imagecache under processing. Job adopted and imagecache resumed",
+			jobList: &batchv1.JobList{
+				Items: []batchv1.Job{
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "foo-0123456789-abcde",
+							Labels: map[string]string{
+								"app":                   "kubefledged",
+								"kubefledged":           "kubefledged-image-manager",
+								"imagecache":            "foo",
+								"kubefledged-work-hash": "0123456789",
+								"kubefledged-work":      "pull",
+							},
+						},
+					},
+				},
+			},
+			jobDeleteError: fmt.Errorf("fake error"),
+			imageCacheList: &kubefledgedv1alpha2.ImageCacheList{
+				Items: []kubefledgedv1alpha2.ImageCache{
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "foo",
+						},
+						Status: kubefledgedv1alpha2.ImageCacheStatus{
+							Status: kubefledgedv1alpha2.ImageCacheActionStatusProcessing,
+							Reason: kubefledgedv1alpha2.ImageCacheReasonImageCacheCreate,
+						},
+					},
+				},
+			},
+			imageCacheUpdateError: fmt.Errorf("fake error"),
+			expectedResumed:       1,
+			expectErr:             false,
+			errorString:           "",
+		},
 	}
 	for _, test := range tests {
 		fakekubeclientset := &fakeclientset.Clientset{}
 		fakefledgedclientset := &kubefledgedclientsetfake.Clientset{}
+		fakekubeclientset.AddReactor("create", "selfsubjectaccessreviews", func(action core.Action) (handled bool, ret runtime.Object, err error) {
+			review := action.(core.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview).DeepCopy()
+			review.Status.Allowed = review.Spec.ResourceAttributes.Verb != test.deniedVerb
+			return true, review, nil
+		})
+		if test.namespaceNotFound {
+			fakekubeclientset.AddReactor("get", "namespaces", func(action core.Action) (handled bool, ret runtime.Object, err error) {
+				return true, nil, apierrors.NewNotFound(corev1.Resource("namespaces"), fledgedNameSpace)
+			})
+		}
 		if test.jobListError != nil {
 			listError := apierrors.NewInternalError(test.jobListError)
 			fakekubeclientset.AddReactor("list", "jobs", func(action core.Action) (handled bool, ret runtime.Object, err error) {
@@ -235,6 +355,9 @@ func TestPreFlightChecks(t *testing.T) {
 			})
 		} else {
 			fakekubeclientset.AddReactor("list", "jobs", func(action core.Action) (handled bool, ret runtime.Object, err error) {
+				if action.GetNamespace() != test.watchNamespace {
+					return true, nil, fmt.Errorf("jobs listed in namespace %q", action.GetNamespace())
+				}
 				return true, test.jobList, nil
 			})
 		}
@@ -256,6 +379,9 @@ func TestPreFlightChecks(t *testing.T) {
 			})
 		} else {
 			fakefledgedclientset.AddReactor("list", "imagecaches", func(action core.Action) (handled bool, ret runtime.Object, err error) {
+				if action.GetNamespace() != test.watchNamespace {
+					return true, nil, fmt.Errorf("imagecaches listed in namespace %q", action.GetNamespace())
+				}
 				return true, test.imageCacheList, nil
 			})
 		}
@@ -271,6 +397,8 @@ func TestPreFlightChecks(t *testing.T) {
 		}
 
 		controller, _, _ := newTestController(fakekubeclientset, fakefledgedclientset)
+		controller.imageCacheLabelSelector = test.imageCacheLabelSelector
+		controller.watchNamespace = test.watchNamespace
 
 		err := controller.PreFlightChecks()
 		if test.expectErr {
@@ -282,6 +410,9 @@ func TestPreFlightChecks(t *testing.T) {
 				t.Errorf("Test: %s failed. err received = %s", test.name, err.Error())
 			}
 		}
+		if actual := controller.workqueue.Len(); !test.expectErr && actual != test.expectedResumed {
+			t.Errorf("Test: %s failed: expectedResumed=%d, actualResumed=%d", test.name, test.expectedResumed, actual)
+		}
 	}
 	t.Logf("%d tests passed", len(tests))
 }
@@ -473,6 +604,8 @@ func TestSyncHandler(t *testing.T) {
 		imageCache        kubefledgedv1alpha2.ImageCache
 		wqKey             images.WorkQueueKey
 		nodeList          *corev1.NodeList
+		configMap         *corev1.ConfigMap
+		maxImages         int
 		expectedActions   []ActionReaction
 		expectErr         bool
 		expectedErrString string
@@ -508,6 +641,66 @@ func TestSyncHandler(t *testing.T) {
 			expectErr:         true,
 			expectedErrString: "No images specified within image list",
 		},*/
+		{
+			name: "#2a: Create - ConfigMap referenced in imagesFrom not found",
+			imageCache: kubefledgedv1alpha2.ImageCache{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "kube-fledged",
+				},
+				Spec: kubefledgedv1alpha2.ImageCacheSpec{
+					CacheSpec: []kubefledgedv1alpha2.CacheSpecImages{
+						{
+							ImagesFrom: &corev1.ConfigMapKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "foo"},
+								Key:                  "images",
+							},
+						},
+					},
+				},
+			},
+			wqKey: images.WorkQueueKey{
+				ObjKey:   "kube-fledged/foo",
+				WorkType: images.ImageCacheCreate,
+			},
+			nodeList:          defaultNodeList,
+			expectedActions:   []ActionReaction{{action: "get", reaction: ""}, {action: "update", reaction: ""}},
+			expectErr:         true,
+			expectedErrString: "ImagesFromConfigMapFailed",
+		},
+		{
+			name: "#2b: Create - Too many images once imagesFrom is read",
+			imageCache: kubefledgedv1alpha2.ImageCache{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "kube-fledged",
+				},
+				Spec: kubefledgedv1alpha2.ImageCacheSpec{
+					CacheSpec: []kubefledgedv1alpha2.CacheSpecImages{
+						{
+							Images: []string{"foo:v1"},
+							ImagesFrom: &corev1.ConfigMapKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "foo"},
+								Key:                  "images",
+							},
+						},
+					},
+				},
+			},
+			wqKey: images.WorkQueueKey{
+				ObjKey:   "kube-fledged/foo",
+				WorkType: images.ImageCacheCreate,
+			},
+			nodeList: defaultNodeList,
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "kube-fledged"},
+				Data:       map[string]string{"images": "bar:v1\nbaz:v1\n"},
+			},
+			maxImages:         2,
+			expectedActions:   []ActionReaction{{action: "get", reaction: ""}, {action: "update", reaction: ""}},
+			expectErr:         true,
+			expectedErrString: "TooManyImages: No. of images (3)",
+		},
 		{
 			name:       "#3: Update - Old imagecache pointer is nil",
 			imageCache: defaultImageCache,
@@ -866,7 +1059,13 @@ func TestSyncHandler(t *testing.T) {
 			}
 		}
 		imagecacheInformer.Informer().GetIndexer().Add(&test.imageCache)
-		err := controller.syncHandler(test.wqKey)
+		if test.configMap != nil {
+			configMapInformer := kubeinformers.NewSharedInformerFactory(fakekubeclientset, noResyncPeriodFunc()).Core().V1().ConfigMaps()
+			configMapInformer.Informer().GetIndexer().Add(test.configMap)
+			controller.configMapsLister = configMapInformer.Lister()
+		}
+		controller.maxImagesPerCache = test.maxImages
+		err := controller.syncHandler(context.TODO(), test.wqKey)
 		if test.expectErr {
 			if err == nil {
 				t.Errorf("Test: %s failed: expectedError=%s, actualError=nil", test.name, test.expectedErrString)
@@ -1059,6 +1258,82 @@ func TestEnqueueImageCache(t *testing.T) {
 			},
 			expectedResult: true,
 		},
+		{
+			name:     "#11: Update - Imagecache refresh-now bumped. Successful queueing",
+			workType: images.ImageCacheUpdate,
+			oldImageCache: kubefledgedv1alpha2.ImageCache{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "foo",
+					Namespace:   "kube-fledged",
+					Annotations: map[string]string{imageCacheRefreshNowAnnotationKey: "2026-01-01T00:00:00Z"},
+				},
+				Spec: defaultImageCache.Spec,
+			},
+			newImageCache: kubefledgedv1alpha2.ImageCache{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "foo",
+					Namespace:   "kube-fledged",
+					Annotations: map[string]string{imageCacheRefreshNowAnnotationKey: "2026-01-02T00:00:00Z"},
+				},
+				Spec: defaultImageCache.Spec,
+			},
+			expectedResult: true,
+		},
+		{
+			name:     "#12: Update - Imagecache refresh-now unchanged. No queueing",
+			workType: images.ImageCacheUpdate,
+			oldImageCache: kubefledgedv1alpha2.ImageCache{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "foo",
+					Namespace:   "kube-fledged",
+					Annotations: map[string]string{imageCacheRefreshNowAnnotationKey: "2026-01-01T00:00:00Z"},
+				},
+				Spec: defaultImageCache.Spec,
+			},
+			newImageCache: kubefledgedv1alpha2.ImageCache{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "foo",
+					Namespace:   "kube-fledged",
+					Annotations: map[string]string{imageCacheRefreshNowAnnotationKey: "2026-01-01T00:00:00Z"},
+				},
+				Spec: defaultImageCache.Spec,
+			},
+			expectedResult: false,
+		},
+		{
+			name:          "#13: Update - Spec changed but generation already reconciled. No queueing",
+			workType:      images.ImageCacheUpdate,
+			oldImageCache: defaultImageCache,
+			newImageCache: kubefledgedv1alpha2.ImageCache{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "foo",
+					Namespace:  "kube-fledged",
+					Generation: 3,
+				},
+				Spec: kubefledgedv1alpha2.ImageCacheSpec{
+					CacheSpec: []kubefledgedv1alpha2.CacheSpecImages{{Images: []string{"foo", "bar"}}},
+				},
+				Status: kubefledgedv1alpha2.ImageCacheStatus{ObservedGeneration: 3},
+			},
+			expectedResult: false,
+		},
+		{
+			name:          "#14: Update - Spec changed with generation advanced. Successful queueing",
+			workType:      images.ImageCacheUpdate,
+			oldImageCache: defaultImageCache,
+			newImageCache: kubefledgedv1alpha2.ImageCache{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "foo",
+					Namespace:  "kube-fledged",
+					Generation: 4,
+				},
+				Spec: kubefledgedv1alpha2.ImageCacheSpec{
+					CacheSpec: []kubefledgedv1alpha2.CacheSpecImages{{Images: []string{"foo", "bar"}}},
+				},
+				Status: kubefledgedv1alpha2.ImageCacheStatus{ObservedGeneration: 3},
+			},
+			expectedResult: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -1072,6 +1347,97 @@ func TestEnqueueImageCache(t *testing.T) {
 	}
 }
 
+func TestEnqueueImageCacheDebounce(t *testing.T) {
+	oldImageCache := &kubefledgedv1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "kube-fledged",
+		},
+		Spec: kubefledgedv1alpha2.ImageCacheSpec{
+			CacheSpec: []kubefledgedv1alpha2.CacheSpecImages{{Images: []string{"foo"}}},
+		},
+	}
+	firstUpdate := oldImageCache.DeepCopy()
+	firstUpdate.Spec.CacheSpec[0].Images = []string{"foo", "bar"}
+	secondUpdate := oldImageCache.DeepCopy()
+	secondUpdate.Spec.CacheSpec[0].Images = []string{"foo", "bar", "baz"}
+
+	fakekubeclientset := &fakeclientset.Clientset{}
+	fakefledgedclientset := &kubefledgedclientsetfake.Clientset{}
+	controller, _, _ := newTestController(fakekubeclientset, fakefledgedclientset)
+	controller.updateDebounceWindow = 50 * time.Millisecond
+
+	controller.enqueueImageCache(images.ImageCacheUpdate, oldImageCache, firstUpdate)
+	controller.enqueueImageCache(images.ImageCacheUpdate, firstUpdate, secondUpdate)
+	if controller.workqueue.Len() != 0 {
+		t.Errorf("Update queued before the debounce window: workqueue length=%d", controller.workqueue.Len())
+	}
+	time.Sleep(150 * time.Millisecond)
+	if controller.workqueue.Len() != 1 {
+		t.Fatalf("Updates within the debounce window not coalesced: expected workqueue length=1, actual=%d", controller.workqueue.Len())
+	}
+	obj, _ := controller.workqueue.Get()
+	if wqKey := obj.(images.WorkQueueKey); wqKey.OldImageCache != oldImageCache {
+		t.Errorf("Coalesced update does not compare with the spec before the first update: %+v", wqKey.OldImageCache)
+	}
+	controller.workqueue.Done(obj)
+
+	// after the pending update is processed, the next update is queued again
+	controller.pendingUpdates = map[string]bool{}
+	controller.enqueueImageCache(images.ImageCacheUpdate, secondUpdate, firstUpdate)
+	time.Sleep(150 * time.Millisecond)
+	if controller.workqueue.Len() != 1 {
+		t.Errorf("Update after the debounce window not queued: expected workqueue length=1, actual=%d", controller.workqueue.Len())
+	}
+}
+
+func TestAfterStartupDelay(t *testing.T) {
+	controller, _, _ := newTestController(&fakeclientset.Clientset{}, &kubefledgedclientsetfake.Clientset{})
+	controller.startupDelay = 50 * time.Millisecond
+
+	start := time.Now()
+	ran := time.Time{}
+	controller.afterStartupDelay(make(chan struct{}), func() { ran = time.Now() })
+	if ran.IsZero() || ran.Sub(start) < controller.startupDelay {
+		t.Errorf("Test: expected to run after %s, ran after %s", controller.startupDelay, ran.Sub(start))
+	}
+
+	stopCh := make(chan struct{})
+	close(stopCh)
+	controller.afterStartupDelay(stopCh, func() { t.Errorf("Test: ran after stopCh was closed") })
+}
+
+func TestStartReconcile(t *testing.T) {
+	controller, _, _ := newTestController(fakeclientset.NewSimpleClientset(), kubefledgedclientsetfake.NewSimpleClientset())
+	refresh := images.WorkQueueKey{ObjKey: "kube-fledged/foo", WorkType: images.ImageCacheRefresh}
+	purge := images.WorkQueueKey{ObjKey: "kube-fledged/foo", WorkType: images.ImageCachePurge}
+	otherCache := images.WorkQueueKey{ObjKey: "kube-fledged/bar", WorkType: images.ImageCachePurge}
+	statusUpdate := images.WorkQueueKey{ObjKey: "kube-fledged/foo", WorkType: images.ImageCacheStatusUpdate}
+
+	if !controller.startReconcile(refresh) {
+		t.Fatalf("Refresh of idle image cache held back")
+	}
+	if controller.startReconcile(purge) {
+		t.Errorf("Purge started while refresh of the image cache is active")
+	}
+	if !controller.startReconcile(otherCache) {
+		t.Errorf("Purge of other image cache held back by refresh")
+	}
+	if !controller.startReconcile(statusUpdate) {
+		t.Errorf("Status update held back by active refresh")
+	}
+	controller.endReconcile(refresh.ObjKey)
+	if !controller.startReconcile(purge) {
+		t.Errorf("Purge held back after refresh of the image cache completed")
+	}
+
+	// an active reconcile whose status update was lost times out
+	controller.activeReconciles[purge.ObjKey] = time.Now().Add(-2 * controller.reconcileTimeout)
+	if !controller.startReconcile(refresh) {
+		t.Errorf("Refresh held back by timed out purge")
+	}
+}
+
 func TestProcessNextWorkItem(t *testing.T) {
 	type ActionReaction struct {
 		action   string
@@ -1188,3 +1554,439 @@ func TestProcessNextWorkItem(t *testing.T) {
 	}
 	t.Logf("%d tests passed", len(tests))
 }
+
+func TestSyncHandlerIncrementalUpdate(t *testing.T) {
+	imageCacheOf := func(images ...string) kubefledgedv1alpha2.ImageCache {
+		return kubefledgedv1alpha2.ImageCache{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: fledgedNameSpace,
+			},
+			Spec: kubefledgedv1alpha2.ImageCacheSpec{
+				CacheSpec: []kubefledgedv1alpha2.CacheSpecImages{
+					{
+						Images: images,
+					},
+				},
+			},
+		}
+	}
+	tests := []struct {
+		name           string
+		oldImageCache  kubefledgedv1alpha2.ImageCache
+		newImageCache  kubefledgedv1alpha2.ImageCache
+		expectedPulls  []string
+		expectedPurges []string
+		expectedSkip   bool
+	}{
+		{
+			name:          "#1: Add one image",
+			oldImageCache: imageCacheOf("foo:v1", "bar:v1"),
+			newImageCache: imageCacheOf("foo:v1", "bar:v1", "baz:v1"),
+			expectedPulls: []string{"baz:v1"},
+		},
+		{
+			name:           "#2: Remove one image",
+			oldImageCache:  imageCacheOf("foo:v1", "bar:v1"),
+			newImageCache:  imageCacheOf("foo:v1"),
+			expectedPurges: []string{"bar:v1"},
+		},
+		{
+			name:           "#3: Change tag of an image",
+			oldImageCache:  imageCacheOf("foo:v1", "bar:v1"),
+			newImageCache:  imageCacheOf("foo:v2", "bar:v1"),
+			expectedPulls:  []string{"foo:v2"},
+			expectedPurges: []string{"foo:v1"},
+		},
+		{
+			name:          "#4: Generation already reconciled",
+			oldImageCache: imageCacheOf("foo:v1", "bar:v1"),
+			newImageCache: func() kubefledgedv1alpha2.ImageCache {
+				imageCache := imageCacheOf("foo:v1")
+				imageCache.Generation = 2
+				imageCache.Status.ObservedGeneration = 2
+				return imageCache
+			}(),
+			expectedSkip: true,
+		},
+	}
+	for _, test := range tests {
+		fakekubeclientset := &fakeclientset.Clientset{}
+		fakefledgedclientset := &kubefledgedclientsetfake.Clientset{}
+		newImageCache := test.newImageCache
+		fakefledgedclientset.AddReactor("*", "imagecaches", func(action core.Action) (handled bool, ret runtime.Object, err error) {
+			return true, &newImageCache, nil
+		})
+		controller, nodeInformer, imagecacheInformer := newTestController(fakekubeclientset, fakefledgedclientset)
+		nodeInformer.Informer().GetIndexer().Add(&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "fakenode",
+				Labels: map[string]string{"kubernetes.io/hostname": "bar"},
+			},
+		})
+		imagecacheInformer.Informer().GetIndexer().Add(&newImageCache)
+		err := controller.syncHandler(context.TODO(), images.WorkQueueKey{
+			ObjKey:        "kube-fledged/foo",
+			WorkType:      images.ImageCacheUpdate,
+			OldImageCache: &test.oldImageCache,
+		})
+		if err != nil {
+			t.Errorf("Test: %s failed. expectedError=nil, actualError=%s", test.name, err.Error())
+			continue
+		}
+		// one work request per image plus the request signalling the end of the sync action
+		expectedRequests := len(test.expectedPulls) + len(test.expectedPurges) + 1
+		if test.expectedSkip {
+			expectedRequests = 0
+		}
+		wait.PollImmediate(time.Millisecond, time.Second, func() (bool, error) {
+			return controller.imageworkqueue.Len() == expectedRequests, nil
+		})
+		var pulls, purges []string
+		for controller.imageworkqueue.Len() > 0 {
+			obj, _ := controller.imageworkqueue.Get()
+			iwr := obj.(images.ImageWorkRequest)
+			controller.imageworkqueue.Done(obj)
+			if iwr.Image == "" {
+				continue
+			}
+			if iwr.WorkType == images.ImageCachePurge {
+				purges = append(purges, iwr.Image)
+			} else {
+				pulls = append(pulls, iwr.Image)
+			}
+		}
+		if !reflect.DeepEqual(pulls, test.expectedPulls) || !reflect.DeepEqual(purges, test.expectedPurges) {
+			t.Errorf("Test: %s failed: expectedPulls=%v, actualPulls=%v, expectedPurges=%v, actualPurges=%v",
+				test.name, test.expectedPulls, pulls, test.expectedPurges, purges)
+		}
+	}
+	t.Logf("%d tests passed", len(tests))
+}
+
+func TestFilterSkipCacheNodes(t *testing.T) {
+	nodeFoo := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+	nodeBar := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "bar", Annotations: map[string]string{nodeSkipCacheAnnotationKey: "true"}}}
+	nodeBaz := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "baz", Annotations: map[string]string{nodeSkipCacheAnnotationKey: "false"}}}
+	tests := []struct {
+		name          string
+		nodes         []*corev1.Node
+		expectedNodes []*corev1.Node
+	}{
+		{
+			name:          "#1: No nodes opted out",
+			nodes:         []*corev1.Node{nodeFoo, nodeBaz},
+			expectedNodes: []*corev1.Node{nodeFoo, nodeBaz},
+		},
+		{
+			name:          "#2: Node annotated with skip-cache=true",
+			nodes:         []*corev1.Node{nodeFoo, nodeBar, nodeBaz},
+			expectedNodes: []*corev1.Node{nodeFoo, nodeBaz},
+		},
+		{
+			name:          "#3: All nodes opted out",
+			nodes:         []*corev1.Node{nodeBar},
+			expectedNodes: []*corev1.Node{},
+		},
+	}
+	for _, test := range tests {
+		nodes := filterSkipCacheNodes(test.nodes)
+		if !reflect.DeepEqual(nodes, test.expectedNodes) {
+			t.Errorf("Test: %s failed: expectedNodes=%v, actualNodes=%v", test.name, test.expectedNodes, nodes)
+		}
+	}
+	t.Logf("%d tests passed", len(tests))
+}
+
+func TestImageListNodesNodeNames(t *testing.T) {
+	controller, nodeInformer, _ := newTestController(&fakeclientset.Clientset{}, &kubefledgedclientsetfake.Clientset{})
+	for _, n := range []*corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{"pool": "foo"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node2", Labels: map[string]string{"pool": "foo"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node3", Labels: map[string]string{"pool": "bar"}}},
+	} {
+		nodeInformer.Informer().GetIndexer().Add(n)
+	}
+	cacheSpecImages := kubefledgedv1alpha2.CacheSpecImages{
+		Images:       []string{"foo:v1"},
+		NodeSelector: map[string]string{"pool": "foo"},
+		NodeNames:    []string{"node2", "node3", "node9"},
+	}
+	nodes, err := controller.imageListNodes(fledgedNameSpace, cacheSpecImages)
+	if err != nil || len(nodes) != 1 || nodes[0].Name != "node2" {
+		t.Errorf("Test: expectedNodes=[node2], actualNodes=%v, err=%v", nodes, err)
+	}
+
+	imageCache := &kubefledgedv1alpha2.ImageCache{
+		Spec: kubefledgedv1alpha2.ImageCacheSpec{CacheSpec: []kubefledgedv1alpha2.CacheSpecImages{
+			cacheSpecImages, {Images: []string{"bar:v1"}, NodeNames: []string{"node8", "node1"}}}},
+	}
+	missing := controller.missingNodeNames(imageCache)
+	if expected := []string{"node8", "node9"}; !reflect.DeepEqual(missing, expected) {
+		t.Errorf("Test: expectedMissing=%v, actualMissing=%v", expected, missing)
+	}
+	expectedMessage := kubefledgedv1alpha2.ImageCacheMessagePullingImages + ". " + kubefledgedv1alpha2.ImageCacheMessageNodesNotFound + ": node8, node9"
+	if message := withMissingNodes(kubefledgedv1alpha2.ImageCacheMessagePullingImages, missing); message != expectedMessage {
+		t.Errorf("Test: expectedMessage=%q, actualMessage=%q", expectedMessage, message)
+	}
+}
+
+func TestRetargetNode(t *testing.T) {
+	ready := []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+	notReady := []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionFalse}}
+	pool := map[string]string{"pool": "foo"}
+	deletedNode := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: pool}}
+	nodes := []*corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node4", Labels: pool}, Status: corev1.NodeStatus{Conditions: ready}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node3", Labels: pool}, Status: corev1.NodeStatus{Conditions: ready}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node2", Labels: pool}, Status: corev1.NodeStatus{Conditions: notReady}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node0", Labels: map[string]string{"pool": "bar"}}, Status: corev1.NodeStatus{Conditions: ready}},
+	}
+	tests := []struct {
+		name         string
+		nodeSelector map[string]string
+		imageList    int
+		expectedNode string
+	}{
+		{name: "#1: First ready node of the pool", nodeSelector: pool, expectedNode: "node3"},
+		{name: "#2: No ready node of the pool", nodeSelector: map[string]string{"pool": "baz"}, expectedNode: ""},
+		{name: "#3: Image list not in the spec", nodeSelector: pool, imageList: 1, expectedNode: ""},
+	}
+	for _, test := range tests {
+		fakekubeclientset := &fakeclientset.Clientset{}
+		controller, nodeInformer, _ := newTestController(fakekubeclientset, &kubefledgedclientsetfake.Clientset{})
+		for _, n := range nodes {
+			nodeInformer.Informer().GetIndexer().Add(n)
+		}
+		imageCache := &kubefledgedv1alpha2.ImageCache{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: fledgedNameSpace},
+			Spec: kubefledgedv1alpha2.ImageCacheSpec{
+				CacheSpec: []kubefledgedv1alpha2.CacheSpecImages{{Images: []string{"foo:v1"}, NodeSelector: test.nodeSelector, RetargetOnNodeDeletion: true}},
+			},
+		}
+		node := controller.retargetNode(images.ImageWorkRequest{Image: "foo:v1", Node: deletedNode, Imagecache: imageCache, ImageList: test.imageList})
+		actualNode := ""
+		if node != nil {
+			actualNode = node.Name
+		}
+		if actualNode != test.expectedNode {
+			t.Errorf("Test: %s failed: expectedNode=%s, actualNode=%s", test.name, test.expectedNode, actualNode)
+		}
+	}
+	t.Logf("%d tests passed", len(tests))
+}
+
+func TestImagesOf(t *testing.T) {
+	optional := true
+	configMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: fledgedNameSpace,
+		},
+		Data: map[string]string{
+			"images": "foo:v1\n\n# comment\n bar:v1 \nfoo:v1\n",
+		},
+	}
+	tests := []struct {
+		name              string
+		cacheSpecImages   kubefledgedv1alpha2.CacheSpecImages
+		expectedImages    []string
+		expectErr         bool
+		expectedErrString string
+	}{
+		{
+			name:            "#1: No imagesFrom",
+			cacheSpecImages: kubefledgedv1alpha2.CacheSpecImages{Images: []string{"foo:v1"}},
+			expectedImages:  []string{"foo:v1"},
+		},
+		{
+			name: "#2: Images merged with configmap",
+			cacheSpecImages: kubefledgedv1alpha2.CacheSpecImages{
+				Images: []string{"baz:v1", "bar:v1"},
+				ImagesFrom: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "foo"},
+					Key:                  "images",
+				},
+			},
+			expectedImages: []string{"baz:v1", "bar:v1", "foo:v1"},
+		},
+		{
+			name: "#3: Key not found",
+			cacheSpecImages: kubefledgedv1alpha2.CacheSpecImages{
+				ImagesFrom: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "foo"},
+					Key:                  "nokey",
+				},
+			},
+			expectErr:         true,
+			expectedErrString: "key nokey not found in configmap foo",
+		},
+		{
+			name: "#4: ConfigMap not found",
+			cacheSpecImages: kubefledgedv1alpha2.CacheSpecImages{
+				ImagesFrom: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "bar"},
+					Key:                  "images",
+				},
+			},
+			expectErr:         true,
+			expectedErrString: "\"bar\" not found",
+		},
+		{
+			name: "#5: Optional configmap not found",
+			cacheSpecImages: kubefledgedv1alpha2.CacheSpecImages{
+				Images: []string{"foo:v1"},
+				ImagesFrom: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "bar"},
+					Key:                  "images",
+					Optional:             &optional,
+				},
+			},
+			expectedImages: []string{"foo:v1"},
+		},
+		{
+			name: "#6: Images merged with repository tags",
+			cacheSpecImages: kubefledgedv1alpha2.CacheSpecImages{
+				Images: []string{"nginx:v1.0"},
+				Repositories: []kubefledgedv1alpha2.CacheSpecRepository{
+					{Repository: "nginx", IncludeTags: []string{"v1.*"}, ExcludeTags: []string{"*-rc"}},
+				},
+			},
+			expectedImages: []string{"nginx:v1.0", "nginx:v1.1"},
+		},
+		{
+			name: "#7: Repository of a registry not listing tags is skipped",
+			cacheSpecImages: kubefledgedv1alpha2.CacheSpecImages{
+				Images: []string{"foo:v1"},
+				Repositories: []kubefledgedv1alpha2.CacheSpecRepository{
+					{Repository: "example.com/private"},
+				},
+			},
+			expectedImages: []string{"foo:v1"},
+		},
+		{
+			name: "#8: Listing tags fails",
+			cacheSpecImages: kubefledgedv1alpha2.CacheSpecImages{
+				Repositories: []kubefledgedv1alpha2.CacheSpecRepository{
+					{Repository: "example.com/unavailable"},
+				},
+			},
+			expectErr:         true,
+			expectedErrString: "error listing tags of repository example.com/unavailable",
+		},
+	}
+	listTags := func(repository string) ([]string, error) {
+		switch repository {
+		case "nginx":
+			return []string{"v1.0", "v1.1", "v1.2-rc", "v2.0"}, nil
+		case "example.com/private":
+			return nil, fmt.Errorf("%w: repository private: 404 Not Found", images.ErrTagsNotListable)
+		}
+		return nil, fmt.Errorf("listing tags of repository unavailable: 503 Service Unavailable")
+	}
+	for _, test := range tests {
+		// the ConfigMaps are read from the informer cache if they are watched, and from the api server otherwise
+		for _, watched := range []bool{true, false} {
+			fakekubeclientset := fakeclientset.NewSimpleClientset(&configMap)
+			fakefledgedclientset := &kubefledgedclientsetfake.Clientset{}
+			controller, _, _ := newTestController(fakekubeclientset, fakefledgedclientset)
+			controller.listTags = listTags
+			controller.configMapsLister = nil
+			if watched {
+				configMapInformer := kubeinformers.NewSharedInformerFactory(fakekubeclientset, noResyncPeriodFunc()).Core().V1().ConfigMaps()
+				configMapInformer.Informer().GetIndexer().Add(&configMap)
+				controller.configMapsLister = configMapInformer.Lister()
+			}
+
+			imageList, err := controller.imagesOf(fledgedNameSpace, test.cacheSpecImages)
+			if test.expectErr {
+				if err == nil || !strings.Contains(err.Error(), test.expectedErrString) {
+					t.Errorf("Test: %s (watched=%t) failed: expectedError=%s, actualError=%v", test.name, watched, test.expectedErrString, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("Test: %s (watched=%t) failed. expectedError=nil, actualError=%s", test.name, watched, err.Error())
+			}
+			if !reflect.DeepEqual(imageList, test.expectedImages) {
+				t.Errorf("Test: %s (watched=%t) failed: expectedImages=%v, actualImages=%v", test.name, watched, test.expectedImages, imageList)
+			}
+		}
+	}
+	t.Logf("%d tests passed", len(tests))
+}
+
+func TestSetImageCacheConditions(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   kubefledgedv1alpha2.ImageCacheActionStatus
+		failures map[string]kubefledgedv1alpha2.NodeReasonMessageList
+		expected map[string]metav1.ConditionStatus
+	}{
+		{
+			name:   "#1: Processing",
+			status: kubefledgedv1alpha2.ImageCacheActionStatusProcessing,
+			expected: map[string]metav1.ConditionStatus{
+				kubefledgedv1alpha2.ImageCacheConditionReady:       metav1.ConditionUnknown,
+				kubefledgedv1alpha2.ImageCacheConditionProgressing: metav1.ConditionTrue,
+			},
+		},
+		{
+			name:   "#2: Succeeded",
+			status: kubefledgedv1alpha2.ImageCacheActionStatusSucceeded,
+			expected: map[string]metav1.ConditionStatus{
+				kubefledgedv1alpha2.ImageCacheConditionReady:       metav1.ConditionTrue,
+				kubefledgedv1alpha2.ImageCacheConditionProgressing: metav1.ConditionFalse,
+				kubefledgedv1alpha2.ImageCacheConditionDegraded:    metav1.ConditionFalse,
+			},
+		},
+		{
+			name:   "#3: Failed",
+			status: kubefledgedv1alpha2.ImageCacheActionStatusFailed,
+			expected: map[string]metav1.ConditionStatus{
+				kubefledgedv1alpha2.ImageCacheConditionReady:       metav1.ConditionFalse,
+				kubefledgedv1alpha2.ImageCacheConditionProgressing: metav1.ConditionFalse,
+				kubefledgedv1alpha2.ImageCacheConditionDegraded:    metav1.ConditionTrue,
+			},
+		},
+		{
+			name:     "#4: Succeeded with failures",
+			status:   kubefledgedv1alpha2.ImageCacheActionStatusSucceeded,
+			failures: map[string]kubefledgedv1alpha2.NodeReasonMessageList{"foo:v1": {{Node: "node1"}}},
+			expected: map[string]metav1.ConditionStatus{
+				kubefledgedv1alpha2.ImageCacheConditionReady:       metav1.ConditionTrue,
+				kubefledgedv1alpha2.ImageCacheConditionProgressing: metav1.ConditionFalse,
+				kubefledgedv1alpha2.ImageCacheConditionDegraded:    metav1.ConditionTrue,
+			},
+		},
+	}
+	for _, test := range tests {
+		status := kubefledgedv1alpha2.ImageCacheStatus{Status: test.status, Reason: "fakereason", Message: "fakemessage", Failures: test.failures}
+		setImageCacheConditions(&status, 2)
+		if len(status.Conditions) != len(test.expected) {
+			t.Errorf("Test: %s failed: expectedConditions=%d, actualConditions=%d", test.name, len(test.expected), len(status.Conditions))
+		}
+		for _, condition := range status.Conditions {
+			if condition.Status != test.expected[condition.Type] {
+				t.Errorf("Test: %s failed: condition %s expectedStatus=%s, actualStatus=%s", test.name, condition.Type, test.expected[condition.Type], condition.Status)
+			}
+			if condition.ObservedGeneration != 2 || condition.Reason != "fakereason" || condition.LastTransitionTime.IsZero() {
+				t.Errorf("Test: %s failed: condition %s not populated: %+v", test.name, condition.Type, condition)
+			}
+		}
+	}
+
+	// the last transition time is retained while the status of a condition does not change
+	status := kubefledgedv1alpha2.ImageCacheStatus{Status: kubefledgedv1alpha2.ImageCacheActionStatusSucceeded, Reason: "fakereason"}
+	setImageCacheConditions(&status, 1)
+	transitionTime := metav1.NewTime(time.Now().Add(-time.Hour))
+	for i := range status.Conditions {
+		status.Conditions[i].LastTransitionTime = transitionTime
+	}
+	setImageCacheConditions(&status, 2)
+	for _, condition := range status.Conditions {
+		if !condition.LastTransitionTime.Equal(&transitionTime) {
+			t.Errorf("Test: retained transition time failed: condition %s lastTransitionTime changed", condition.Type)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// coveragePercent returns the percentage of the nodes covered by the latest reconcile of the image cache,
+// rounded down. A purge leaves no node covered, and an image cache without nodes is fully covered
+func coveragePercent(results map[string]images.ImageWorkResult) int32 {
+	coverage, purged := coverageOf(results)
+	if purged {
+		return 0
+	}
+	if coverage.nodes == 0 {
+		return 100
+	}
+	return int32(coverage.nodesCovered * 100 / coverage.nodes)
+}
+
+// setCoverageCondition sets the SufficientCoverage condition of an image cache with a min coverage percent
+// from its coverage percent, and removes the condition of an image cache without one. The condition is not
+// set until the coverage is known. The last transition time is retained if its status does not change
+func setCoverageCondition(status *v1alpha2.ImageCacheStatus, minCoveragePercent *int32, generation int64) {
+	if minCoveragePercent == nil {
+		meta.RemoveStatusCondition(&status.Conditions, v1alpha2.ImageCacheConditionSufficientCoverage)
+		return
+	}
+	if status.CoveragePercent == nil {
+		return
+	}
+	condition := metav1.Condition{
+		Type:               v1alpha2.ImageCacheConditionSufficientCoverage,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             v1alpha2.ImageCacheReasonCoverageReached,
+		Message:            fmt.Sprintf("%d%% of the nodes covered, minimum %d%%", *status.CoveragePercent, *minCoveragePercent),
+	}
+	if *status.CoveragePercent < *minCoveragePercent {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = v1alpha2.ImageCacheReasonCoverageBelowMinimum
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+}
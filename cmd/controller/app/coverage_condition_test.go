@@ -0,0 +1,78 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"testing"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCoverageCondition(t *testing.T) {
+	result := func(node string, status string, workType images.WorkType) images.ImageWorkResult {
+		return images.ImageWorkResult{Status: status, ImageWorkRequest: images.ImageWorkRequest{Image: "foo:v1", WorkType: workType,
+			Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"kubernetes.io/hostname": node}}}}}
+	}
+	results := map[string]images.ImageWorkResult{
+		"job1": result("node1", images.ImageWorkResultStatusSucceeded, images.ImageCacheCreate),
+		"job2": result("node2", images.ImageWorkResultStatusAlreadyPulled, images.ImageCacheCreate),
+		"job3": result("node3", images.ImageWorkResultStatusFailed, images.ImageCacheCreate),
+	}
+	purge := map[string]images.ImageWorkResult{"job1": result("node1", images.ImageWorkResultStatusSucceeded, images.ImageCachePurge)}
+
+	percent := func(p int32) *int32 { return &p }
+	tests := []struct {
+		name               string
+		results            map[string]images.ImageWorkResult
+		minCoveragePercent *int32
+		expectedPercent    int32
+		expectedCondition  metav1.ConditionStatus
+	}{
+		{name: "#1: Coverage below the minimum", results: results, minCoveragePercent: percent(90),
+			expectedPercent: 66, expectedCondition: metav1.ConditionFalse},
+		{name: "#2: Coverage reaching the minimum", results: results, minCoveragePercent: percent(66),
+			expectedPercent: 66, expectedCondition: metav1.ConditionTrue},
+		{name: "#3: No minimum", results: results, expectedPercent: 66},
+		{name: "#4: Purge", results: purge, minCoveragePercent: percent(0),
+			expectedPercent: 0, expectedCondition: metav1.ConditionTrue},
+		{name: "#5: No nodes", results: map[string]images.ImageWorkResult{}, minCoveragePercent: percent(100),
+			expectedPercent: 100, expectedCondition: metav1.ConditionTrue},
+	}
+	for _, test := range tests {
+		coverage := coveragePercent(test.results)
+		status := v1alpha2.ImageCacheStatus{CoveragePercent: &coverage, Conditions: []metav1.Condition{
+			{Type: v1alpha2.ImageCacheConditionSufficientCoverage, Status: metav1.ConditionTrue, Reason: v1alpha2.ImageCacheReasonCoverageReached}}}
+		setCoverageCondition(&status, test.minCoveragePercent, 2)
+		if coverage != test.expectedPercent {
+			t.Errorf("Test: %s failed: expectedPercent=%d, actualPercent=%d", test.name, test.expectedPercent, coverage)
+		}
+		condition := meta.FindStatusCondition(status.Conditions, v1alpha2.ImageCacheConditionSufficientCoverage)
+		if test.expectedCondition == "" {
+			if condition != nil {
+				t.Errorf("Test: %s failed: expectedCondition=<none>, actualCondition=%s", test.name, condition.Status)
+			}
+			continue
+		}
+		if condition == nil || condition.Status != test.expectedCondition || condition.ObservedGeneration != 2 {
+			t.Errorf("Test: %s failed: expectedCondition=%s, actualCondition=%+v", test.name, test.expectedCondition, condition)
+		}
+	}
+}
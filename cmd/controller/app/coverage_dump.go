@@ -0,0 +1,100 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/golang/glog"
+)
+
+// nodeCoverage is the coverage of an image cache on a node in the coverage table
+type nodeCoverage struct {
+	images, cached, failed, jobs int
+}
+
+// DumpCoverage writes a table of the coverage of every image cache on each of its nodes to out: the no. of
+// images of the image cache meant for the node, and how many of them are cached or failed as per the plan
+// of the image cache, with the no. of its jobs in flight on the node
+func (c *Controller) DumpCoverage(out io.Writer) error {
+	plans, err := c.plan()
+	if err != nil {
+		return fmt.Errorf("error building the plan of the image caches: %v", err)
+	}
+	return writeCoverage(out, plans, c.imageManager.JobsInFlight())
+}
+
+// writeCoverage writes the coverage of the image caches by node, sorted by image cache and node. An image
+// cache without nodes has a single row with no node
+func writeCoverage(out io.Writer, plans map[string]imageCachePlan, jobsInFlight map[string]map[string]int) error {
+	keys := make([]string, 0, len(plans))
+	for key := range plans {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "IMAGECACHE\tSTATUS\tNODE\tIMAGES\tCACHED\tFAILED\tJOBS\tCOVERAGE")
+	for _, key := range keys {
+		plan := plans[key]
+		if plan.Error != "" {
+			glog.Warningf("Coverage of image cache %s is partial: %s", key, plan.Error)
+		}
+		coverage := map[string]*nodeCoverage{}
+		for _, nodes := range plan.Images {
+			for node, status := range nodes {
+				if coverage[node] == nil {
+					coverage[node] = &nodeCoverage{}
+				}
+				coverage[node].images++
+				switch status {
+				case planStatusCached:
+					coverage[node].cached++
+				case planStatusPending, planStatusPurged:
+				default:
+					coverage[node].failed++
+				}
+			}
+		}
+		for node, jobs := range jobsInFlight[key] {
+			if coverage[node] == nil {
+				coverage[node] = &nodeCoverage{}
+			}
+			coverage[node].jobs = jobs
+		}
+		if len(coverage) == 0 {
+			fmt.Fprintf(w, "%s\t%s\t<none>\t0\t0\t0\t0\t-\n", key, plan.Status)
+			continue
+		}
+		nodes := make([]string, 0, len(coverage))
+		for node := range coverage {
+			nodes = append(nodes, node)
+		}
+		sort.Strings(nodes)
+		for _, node := range nodes {
+			nc := coverage[node]
+			percent := "-"
+			if nc.images > 0 {
+				percent = fmt.Sprintf("%d%%", nc.cached*100/nc.images)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t%s\n", key, plan.Status, node, nc.images, nc.cached, nc.failed, nc.jobs, percent)
+		}
+	}
+	return w.Flush()
+}
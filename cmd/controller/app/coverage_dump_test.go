@@ -0,0 +1,82 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"bytes"
+	"testing"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWriteCoverage(t *testing.T) {
+	plans := map[string]imageCachePlan{
+		"kube-fledged/foo": {
+			Status: v1alpha2.ImageCacheActionStatusFailed,
+			Images: map[string]map[string]string{
+				"foo:v1": {"node1": planStatusCached, "node2": "ErrImagePull"},
+				"bar:v1": {"node1": planStatusCached, "node2": planStatusCached},
+			},
+		},
+		"kube-fledged/bar": {
+			Status: v1alpha2.ImageCacheActionStatusProcessing,
+			Images: map[string]map[string]string{"bar:v1": {"node1": planStatusPending}},
+		},
+		"kube-fledged/empty": {Status: v1alpha2.ImageCacheActionStatusSucceeded, Images: map[string]map[string]string{}},
+	}
+	jobsInFlight := map[string]map[string]int{"kube-fledged/bar": {"node1": 1}}
+
+	var out bytes.Buffer
+	if err := writeCoverage(&out, plans, jobsInFlight); err != nil {
+		t.Fatalf("Test: writing coverage failed: %v", err)
+	}
+	expected := "" +
+		"IMAGECACHE          STATUS      NODE    IMAGES  CACHED  FAILED  JOBS  COVERAGE\n" +
+		"kube-fledged/bar    Processing  node1   1       0       0       1     0%\n" +
+		"kube-fledged/empty  Succeeded   <none>  0       0       0       0     -\n" +
+		"kube-fledged/foo    Failed      node1   2       2       0       0     100%\n" +
+		"kube-fledged/foo    Failed      node2   2       1       1       0     50%\n"
+	if actual := out.String(); actual != expected {
+		t.Errorf("Test: expectedOutput=\n%s\nactualOutput=\n%s", expected, actual)
+	}
+}
+
+func TestDumpCoverage(t *testing.T) {
+	controller, nodeInformer, imagecacheInformer := newTestController(&fakeclientset.Clientset{}, &kubefledgedclientsetfake.Clientset{})
+	nodeInformer.Informer().GetIndexer().Add(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1",
+		Labels: map[string]string{"kubernetes.io/hostname": "node1"}}})
+	imagecacheInformer.Informer().GetIndexer().Add(&v1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: fledgedNameSpace},
+		Spec:       v1alpha2.ImageCacheSpec{CacheSpec: []v1alpha2.CacheSpecImages{{Images: []string{"foo:v1"}}}},
+		Status:     v1alpha2.ImageCacheStatus{Status: v1alpha2.ImageCacheActionStatusSucceeded},
+	})
+
+	var out bytes.Buffer
+	if err := controller.DumpCoverage(&out); err != nil {
+		t.Fatalf("Test: dumping coverage failed: %v", err)
+	}
+	expected := "" +
+		"IMAGECACHE        STATUS     NODE   IMAGES  CACHED  FAILED  JOBS  COVERAGE\n" +
+		"kube-fledged/foo  Succeeded  node1  1       1       0       0     100%\n"
+	if actual := out.String(); actual != expected {
+		t.Errorf("Test: expectedOutput=\n%s\nactualOutput=\n%s", expected, actual)
+	}
+}
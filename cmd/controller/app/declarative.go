@@ -0,0 +1,179 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	"github.com/senthilrch/kube-fledged/pkg/tracing"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// isDeclarative returns true if the nodes are reconciled declaratively with the image lists of the image cache
+func isDeclarative(imageCache *v1alpha2.ImageCache) bool {
+	return imageCache.Spec.ReconcileMode == v1alpha2.ImageCacheReconcileModeDeclarative
+}
+
+// declarativeImages returns the images of the image lists in sorted order without duplicates, to be recorded
+// as the cached images in the status of an image cache in declarative reconcile mode
+func declarativeImages(imageLists [][]string) []string {
+	seen := map[string]bool{}
+	imageList := []string{}
+	for _, images := range imageLists {
+		for _, image := range images {
+			if !seen[image] {
+				seen[image] = true
+				imageList = append(imageList, image)
+			}
+		}
+	}
+	sort.Strings(imageList)
+	return imageList
+}
+
+// staleImages returns the cached images in the status of the image cache which are no longer in its image
+// lists, in sorted order. Images still listed by any image cache, directly or as a tag of a repository, and
+// images already being deleted by the purge lists are left out, so that only images this image cache pulled
+// and no other image cache needs are deleted
+func (c *Controller) staleImages(imageCache *v1alpha2.ImageCache, imageLists, purgeLists [][]string) []string {
+	if len(imageCache.Status.CachedImages) == 0 {
+		return nil
+	}
+	kept := map[string]bool{}
+	for _, images := range append(append([][]string{}, imageLists...), purgeLists...) {
+		for _, image := range images {
+			kept[image] = true
+		}
+	}
+	listed, repositories := c.cachedImages()
+	for _, image := range listed {
+		kept[image] = true
+	}
+	var stale []string
+	for _, image := range imageCache.Status.CachedImages {
+		if !kept[image] && !isRepositoryTag(image, repositories) {
+			stale = append(stale, image)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// isRepositoryTag returns true if the image is a tag or digest of any of the repositories
+func isRepositoryTag(image string, repositories []string) bool {
+	for _, repository := range repositories {
+		if strings.HasPrefix(image, repository+":") || strings.HasPrefix(image, repository+"@") {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeHasImage returns true if the image is one of the images in the node status
+func nodeHasImage(node *corev1.Node, image string) bool {
+	for _, nodeImage := range node.Status.Images {
+		if nodeImageRefersTo(nodeImage.Names, image) {
+			return true
+		}
+	}
+	return false
+}
+
+// staleImageDeletions returns the deletions of the stale images from the nodes of the image lists which have
+// them as per the node status. Each image is deleted once from a node in several image lists
+func staleImageDeletions(nodeLists [][]*corev1.Node, staleImages []string) []images.ImageWorkRequest {
+	var deletions []images.ImageWorkRequest
+	seen := map[string]bool{}
+	for k, nodes := range nodeLists {
+		for _, n := range nodes {
+			if seen[n.Name] {
+				continue
+			}
+			seen[n.Name] = true
+			for _, image := range staleImages {
+				if !nodeHasImage(n, image) {
+					continue
+				}
+				deletions = append(deletions, images.ImageWorkRequest{
+					Image:                   image,
+					Node:                    n,
+					ContainerRuntimeVersion: n.Status.NodeInfo.ContainerRuntimeVersion,
+					ImageList:               k,
+					WorkType:                images.ImageCachePurge,
+				})
+			}
+		}
+	}
+	return deletions
+}
+
+// queueStaleImagePurges queues the deletion of the stale images from the nodes of the image lists which have
+// them as per the node status
+func (c *Controller) queueStaleImagePurges(ctx context.Context, imageCache *v1alpha2.ImageCache, nodeLists [][]*corev1.Node, staleImages []string) {
+	if len(staleImages) == 0 {
+		return
+	}
+	glog.Infof("Deleting images %v no longer in imagecache(%s) from its nodes", staleImages, imageCache.Name)
+	for _, iwr := range c.withoutBaseImageWork(staleImageDeletions(nodeLists, staleImages)) {
+		iwr.Imagecache = imageCache
+		iwr.Parent = tracing.ParentOf(ctx)
+		c.imageworkqueue.AddRateLimited(iwr)
+	}
+}
+
+// isShadowed returns true if the deletions of the image cache are shadowed by its shadowUntil at the given time
+func isShadowed(imageCache *v1alpha2.ImageCache, now time.Time) bool {
+	return imageCache.Spec.ShadowUntil != nil && now.Before(imageCache.Spec.ShadowUntil.Time)
+}
+
+// shadowDeletions logs the deletions of the stale images from the nodes which are shadowed, and records them in
+// a Normal event of the image cache. It returns the sorted nodes from which each image would be deleted
+func (c *Controller) shadowDeletions(imageCache *v1alpha2.ImageCache, nodeLists [][]*corev1.Node, staleImages []string) map[string][]string {
+	shadowed := map[string][]string{}
+	for _, iwr := range c.withoutBaseImageWork(staleImageDeletions(nodeLists, staleImages)) {
+		glog.Infof("Shadow: would delete (delete:- %s --> %s) of imagecache(%s) until %s", iwr.Image,
+			iwr.Node.Labels["kubernetes.io/hostname"], imageCache.Name, imageCache.Spec.ShadowUntil.UTC().Format(time.RFC3339))
+		shadowed[iwr.Image] = append(shadowed[iwr.Image], iwr.Node.Name)
+	}
+	if len(shadowed) == 0 {
+		return shadowed
+	}
+	shadowedImages := make([]string, 0, len(shadowed))
+	for image, nodes := range shadowed {
+		sort.Strings(nodes)
+		shadowedImages = append(shadowedImages, fmt.Sprintf("%s (%d nodes)", image, len(nodes)))
+	}
+	sort.Strings(shadowedImages)
+	c.recorder.Eventf(imageCache, corev1.EventTypeNormal, v1alpha2.ImageCacheReasonShadowedDeletions,
+		"Shadow mode until %s, not deleted: %s", imageCache.Spec.ShadowUntil.UTC().Format(time.RFC3339), strings.Join(shadowedImages, ", "))
+	return shadowed
+}
+
+// withShadowedDeletions appends a note of the shadowed deletions to the status message
+func withShadowedDeletions(message string, shadowed map[string][]string) string {
+	if len(shadowed) == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s. %s", message, v1alpha2.ImageCacheMessageShadowedDeletions)
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStaleImages(t *testing.T) {
+	controller, _, imagecacheInformer := newTestController(fakeclientset.NewSimpleClientset(), &kubefledgedclientsetfake.Clientset{})
+	imagecacheInformer.Informer().GetIndexer().Add(&v1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "kube-fledged"},
+		Spec: v1alpha2.ImageCacheSpec{CacheSpec: []v1alpha2.CacheSpecImages{{Images: []string{"shared:v1"},
+			Repositories: []v1alpha2.CacheSpecRepository{{Repository: "docker.io/library/repo"}}}}},
+	})
+	imageCache := func(cachedImages ...string) *v1alpha2.ImageCache {
+		return &v1alpha2.ImageCache{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "kube-fledged"},
+			Spec:       v1alpha2.ImageCacheSpec{ReconcileMode: v1alpha2.ImageCacheReconcileModeDeclarative},
+			Status:     v1alpha2.ImageCacheStatus{CachedImages: cachedImages},
+		}
+	}
+	tests := []struct {
+		name       string
+		imageCache *v1alpha2.ImageCache
+		imageLists [][]string
+		purgeLists [][]string
+		expected   []string
+	}{
+		{name: "#1: No cached images", imageCache: imageCache(), imageLists: [][]string{{"foo:v1"}}, expected: nil},
+		{name: "#2: Images removed from the image lists", imageCache: imageCache("foo:v1", "bar:v1", "baz:v1"),
+			imageLists: [][]string{{"foo:v1"}}, expected: []string{"bar:v1", "baz:v1"}},
+		{name: "#3: Images of other image caches", imageCache: imageCache("foo:v1", "shared:v1", "docker.io/library/repo:v2"),
+			imageLists: [][]string{{}}, expected: []string{"foo:v1"}},
+		{name: "#4: Images already purged", imageCache: imageCache("foo:v1", "bar:v1"),
+			imageLists: [][]string{{}}, purgeLists: [][]string{{"bar:v1"}}, expected: []string{"foo:v1"}},
+	}
+	for _, test := range tests {
+		if actual := controller.staleImages(test.imageCache, test.imageLists, test.purgeLists); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Test: %s failed: expectedStaleImages=%v, actualStaleImages=%v", test.name, test.expected, actual)
+		}
+	}
+
+	expected := []string{"bar:v1", "foo:v1"}
+	if actual := declarativeImages([][]string{{"foo:v1", "bar:v1"}, {"foo:v1"}}); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Test: expectedCachedImages=%v, actualCachedImages=%v", expected, actual)
+	}
+}
+
+func TestQueueStaleImagePurges(t *testing.T) {
+	controller, _, _ := newTestController(fakeclientset.NewSimpleClientset(), &kubefledgedclientsetfake.Clientset{})
+	node := func(name string, images ...string) *corev1.Node {
+		n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		for _, image := range images {
+			n.Status.Images = append(n.Status.Images, corev1.ContainerImage{Names: []string{"docker.io/library/" + image}})
+		}
+		return n
+	}
+	node1, node2 := node("node1", "foo:v1", "bar:v1"), node("node2", "bar:v1")
+	imageCache := &v1alpha2.ImageCache{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "kube-fledged"}}
+	// node1 is in both image lists, but each image is deleted from it once
+	controller.queueStaleImagePurges(context.TODO(), imageCache, [][]*corev1.Node{{node1}, {node1, node2}}, []string{"foo:v1", "bar:v1"})
+
+	expected := []string{"node1/bar:v1", "node1/foo:v1", "node2/bar:v1"}
+	wait.PollImmediate(time.Millisecond, time.Second, func() (bool, error) {
+		return controller.imageworkqueue.Len() == len(expected), nil
+	})
+	var actual []string
+	for controller.imageworkqueue.Len() > 0 {
+		obj, _ := controller.imageworkqueue.Get()
+		iwr := obj.(images.ImageWorkRequest)
+		controller.imageworkqueue.Done(obj)
+		if iwr.WorkType == images.ImageCachePurge {
+			actual = append(actual, iwr.Node.Name+"/"+iwr.Image)
+		}
+	}
+	sort.Strings(actual)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Test: expectedPurges=%v, actualPurges=%v", expected, actual)
+	}
+}
+
+func TestShadowDeletions(t *testing.T) {
+	controller, _, _ := newTestController(fakeclientset.NewSimpleClientset(), &kubefledgedclientsetfake.Clientset{})
+	node := func(name string, images ...string) *corev1.Node {
+		n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		for _, image := range images {
+			n.Status.Images = append(n.Status.Images, corev1.ContainerImage{Names: []string{"docker.io/library/" + image}})
+		}
+		return n
+	}
+	now := time.Now()
+	shadowUntil := metav1.NewTime(now.Add(time.Hour))
+	imageCache := &v1alpha2.ImageCache{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "kube-fledged"},
+		Spec: v1alpha2.ImageCacheSpec{ShadowUntil: &shadowUntil}}
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected bool
+	}{
+		{name: "#1: Before shadowUntil", now: now, expected: true},
+		{name: "#2: After shadowUntil", now: now.Add(2 * time.Hour), expected: false},
+	}
+	for _, test := range tests {
+		if actual := isShadowed(imageCache, test.now); actual != test.expected {
+			t.Errorf("Test: %s failed: expectedShadowed=%t, actualShadowed=%t", test.name, test.expected, actual)
+		}
+	}
+	if isShadowed(&v1alpha2.ImageCache{}, now) {
+		t.Errorf("Test: image cache without shadowUntil failed: expectedShadowed=false, actualShadowed=true")
+	}
+
+	node1, node2 := node("node2", "foo:v1", "bar:v1"), node("node1", "bar:v1")
+	actual := controller.shadowDeletions(imageCache, [][]*corev1.Node{{node1}, {node1, node2}}, []string{"foo:v1", "bar:v1"})
+	expected := map[string][]string{"bar:v1": {"node1", "node2"}, "foo:v1": {"node2"}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Test: expectedShadowedDeletions=%v, actualShadowedDeletions=%v", expected, actual)
+	}
+	if controller.imageworkqueue.Len() != 0 {
+		t.Errorf("Test: shadowed deletions queued: expectedQueueLength=0, actualQueueLength=%d", controller.imageworkqueue.Len())
+	}
+
+	message := v1alpha2.ImageCacheMessageImagesPulledSuccessfully + ". " + v1alpha2.ImageCacheMessageShadowedDeletions
+	if actual := withShadowedDeletions(v1alpha2.ImageCacheMessageImagesPulledSuccessfully, expected); actual != message {
+		t.Errorf("Test: expectedMessage=%q, actualMessage=%q", message, actual)
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"sort"
+	"strings"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	"github.com/senthilrch/kube-fledged/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// maxFailureEventMessage is the length up to which the reason and the message of a failure are reported in its event
+const maxFailureEventMessage = 512
+
+// imageFailure is an image whose pull failed on some nodes for a category of failure
+type imageFailure struct {
+	image    string
+	category string
+}
+
+// recordImageFailureEvents emits a Warning event for every image whose pull failed, with the category of the
+// failure (e.g. ImagePullAuthError or ImageNotFound) as the reason. Failures of an image in the same category
+// on several nodes are reported in one event
+func (c *Controller) recordImageFailureEvents(imageCache *v1alpha2.ImageCache, results map[string]images.ImageWorkResult) {
+	nodes := map[imageFailure][]string{}
+	messages := map[imageFailure]string{}
+	for _, iwres := range results {
+		if iwres.Status != images.ImageWorkResultStatusFailed || iwres.ImageWorkRequest.WorkType == images.ImageCachePurge ||
+			iwres.ImageWorkRequest.Node == nil {
+			continue
+		}
+		failure := imageFailure{image: iwres.ImageWorkRequest.Image, category: images.FailureCategory(iwres)}
+		nodes[failure] = append(nodes[failure], iwres.ImageWorkRequest.Node.Labels["kubernetes.io/hostname"])
+		if _, ok := messages[failure]; !ok {
+			message := iwres.Reason + ": " + iwres.Message
+			if len(message) > maxFailureEventMessage {
+				message = message[:maxFailureEventMessage] + "..."
+			}
+			messages[failure] = message
+		}
+	}
+	failures := make([]imageFailure, 0, len(nodes))
+	for failure := range nodes {
+		failures = append(failures, failure)
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		if failures[i].image != failures[j].image {
+			return failures[i].image < failures[j].image
+		}
+		return failures[i].category < failures[j].category
+	})
+	for _, failure := range failures {
+		sort.Strings(nodes[failure])
+		c.recorder.Eventf(imageCache, corev1.EventTypeWarning, failure.category, "Pull of image %s failed on node(s) %s: %s",
+			failure.image, strings.Join(nodes[failure], ","), messages[failure])
+	}
+}
+
+// recordDeadlineTimeout counts the image pull deadline of the image cache reached before all its jobs were
+// done, and emits a Warning event for it. The jobs still pending are reported as failed in the status
+func (c *Controller) recordDeadlineTimeout(cacheKey string, imageCache *v1alpha2.ImageCache) {
+	metrics.DeadlineTimeouts.WithLabelValues(cacheKey).Inc()
+	c.recorder.Event(imageCache, corev1.EventTypeWarning, v1alpha2.ImageCacheReasonImagePullDeadlineExceeded,
+		"Image pull deadline reached before all the jobs were done")
+}
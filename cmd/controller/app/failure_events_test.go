@@ -0,0 +1,95 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	"github.com/senthilrch/kube-fledged/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRecordImageFailureEvents(t *testing.T) {
+	imageCache := &v1alpha2.ImageCache{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: fledgedNameSpace}}
+	node := func(name string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"kubernetes.io/hostname": name}}}
+	}
+	result := func(image, hostname string, workType images.WorkType, status, reason, message string) images.ImageWorkResult {
+		return images.ImageWorkResult{
+			ImageWorkRequest: images.ImageWorkRequest{Image: image, Node: node(hostname), WorkType: workType, Imagecache: imageCache},
+			Status:           status,
+			Reason:           reason,
+			Message:          message,
+		}
+	}
+	results := map[string]images.ImageWorkResult{
+		"job1": result("private:v1", "node1", images.ImageCacheCreate, images.ImageWorkResultStatusFailed, "ErrImagePull", "unauthorized: authentication required"),
+		"job2": result("private:v1", "node2", images.ImageCacheCreate, images.ImageWorkResultStatusFailed, "ErrImagePull", "unauthorized: authentication required"),
+		"job3": result("typo:v1", "node1", images.ImageCacheCreate, images.ImageWorkResultStatusFailed, "ErrImagePull", "manifest unknown"),
+		"job4": result("big:v1", "node2", images.ImageCacheCreate, images.ImageWorkResultStatusFailed, v1alpha2.ImageCacheReasonInsufficientDisk, "insufficient disk"),
+		"job5": result("ok:v1", "node1", images.ImageCacheCreate, images.ImageWorkResultStatusSucceeded, "", ""),
+		"job6": result("old:v1", "node1", images.ImageCachePurge, images.ImageWorkResultStatusFailed, "Error", "error deleting image"),
+	}
+	fakeRecorder := record.NewFakeRecorder(10)
+	controller, _, _ := newTestController(&fakeclientset.Clientset{}, &kubefledgedclientsetfake.Clientset{})
+	controller.recorder = fakeRecorder
+
+	controller.recordImageFailureEvents(imageCache, results)
+	close(fakeRecorder.Events)
+	events := []string{}
+	for event := range fakeRecorder.Events {
+		events = append(events, event)
+	}
+	expectedEvents := []string{
+		"Warning DiskPressure Pull of image big:v1 failed on node(s) node2: InsufficientDisk: insufficient disk",
+		"Warning ImagePullAuthError Pull of image private:v1 failed on node(s) node1,node2: ErrImagePull: unauthorized: authentication required",
+		"Warning ImageNotFound Pull of image typo:v1 failed on node(s) node1: ErrImagePull: manifest unknown",
+	}
+	if !reflect.DeepEqual(events, expectedEvents) {
+		t.Errorf("Test: expectedEvents=%q, actualEvents=%q", expectedEvents, events)
+	}
+}
+
+func TestRecordDeadlineTimeout(t *testing.T) {
+	imageCache := &v1alpha2.ImageCache{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: fledgedNameSpace}}
+	cacheKey := fledgedNameSpace + "/foo"
+	fakeRecorder := record.NewFakeRecorder(10)
+	controller, _, _ := newTestController(&fakeclientset.Clientset{}, &kubefledgedclientsetfake.Clientset{})
+	controller.recorder = fakeRecorder
+
+	controller.recordDeadlineTimeout(cacheKey, imageCache)
+	controller.recordDeadlineTimeout(cacheKey, imageCache)
+	if actual := testutil.ToFloat64(metrics.DeadlineTimeouts.WithLabelValues(cacheKey)); actual != 2 {
+		t.Errorf("Test: deadline timeouts failed: expected=2, actual=%v", actual)
+	}
+	metrics.DeadlineTimeouts.DeleteLabelValues(cacheKey)
+	close(fakeRecorder.Events)
+	expectedEvent := "Warning ImagePullDeadlineExceeded Image pull deadline reached before all the jobs were done"
+	for event := range fakeRecorder.Events {
+		if event != expectedEvent {
+			t.Errorf("Test: expectedEvent=%q, actualEvent=%q", expectedEvent, event)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+)
+
+// applyFailurePolicy reports a failed reconcile pulling the images as succeeded under the "partial" failure
+// policy, if some images were cached by it. The failures stay listed in the status, and the message says some
+// images failed. A reconcile which cached no image fails under any policy, and so does a purge
+func applyFailurePolicy(policy v1alpha2.ImageCacheFailurePolicy, status *v1alpha2.ImageCacheStatus,
+	results map[string]images.ImageWorkResult) {
+	if policy != v1alpha2.ImageCacheFailurePolicyPartial || status.Status != v1alpha2.ImageCacheActionStatusFailed {
+		return
+	}
+	cached := false
+	for _, result := range results {
+		if result.ImageWorkRequest.WorkType == images.ImageCachePurge {
+			return
+		}
+		if result.Status == images.ImageWorkResultStatusSucceeded || result.Status == images.ImageWorkResultStatusAlreadyPulled {
+			cached = true
+		}
+	}
+	if cached {
+		status.Status = v1alpha2.ImageCacheActionStatusSucceeded
+		status.Message = v1alpha2.ImageCacheMessageImagePullFailedForSomeImages
+	}
+}
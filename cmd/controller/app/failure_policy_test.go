@@ -0,0 +1,68 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"testing"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+)
+
+func TestApplyFailurePolicy(t *testing.T) {
+	result := func(status string, workType images.WorkType) images.ImageWorkResult {
+		return images.ImageWorkResult{ImageWorkRequest: images.ImageWorkRequest{WorkType: workType}, Status: status}
+	}
+	someFailed := map[string]images.ImageWorkResult{
+		"job1": result(images.ImageWorkResultStatusAlreadyPulled, images.ImageCacheRefresh),
+		"job2": result(images.ImageWorkResultStatusFailed, images.ImageCacheRefresh),
+	}
+	allFailed := map[string]images.ImageWorkResult{
+		"job1": result(images.ImageWorkResultStatusFailed, images.ImageCacheCreate),
+		"job2": result(images.ImageWorkResultStatusUnknown, images.ImageCacheCreate),
+	}
+	purgeFailed := map[string]images.ImageWorkResult{
+		"job1": result(images.ImageWorkResultStatusSucceeded, images.ImageCachePurge),
+		"job2": result(images.ImageWorkResultStatusFailed, images.ImageCachePurge),
+	}
+	tests := []struct {
+		name            string
+		policy          v1alpha2.ImageCacheFailurePolicy
+		results         map[string]images.ImageWorkResult
+		expectedStatus  v1alpha2.ImageCacheActionStatus
+		expectedMessage string
+	}{
+		{name: "#1: Default policy", policy: "", results: someFailed,
+			expectedStatus: v1alpha2.ImageCacheActionStatusFailed, expectedMessage: "fakemessage"},
+		{name: "#2: All policy", policy: v1alpha2.ImageCacheFailurePolicyAll, results: someFailed,
+			expectedStatus: v1alpha2.ImageCacheActionStatusFailed, expectedMessage: "fakemessage"},
+		{name: "#3: Partial policy, some images cached", policy: v1alpha2.ImageCacheFailurePolicyPartial, results: someFailed,
+			expectedStatus: v1alpha2.ImageCacheActionStatusSucceeded, expectedMessage: v1alpha2.ImageCacheMessageImagePullFailedForSomeImages},
+		{name: "#4: Partial policy, no image cached", policy: v1alpha2.ImageCacheFailurePolicyPartial, results: allFailed,
+			expectedStatus: v1alpha2.ImageCacheActionStatusFailed, expectedMessage: "fakemessage"},
+		{name: "#5: Partial policy, purge", policy: v1alpha2.ImageCacheFailurePolicyPartial, results: purgeFailed,
+			expectedStatus: v1alpha2.ImageCacheActionStatusFailed, expectedMessage: "fakemessage"},
+	}
+	for _, test := range tests {
+		status := &v1alpha2.ImageCacheStatus{Status: v1alpha2.ImageCacheActionStatusFailed, Message: "fakemessage"}
+		applyFailurePolicy(test.policy, status, test.results)
+		if status.Status != test.expectedStatus || status.Message != test.expectedMessage {
+			t.Errorf("Test: %s failed: expectedStatus=%s, actualStatus=%s, expectedMessage=%q, actualMessage=%q",
+				test.name, test.expectedStatus, status.Status, test.expectedMessage, status.Message)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	"github.com/senthilrch/kube-fledged/pkg/metrics"
+)
+
+// imageHistoryLength is the no. of the latest results kept in the history of an image on a node
+const imageHistoryLength = 10
+
+// imageHistoryKey is an image of an image cache on a node
+type imageHistoryKey struct {
+	cache string
+	image string
+	node  string
+}
+
+// imageHistoryEntry is the result of an image on a node in a reconcile of its image cache
+type imageHistoryEntry struct {
+	time   time.Time
+	status string
+}
+
+// cached returns true if the image was pulled to, or already present on, the node
+func (e imageHistoryEntry) cached() bool {
+	return e.status == images.ImageWorkResultStatusSucceeded || e.status == images.ImageWorkResultStatusAlreadyPulled
+}
+
+// recordImageHistory appends the results of the latest reconcile of the image cache to the histories of its
+// images on their nodes, keeping the latest imageHistoryLength results of each. An image flaps on a node when
+// it is cached after having failed, or fails after having been cached, and every flap is counted in the
+// kubefledged_image_flaps_total metric. The histories of a purged image cache are removed
+func (c *Controller) recordImageHistory(cacheKey string, results map[string]images.ImageWorkResult, now time.Time) {
+	c.imageHistoryLock.Lock()
+	defer c.imageHistoryLock.Unlock()
+	for _, result := range results {
+		if result.ImageWorkRequest.WorkType == images.ImageCachePurge {
+			c.deleteImageHistory(cacheKey)
+			return
+		}
+		if result.Status == images.ImageWorkResultStatusNodeDeleted || result.ImageWorkRequest.Node == nil {
+			continue
+		}
+		key := imageHistoryKey{cache: cacheKey, image: result.ImageWorkRequest.Image,
+			node: result.ImageWorkRequest.Node.Labels["kubernetes.io/hostname"]}
+		entry := imageHistoryEntry{time: now, status: result.Status}
+		history := c.imageHistory[key]
+		if len(history) > 0 && history[len(history)-1].cached() != entry.cached() {
+			metrics.ImageFlaps.WithLabelValues(key.cache, key.image, key.node).Inc()
+			glog.Infof("Image %s of image cache %s flapped on node %s: %s", key.image, key.cache, key.node,
+				historyStatuses(append(history, entry)))
+		}
+		history = append(history, entry)
+		if len(history) > imageHistoryLength {
+			history = history[len(history)-imageHistoryLength:]
+		}
+		c.imageHistory[key] = history
+	}
+}
+
+// deleteImageHistory removes the histories and the flap counts of the images of the image cache. It is called
+// with imageHistoryLock held
+func (c *Controller) deleteImageHistory(cacheKey string) {
+	for key := range c.imageHistory {
+		if key.cache == cacheKey {
+			delete(c.imageHistory, key)
+		}
+	}
+	metrics.ImageFlaps.DeletePartialMatch(prometheus.Labels{"cache": cacheKey})
+}
+
+// historyStatuses returns the statuses of the history, oldest first, e.g. "succeeded,failed,succeeded"
+func historyStatuses(history []imageHistoryEntry) string {
+	statuses := make([]string, 0, len(history))
+	for _, entry := range history {
+		statuses = append(statuses, entry.status)
+	}
+	return strings.Join(statuses, ",")
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	"github.com/senthilrch/kube-fledged/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRecordImageHistory(t *testing.T) {
+	controller, _, _ := newTestController(fakeclientset.NewSimpleClientset(), &kubefledgedclientsetfake.Clientset{})
+	result := func(hostname, status string, workType images.WorkType) images.ImageWorkResult {
+		return images.ImageWorkResult{
+			ImageWorkRequest: images.ImageWorkRequest{Image: "foo:v1", WorkType: workType,
+				Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"kubernetes.io/hostname": hostname}}}},
+			Status: status,
+		}
+	}
+	cacheKey := "kube-fledged/history"
+	tests := []struct {
+		name           string
+		node1Status    string
+		node2Status    string
+		expectedFlaps1 float64
+		expectedFlaps2 float64
+	}{
+		{name: "#1: First results", node1Status: images.ImageWorkResultStatusSucceeded, node2Status: images.ImageWorkResultStatusFailed,
+			expectedFlaps1: 0, expectedFlaps2: 0},
+		{name: "#2: Already pulled is still cached", node1Status: images.ImageWorkResultStatusAlreadyPulled, node2Status: images.ImageWorkResultStatusUnknown,
+			expectedFlaps1: 0, expectedFlaps2: 0},
+		{name: "#3: Both nodes flap", node1Status: images.ImageWorkResultStatusFailed, node2Status: images.ImageWorkResultStatusSucceeded,
+			expectedFlaps1: 1, expectedFlaps2: 1},
+		{name: "#4: Node1 flaps back", node1Status: images.ImageWorkResultStatusSucceeded, node2Status: images.ImageWorkResultStatusAlreadyPulled,
+			expectedFlaps1: 2, expectedFlaps2: 1},
+	}
+	now := time.Now()
+	for i, test := range tests {
+		controller.recordImageHistory(cacheKey, map[string]images.ImageWorkResult{
+			"job1": result("node1", test.node1Status, images.ImageCacheRefresh),
+			"job2": result("node2", test.node2Status, images.ImageCacheRefresh),
+		}, now.Add(time.Duration(i)*time.Minute))
+		if actual := testutil.ToFloat64(metrics.ImageFlaps.WithLabelValues(cacheKey, "foo:v1", "node1")); actual != test.expectedFlaps1 {
+			t.Errorf("Test: %s failed: expectedFlaps(node1)=%v, actualFlaps(node1)=%v", test.name, test.expectedFlaps1, actual)
+		}
+		if actual := testutil.ToFloat64(metrics.ImageFlaps.WithLabelValues(cacheKey, "foo:v1", "node2")); actual != test.expectedFlaps2 {
+			t.Errorf("Test: %s failed: expectedFlaps(node2)=%v, actualFlaps(node2)=%v", test.name, test.expectedFlaps2, actual)
+		}
+	}
+
+	// the history is bounded
+	for i := 0; i < 2*imageHistoryLength; i++ {
+		controller.recordImageHistory(cacheKey, map[string]images.ImageWorkResult{
+			"job1": result("node1", images.ImageWorkResultStatusSucceeded, images.ImageCacheRefresh),
+		}, now.Add(time.Hour))
+	}
+	history := controller.imageHistory[imageHistoryKey{cache: cacheKey, image: "foo:v1", node: "node1"}]
+	if len(history) != imageHistoryLength {
+		t.Errorf("Test: bounded history failed: expectedLength=%d, actualLength=%d", imageHistoryLength, len(history))
+	}
+
+	// the histories and the flap counts of a purged image cache are removed
+	controller.recordImageHistory(cacheKey, map[string]images.ImageWorkResult{
+		"job1": result("node1", images.ImageWorkResultStatusSucceeded, images.ImageCachePurge),
+	}, now.Add(2*time.Hour))
+	if len(controller.imageHistory) != 0 {
+		t.Errorf("Test: purge failed: expectedHistories=0, actualHistories=%d", len(controller.imageHistory))
+	}
+	if actual := testutil.CollectAndCount(metrics.ImageFlaps); actual != 0 {
+		t.Errorf("Test: purge failed: expectedFlapCounters=0, actualFlapCounters=%d", actual)
+	}
+}
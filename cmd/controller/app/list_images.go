@@ -0,0 +1,156 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/golang/glog"
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	clientset "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// managedImage is an image of the image caches in the inventory of the list-images command
+type managedImage struct {
+	image       string
+	imageCaches []string
+	nodes       []string
+	sizeBytes   int64
+}
+
+// ListImages writes a table of the images of all the image caches matching the label selector to out:
+// for each image, the image caches listing it, the nodes on which it is cached and its size, as per the
+// node statuses. It only makes direct list calls to the API server, so that it can be run out-of-cluster
+// with a kubeconfig. The images of the repositories of the image lists are left out
+func ListImages(kubeClient kubernetes.Interface, fledgedClient clientset.Interface, labelSelector string, out io.Writer) error {
+	imageCaches, err := fledgedClient.KubefledgedV1alpha2().ImageCaches(metav1.NamespaceAll).List(context.TODO(),
+		metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return fmt.Errorf("error listing image caches: %v", err)
+	}
+	nodes, err := kubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("error listing nodes: %v", err)
+	}
+	imagesFrom := func(namespace string, imagesFrom *corev1.ConfigMapKeySelector) []string {
+		configMap, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(context.TODO(), imagesFrom.Name, metav1.GetOptions{})
+		if err != nil {
+			if !apierrors.IsNotFound(err) || imagesFrom.Optional == nil || !*imagesFrom.Optional {
+				glog.Warningf("Images of configmap %s/%s left out: %v", namespace, imagesFrom.Name, err)
+			}
+			return nil
+		}
+		return parseImageList(configMap.Data[imagesFrom.Key])
+	}
+	return writeManagedImages(out, managedImages(imageCaches.Items, nodes.Items, imagesFrom))
+}
+
+// managedImages returns the images of the image caches sorted by image, with the nodes on which they
+// are cached as per the node statuses
+func managedImages(imageCaches []v1alpha2.ImageCache, nodes []corev1.Node,
+	imagesFrom func(string, *corev1.ConfigMapKeySelector) []string) []managedImage {
+	byImage := map[string]*managedImage{}
+	for _, imageCache := range imageCaches {
+		key := imageCache.Namespace + "/" + imageCache.Name
+		for _, cacheSpecImages := range imageCache.Spec.CacheSpec {
+			imageList := cacheSpecImages.Images
+			if cacheSpecImages.ImagesFrom != nil {
+				imageList = append(append([]string{}, imageList...), imagesFrom(imageCache.Namespace, cacheSpecImages.ImagesFrom)...)
+			}
+			for _, image := range imageList {
+				mi, ok := byImage[image]
+				if !ok {
+					mi = &managedImage{image: image}
+					byImage[image] = mi
+				}
+				if len(mi.imageCaches) == 0 || mi.imageCaches[len(mi.imageCaches)-1] != key {
+					mi.imageCaches = append(mi.imageCaches, key)
+				}
+			}
+		}
+	}
+	for i := range nodes {
+		for _, nodeImage := range nodes[i].Status.Images {
+			for image, mi := range byImage {
+				if !nodeImageRefersTo(nodeImage.Names, image) {
+					continue
+				}
+				mi.nodes = append(mi.nodes, nodes[i].Name)
+				if nodeImage.SizeBytes > mi.sizeBytes {
+					mi.sizeBytes = nodeImage.SizeBytes
+				}
+			}
+		}
+	}
+	list := make([]managedImage, 0, len(byImage))
+	for _, mi := range byImage {
+		sort.Strings(mi.imageCaches)
+		sort.Strings(mi.nodes)
+		list = append(list, *mi)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].image < list[j].image })
+	return list
+}
+
+// nodeImageRefersTo returns true if any of the names of an image in the node status e.g.
+// docker.io/library/nginx:1.23 refers to image e.g. nginx:1.23
+func nodeImageRefersTo(names []string, image string) bool {
+	for _, name := range names {
+		if name == image || strings.HasSuffix(name, "/"+image) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeManagedImages writes the images as a table. The size of images not cached on any node is unknown
+func writeManagedImages(out io.Writer, list []managedImage) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "IMAGE\tIMAGECACHES\tNODES\tSIZE")
+	for _, mi := range list {
+		nodes, size := "<none>", "<unknown>"
+		if len(mi.nodes) > 0 {
+			nodes = fmt.Sprintf("%d (%s)", len(mi.nodes), strings.Join(mi.nodes, ","))
+			size = formatBytes(mi.sizeBytes)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", mi.image, strings.Join(mi.imageCaches, ","), nodes, size)
+	}
+	return w.Flush()
+}
+
+// formatBytes returns the size in the largest binary unit in which it is at least 1 e.g. 141.2Mi
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d", size)
+	}
+	value, exp := float64(size)/unit, 0
+	for value >= unit && exp < 3 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%si", value, string("KMGT"[exp]))
+}
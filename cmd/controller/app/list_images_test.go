@@ -0,0 +1,84 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"bytes"
+	"testing"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestListImages(t *testing.T) {
+	optional := true
+	kubeClient := fakeclientset.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}, Status: corev1.NodeStatus{Images: []corev1.ContainerImage{
+			{Names: []string{"docker.io/library/foo:v1"}, SizeBytes: 148 * 1024 * 1024},
+			{Names: []string{"docker.io/library/baz:v1"}, SizeBytes: 3 * 1024 * 1024 * 1024}}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}, Status: corev1.NodeStatus{Images: []corev1.ContainerImage{
+			{Names: []string{"docker.io/library/foo:v1"}, SizeBytes: 148 * 1024 * 1024}}}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "images", Namespace: "kube-fledged"},
+			Data: map[string]string{"images": "# from the configmap\nbaz:v1\n"}},
+	)
+	fledgedClient := kubefledgedclientsetfake.NewSimpleClientset(
+		&v1alpha2.ImageCache{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "kube-fledged"},
+			Spec: v1alpha2.ImageCacheSpec{CacheSpec: []v1alpha2.CacheSpecImages{
+				{Images: []string{"foo:v1", "bar:v1"}},
+				{Images: []string{"foo:v1"}, ImagesFrom: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "images"}, Key: "images"}},
+			}}},
+		&v1alpha2.ImageCache{ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "default"},
+			Spec: v1alpha2.ImageCacheSpec{CacheSpec: []v1alpha2.CacheSpecImages{
+				{Images: []string{"foo:v1"}, ImagesFrom: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "missing"}, Key: "images", Optional: &optional}},
+			}}},
+	)
+
+	var out bytes.Buffer
+	if err := ListImages(kubeClient, fledgedClient, "", &out); err != nil {
+		t.Fatalf("Test: listing images failed: %v", err)
+	}
+	expected := "" +
+		"IMAGE   IMAGECACHES                   NODES            SIZE\n" +
+		"bar:v1  kube-fledged/foo              <none>           <unknown>\n" +
+		"baz:v1  kube-fledged/foo              1 (node1)        3.0Gi\n" +
+		"foo:v1  default/bar,kube-fledged/foo  2 (node1,node2)  148.0Mi\n"
+	if actual := out.String(); actual != expected {
+		t.Errorf("Test: expectedOutput=\n%s\nactualOutput=\n%s", expected, actual)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		size     int64
+		expected string
+	}{
+		{size: 512, expected: "512"},
+		{size: 1536, expected: "1.5Ki"},
+		{size: 148 * 1024 * 1024, expected: "148.0Mi"},
+		{size: 5 * 1024 * 1024 * 1024 * 1024 * 1024, expected: "5120.0Ti"},
+	}
+	for _, test := range tests {
+		if actual := formatBytes(test.size); actual != test.expected {
+			t.Errorf("Test: size %d failed: expected=%s, actual=%s", test.size, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// underMaintenance returns true if the node has a taint or an annotation with the maintenance key
+func (c *Controller) underMaintenance(node *corev1.Node) bool {
+	if c.maintenanceKey == "" {
+		return false
+	}
+	if _, ok := node.Annotations[c.maintenanceKey]; ok {
+		return true
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == c.maintenanceKey {
+			return true
+		}
+	}
+	return false
+}
+
+// filterMaintenanceNodes removes the nodes under maintenance
+func (c *Controller) filterMaintenanceNodes(nodes []*corev1.Node) []*corev1.Node {
+	if c.maintenanceKey == "" {
+		return nodes
+	}
+	filtered := make([]*corev1.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if c.underMaintenance(n) {
+			glog.V(4).Infof("Skipping node %s under maintenance (%s)", n.Name, c.maintenanceKey)
+			continue
+		}
+		filtered = append(filtered, n)
+	}
+	return filtered
+}
+
+// maintenanceNodes returns the nodes of the image lists of the image cache which are skipped because they
+// are under maintenance, in sorted order
+func (c *Controller) maintenanceNodes(imageCache *v1alpha2.ImageCache) []string {
+	if c.maintenanceKey == "" {
+		return nil
+	}
+	skipped := map[string]bool{}
+	for _, i := range imageCache.Spec.CacheSpec {
+		nodes, err := c.nodesLister.List(labels.Set(i.NodeSelector).AsSelector())
+		if err != nil {
+			continue
+		}
+		nodes = filterSkipCacheNodes(nodes)
+		if len(i.NodeNames) > 0 {
+			nodes = filterNodeNames(nodes, i.NodeNames)
+		}
+		for _, n := range nodes {
+			if c.underMaintenance(n) {
+				skipped[n.Name] = true
+			}
+		}
+	}
+	nodes := make([]string, 0, len(skipped))
+	for n := range skipped {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// withMaintenanceNodes appends a note of the nodes skipped because they are under maintenance to the
+// status message
+func withMaintenanceNodes(message string, nodes []string) string {
+	if len(nodes) == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s. %s: %s", message, v1alpha2.ImageCacheMessageNodesUnderMaintenance, strings.Join(nodes, ", "))
+}
+
+// enqueueImageCachesAfterMaintenance queues a refresh of the image caches once the maintenance of the
+// node ends, so that it catches up on the work skipped during the maintenance
+func (c *Controller) enqueueImageCachesAfterMaintenance(node *corev1.Node) {
+	imageCaches, err := c.imageCachesLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("Error in listing image caches: %v", err)
+		return
+	}
+	glog.Infof("Maintenance of node %s ended, refreshing image caches", node.Name)
+	for i := range imageCaches {
+		if imageCaches[i].Status.Status == v1alpha2.ImageCacheActionStatusProcessing {
+			continue
+		}
+		c.enqueueImageCache(images.ImageCacheRefresh, imageCaches[i], nil)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMaintenanceNodes(t *testing.T) {
+	controller, nodeInformer, _ := newTestController(fakeclientset.NewSimpleClientset(), &kubefledgedclientsetfake.Clientset{})
+	for _, n := range []*corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node2"}, Spec: corev1.NodeSpec{Unschedulable: true,
+			Taints: []corev1.Taint{{Key: "node.kubernetes.io/unschedulable", Effect: corev1.TaintEffectNoSchedule}}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node3", Annotations: map[string]string{"example.com/maintenance": "true"}}},
+	} {
+		nodeInformer.Informer().GetIndexer().Add(n)
+	}
+	imageCache := &v1alpha2.ImageCache{Spec: v1alpha2.ImageCacheSpec{CacheSpec: []v1alpha2.CacheSpecImages{
+		{Images: []string{"foo:v1"}},
+	}}}
+	tests := []struct {
+		name            string
+		maintenanceKey  string
+		expectedNodes   []string
+		expectedSkipped []string
+	}{
+		{name: "#1: No maintenance key", maintenanceKey: "",
+			expectedNodes: []string{"node1", "node2", "node3"}, expectedSkipped: []string{}},
+		{name: "#2: Taint of cordoned nodes", maintenanceKey: "node.kubernetes.io/unschedulable",
+			expectedNodes: []string{"node1", "node3"}, expectedSkipped: []string{"node2"}},
+		{name: "#3: Annotation", maintenanceKey: "example.com/maintenance",
+			expectedNodes: []string{"node1", "node2"}, expectedSkipped: []string{"node3"}},
+	}
+	for _, test := range tests {
+		controller.maintenanceKey = test.maintenanceKey
+		nodes, err := controller.imageListNodes("default", imageCache.Spec.CacheSpec[0])
+		actual := []string{}
+		for _, n := range nodes {
+			actual = append(actual, n.Name)
+		}
+		sort.Strings(actual)
+		if err != nil || !reflect.DeepEqual(actual, test.expectedNodes) {
+			t.Errorf("Test: %s failed: expectedNodes=%v, actualNodes=%v, err=%v", test.name, test.expectedNodes, actual, err)
+		}
+		skipped := controller.maintenanceNodes(imageCache)
+		if skipped == nil {
+			skipped = []string{}
+		}
+		if !reflect.DeepEqual(skipped, test.expectedSkipped) {
+			t.Errorf("Test: %s failed: expectedSkipped=%v, actualSkipped=%v", test.name, test.expectedSkipped, skipped)
+		}
+	}
+
+	expected := v1alpha2.ImageCacheMessagePullingImages + ". " + v1alpha2.ImageCacheMessageNodesUnderMaintenance + ": node2"
+	if actual := withMaintenanceNodes(v1alpha2.ImageCacheMessagePullingImages, []string{"node2"}); actual != expected {
+		t.Errorf("Test: expectedMessage=%q, actualMessage=%q", expected, actual)
+	}
+}
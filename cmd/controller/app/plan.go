@@ -0,0 +1,164 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/golang/glog"
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/yaml"
+)
+
+// Status of an image on a node in the plan of an image cache. An image which failed on the node
+// has the reason of the failure instead
+const (
+	planStatusCached  = "Cached"
+	planStatusPending = "Pending"
+	planStatusPurged  = "Purged"
+)
+
+// imageCachePlan is the plan of an image cache: the nodes each of its images should be on, and the
+// status of the image on each node
+type imageCachePlan struct {
+	Status v1alpha2.ImageCacheActionStatus `json:"status"`
+	Reason string                          `json:"reason,omitempty"`
+	Images map[string]map[string]string    `json:"images"`
+	Error  string                          `json:"error,omitempty"`
+}
+
+// plan returns the plans of all the image caches, keyed by their namespace/name. It is built from the
+// informer caches of the controller, and the workloads referenced by image lists: the images of the
+// repositories of the image lists, whose tags are only listed by reconciles, are left out
+func (c *Controller) plan() (map[string]imageCachePlan, error) {
+	imageCaches, err := c.imageCachesLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	plans := make(map[string]imageCachePlan, len(imageCaches))
+	for _, imageCache := range imageCaches {
+		key, err := cache.MetaNamespaceKeyFunc(imageCache)
+		if err != nil {
+			continue
+		}
+		plans[key] = c.imageCachePlan(imageCache)
+	}
+	return plans, nil
+}
+
+// imageCachePlan returns the plan of the image cache from its spec, the nodes matching the node
+// selectors of its image lists and its status
+func (c *Controller) imageCachePlan(imageCache *v1alpha2.ImageCache) imageCachePlan {
+	plan := imageCachePlan{
+		Status: imageCache.Status.Status,
+		Reason: imageCache.Status.Reason,
+		Images: map[string]map[string]string{},
+	}
+	imageLists := make([][]string, len(imageCache.Spec.CacheSpec))
+	for k, cacheSpecImages := range imageCache.Spec.CacheSpec {
+		imageLists[k] = cacheSpecImages.Images
+		if cacheSpecImages.ImagesFrom == nil {
+			continue
+		}
+		data, err := c.imagesFromData(imageCache.Namespace, cacheSpecImages.ImagesFrom)
+		if err != nil {
+			plan.Error = err.Error()
+			continue
+		}
+		imageLists[k] = append(append([]string{}, imageLists[k]...), parseImageList(data)...)
+	}
+	if !c.isBaselineImageCache(imageCache) {
+		imageLists = withoutImages(imageLists, c.baselineImages)
+	}
+	for k, imageList := range imageLists {
+		nodes, err := c.imageListNodes(imageCache.Namespace, imageCache.Spec.CacheSpec[k])
+		if err != nil {
+			plan.Error = err.Error()
+			continue
+		}
+		for _, image := range imageList {
+			if plan.Images[image] == nil {
+				plan.Images[image] = map[string]string{}
+			}
+			for _, n := range nodes {
+				plan.Images[image][n.Name] = imagePlanStatus(imageCache, image, n)
+			}
+		}
+	}
+	return plan
+}
+
+// imagePlanStatus returns the status of the image on the node as per the status of the image cache
+func imagePlanStatus(imageCache *v1alpha2.ImageCache, image string, node *corev1.Node) string {
+	for _, failure := range imageCache.Status.Failures[image] {
+		if failure.Node == node.Labels["kubernetes.io/hostname"] {
+			return failure.Reason
+		}
+	}
+	switch {
+	case imageCache.Status.Reason == v1alpha2.ImageCacheReasonImageCachePurge &&
+		imageCache.Status.Status == v1alpha2.ImageCacheActionStatusSucceeded:
+		return planStatusPurged
+	case imageCache.Status.Status == v1alpha2.ImageCacheActionStatusSucceeded ||
+		imageCache.Status.Status == v1alpha2.ImageCacheActionStatusFailed:
+		return planStatusCached
+	}
+	return planStatusPending
+}
+
+// ServePlan serves the plans of all the image caches on the given address at /plan, as YAML or as
+// JSON with ?format=json. It blocks until the server fails
+func (c *Controller) ServePlan(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/plan", c.handlePlan)
+	glog.Infof("Serving image cache plan on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		glog.Errorf("Error serving image cache plan: %v", err)
+	}
+}
+
+// handlePlan writes the plans of all the image caches in the format of the request
+func (c *Controller) handlePlan(w http.ResponseWriter, r *http.Request) {
+	plans, err := c.plan()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var data []byte
+	var contentType string
+	switch r.URL.Query().Get("format") {
+	case "", "yaml":
+		contentType = "application/yaml"
+		data, err = yaml.Marshal(plans)
+	case "json":
+		contentType = "application/json"
+		data, err = json.MarshalIndent(plans, "", "  ")
+	default:
+		http.Error(w, "format must be yaml or json", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(data)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+)
+
+func TestPlan(t *testing.T) {
+	controller, nodeInformer, imagecacheInformer := newTestController(&fakeclientset.Clientset{}, &kubefledgedclientsetfake.Clientset{})
+	controller.baselineImages = []string{"baseline:v1"}
+	for _, node := range []*corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{"kubernetes.io/hostname": "node1", "pool": "gpu"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node2", Labels: map[string]string{"kubernetes.io/hostname": "node2"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node3", Labels: map[string]string{"kubernetes.io/hostname": "node3"},
+			Annotations: map[string]string{nodeSkipCacheAnnotationKey: "true"}}},
+	} {
+		nodeInformer.Informer().GetIndexer().Add(node)
+	}
+	imagecacheInformer.Informer().GetIndexer().Add(&v1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: fledgedNameSpace},
+		Spec: v1alpha2.ImageCacheSpec{CacheSpec: []v1alpha2.CacheSpecImages{
+			{Images: []string{"foo:v1", "baseline:v1"}},
+			{Images: []string{"bar:v1"}, NodeSelector: map[string]string{"pool": "gpu"}},
+		}},
+		Status: v1alpha2.ImageCacheStatus{
+			Status: v1alpha2.ImageCacheActionStatusFailed,
+			Reason: v1alpha2.ImageCacheReasonImageCacheCreate,
+			Failures: map[string]v1alpha2.NodeReasonMessageList{
+				"foo:v1": {{Node: "node2", Reason: "ErrImagePull"}},
+			},
+		},
+	})
+	imagecacheInformer.Informer().GetIndexer().Add(&v1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: fledgedNameSpace},
+		Spec:       v1alpha2.ImageCacheSpec{CacheSpec: []v1alpha2.CacheSpecImages{{Images: []string{"bar:v1"}}}},
+		Status:     v1alpha2.ImageCacheStatus{Status: v1alpha2.ImageCacheActionStatusProcessing},
+	})
+
+	expected := map[string]imageCachePlan{
+		"kube-fledged/foo": {
+			Status: v1alpha2.ImageCacheActionStatusFailed,
+			Reason: v1alpha2.ImageCacheReasonImageCacheCreate,
+			Images: map[string]map[string]string{
+				"foo:v1": {"node1": planStatusCached, "node2": "ErrImagePull"},
+				"bar:v1": {"node1": planStatusCached},
+			},
+		},
+		"kube-fledged/bar": {
+			Status: v1alpha2.ImageCacheActionStatusProcessing,
+			Images: map[string]map[string]string{"bar:v1": {"node1": planStatusPending, "node2": planStatusPending}},
+		},
+	}
+	plans, err := controller.plan()
+	if err != nil {
+		t.Fatalf("Test: error building plan: %v", err)
+	}
+	if !reflect.DeepEqual(plans, expected) {
+		t.Errorf("Test: expectedPlan=%+v, actualPlan=%+v", expected, plans)
+	}
+
+	tests := []struct {
+		format      string
+		unmarshal   func([]byte, interface{}) error
+		contentType string
+	}{
+		{format: "", unmarshal: func(data []byte, v interface{}) error { return yaml.Unmarshal(data, v) }, contentType: "application/yaml"},
+		{format: "json", unmarshal: json.Unmarshal, contentType: "application/json"},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		controller.handlePlan(recorder, httptest.NewRequest(http.MethodGet, "/plan?format="+test.format, nil))
+		if contentType := recorder.Header().Get("Content-Type"); contentType != test.contentType {
+			t.Errorf("Test: format %q: expectedContentType=%s, actualContentType=%s", test.format, test.contentType, contentType)
+		}
+		served := map[string]imageCachePlan{}
+		if err := test.unmarshal(recorder.Body.Bytes(), &served); err != nil {
+			t.Fatalf("Test: format %q: error unmarshalling plan: %v", test.format, err)
+		}
+		if !reflect.DeepEqual(served, expected) {
+			t.Errorf("Test: format %q: expectedPlan=%+v, actualPlan=%+v", test.format, expected, served)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	controller.handlePlan(recorder, httptest.NewRequest(http.MethodGet, "/plan?format=xml", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Test: unsupported format: expectedCode=%d, actualCode=%d", http.StatusBadRequest, recorder.Code)
+	}
+}
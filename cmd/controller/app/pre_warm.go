@@ -0,0 +1,124 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/robfig/cron/v3"
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// defaultPreWarmLeadMinutes is how long before a scheduled run of its CronJob an image cache is pre-warmed,
+// if the image cache does not specify it
+const defaultPreWarmLeadMinutes = 10
+
+// runPreWarmWorker refreshes the image caches which are pre-warmed ahead of the runs of a CronJob, when the
+// next run is within their lead time, and purges them once the purge delay after the run has passed
+func (c *Controller) runPreWarmWorker() {
+	imageCaches, err := c.imageCachesLister.ImageCaches("").List(labels.Everything())
+	if err != nil {
+		glog.Errorf("Error in listing image caches: %v", err)
+		return
+	}
+	now := time.Now()
+	preWarmed := map[string]bool{}
+	for _, imageCache := range imageCaches {
+		key, err := cache.MetaNamespaceKeyFunc(imageCache)
+		if err != nil || imageCache.Spec.PreWarm == nil {
+			continue
+		}
+		preWarmed[key] = true
+		// Wait until the image cache is created, and for the image cache to be processed
+		if reflect.DeepEqual(imageCache.Status, v1alpha2.ImageCacheStatus{}) ||
+			imageCache.Status.Status == v1alpha2.ImageCacheActionStatusProcessing {
+			continue
+		}
+		nextRun, err := c.nextCronJobRun(imageCache.Namespace, imageCache.Spec.PreWarm.CronJob, now)
+		if err != nil {
+			glog.Warningf("Not pre-warming image cache %s: %v", key, err)
+			continue
+		}
+		switch preWarmAction(imageCache.Spec.PreWarm, nextRun, c.preWarmedRuns[key], now) {
+		case images.ImageCacheRefresh:
+			// the image cache is pre-warmed once the quiet hours end, if the run has not started yet
+			if c.quietHours.contains(now) {
+				glog.V(4).Infof("Quiet hours: deferring the pre-warm of image cache %s", key)
+				continue
+			}
+			glog.Infof("Pre-warming image cache %s for the run of CronJob %s at %s", key, imageCache.Spec.PreWarm.CronJob, nextRun)
+			if c.enqueueImageCache(images.ImageCacheRefresh, imageCache, nil) {
+				c.preWarmedRuns[key] = nextRun
+			}
+		case images.ImageCachePurge:
+			glog.Infof("Purging image cache %s after the run of CronJob %s at %s", key, imageCache.Spec.PreWarm.CronJob, c.preWarmedRuns[key])
+			c.workqueue.AddRateLimited(images.WorkQueueKey{WorkType: images.ImageCachePurge, ObjKey: key})
+			delete(c.preWarmedRuns, key)
+		}
+	}
+	// forget the runs of image caches which were deleted or are no longer pre-warmed
+	for key := range c.preWarmedRuns {
+		if !preWarmed[key] {
+			delete(c.preWarmedRuns, key)
+		}
+	}
+}
+
+// preWarmAction returns the work to be done for an image cache pre-warmed ahead of the runs of a CronJob: a refresh
+// if the next run is within the lead time and the image cache is not yet pre-warmed for it, or a purge if the purge
+// delay after the run the image cache was last pre-warmed for has passed. It is empty if there is nothing to be done
+func preWarmAction(preWarm *v1alpha2.ImageCachePreWarm, nextRun, preWarmedRun, now time.Time) images.WorkType {
+	lead := time.Duration(defaultPreWarmLeadMinutes) * time.Minute
+	if preWarm.LeadMinutes != nil {
+		lead = time.Duration(*preWarm.LeadMinutes) * time.Minute
+	}
+	if !nextRun.IsZero() && !now.Before(nextRun.Add(-lead)) && !nextRun.Equal(preWarmedRun) {
+		return images.ImageCacheRefresh
+	}
+	if preWarm.PurgeAfterMinutes != nil && !preWarmedRun.IsZero() &&
+		!now.Before(preWarmedRun.Add(time.Duration(*preWarm.PurgeAfterMinutes)*time.Minute)) {
+		return images.ImageCachePurge
+	}
+	return ""
+}
+
+// nextCronJobRun returns the next scheduled run of the CronJob after now. It is the zero time if the CronJob is suspended
+func (c *Controller) nextCronJobRun(namespace, name string, now time.Time) (time.Time, error) {
+	cronJob, err := c.cronJobsLister.CronJobs(namespace).Get(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend {
+		return time.Time{}, nil
+	}
+	// the schedule is parsed as the CronJob controller does, so that the runs pre-warmed are the runs of the CronJob
+	spec := cronJob.Spec.Schedule
+	if cronJob.Spec.TimeZone != nil {
+		spec = fmt.Sprintf("TZ=%s %s", *cronJob.Spec.TimeZone, spec)
+	}
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid schedule of CronJob %s: %v", name, err)
+	}
+	return schedule.Next(now), nil
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubeinformers "k8s.io/client-go/informers"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPreWarmAction(t *testing.T) {
+	lead, purgeAfter := int32(30), int32(60)
+	nextRun := time.Date(2026, 10, 14, 2, 0, 0, 0, time.UTC)
+	previousRun := nextRun.AddDate(0, 0, -1)
+	tests := []struct {
+		name           string
+		preWarm        v1alpha2.ImageCachePreWarm
+		preWarmedRun   time.Time
+		now            time.Time
+		suspended      bool
+		expectedAction images.WorkType
+	}{
+		{name: "#1: Before the default lead time", now: nextRun.Add(-11 * time.Minute), expectedAction: ""},
+		{name: "#2: Within the default lead time", now: nextRun.Add(-10 * time.Minute), expectedAction: images.ImageCacheRefresh},
+		{name: "#3: Within the lead time", preWarm: v1alpha2.ImageCachePreWarm{LeadMinutes: &lead}, now: nextRun.Add(-29 * time.Minute), expectedAction: images.ImageCacheRefresh},
+		{name: "#4: Already pre-warmed for the run", preWarmedRun: nextRun, now: nextRun.Add(-5 * time.Minute), expectedAction: ""},
+		{name: "#5: Purge delay passed", preWarm: v1alpha2.ImageCachePreWarm{PurgeAfterMinutes: &purgeAfter}, preWarmedRun: previousRun, now: previousRun.Add(time.Hour), expectedAction: images.ImageCachePurge},
+		{name: "#6: Purge delay not passed", preWarm: v1alpha2.ImageCachePreWarm{PurgeAfterMinutes: &purgeAfter}, preWarmedRun: previousRun, now: previousRun.Add(59 * time.Minute), expectedAction: ""},
+		{name: "#7: Not purged", preWarmedRun: previousRun, now: previousRun.Add(2 * time.Hour), expectedAction: ""},
+		{name: "#8: Suspended CronJob", now: nextRun, suspended: true, expectedAction: ""},
+	}
+	for _, test := range tests {
+		run := nextRun
+		if test.suspended {
+			run = time.Time{}
+		}
+		if action := preWarmAction(&test.preWarm, run, test.preWarmedRun, test.now); action != test.expectedAction {
+			t.Errorf("Test: %s failed: expectedAction=%s, actualAction=%s", test.name, test.expectedAction, action)
+		}
+	}
+	t.Logf("%d tests passed", len(tests))
+}
+
+func TestNextCronJobRun(t *testing.T) {
+	tests := []struct {
+		name         string
+		schedule     string
+		timeZone     string
+		suspended    bool
+		from         string
+		expectedNext string
+		expectErr    bool
+	}{
+		{name: "#1: Every 15 minutes", schedule: "*/15 * * * *", from: "2026-10-14T10:07:30Z", expectedNext: "2026-10-14T10:15:00Z"},
+		{name: "#2: Weekdays at 02:30", schedule: "30 2 * * 1-5", from: "2026-10-16T03:00:00Z", expectedNext: "2026-10-19T02:30:00Z"},
+		{name: "#3: Day of month or day of week", schedule: "0 12 1 * 0", from: "2026-10-14T00:00:00Z", expectedNext: "2026-10-18T12:00:00Z"},
+		{name: "#4: Macro", schedule: "@monthly", from: "2026-10-14T00:00:00Z", expectedNext: "2026-11-01T00:00:00Z"},
+		{name: "#5: CRON_TZ prefix", schedule: "CRON_TZ=Asia/Kolkata 0 9 * * *", from: "2026-10-14T00:00:00Z", expectedNext: "2026-10-14T03:30:00Z"},
+		{name: "#6: Time zone of the CronJob", schedule: "0 9 * * *", timeZone: "Asia/Kolkata", from: "2026-10-14T00:00:00Z", expectedNext: "2026-10-14T03:30:00Z"},
+		{name: "#7: Run skipped by the start of DST", schedule: "30 2 * * *", timeZone: "America/New_York", from: "2026-03-08T05:00:00Z", expectedNext: "2026-03-09T06:30:00Z"},
+		{name: "#8: Never", schedule: "0 0 30 2 *", from: "2026-10-14T00:00:00Z", expectedNext: ""},
+		{name: "#9: Suspended", schedule: "0 0 * * *", suspended: true, from: "2026-10-14T00:00:00Z", expectedNext: ""},
+		{name: "#10: Too few fields", schedule: "0 0 * *", expectErr: true},
+		{name: "#11: Value out of range", schedule: "60 * * * *", expectErr: true},
+		{name: "#12: Unknown time zone", schedule: "0 0 * * *", timeZone: "Mars/Olympus_Mons", expectErr: true},
+	}
+	for _, test := range tests {
+		cronJob := &batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "report", Namespace: fledgedNameSpace},
+			Spec:       batchv1.CronJobSpec{Schedule: test.schedule, Suspend: &test.suspended},
+		}
+		if test.timeZone != "" {
+			cronJob.Spec.TimeZone = &test.timeZone
+		}
+		fakekubeclientset := &fakeclientset.Clientset{}
+		controller, _, _ := newTestController(fakekubeclientset, &kubefledgedclientsetfake.Clientset{})
+		cronJobInformer := kubeinformers.NewSharedInformerFactory(fakekubeclientset, noResyncPeriodFunc()).Batch().V1().CronJobs()
+		cronJobInformer.Informer().GetIndexer().Add(cronJob)
+		controller.cronJobsLister = cronJobInformer.Lister()
+
+		from, _ := time.Parse(time.RFC3339, test.from)
+		n, err := controller.nextCronJobRun(fledgedNameSpace, "report", from)
+		if test.expectErr {
+			if err == nil {
+				t.Errorf("Test: %s failed: expected error, got none", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: %s failed: expectedError=nil, actualError=%v", test.name, err)
+			continue
+		}
+		next := ""
+		if !n.IsZero() {
+			next = n.UTC().Format(time.RFC3339)
+		}
+		if next != test.expectedNext {
+			t.Errorf("Test: %s failed: expectedNext=%s, actualNext=%s", test.name, test.expectedNext, next)
+		}
+	}
+	t.Logf("%d tests passed", len(tests))
+}
+
+func TestRunPreWarmWorker(t *testing.T) {
+	now := time.Now()
+	// the CronJob runs within the next minutes
+	schedule := now.Add(5 * time.Minute).UTC()
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "report", Namespace: fledgedNameSpace},
+		Spec:       batchv1.CronJobSpec{Schedule: "CRON_TZ=UTC " + schedule.Format("4 15 2 1") + " *"},
+	}
+	imageCache := &v1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: fledgedNameSpace},
+		Spec: v1alpha2.ImageCacheSpec{
+			CacheSpec: []v1alpha2.CacheSpecImages{{Images: []string{"report:v1"}}},
+			PreWarm:   &v1alpha2.ImageCachePreWarm{CronJob: "report"},
+		},
+		Status: v1alpha2.ImageCacheStatus{Status: v1alpha2.ImageCacheActionStatusSucceeded},
+	}
+	fakekubeclientset := &fakeclientset.Clientset{}
+	controller, _, imageCacheInformer := newTestController(fakekubeclientset, &kubefledgedclientsetfake.Clientset{})
+	imageCacheInformer.Informer().GetIndexer().Add(imageCache)
+	cronJobInformer := kubeinformers.NewSharedInformerFactory(fakekubeclientset, noResyncPeriodFunc()).Batch().V1().CronJobs()
+	cronJobInformer.Informer().GetIndexer().Add(cronJob)
+	controller.cronJobsLister = cronJobInformer.Lister()
+
+	controller.runPreWarmWorker()
+	wait.PollImmediate(time.Millisecond, time.Second, func() (bool, error) {
+		return controller.workqueue.Len() == 1, nil
+	})
+	if controller.workqueue.Len() != 1 {
+		t.Fatalf("Test: expectedQueued=1, actualQueued=%d", controller.workqueue.Len())
+	}
+	obj, _ := controller.workqueue.Get()
+	if wqKey := obj.(images.WorkQueueKey); wqKey.WorkType != images.ImageCacheRefresh || wqKey.ObjKey != fledgedNameSpace+"/foo" {
+		t.Errorf("Test: expectedWorkQueueKey=refresh of %s/foo, actualWorkQueueKey=%+v", fledgedNameSpace, wqKey)
+	}
+	controller.workqueue.Done(obj)
+	if run := controller.preWarmedRuns[fledgedNameSpace+"/foo"]; !run.Equal(schedule.Truncate(time.Minute)) {
+		t.Errorf("Test: expectedPreWarmedRun=%s, actualPreWarmedRun=%s", schedule.Truncate(time.Minute), run)
+	}
+
+	// the image cache is not pre-warmed again for the same run
+	controller.runPreWarmWorker()
+	time.Sleep(100 * time.Millisecond)
+	if controller.workqueue.Len() != 0 {
+		t.Errorf("Test: expectedQueued=0, actualQueued=%d", controller.workqueue.Len())
+	}
+	controller.workqueue.ShutDown()
+}
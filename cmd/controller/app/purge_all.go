@@ -0,0 +1,105 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+)
+
+// purgeAllProgressInterval is how often the progress of purging all the image caches is logged
+const purgeAllProgressInterval = 15 * time.Second
+
+// PurgeAll purges the images of all the image caches from all the nodes, by queueing a purge of each
+// image cache, and logs the progress until all of them are purged or stopCh is closed
+func (c *Controller) PurgeAll(stopCh <-chan struct{}) {
+	if ok := cache.WaitForCacheSync(stopCh, c.imageCachesSynced); !ok {
+		return
+	}
+	imageCaches, err := c.imageCachesLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("Error in listing image caches to purge: %v", err)
+		return
+	}
+	since := metav1.NewTime(time.Now().Truncate(time.Second))
+	keys := make([]string, 0, len(imageCaches))
+	for _, imageCache := range imageCaches {
+		key, err := cache.MetaNamespaceKeyFunc(imageCache)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+		c.workqueue.AddRateLimited(images.WorkQueueKey{WorkType: images.ImageCachePurge, ObjKey: key})
+	}
+	glog.Infof("Purging all %d image caches", len(keys))
+
+	wait.PollImmediateUntil(purgeAllProgressInterval, func() (bool, error) {
+		imageCaches, err := c.imageCachesLister.List(labels.Everything())
+		if err != nil {
+			glog.Errorf("Error in listing image caches: %v", err)
+			return false, nil
+		}
+		purged, failed, remaining := purgeAllProgress(imageCaches, keys, since)
+		if len(remaining) > 0 {
+			glog.Infof("Purge all: %d of %d image caches purged, waiting for %v", purged, len(keys), remaining)
+			return false, nil
+		}
+		if len(failed) > 0 {
+			glog.Warningf("Purge all: %d of %d image caches purged, failed to purge %v", purged, len(keys), failed)
+			return true, nil
+		}
+		glog.Infof("Purge all: all %d image caches purged, kubefledged can be uninstalled", len(keys))
+		return true, nil
+	}, stopCh)
+}
+
+// purgeAllProgress returns the no. of the image caches with the given keys whose purge since the given time
+// succeeded, and the keys of those whose purge failed or is not completed. Image caches which have been
+// deleted are counted as purged
+func purgeAllProgress(imageCaches []*v1alpha2.ImageCache, keys []string, since metav1.Time) (purged int, failed, remaining []string) {
+	statuses := map[string]v1alpha2.ImageCacheStatus{}
+	for _, imageCache := range imageCaches {
+		if key, err := cache.MetaNamespaceKeyFunc(imageCache); err == nil {
+			statuses[key] = imageCache.Status
+		}
+	}
+	for _, key := range keys {
+		status, ok := statuses[key]
+		switch {
+		case !ok:
+			purged++
+		case status.Reason != v1alpha2.ImageCacheReasonImageCachePurge || status.StartTime == nil ||
+			status.StartTime.Before(&since) || status.Status == v1alpha2.ImageCacheActionStatusProcessing:
+			remaining = append(remaining, key)
+		case status.Status == v1alpha2.ImageCacheActionStatusFailed:
+			failed = append(failed, key)
+		default:
+			purged++
+		}
+	}
+	sort.Strings(failed)
+	sort.Strings(remaining)
+	return purged, failed, remaining
+}
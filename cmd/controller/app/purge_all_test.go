@@ -0,0 +1,59 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPurgeAllProgress(t *testing.T) {
+	since := metav1.NewTime(time.Date(2026, 10, 14, 2, 0, 0, 0, time.UTC))
+	before := metav1.NewTime(since.Add(-time.Hour))
+	imageCache := func(name string, status v1alpha2.ImageCacheActionStatus, reason string, startTime *metav1.Time) *v1alpha2.ImageCache {
+		return &v1alpha2.ImageCache{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: fledgedNameSpace},
+			Status:     v1alpha2.ImageCacheStatus{Status: status, Reason: reason, StartTime: startTime},
+		}
+	}
+	imageCaches := []*v1alpha2.ImageCache{
+		imageCache("purged", v1alpha2.ImageCacheActionStatusSucceeded, v1alpha2.ImageCacheReasonImageCachePurge, &since),
+		imageCache("nothing-to-delete", v1alpha2.ImageCacheActioneNoImagesPulledOrDeleted, v1alpha2.ImageCacheReasonImageCachePurge, &since),
+		imageCache("failed", v1alpha2.ImageCacheActionStatusFailed, v1alpha2.ImageCacheReasonImageCachePurge, &since),
+		imageCache("processing", v1alpha2.ImageCacheActionStatusProcessing, v1alpha2.ImageCacheReasonImageCachePurge, &since),
+		imageCache("purged-before", v1alpha2.ImageCacheActionStatusSucceeded, v1alpha2.ImageCacheReasonImageCachePurge, &before),
+		imageCache("refreshed", v1alpha2.ImageCacheActionStatusSucceeded, v1alpha2.ImageCacheReasonImageCacheRefresh, &since),
+	}
+	keys := []string{fledgedNameSpace + "/purged", fledgedNameSpace + "/nothing-to-delete", fledgedNameSpace + "/failed",
+		fledgedNameSpace + "/processing", fledgedNameSpace + "/purged-before", fledgedNameSpace + "/refreshed", fledgedNameSpace + "/deleted"}
+
+	purged, failed, remaining := purgeAllProgress(imageCaches, keys, since)
+	if purged != 3 {
+		t.Errorf("Test: expectedPurged=3, actualPurged=%d", purged)
+	}
+	if expected := []string{fledgedNameSpace + "/failed"}; !reflect.DeepEqual(failed, expected) {
+		t.Errorf("Test: expectedFailed=%v, actualFailed=%v", expected, failed)
+	}
+	expected := []string{fledgedNameSpace + "/processing", fledgedNameSpace + "/purged-before", fledgedNameSpace + "/refreshed"}
+	if !reflect.DeepEqual(remaining, expected) {
+		t.Errorf("Test: expectedRemaining=%v, actualRemaining=%v", expected, remaining)
+	}
+}
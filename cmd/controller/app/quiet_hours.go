@@ -0,0 +1,85 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QuietHours are the daily time ranges, in the time zone of the controller, in which image caches are
+// not refreshed periodically nor pre-warmed
+type QuietHours []quietHoursRange
+
+// quietHoursRange is a time range of the day, from start included to end excluded, as durations since
+// midnight. A range whose end is before its start spans midnight
+type quietHoursRange struct {
+	start, end time.Duration
+}
+
+// ParseQuietHours parses a comma-separated list of time ranges of the day, e.g. "01:00-05:00,22:30-23:30".
+// A range ending before it starts spans midnight, e.g. "22:00-06:00"
+func ParseQuietHours(spec string) (QuietHours, error) {
+	var quietHours QuietHours
+	for _, r := range strings.Split(spec, ",") {
+		if r = strings.TrimSpace(r); r == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(r, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid quiet hours %q: must be HH:MM-HH:MM", r)
+		}
+		start, err := timeOfDay(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quiet hours %q: %v", r, err)
+		}
+		end, err := timeOfDay(to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quiet hours %q: %v", r, err)
+		}
+		if start == end {
+			return nil, fmt.Errorf("invalid quiet hours %q: empty range", r)
+		}
+		quietHours = append(quietHours, quietHoursRange{start: start, end: end})
+	}
+	return quietHours, nil
+}
+
+// timeOfDay parses a time of the day HH:MM as the duration since midnight
+func timeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q: must be HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// contains returns true if t is within any of the quiet hours
+func (q QuietHours) contains(t time.Time) bool {
+	sinceMidnight := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+	for _, r := range q {
+		if r.start < r.end && sinceMidnight >= r.start && sinceMidnight < r.end {
+			return true
+		}
+		if r.start > r.end && (sinceMidnight >= r.start || sinceMidnight < r.end) {
+			return true
+		}
+	}
+	return false
+}
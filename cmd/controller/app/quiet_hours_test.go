@@ -0,0 +1,95 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestQuietHours(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, time.October, 15, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name        string
+		spec        string
+		expectedErr bool
+		quiet       []time.Time
+		notQuiet    []time.Time
+	}{
+		{name: "#1: No quiet hours", spec: "", notQuiet: []time.Time{at(0, 0), at(12, 0)}},
+		{name: "#2: Range within the day", spec: "01:00-05:00",
+			quiet: []time.Time{at(1, 0), at(4, 59)}, notQuiet: []time.Time{at(0, 59), at(5, 0)}},
+		{name: "#3: Range spanning midnight", spec: "22:00-06:00",
+			quiet: []time.Time{at(22, 0), at(0, 0), at(5, 59)}, notQuiet: []time.Time{at(6, 0), at(21, 59)}},
+		{name: "#4: Several ranges", spec: "01:00-02:00, 12:30-13:00",
+			quiet: []time.Time{at(1, 30), at(12, 45)}, notQuiet: []time.Time{at(2, 30), at(13, 0)}},
+		{name: "#5: Missing end", spec: "01:00", expectedErr: true},
+		{name: "#6: Invalid time", spec: "25:00-02:00", expectedErr: true},
+		{name: "#7: Empty range", spec: "02:00-02:00", expectedErr: true},
+	}
+	for _, test := range tests {
+		quietHours, err := ParseQuietHours(test.spec)
+		if (err != nil) != test.expectedErr {
+			t.Errorf("Test: %s failed: expectedErr=%t, actualErr=%v", test.name, test.expectedErr, err)
+			continue
+		}
+		for _, q := range test.quiet {
+			if !quietHours.contains(q) {
+				t.Errorf("Test: %s failed: %s expected in quiet hours", test.name, q.Format("15:04"))
+			}
+		}
+		for _, q := range test.notQuiet {
+			if quietHours.contains(q) {
+				t.Errorf("Test: %s failed: %s not expected in quiet hours", test.name, q.Format("15:04"))
+			}
+		}
+	}
+}
+
+func TestRunRefreshWorkerQuietHours(t *testing.T) {
+	controller, _, imagecacheInformer := newTestController(fakeclientset.NewSimpleClientset(), &kubefledgedclientsetfake.Clientset{})
+	controller.imageCacheRefreshFrequency = 15 * time.Minute
+	imagecacheInformer.Informer().GetIndexer().Add(&v1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "kube-fledged"},
+		Status:     v1alpha2.ImageCacheStatus{Status: v1alpha2.ImageCacheActionStatusSucceeded}})
+	now := time.Now()
+	quietHours, err := ParseQuietHours(now.Add(-time.Hour).Format("15:04") + "-" + now.Add(time.Hour).Format("15:04"))
+	if err != nil {
+		t.Fatalf("Test: parsing quiet hours failed: %v", err)
+	}
+	lastRefresh := now.Add(-time.Hour)
+	controller.lastRefreshes = map[string]time.Time{"kube-fledged/foo": lastRefresh}
+
+	controller.quietHours = quietHours
+	controller.runRefreshWorker()
+	if !controller.lastRefreshes["kube-fledged/foo"].Equal(lastRefresh) {
+		t.Errorf("Test: image cache refreshed in quiet hours: %s", controller.lastRefreshes["kube-fledged/foo"])
+	}
+
+	controller.quietHours = nil
+	controller.runRefreshWorker()
+	if controller.lastRefreshes["kube-fledged/foo"].Equal(lastRefresh) {
+		t.Errorf("Test: refresh deferred by quiet hours not made once they ended")
+	}
+}
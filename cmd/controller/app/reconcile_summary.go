@@ -0,0 +1,56 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/senthilrch/kube-fledged/pkg/images"
+)
+
+// reconcileSummary returns the summary of the results of a reconcile of the image cache, with the no. of
+// image pulls (or deletes, for a purge) per status, the no. of nodes and how long the reconcile took e.g.
+// "ImageCache default/foo reconciled: 48 cached, 2 failed, 3 already-present across 10 nodes in 42s"
+func reconcileSummary(cacheKey string, results map[string]images.ImageWorkResult, duration time.Duration) string {
+	succeeded, failed, alreadyPresent, nodeDeleted := 0, 0, 0, 0
+	nodes := map[string]bool{}
+	done := "cached"
+	for _, result := range results {
+		if result.ImageWorkRequest.WorkType == images.ImageCachePurge {
+			done = "deleted"
+		}
+		if node := result.ImageWorkRequest.Node; node != nil {
+			nodes[node.Labels["kubernetes.io/hostname"]] = true
+		}
+		switch result.Status {
+		case images.ImageWorkResultStatusSucceeded:
+			succeeded++
+		case images.ImageWorkResultStatusAlreadyPulled:
+			alreadyPresent++
+		case images.ImageWorkResultStatusNodeDeleted:
+			nodeDeleted++
+		default:
+			failed++
+		}
+	}
+	summary := fmt.Sprintf("ImageCache %s reconciled: %d %s, %d failed, %d already-present", cacheKey, succeeded, done, failed, alreadyPresent)
+	if nodeDeleted > 0 {
+		summary += fmt.Sprintf(", %d node-deleted", nodeDeleted)
+	}
+	return summary + fmt.Sprintf(" across %d nodes in %s", len(nodes), duration.Round(time.Second))
+}
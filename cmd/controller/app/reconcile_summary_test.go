@@ -0,0 +1,74 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReconcileSummary(t *testing.T) {
+	result := func(hostname string, workType images.WorkType, status string) images.ImageWorkResult {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: hostname, Labels: map[string]string{"kubernetes.io/hostname": hostname}}}
+		return images.ImageWorkResult{
+			ImageWorkRequest: images.ImageWorkRequest{Image: "foo:v1", Node: node, WorkType: workType},
+			Status:           status,
+		}
+	}
+	tests := []struct {
+		name            string
+		results         map[string]images.ImageWorkResult
+		duration        time.Duration
+		expectedSummary string
+	}{
+		{
+			name: "#1: Pulls",
+			results: map[string]images.ImageWorkResult{
+				"job1": result("node1", images.ImageCacheCreate, images.ImageWorkResultStatusSucceeded),
+				"job2": result("node2", images.ImageCacheCreate, images.ImageWorkResultStatusSucceeded),
+				"job3": result("node1", images.ImageCacheCreate, images.ImageWorkResultStatusFailed),
+				"job4": result("node2", images.ImageCacheCreate, images.ImageWorkResultStatusUnknown),
+				"job5": result("node3", images.ImageCacheCreate, images.ImageWorkResultStatusAlreadyPulled),
+			},
+			duration:        42*time.Second + 300*time.Millisecond,
+			expectedSummary: "ImageCache kube-fledged/foo reconciled: 2 cached, 2 failed, 1 already-present across 3 nodes in 42s",
+		},
+		{
+			name: "#2: Deletes with a deleted node",
+			results: map[string]images.ImageWorkResult{
+				"job1": result("node1", images.ImageCachePurge, images.ImageWorkResultStatusSucceeded),
+				"job2": result("node2", images.ImageCachePurge, images.ImageWorkResultStatusNodeDeleted),
+			},
+			duration:        90 * time.Second,
+			expectedSummary: "ImageCache kube-fledged/foo reconciled: 1 deleted, 0 failed, 0 already-present, 1 node-deleted across 2 nodes in 1m30s",
+		},
+		{
+			name:            "#3: No results",
+			results:         map[string]images.ImageWorkResult{},
+			expectedSummary: "ImageCache kube-fledged/foo reconciled: 0 cached, 0 failed, 0 already-present across 0 nodes in 0s",
+		},
+	}
+	for _, test := range tests {
+		if summary := reconcileSummary("kube-fledged/foo", test.results, test.duration); summary != test.expectedSummary {
+			t.Errorf("Test: %s failed: expectedSummary=%q, actualSummary=%q", test.name, test.expectedSummary, summary)
+		}
+	}
+}
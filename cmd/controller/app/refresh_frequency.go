@@ -0,0 +1,53 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"time"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+)
+
+// refreshTickPeriod is how often the refresh worker checks which image caches are due for a refresh, unless
+// the refresh frequency of the controller is shorter
+const refreshTickPeriod = time.Minute
+
+// refreshTick returns the period of the refresh worker
+func (c *Controller) refreshTick() time.Duration {
+	if c.imageCacheRefreshFrequency < refreshTickPeriod {
+		return c.imageCacheRefreshFrequency
+	}
+	return refreshTickPeriod
+}
+
+// refreshFrequency returns the refresh frequency of the image cache, which overrides that of the controller
+func (c *Controller) refreshFrequency(imageCache *v1alpha2.ImageCache) time.Duration {
+	if imageCache.Spec.RefreshFrequency != nil && imageCache.Spec.RefreshFrequency.Duration > 0 {
+		return imageCache.Spec.RefreshFrequency.Duration
+	}
+	return c.imageCacheRefreshFrequency
+}
+
+// refreshDue returns true if the image cache was last refreshed by the refresh worker at least its refresh
+// frequency ago, or was not refreshed since the controller started. The refresh frequency is rounded to the
+// nearest tick of the refresh worker
+func (c *Controller) refreshDue(imageCache *v1alpha2.ImageCache, lastRefresh time.Time, now time.Time) bool {
+	if lastRefresh.IsZero() {
+		return true
+	}
+	return now.Sub(lastRefresh)+c.refreshTick()/2 >= c.refreshFrequency(imageCache)
+}
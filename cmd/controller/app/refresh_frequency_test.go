@@ -0,0 +1,88 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRefreshDue(t *testing.T) {
+	controller, _, _ := newTestController(fakeclientset.NewSimpleClientset(), &kubefledgedclientsetfake.Clientset{})
+	controller.imageCacheRefreshFrequency = 15 * time.Minute
+	now := time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)
+	hot := &metav1.Duration{Duration: 5 * time.Minute}
+	tests := []struct {
+		name             string
+		refreshFrequency *metav1.Duration
+		lastRefresh      time.Time
+		expected         bool
+	}{
+		{name: "#1: Not refreshed since startup", lastRefresh: time.Time{}, expected: true},
+		{name: "#2: Controller frequency not elapsed", lastRefresh: now.Add(-10 * time.Minute), expected: false},
+		{name: "#3: Controller frequency elapsed", lastRefresh: now.Add(-15 * time.Minute), expected: true},
+		{name: "#4: Controller frequency elapsed but for the jitter of the tick", lastRefresh: now.Add(-15*time.Minute + time.Second), expected: true},
+		{name: "#5: Frequency of the image cache elapsed", refreshFrequency: hot, lastRefresh: now.Add(-5 * time.Minute), expected: true},
+		{name: "#6: Frequency of the image cache not elapsed", refreshFrequency: hot, lastRefresh: now.Add(-3 * time.Minute), expected: false},
+	}
+	for _, test := range tests {
+		imageCache := &v1alpha2.ImageCache{Spec: v1alpha2.ImageCacheSpec{RefreshFrequency: test.refreshFrequency}}
+		if actual := controller.refreshDue(imageCache, test.lastRefresh, now); actual != test.expected {
+			t.Errorf("Test: %s failed: expected=%t, actual=%t", test.name, test.expected, actual)
+		}
+	}
+
+	controller.imageCacheRefreshFrequency = 30 * time.Second
+	if actual := controller.refreshTick(); actual != 30*time.Second {
+		t.Errorf("Test: expectedRefreshTick=30s, actualRefreshTick=%s", actual)
+	}
+}
+
+func TestRunRefreshWorkerFrequency(t *testing.T) {
+	controller, _, imagecacheInformer := newTestController(fakeclientset.NewSimpleClientset(), &kubefledgedclientsetfake.Clientset{})
+	controller.imageCacheRefreshFrequency = 15 * time.Minute
+	succeeded := v1alpha2.ImageCacheStatus{Status: v1alpha2.ImageCacheActionStatusSucceeded}
+	imagecacheInformer.Informer().GetIndexer().Add(&v1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{Name: "hot", Namespace: "kube-fledged"},
+		Spec:       v1alpha2.ImageCacheSpec{RefreshFrequency: &metav1.Duration{Duration: 5 * time.Minute}},
+		Status:     succeeded})
+	imagecacheInformer.Informer().GetIndexer().Add(&v1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{Name: "cold", Namespace: "kube-fledged"}, Status: succeeded})
+	sixMinutesAgo := time.Now().Add(-6 * time.Minute)
+	controller.lastRefreshes = map[string]time.Time{
+		"kube-fledged/hot":     sixMinutesAgo,
+		"kube-fledged/cold":    sixMinutesAgo,
+		"kube-fledged/deleted": sixMinutesAgo,
+	}
+
+	// the refreshes are added to the workqueue rate limited, so the last refreshes are checked instead
+	controller.runRefreshWorker()
+	if !controller.lastRefreshes["kube-fledged/cold"].Equal(sixMinutesAgo) {
+		t.Errorf("Test: cold image cache refreshed before its frequency elapsed: %s", controller.lastRefreshes["kube-fledged/cold"])
+	}
+	if _, ok := controller.lastRefreshes["kube-fledged/deleted"]; ok || len(controller.lastRefreshes) != 2 {
+		t.Errorf("Test: last refreshes of the image caches not pruned: %v", controller.lastRefreshes)
+	}
+	if time.Since(controller.lastRefreshes["kube-fledged/hot"]) > time.Minute {
+		t.Errorf("Test: last refresh of the hot image cache not updated: %s", controller.lastRefreshes["kube-fledged/hot"])
+	}
+}
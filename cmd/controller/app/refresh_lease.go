@@ -0,0 +1,75 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/glog"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+// refreshLeaseHolder is the holder identity of the refresh lease
+const refreshLeaseHolder = "kubefledged-controller"
+
+// renewRefreshLease renews the refresh lease, creating it if needed, so that external tooling can check that
+// the refresh loop is alive. The lease duration is twice the refresh frequency, after which the lease can be
+// considered expired
+func (c *Controller) renewRefreshLease(now time.Time) {
+	if c.refreshLeaseName == "" {
+		return
+	}
+	leases := c.kubeclientset.CoordinationV1().Leases(c.refreshLeaseNamespace)
+	holder := refreshLeaseHolder
+	durationSeconds := int32(2 * c.imageCacheRefreshFrequency / time.Second)
+	renewTime := metav1.NewMicroTime(now)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		lease, err := leases.Get(context.TODO(), c.refreshLeaseName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			_, err = leases.Create(context.TODO(), &coordinationv1.Lease{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      c.refreshLeaseName,
+					Namespace: c.refreshLeaseNamespace,
+					Labels:    map[string]string{"app": "kubefledged", "kubefledged": "kubefledged-controller"},
+				},
+				Spec: coordinationv1.LeaseSpec{
+					HolderIdentity:       &holder,
+					LeaseDurationSeconds: &durationSeconds,
+					AcquireTime:          &renewTime,
+					RenewTime:            &renewTime,
+				},
+			}, metav1.CreateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		leaseCopy := lease.DeepCopy()
+		leaseCopy.Spec.HolderIdentity = &holder
+		leaseCopy.Spec.LeaseDurationSeconds = &durationSeconds
+		leaseCopy.Spec.RenewTime = &renewTime
+		_, err = leases.Update(context.TODO(), leaseCopy, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		glog.Errorf("Error renewing refresh lease %s/%s: %v", c.refreshLeaseNamespace, c.refreshLeaseName, err)
+	}
+}
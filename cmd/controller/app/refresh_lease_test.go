@@ -0,0 +1,58 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRenewRefreshLease(t *testing.T) {
+	fakekubeclientset := fakeclientset.NewSimpleClientset()
+	controller, _, _ := newTestController(fakekubeclientset, &kubefledgedclientsetfake.Clientset{})
+	controller.imageCacheRefreshFrequency = 15 * time.Minute
+	start := time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)
+
+	// no lease is renewed without a lease name
+	controller.renewRefreshLease(start)
+	if actions := fakekubeclientset.Actions(); len(actions) != 0 {
+		t.Errorf("Test: lease renewed without a lease name: actions=%v", actions)
+	}
+
+	controller.refreshLeaseNamespace, controller.refreshLeaseName = "monitoring", "kubefledged-refresh"
+	for _, now := range []time.Time{start, start.Add(15 * time.Minute)} {
+		controller.renewRefreshLease(now)
+		lease, err := fakekubeclientset.CoordinationV1().Leases("monitoring").Get(context.TODO(), "kubefledged-refresh", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Test: error getting refresh lease: %v", err)
+		}
+		if lease.Spec.RenewTime == nil || !lease.Spec.RenewTime.Time.Equal(now) {
+			t.Errorf("Test: expectedRenewTime=%s, actualRenewTime=%v", now, lease.Spec.RenewTime)
+		}
+		if lease.Spec.AcquireTime == nil || !lease.Spec.AcquireTime.Time.Equal(start) {
+			t.Errorf("Test: expectedAcquireTime=%s, actualAcquireTime=%v", start, lease.Spec.AcquireTime)
+		}
+		if lease.Spec.LeaseDurationSeconds == nil || *lease.Spec.LeaseDurationSeconds != 1800 {
+			t.Errorf("Test: expectedLeaseDurationSeconds=1800, actualLeaseDurationSeconds=%v", lease.Spec.LeaseDurationSeconds)
+		}
+	}
+}
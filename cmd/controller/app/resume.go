@@ -0,0 +1,66 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+
+	"github.com/golang/glog"
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// processingImageCaches returns the keys of the image caches whose reconcile was under processing when the
+// controller stopped
+func (c *Controller) processingImageCaches() (map[string]bool, error) {
+	imagecachelist, err := c.kubefledgedclientset.KubefledgedV1alpha2().ImageCaches(c.watchNamespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: c.imageCacheLabelSelector,
+	})
+	if err != nil {
+		glog.Errorf("Error listing imagecaches: %v", err)
+		return nil, err
+	}
+	processing := map[string]bool{}
+	for _, imagecache := range imagecachelist.Items {
+		if imagecache.Status.Status == v1alpha2.ImageCacheActionStatusProcessing {
+			processing[imagecache.Namespace+"/"+imagecache.Name] = true
+		}
+	}
+	return processing, nil
+}
+
+// resumeWorkType returns the work type of the reconcile resuming the one of the status reason. An update is
+// resumed as a refresh, since the spec it was compared with is gone
+func resumeWorkType(reason string) images.WorkType {
+	switch reason {
+	case v1alpha2.ImageCacheReasonImageCacheCreate:
+		return images.ImageCacheCreate
+	case v1alpha2.ImageCacheReasonImageCachePurge:
+		return images.ImageCachePurge
+	}
+	return images.ImageCacheRefresh
+}
+
+// resumeReconcile queues the reconcile of the image cache under processing when the controller stopped. Its
+// image work claims the jobs adopted by the image manager rather than creating them again, so that the
+// progress of the reconcile survives the restart
+func (c *Controller) resumeReconcile(imagecache *v1alpha2.ImageCache) {
+	workType := resumeWorkType(imagecache.Status.Reason)
+	c.workqueue.Add(images.WorkQueueKey{WorkType: workType, ObjKey: imagecache.Namespace + "/" + imagecache.Name})
+	glog.Infof("Image cache(%s) under processing resumed (%s)", imagecache.Name, workType)
+}
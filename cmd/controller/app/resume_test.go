@@ -0,0 +1,41 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"testing"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+)
+
+func TestResumeWorkType(t *testing.T) {
+	tests := []struct {
+		reason   string
+		expected images.WorkType
+	}{
+		{reason: v1alpha2.ImageCacheReasonImageCacheCreate, expected: images.ImageCacheCreate},
+		{reason: v1alpha2.ImageCacheReasonImageCacheUpdate, expected: images.ImageCacheRefresh},
+		{reason: v1alpha2.ImageCacheReasonImageCacheRefresh, expected: images.ImageCacheRefresh},
+		{reason: v1alpha2.ImageCacheReasonImageCachePurge, expected: images.ImageCachePurge},
+	}
+	for _, test := range tests {
+		if actual := resumeWorkType(test.reason); actual != test.expected {
+			t.Errorf("Test: reason %s failed: expectedWorkType=%s, actualWorkType=%s", test.reason, test.expected, actual)
+		}
+	}
+}
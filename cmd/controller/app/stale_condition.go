@@ -0,0 +1,146 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/golang/glog"
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	"github.com/senthilrch/kube-fledged/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// staleRefreshFactor is the no. of refresh frequencies after its last successful refresh an image cache
+	// is stale, unless the stale threshold is set
+	staleRefreshFactor = 3
+	// staleCheckPeriod is how often the stale worker checks the image caches
+	staleCheckPeriod = time.Minute
+)
+
+// isSuccessfulRefresh returns true if a reconcile with the reason, which pulls the images unless it is a purge,
+// ended with the status of a successful refresh
+func isSuccessfulRefresh(reason string, status v1alpha2.ImageCacheActionStatus) bool {
+	if reason == v1alpha2.ImageCacheReasonImageCachePurge {
+		return false
+	}
+	return status == v1alpha2.ImageCacheActionStatusSucceeded || status == v1alpha2.ImageCacheActioneNoImagesPulledOrDeleted
+}
+
+// staleThreshold returns how long after its last successful refresh the image cache is stale: the stale
+// threshold of the controller, or else staleRefreshFactor times its refresh frequency. It is 0, and the image
+// cache is never stale, for the purged image caches, and for the pre-warmed ones without a stale threshold,
+// which are not refreshed periodically
+func (c *Controller) staleThreshold(imageCache *v1alpha2.ImageCache) time.Duration {
+	if imageCache.Status.Reason == v1alpha2.ImageCacheReasonImageCachePurge {
+		return 0
+	}
+	if c.staleAfter > 0 {
+		return c.staleAfter
+	}
+	if imageCache.Spec.PreWarm != nil || c.imageCacheRefreshFrequency == 0 {
+		return 0
+	}
+	return staleRefreshFactor * c.refreshFrequency(imageCache)
+}
+
+// setStaleCondition sets the Stale condition of an image cache from the time of its last successful refresh,
+// and removes the condition if the image cache is never stale. The condition is not set until the image cache
+// is refreshed successfully. The last transition time is retained if its status does not change
+func setStaleCondition(status *v1alpha2.ImageCacheStatus, threshold time.Duration, generation int64, now time.Time) {
+	if threshold == 0 {
+		meta.RemoveStatusCondition(&status.Conditions, v1alpha2.ImageCacheConditionStale)
+		return
+	}
+	if status.LastSuccessfulRefreshTime == nil {
+		return
+	}
+	condition := metav1.Condition{
+		Type:               v1alpha2.ImageCacheConditionStale,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             v1alpha2.ImageCacheReasonRefreshedRecently,
+		Message: fmt.Sprintf("Last successful refresh at %s, stale after %s",
+			status.LastSuccessfulRefreshTime.UTC().Format(time.RFC3339), threshold),
+	}
+	if now.Sub(status.LastSuccessfulRefreshTime.Time) > threshold {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = v1alpha2.ImageCacheReasonRefreshOverdue
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+}
+
+// runStaleWorker sets the Stale condition and the kubefledged_cache_stale gauge of all the image caches.
+// The status of an image cache is only updated when its Stale condition changes, and a Warning event is
+// emitted when it becomes stale
+func (c *Controller) runStaleWorker() {
+	imageCaches, err := c.imageCachesLister.ImageCaches("").List(labels.Everything())
+	if err != nil {
+		glog.Errorf("Error in listing image caches: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, imageCache := range imageCaches {
+		key, err := cache.MetaNamespaceKeyFunc(imageCache)
+		if err != nil {
+			continue
+		}
+		status := imageCache.Status.DeepCopy()
+		setStaleCondition(status, c.staleThreshold(imageCache), imageCache.Generation, now)
+		condition := meta.FindStatusCondition(status.Conditions, v1alpha2.ImageCacheConditionStale)
+		switch {
+		case condition == nil:
+			metrics.CacheStale.DeleteLabelValues(key)
+		case condition.Status == metav1.ConditionTrue:
+			metrics.CacheStale.WithLabelValues(key).Set(1)
+		default:
+			metrics.CacheStale.WithLabelValues(key).Set(0)
+		}
+		if reflect.DeepEqual(status.Conditions, imageCache.Status.Conditions) {
+			continue
+		}
+		if err := c.updateStaleCondition(imageCache, now); err != nil {
+			glog.Errorf("Error updating the Stale condition of image cache %s: %v", key, err)
+			continue
+		}
+		if condition != nil && condition.Status == metav1.ConditionTrue &&
+			!meta.IsStatusConditionTrue(imageCache.Status.Conditions, v1alpha2.ImageCacheConditionStale) {
+			glog.Warningf("Image cache %s is stale: %s", key, condition.Message)
+			c.recorder.Event(imageCache, corev1.EventTypeWarning, condition.Reason, condition.Message)
+		}
+	}
+}
+
+// updateStaleCondition updates the status of the image cache with its Stale condition. A conflicting update
+// of the status is retried by the next run of the stale worker
+func (c *Controller) updateStaleCondition(imageCache *v1alpha2.ImageCache, now time.Time) error {
+	imageCacheCopy, err := c.kubefledgedclientset.KubefledgedV1alpha2().ImageCaches(imageCache.Namespace).Get(context.TODO(), imageCache.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	setStaleCondition(&imageCacheCopy.Status, c.staleThreshold(imageCacheCopy), imageCacheCopy.Generation, now)
+	_, err = c.kubefledgedclientset.KubefledgedV1alpha2().ImageCaches(imageCache.Namespace).UpdateStatus(context.TODO(), imageCacheCopy, metav1.UpdateOptions{})
+	return err
+}
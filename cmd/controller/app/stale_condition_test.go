@@ -0,0 +1,117 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	"github.com/senthilrch/kube-fledged/pkg/metrics"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStaleCondition(t *testing.T) {
+	controller, _, _ := newTestController(fakeclientset.NewSimpleClientset(), &kubefledgedclientsetfake.Clientset{})
+	now := time.Now()
+	refreshedAgo := func(d time.Duration) *metav1.Time {
+		refreshTime := metav1.NewTime(now.Add(-d))
+		return &refreshTime
+	}
+	tests := []struct {
+		name              string
+		refreshFrequency  time.Duration
+		staleAfter        time.Duration
+		spec              v1alpha2.ImageCacheSpec
+		reason            string
+		lastRefresh       *metav1.Time
+		expectedThreshold time.Duration
+		expectedCondition metav1.ConditionStatus
+		expectedReason    string
+	}{
+		{name: "#1: Refreshed within 3 refresh frequencies", refreshFrequency: time.Hour, lastRefresh: refreshedAgo(2 * time.Hour),
+			expectedThreshold: 3 * time.Hour, expectedCondition: metav1.ConditionFalse, expectedReason: v1alpha2.ImageCacheReasonRefreshedRecently},
+		{name: "#2: Not refreshed for 3 refresh frequencies", refreshFrequency: time.Hour, lastRefresh: refreshedAgo(4 * time.Hour),
+			expectedThreshold: 3 * time.Hour, expectedCondition: metav1.ConditionTrue, expectedReason: v1alpha2.ImageCacheReasonRefreshOverdue},
+		{name: "#3: Refresh frequency of the image cache", refreshFrequency: time.Hour, lastRefresh: refreshedAgo(4 * time.Hour),
+			spec:              v1alpha2.ImageCacheSpec{RefreshFrequency: &metav1.Duration{Duration: 2 * time.Hour}},
+			expectedThreshold: 6 * time.Hour, expectedCondition: metav1.ConditionFalse, expectedReason: v1alpha2.ImageCacheReasonRefreshedRecently},
+		{name: "#4: Stale threshold overrides the refresh frequency", refreshFrequency: time.Hour, staleAfter: 30 * time.Minute,
+			lastRefresh: refreshedAgo(time.Hour), expectedThreshold: 30 * time.Minute, expectedCondition: metav1.ConditionTrue, expectedReason: v1alpha2.ImageCacheReasonRefreshOverdue},
+		{name: "#5: Refresh disabled", refreshFrequency: 0, lastRefresh: refreshedAgo(48 * time.Hour), expectedThreshold: 0},
+		{name: "#6: Pre-warmed image cache", refreshFrequency: time.Hour, lastRefresh: refreshedAgo(48 * time.Hour),
+			spec: v1alpha2.ImageCacheSpec{PreWarm: &v1alpha2.ImageCachePreWarm{CronJob: "nightly"}}, expectedThreshold: 0},
+		{name: "#7: Purged image cache", refreshFrequency: time.Hour, staleAfter: time.Hour, reason: v1alpha2.ImageCacheReasonImageCachePurge,
+			lastRefresh: refreshedAgo(48 * time.Hour), expectedThreshold: 0},
+		{name: "#8: Never refreshed successfully", refreshFrequency: time.Hour, lastRefresh: nil, expectedThreshold: 3 * time.Hour},
+	}
+	for _, test := range tests {
+		controller.imageCacheRefreshFrequency = test.refreshFrequency
+		controller.staleAfter = test.staleAfter
+		imageCache := &v1alpha2.ImageCache{Spec: test.spec, Status: v1alpha2.ImageCacheStatus{
+			Reason: test.reason, LastSuccessfulRefreshTime: test.lastRefresh, Conditions: []metav1.Condition{
+				{Type: v1alpha2.ImageCacheConditionStale, Status: metav1.ConditionFalse, Reason: v1alpha2.ImageCacheReasonRefreshedRecently}}}}
+		if test.lastRefresh == nil {
+			imageCache.Status.Conditions = nil
+		}
+		threshold := controller.staleThreshold(imageCache)
+		if threshold != test.expectedThreshold {
+			t.Errorf("Test: %s failed: expectedThreshold=%s, actualThreshold=%s", test.name, test.expectedThreshold, threshold)
+		}
+		setStaleCondition(&imageCache.Status, threshold, 2, now)
+		condition := meta.FindStatusCondition(imageCache.Status.Conditions, v1alpha2.ImageCacheConditionStale)
+		if test.expectedCondition == "" {
+			if condition != nil {
+				t.Errorf("Test: %s failed: expectedCondition=<none>, actualCondition=%s", test.name, condition.Status)
+			}
+			continue
+		}
+		if condition == nil || condition.Status != test.expectedCondition || condition.Reason != test.expectedReason || condition.ObservedGeneration != 2 {
+			t.Errorf("Test: %s failed: expectedCondition=%s, actualCondition=%+v", test.name, test.expectedCondition, condition)
+		}
+	}
+}
+
+func TestRunStaleWorker(t *testing.T) {
+	lastRefresh := metav1.NewTime(time.Now().Add(-4 * time.Hour))
+	imageCache := &v1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{Name: "stale", Namespace: fledgedNameSpace},
+		Status:     v1alpha2.ImageCacheStatus{Status: v1alpha2.ImageCacheActionStatusSucceeded, LastSuccessfulRefreshTime: &lastRefresh},
+	}
+	fledgedClient := kubefledgedclientsetfake.NewSimpleClientset(imageCache)
+	controller, _, imageCacheInformer := newTestController(fakeclientset.NewSimpleClientset(), fledgedClient)
+	controller.imageCacheRefreshFrequency = time.Hour
+	imageCacheInformer.Informer().GetIndexer().Add(imageCache)
+	defer deleteCacheMetrics(fledgedNameSpace + "/stale")
+
+	controller.runStaleWorker()
+	if stale := testutil.ToFloat64(metrics.CacheStale.WithLabelValues(fledgedNameSpace + "/stale")); stale != 1 {
+		t.Errorf("Test: expectedStale=1, actualStale=%v", stale)
+	}
+	updated, err := fledgedClient.KubefledgedV1alpha2().ImageCaches(fledgedNameSpace).Get(context.TODO(), "stale", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Test: getting image cache failed: %v", err)
+	}
+	if !meta.IsStatusConditionTrue(updated.Status.Conditions, v1alpha2.ImageCacheConditionStale) {
+		t.Errorf("Test: expectedCondition=True, actualConditions=%+v", updated.Status.Conditions)
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/golang/glog"
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+)
+
+// statusConfigMapKey is the key of the status ConfigMap holding the summary of the image caches
+const statusConfigMapKey = "status.json"
+
+// cacheStatusSummary is the summary of an image cache in the status ConfigMap
+type cacheStatusSummary struct {
+	Status         v1alpha2.ImageCacheActionStatus `json:"status"`
+	Reason         string                          `json:"reason,omitempty"`
+	CachedImages   int                             `json:"cachedImages"`
+	FailedImages   int                             `json:"failedImages"`
+	NodesCovered   int                             `json:"nodesCovered"`
+	Nodes          int                             `json:"nodes"`
+	CompletionTime *metav1.Time                    `json:"completionTime,omitempty"`
+}
+
+// recordStatusSummary sets the summary of the image cache from the status and the results of its latest
+// reconcile, and writes the summaries of all the image caches to the status ConfigMap
+func (c *Controller) recordStatusSummary(cacheKey string, status *v1alpha2.ImageCacheStatus, results map[string]images.ImageWorkResult) {
+	if c.statusConfigMap == "" {
+		return
+	}
+	coverage, _ := coverageOf(results)
+	now := metav1.Now()
+	c.statusSummariesLock.Lock()
+	defer c.statusSummariesLock.Unlock()
+	c.statusSummaries[cacheKey] = cacheStatusSummary{
+		Status:         status.Status,
+		Reason:         status.Reason,
+		CachedImages:   coverage.cachedImages,
+		FailedImages:   coverage.failedImages,
+		NodesCovered:   coverage.nodesCovered,
+		Nodes:          coverage.nodes,
+		CompletionTime: &now,
+	}
+	c.writeStatusConfigMap()
+}
+
+// deleteStatusSummary removes the summary of a deleted image cache from the status ConfigMap
+func (c *Controller) deleteStatusSummary(cacheKey string) {
+	if c.statusConfigMap == "" {
+		return
+	}
+	c.statusSummariesLock.Lock()
+	defer c.statusSummariesLock.Unlock()
+	if _, ok := c.statusSummaries[cacheKey]; !ok && c.statusSummariesLoaded {
+		return
+	}
+	delete(c.statusSummaries, cacheKey)
+	c.writeStatusConfigMap()
+}
+
+// writeStatusConfigMap writes the summaries to the status ConfigMap in the namespace of kubefledged, creating
+// it if needed. The summaries of the ConfigMap written before the controller started are kept for the image
+// caches which still exist. It is called with statusSummariesLock held
+func (c *Controller) writeStatusConfigMap() {
+	configMaps := c.kubeclientset.CoreV1().ConfigMaps(c.fledgedNameSpace)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := configMaps.Get(context.TODO(), c.statusConfigMap, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		if !c.statusSummariesLoaded {
+			if err == nil {
+				c.loadStatusSummaries(configMap.Data[statusConfigMapKey])
+			}
+			c.statusSummariesLoaded = true
+		}
+		data, marshalErr := json.Marshal(c.statusSummaries)
+		if marshalErr != nil {
+			return marshalErr
+		}
+		if apierrors.IsNotFound(err) {
+			_, err = configMaps.Create(context.TODO(), &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      c.statusConfigMap,
+					Namespace: c.fledgedNameSpace,
+					Labels:    map[string]string{"app": "kubefledged", "kubefledged": "kubefledged-controller"},
+				},
+				Data: map[string]string{statusConfigMapKey: string(data)},
+			}, metav1.CreateOptions{})
+			return err
+		}
+		configMapCopy := configMap.DeepCopy()
+		if configMapCopy.Data == nil {
+			configMapCopy.Data = map[string]string{}
+		}
+		configMapCopy.Data[statusConfigMapKey] = string(data)
+		_, err = configMaps.Update(context.TODO(), configMapCopy, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		glog.Errorf("Error writing status configmap %s: %v", c.statusConfigMap, err)
+	}
+}
+
+// loadStatusSummaries adds the summaries of the image caches which still exist, from the data of the status
+// ConfigMap, to the summaries not yet set since the controller started
+func (c *Controller) loadStatusSummaries(data string) {
+	if data == "" {
+		return
+	}
+	summaries := map[string]cacheStatusSummary{}
+	if err := json.Unmarshal([]byte(data), &summaries); err != nil {
+		glog.Warningf("Ignoring summaries of status configmap %s: %v", c.statusConfigMap, err)
+		return
+	}
+	for cacheKey, summary := range summaries {
+		if _, ok := c.statusSummaries[cacheKey]; ok {
+			continue
+		}
+		namespace, name, err := cache.SplitMetaNamespaceKey(cacheKey)
+		if err != nil {
+			continue
+		}
+		if _, err := c.imageCachesLister.ImageCaches(namespace).Get(name); err == nil {
+			c.statusSummaries[cacheKey] = summary
+		}
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRecordStatusSummary(t *testing.T) {
+	existing := cacheStatusSummary{Status: v1alpha2.ImageCacheActionStatusSucceeded, CachedImages: 3, NodesCovered: 2, Nodes: 2}
+	data, _ := json.Marshal(map[string]cacheStatusSummary{"kube-fledged/bar": existing, "kube-fledged/deleted": existing})
+	statusConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubefledged-status", Namespace: fledgedNameSpace},
+		Data:       map[string]string{statusConfigMapKey: string(data)},
+	}
+	fakekubeclientset := fakeclientset.NewSimpleClientset(statusConfigMap)
+	controller, _, imagecacheInformer := newTestController(fakekubeclientset, &kubefledgedclientsetfake.Clientset{})
+	controller.statusConfigMap = "kubefledged-status"
+	for _, name := range []string{"foo", "bar"} {
+		imagecacheInformer.Informer().GetIndexer().Add(&v1alpha2.ImageCache{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: fledgedNameSpace}})
+	}
+	node := func(name string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"kubernetes.io/hostname": name}}}
+	}
+	results := map[string]images.ImageWorkResult{
+		"job1": {ImageWorkRequest: images.ImageWorkRequest{Image: "foo:v1", Node: node("node1")}, Status: images.ImageWorkResultStatusSucceeded},
+		"job2": {ImageWorkRequest: images.ImageWorkRequest{Image: "foo:v1", Node: node("node2")}, Status: images.ImageWorkResultStatusAlreadyPulled},
+		"job3": {ImageWorkRequest: images.ImageWorkRequest{Image: "bar:v1", Node: node("node2")}, Status: images.ImageWorkResultStatusFailed},
+	}
+	status := &v1alpha2.ImageCacheStatus{Status: v1alpha2.ImageCacheActionStatusFailed, Reason: v1alpha2.ImageCacheReasonImageCacheCreate}
+
+	summaries := func() map[string]cacheStatusSummary {
+		configMap, err := fakekubeclientset.CoreV1().ConfigMaps(fledgedNameSpace).Get(context.TODO(), "kubefledged-status", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Test: error getting status configmap: %v", err)
+		}
+		summaries := map[string]cacheStatusSummary{}
+		if err := json.Unmarshal([]byte(configMap.Data[statusConfigMapKey]), &summaries); err != nil {
+			t.Fatalf("Test: error unmarshalling status configmap: %v", err)
+		}
+		return summaries
+	}
+
+	controller.recordStatusSummary("kube-fledged/foo", status, results)
+	written := summaries()
+	if _, ok := written["kube-fledged/deleted"]; ok {
+		t.Errorf("Test: summary of a deleted image cache loaded from the status configmap")
+	}
+	if bar := written["kube-fledged/bar"]; bar.Status != existing.Status || bar.CachedImages != existing.CachedImages {
+		t.Errorf("Test: summary of an existing image cache not loaded from the status configmap: %+v", bar)
+	}
+	foo := written["kube-fledged/foo"]
+	foo.CompletionTime = nil
+	expected := cacheStatusSummary{Status: v1alpha2.ImageCacheActionStatusFailed, Reason: v1alpha2.ImageCacheReasonImageCacheCreate,
+		CachedImages: 1, FailedImages: 1, NodesCovered: 1, Nodes: 2}
+	if foo != expected {
+		t.Errorf("Test: expectedSummary=%+v, actualSummary=%+v", expected, foo)
+	}
+
+	controller.deleteStatusSummary("kube-fledged/bar")
+	if _, ok := summaries()["kube-fledged/bar"]; ok {
+		t.Errorf("Test: summary of a deleted image cache not removed from the status configmap")
+	}
+}
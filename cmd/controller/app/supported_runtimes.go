@@ -0,0 +1,115 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// containerRuntime returns the name of the container runtime of the node e.g. containerd for containerd://1.6.8
+func containerRuntime(node *corev1.Node) string {
+	runtime, _, _ := strings.Cut(node.Status.NodeInfo.ContainerRuntimeVersion, "://")
+	return runtime
+}
+
+// isSupportedRuntime returns true if the container runtime of the node is one of the supported runtimes,
+// or if all runtimes are supported
+func (c *Controller) isSupportedRuntime(node *corev1.Node) bool {
+	if len(c.supportedRuntimes) == 0 {
+		return true
+	}
+	runtime := containerRuntime(node)
+	for _, r := range c.supportedRuntimes {
+		if r == runtime {
+			return true
+		}
+	}
+	return false
+}
+
+// filterSupportedRuntimeNodes removes the nodes whose container runtime is not supported
+func (c *Controller) filterSupportedRuntimeNodes(nodes []*corev1.Node) []*corev1.Node {
+	if len(c.supportedRuntimes) == 0 {
+		return nodes
+	}
+	filtered := make([]*corev1.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if !c.isSupportedRuntime(n) {
+			glog.V(4).Infof("Skipping node %s with unsupported container runtime %q", n.Name, containerRuntime(n))
+			continue
+		}
+		filtered = append(filtered, n)
+	}
+	return filtered
+}
+
+// unsupportedRuntimeNodes returns the nodes of the image lists of the image cache which are skipped because
+// their container runtime is not supported, as "node (runtime)" in sorted order
+func (c *Controller) unsupportedRuntimeNodes(imageCache *v1alpha2.ImageCache) []string {
+	if len(c.supportedRuntimes) == 0 {
+		return nil
+	}
+	skipped := map[string]bool{}
+	for _, i := range imageCache.Spec.CacheSpec {
+		nodes, err := c.nodesLister.List(labels.Set(i.NodeSelector).AsSelector())
+		if err != nil {
+			continue
+		}
+		nodes = filterSkipCacheNodes(nodes)
+		if len(i.NodeNames) > 0 {
+			nodes = filterNodeNames(nodes, i.NodeNames)
+		}
+		for _, n := range nodes {
+			if !c.isSupportedRuntime(n) {
+				skipped[fmt.Sprintf("%s (%s)", n.Name, containerRuntime(n))] = true
+			}
+		}
+	}
+	nodes := make([]string, 0, len(skipped))
+	for n := range skipped {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// withUnsupportedRuntimeNodes appends a note of the nodes skipped because of their container runtime to the
+// status message
+func withUnsupportedRuntimeNodes(message string, nodes []string) string {
+	if len(nodes) == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s. %s: %s", message, v1alpha2.ImageCacheMessageUnsupportedRuntime, strings.Join(nodes, ", "))
+}
+
+// recordUnsupportedRuntimeEvent emits a Warning event listing the nodes of the image cache skipped because
+// of their container runtime
+func (c *Controller) recordUnsupportedRuntimeEvent(imageCache *v1alpha2.ImageCache, nodes []string) {
+	if len(nodes) == 0 {
+		return
+	}
+	c.recorder.Eventf(imageCache, corev1.EventTypeWarning, v1alpha2.ImageCacheReasonUnsupportedRuntime,
+		"%s (supported: %s): %s", v1alpha2.ImageCacheMessageUnsupportedRuntime,
+		strings.Join(c.supportedRuntimes, ","), strings.Join(nodes, ", "))
+}
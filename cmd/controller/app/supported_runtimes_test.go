@@ -0,0 +1,85 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSupportedRuntimes(t *testing.T) {
+	controller, nodeInformer, _ := newTestController(fakeclientset.NewSimpleClientset(), &kubefledgedclientsetfake.Clientset{})
+	node := func(name, runtimeVersion string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{ContainerRuntimeVersion: runtimeVersion}}}
+	}
+	for _, n := range []*corev1.Node{
+		node("node1", "containerd://1.6.8"),
+		node("node2", "docker://20.10.17"),
+		node("node3", "cri-o://1.25.1"),
+	} {
+		nodeInformer.Informer().GetIndexer().Add(n)
+	}
+	imageCache := &v1alpha2.ImageCache{Spec: v1alpha2.ImageCacheSpec{CacheSpec: []v1alpha2.CacheSpecImages{
+		{Images: []string{"foo:v1"}},
+		{Images: []string{"bar:v1"}, NodeNames: []string{"node1", "node2"}},
+	}}}
+	tests := []struct {
+		name              string
+		supportedRuntimes []string
+		expectedNodes     []string
+		expectedSkipped   []string
+	}{
+		{name: "#1: All runtimes supported", supportedRuntimes: nil,
+			expectedNodes: []string{"node1", "node2", "node3"}, expectedSkipped: []string{}},
+		{name: "#2: Only containerd", supportedRuntimes: []string{"containerd"},
+			expectedNodes: []string{"node1"}, expectedSkipped: []string{"node2 (docker)", "node3 (cri-o)"}},
+		{name: "#3: containerd and cri-o", supportedRuntimes: []string{"containerd", "cri-o"},
+			expectedNodes: []string{"node1", "node3"}, expectedSkipped: []string{"node2 (docker)"}},
+	}
+	for _, test := range tests {
+		controller.supportedRuntimes = test.supportedRuntimes
+		nodes, err := controller.imageListNodes("default", imageCache.Spec.CacheSpec[0])
+		actual := []string{}
+		for _, n := range nodes {
+			actual = append(actual, n.Name)
+		}
+		sort.Strings(actual)
+		if err != nil || !reflect.DeepEqual(actual, test.expectedNodes) {
+			t.Errorf("Test: %s failed: expectedNodes=%v, actualNodes=%v, err=%v", test.name, test.expectedNodes, actual, err)
+		}
+		skipped := controller.unsupportedRuntimeNodes(imageCache)
+		if skipped == nil {
+			skipped = []string{}
+		}
+		if !reflect.DeepEqual(skipped, test.expectedSkipped) {
+			t.Errorf("Test: %s failed: expectedSkipped=%v, actualSkipped=%v", test.name, test.expectedSkipped, skipped)
+		}
+	}
+
+	expected := v1alpha2.ImageCacheMessagePullingImages + ". " + v1alpha2.ImageCacheMessageUnsupportedRuntime + ": node2 (docker)"
+	if actual := withUnsupportedRuntimeNodes(v1alpha2.ImageCacheMessagePullingImages, []string{"node2 (docker)"}); actual != expected {
+		t.Errorf("Test: expectedMessage=%q, actualMessage=%q", expected, actual)
+	}
+}
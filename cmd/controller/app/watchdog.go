@@ -0,0 +1,47 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/senthilrch/kube-fledged/pkg/metrics"
+)
+
+// recordProgress marks that a worker has picked up or completed a work item
+func (c *Controller) recordProgress() {
+	c.lastProgress.Store(time.Now().UnixNano())
+}
+
+// checkProgress is run periodically by the watchdog. If the workers have made no progress within the
+// watchdog window while work items are waiting in the workqueue, the workers are likely hung: the stall
+// is logged and counted, and the controller exits if configured to, so that it is restarted
+func (c *Controller) checkProgress() {
+	if c.workqueue.Len() == 0 {
+		return
+	}
+	stalled := time.Since(time.Unix(0, c.lastProgress.Load()))
+	if stalled < c.watchdogWindow {
+		return
+	}
+	metrics.WatchdogStalls.Inc()
+	if c.watchdogCrash {
+		glog.Fatalf("Watchdog: no progress for %s with %d image caches waiting in the workqueue, exiting", stalled.Round(time.Second), c.workqueue.Len())
+	}
+	glog.Errorf("Watchdog: no progress for %s with %d image caches waiting in the workqueue", stalled.Round(time.Second), c.workqueue.Len())
+}
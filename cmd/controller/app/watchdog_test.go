@@ -0,0 +1,55 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	"github.com/senthilrch/kube-fledged/pkg/metrics"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCheckProgress(t *testing.T) {
+	tests := []struct {
+		name          string
+		queued        bool
+		sinceProgress time.Duration
+		expectStall   bool
+	}{
+		{name: "#1: Empty workqueue", queued: false, sinceProgress: time.Hour, expectStall: false},
+		{name: "#2: Progress within the window", queued: true, sinceProgress: time.Second, expectStall: false},
+		{name: "#3: No progress within the window", queued: true, sinceProgress: time.Hour, expectStall: true},
+	}
+	for _, test := range tests {
+		controller, _, _ := newTestController(fakeclientset.NewSimpleClientset(), kubefledgedclientsetfake.NewSimpleClientset())
+		controller.watchdogWindow = time.Minute
+		if test.queued {
+			controller.workqueue.Add(images.WorkQueueKey{WorkType: images.ImageCacheRefresh, ObjKey: "kube-fledged/foo"})
+		}
+		controller.lastProgress.Store(time.Now().Add(-test.sinceProgress).UnixNano())
+		before := testutil.ToFloat64(metrics.WatchdogStalls)
+		controller.checkProgress()
+		if stalled := testutil.ToFloat64(metrics.WatchdogStalls) > before; stalled != test.expectStall {
+			t.Errorf("Test: %s failed: expectStall=%t, actualStall=%t", test.name, test.expectStall, stalled)
+		}
+		controller.workqueue.ShutDown()
+	}
+}
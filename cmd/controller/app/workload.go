@@ -0,0 +1,196 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/golang/glog"
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// workloadCacheAnnotationKey opts a deployment or statefulset (or its pod template) in to caching its images
+	workloadCacheAnnotationKey = "kubefledged.io/cache"
+	// workloadImageCacheName is the name of the image cache, in the namespace of the workloads,
+	// which caches the images of the workloads opted in to caching
+	workloadImageCacheName = "kubefledged-workloads"
+)
+
+// workloadImageCacheLabels returns the labels of the image caches synthesized from workloads
+func workloadImageCacheLabels() map[string]string {
+	return map[string]string{"app": "kubefledged", "kubefledged": "kubefledged-workloads"}
+}
+
+// enqueueWorkload queues a sync of the workload image cache of the namespace of the workload, if the
+// workload is or was opted in to caching
+func (c *Controller) enqueueWorkload(old, new interface{}) {
+	if old != nil && new != nil {
+		oldMeta, newMeta := old.(metav1.Object), new.(metav1.Object)
+		if oldMeta.GetResourceVersion() == newMeta.GetResourceVersion() {
+			// Periodic resync sends update events for all known workloads
+			return
+		}
+	}
+	var namespace string
+	optedIn := false
+	for _, obj := range []interface{}{old, new} {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		meta, template, ok := workloadPodTemplate(obj)
+		if !ok {
+			continue
+		}
+		namespace = meta.GetNamespace()
+		optedIn = optedIn || isCacheAnnotated(meta, template)
+	}
+	if !optedIn {
+		return
+	}
+	c.workqueue.AddRateLimited(images.WorkQueueKey{
+		WorkType: images.ImageCacheWorkloadSync,
+		ObjKey:   namespace + "/" + workloadImageCacheName,
+	})
+}
+
+// workloadPodTemplate returns the metadata and the pod template of a deployment or statefulset
+func workloadPodTemplate(obj interface{}) (metav1.Object, *corev1.PodTemplateSpec, bool) {
+	switch workload := obj.(type) {
+	case *appsv1.Deployment:
+		return workload, &workload.Spec.Template, true
+	case *appsv1.StatefulSet:
+		return workload, &workload.Spec.Template, true
+	}
+	return nil, nil, false
+}
+
+// isCacheAnnotated returns true if the workload or its pod template has the cache annotation set to "true"
+func isCacheAnnotated(meta metav1.Object, template *corev1.PodTemplateSpec) bool {
+	return meta.GetAnnotations()[workloadCacheAnnotationKey] == "true" ||
+		template.Annotations[workloadCacheAnnotationKey] == "true"
+}
+
+// workloadCacheImages returns the sorted images of the containers and init containers of the workloads
+// in the namespace which are opted in to caching
+func (c *Controller) workloadCacheImages(namespace string) ([]string, error) {
+	var workloads []interface{}
+	deployments, err := c.deploymentsLister.Deployments(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for i := range deployments {
+		workloads = append(workloads, deployments[i])
+	}
+	statefulSets, err := c.statefulSetsLister.StatefulSets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for i := range statefulSets {
+		workloads = append(workloads, statefulSets[i])
+	}
+	seen := map[string]bool{}
+	var workloadImages []string
+	for _, workload := range workloads {
+		meta, template, _ := workloadPodTemplate(workload)
+		if !isCacheAnnotated(meta, template) {
+			continue
+		}
+		for _, containers := range [][]corev1.Container{template.Spec.InitContainers, template.Spec.Containers} {
+			for _, container := range containers {
+				if container.Image != "" && !seen[container.Image] {
+					seen[container.Image] = true
+					workloadImages = append(workloadImages, container.Image)
+				}
+			}
+		}
+	}
+	sort.Strings(workloadImages)
+	return workloadImages, nil
+}
+
+// syncWorkloadImageCache creates, updates or deletes the workload image cache of the namespace so that it
+// caches the images of the workloads opted in to caching. The image cache is deleted once no workload is
+// opted in. Image caches of that name which were not created by the controller are left untouched
+func (c *Controller) syncWorkloadImageCache(namespace string) error {
+	workloadImages, err := c.workloadCacheImages(namespace)
+	if err != nil {
+		glog.Errorf("Error listing workloads in namespace %s: %v", namespace, err)
+		return err
+	}
+	imageCaches := c.kubefledgedclientset.KubefledgedV1alpha2().ImageCaches(namespace)
+	imageCache, err := imageCaches.Get(context.TODO(), workloadImageCacheName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if len(workloadImages) == 0 {
+			return nil
+		}
+		imageCache = &v1alpha2.ImageCache{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      workloadImageCacheName,
+				Namespace: namespace,
+				Labels:    workloadImageCacheLabels(),
+			},
+			Spec: v1alpha2.ImageCacheSpec{
+				CacheSpec: []v1alpha2.CacheSpecImages{{Images: workloadImages}},
+			},
+		}
+		if _, err := imageCaches.Create(context.TODO(), imageCache, metav1.CreateOptions{}); err != nil {
+			glog.Errorf("Error creating workload image cache in namespace %s: %v", namespace, err)
+			return err
+		}
+		glog.Infof("Workload image cache(%s/%s) created with %d images", namespace, workloadImageCacheName, len(workloadImages))
+		return nil
+	}
+	if err != nil {
+		glog.Errorf("Error getting workload image cache in namespace %s: %v", namespace, err)
+		return err
+	}
+	if !labels.SelectorFromSet(workloadImageCacheLabels()).Matches(labels.Set(imageCache.Labels)) {
+		glog.Warningf("Image cache %s/%s was not created from workloads, so not syncing it", namespace, workloadImageCacheName)
+		return nil
+	}
+	if len(workloadImages) == 0 {
+		if err := imageCaches.Delete(context.TODO(), workloadImageCacheName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			glog.Errorf("Error deleting workload image cache in namespace %s: %v", namespace, err)
+			return err
+		}
+		glog.Infof("Workload image cache(%s/%s) deleted, no workloads are opted in to caching", namespace, workloadImageCacheName)
+		return nil
+	}
+	spec := v1alpha2.ImageCacheSpec{
+		CacheSpec: []v1alpha2.CacheSpecImages{{Images: workloadImages}},
+	}
+	if reflect.DeepEqual(imageCache.Spec, spec) {
+		return nil
+	}
+	imageCache.Spec = spec
+	if _, err := imageCaches.Update(context.TODO(), imageCache, metav1.UpdateOptions{}); err != nil {
+		glog.Errorf("Error updating workload image cache in namespace %s: %v", namespace, err)
+		return err
+	}
+	glog.Infof("Workload image cache(%s/%s) updated with %d images", namespace, workloadImageCacheName, len(workloadImages))
+	return nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// workloadRefPodSpec returns the pod spec of the workload referenced by an image list
+func (c *Controller) workloadRefPodSpec(namespace string, workloadRef *v1alpha2.CacheSpecWorkloadRef) (*corev1.PodSpec, error) {
+	apps := c.kubeclientset.AppsV1()
+	switch workloadRef.Kind {
+	case "Deployment":
+		deployment, err := apps.Deployments(namespace).Get(context.TODO(), workloadRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &deployment.Spec.Template.Spec, nil
+	case "StatefulSet":
+		statefulSet, err := apps.StatefulSets(namespace).Get(context.TODO(), workloadRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &statefulSet.Spec.Template.Spec, nil
+	case "DaemonSet":
+		daemonSet, err := apps.DaemonSets(namespace).Get(context.TODO(), workloadRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &daemonSet.Spec.Template.Spec, nil
+	}
+	return nil, fmt.Errorf("unsupported kind of workload %q", workloadRef.Kind)
+}
+
+// feasibleNodes returns the nodes on which pods of the pod spec can be scheduled: the nodes matching its
+// node selector and required node affinity, whose NoSchedule and NoExecute taints it tolerates
+func feasibleNodes(podSpec *corev1.PodSpec, nodes []*corev1.Node) []*corev1.Node {
+	feasible := make([]*corev1.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if !labels.SelectorFromSet(podSpec.NodeSelector).Matches(labels.Set(n.Labels)) {
+			continue
+		}
+		if affinity := podSpec.Affinity; affinity != nil && affinity.NodeAffinity != nil &&
+			affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil &&
+			!matchesNodeSelectorTerms(n, affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms) {
+			continue
+		}
+		if !toleratesTaints(podSpec.Tolerations, n.Spec.Taints) {
+			continue
+		}
+		feasible = append(feasible, n)
+	}
+	return feasible
+}
+
+// matchesNodeSelectorTerms returns true if the node matches any of the terms. A node matches a term if
+// it matches all its match expressions and match fields
+func matchesNodeSelectorTerms(node *corev1.Node, terms []corev1.NodeSelectorTerm) bool {
+	for _, term := range terms {
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			continue
+		}
+		selector, err := nodeSelectorRequirementsAsSelector(term.MatchExpressions)
+		if err != nil || !selector.Matches(labels.Set(node.Labels)) {
+			continue
+		}
+		fieldSelector, err := nodeSelectorRequirementsAsSelector(term.MatchFields)
+		if err != nil || !fieldSelector.Matches(labels.Set{"metadata.name": node.Name}) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// nodeSelectorRequirementsAsSelector converts the requirements of a node selector term into a selector
+func nodeSelectorRequirementsAsSelector(requirements []corev1.NodeSelectorRequirement) (labels.Selector, error) {
+	operators := map[corev1.NodeSelectorOperator]selection.Operator{
+		corev1.NodeSelectorOpIn:           selection.In,
+		corev1.NodeSelectorOpNotIn:        selection.NotIn,
+		corev1.NodeSelectorOpExists:       selection.Exists,
+		corev1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+		corev1.NodeSelectorOpGt:           selection.GreaterThan,
+		corev1.NodeSelectorOpLt:           selection.LessThan,
+	}
+	selector := labels.NewSelector()
+	for _, r := range requirements {
+		operator, ok := operators[r.Operator]
+		if !ok {
+			return nil, fmt.Errorf("invalid node selector operator %q", r.Operator)
+		}
+		requirement, err := labels.NewRequirement(r.Key, operator, r.Values)
+		if err != nil {
+			return nil, err
+		}
+		selector = selector.Add(*requirement)
+	}
+	return selector, nil
+}
+
+// toleratesTaints returns true if the tolerations tolerate all the NoSchedule and NoExecute taints
+func toleratesTaints(tolerations []corev1.Toleration, taints []corev1.Taint) bool {
+	for i := range taints {
+		if taints[i].Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for j := range tolerations {
+			if tolerations[j].ToleratesTaint(&taints[i]) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
+
+// workloadRefString returns the kind/name of the workload referenced by an image list
+func workloadRefString(workloadRef *v1alpha2.CacheSpecWorkloadRef) string {
+	return strings.ToLower(workloadRef.Kind) + "/" + workloadRef.Name
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFeasibleNodes(t *testing.T) {
+	nodes := []*corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{"pool": "gpu", "zone": "a"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node2", Labels: map[string]string{"pool": "gpu", "zone": "b"}},
+			Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: "gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule}}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node3", Labels: map[string]string{"pool": "cpu", "zone": "a"}},
+			Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: "spot", Effect: corev1.TaintEffectPreferNoSchedule}}}},
+	}
+	affinity := func(terms ...corev1.NodeSelectorTerm) *corev1.Affinity {
+		return &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+		}}
+	}
+	tests := []struct {
+		name     string
+		podSpec  corev1.PodSpec
+		expected []string
+	}{
+		{name: "#1: No constraints", podSpec: corev1.PodSpec{}, expected: []string{"node1", "node3"}},
+		{name: "#2: Node selector", podSpec: corev1.PodSpec{NodeSelector: map[string]string{"zone": "a"}}, expected: []string{"node1", "node3"}},
+		{name: "#3: Toleration", podSpec: corev1.PodSpec{
+			Tolerations: []corev1.Toleration{{Key: "gpu", Operator: corev1.TolerationOpExists}}}, expected: []string{"node1", "node2", "node3"}},
+		{name: "#4: Required node affinity", podSpec: corev1.PodSpec{
+			Tolerations: []corev1.Toleration{{Key: "gpu", Operator: corev1.TolerationOpEqual, Value: "true", Effect: corev1.TaintEffectNoSchedule}},
+			Affinity: affinity(corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+				{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"gpu"}}}})}, expected: []string{"node1", "node2"}},
+		{name: "#5: Terms are ORed", podSpec: corev1.PodSpec{
+			Affinity: affinity(
+				corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "zone", Operator: corev1.NodeSelectorOpNotIn, Values: []string{"a"}}}},
+				corev1.NodeSelectorTerm{MatchFields: []corev1.NodeSelectorRequirement{{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"node3"}}}},
+			)}, expected: []string{"node3"}},
+	}
+	for _, test := range tests {
+		var actual []string
+		for _, n := range feasibleNodes(&test.podSpec, nodes) {
+			actual = append(actual, n.Name)
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Test: %s: expectedNodes=%v, actualNodes=%v", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestImageListNodesWorkloadRef(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			NodeSelector: map[string]string{"pool": "gpu"},
+		}}},
+	}
+	controller, nodeInformer, _ := newTestController(fakeclientset.NewSimpleClientset(deployment), &kubefledgedclientsetfake.Clientset{})
+	for _, node := range []*corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{"pool": "gpu"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node2", Labels: map[string]string{"pool": "cpu"}}},
+	} {
+		nodeInformer.Informer().GetIndexer().Add(node)
+	}
+
+	nodes, err := controller.imageListNodes("default", v1alpha2.CacheSpecImages{
+		WorkloadRef: &v1alpha2.CacheSpecWorkloadRef{Kind: "Deployment", Name: "foo"},
+	})
+	if err != nil || len(nodes) != 1 || nodes[0].Name != "node1" {
+		t.Errorf("Test: expectedNodes=[node1], actualNodes=%v, err=%v", nodes, err)
+	}
+	_, err = controller.imageListNodes("default", v1alpha2.CacheSpecImages{
+		WorkloadRef: &v1alpha2.CacheSpecWorkloadRef{Kind: "StatefulSet", Name: "foo"},
+	})
+	if !errors.Is(err, errWorkloadRef) {
+		t.Errorf("Test: missing workload: expectedErr=%v, actualErr=%v", errWorkloadRef, err)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	v1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	kubefledgedclientsetfake "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned/fake"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSyncWorkloadImageCache(t *testing.T) {
+	const namespace = "team-a"
+	podTemplate := func(annotations map[string]string, images ...string) corev1.PodTemplateSpec {
+		template := corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}}
+		for _, image := range images {
+			template.Spec.Containers = append(template.Spec.Containers, corev1.Container{Image: image})
+		}
+		return template
+	}
+	optIn := map[string]string{workloadCacheAnnotationKey: "true"}
+	annotatedDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace, Annotations: optIn},
+		Spec:       appsv1.DeploymentSpec{Template: podTemplate(nil, "nginx:1.23", "envoy:v1")},
+	}
+	annotatedStatefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: namespace},
+		Spec:       appsv1.StatefulSetSpec{Template: podTemplate(optIn, "postgres:15", "envoy:v1")},
+	}
+	plainDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "batch", Namespace: namespace},
+		Spec:       appsv1.DeploymentSpec{Template: podTemplate(nil, "worker:v2")},
+	}
+	workloadImageCache := func(images ...string) *v1alpha2.ImageCache {
+		return &v1alpha2.ImageCache{
+			ObjectMeta: metav1.ObjectMeta{Name: workloadImageCacheName, Namespace: namespace, Labels: workloadImageCacheLabels()},
+			Spec:       v1alpha2.ImageCacheSpec{CacheSpec: []v1alpha2.CacheSpecImages{{Images: images}}},
+		}
+	}
+	userImageCache := workloadImageCache("foo")
+	userImageCache.Labels = nil
+	tests := []struct {
+		name           string
+		workloads      []runtime.Object
+		existing       []runtime.Object
+		expectedImages []string
+	}{
+		{name: "#1: No workloads opted in", workloads: []runtime.Object{plainDeployment}, existing: nil, expectedImages: nil},
+		{name: "#2: Create workload image cache", workloads: []runtime.Object{annotatedDeployment, annotatedStatefulSet, plainDeployment}, existing: nil,
+			expectedImages: []string{"envoy:v1", "nginx:1.23", "postgres:15"}},
+		{name: "#3: Update workload image cache", workloads: []runtime.Object{annotatedStatefulSet}, existing: []runtime.Object{workloadImageCache("nginx:1.23")},
+			expectedImages: []string{"envoy:v1", "postgres:15"}},
+		{name: "#4: Delete workload image cache", workloads: []runtime.Object{plainDeployment}, existing: []runtime.Object{workloadImageCache("nginx:1.23")}, expectedImages: nil},
+		{name: "#5: Image cache not created from workloads", workloads: []runtime.Object{annotatedDeployment}, existing: []runtime.Object{userImageCache},
+			expectedImages: []string{"foo"}},
+	}
+	for _, test := range tests {
+		fledgedclientset := kubefledgedclientsetfake.NewSimpleClientset(test.existing...)
+		controller, _, _ := newTestController(fakeclientset.NewSimpleClientset(), fledgedclientset)
+		kubeInformerFactory := kubeinformers.NewSharedInformerFactory(fakeclientset.NewSimpleClientset(), noResyncPeriodFunc())
+		deploymentInformer := kubeInformerFactory.Apps().V1().Deployments()
+		statefulSetInformer := kubeInformerFactory.Apps().V1().StatefulSets()
+		for _, workload := range test.workloads {
+			switch workload.(type) {
+			case *appsv1.Deployment:
+				deploymentInformer.Informer().GetIndexer().Add(workload)
+			case *appsv1.StatefulSet:
+				statefulSetInformer.Informer().GetIndexer().Add(workload)
+			}
+		}
+		controller.deploymentsLister = deploymentInformer.Lister()
+		controller.statefulSetsLister = statefulSetInformer.Lister()
+
+		if err := controller.syncWorkloadImageCache(namespace); err != nil {
+			t.Errorf("Test: %s failed: expectedError=nil, actualError=%v", test.name, err)
+			continue
+		}
+		imageCache, err := fledgedclientset.KubefledgedV1alpha2().ImageCaches(namespace).Get(context.TODO(), workloadImageCacheName, metav1.GetOptions{})
+		if test.expectedImages == nil {
+			if err == nil {
+				t.Errorf("Test: %s failed: workload image cache exists with images %v", test.name, imageCache.Spec.CacheSpec[0].Images)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: %s failed: error getting workload image cache: %v", test.name, err)
+			continue
+		}
+		if actual := imageCache.Spec.CacheSpec[0].Images; !reflect.DeepEqual(actual, test.expectedImages) {
+			t.Errorf("Test: %s failed: expectedImages=%v, actualImages=%v", test.name, test.expectedImages, actual)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// zoneLabelKey is the well-known label of the zone of a node
+const zoneLabelKey = "topology.kubernetes.io/zone"
+
+// zoneBalanced returns the nodes ordered round-robin across their zones, so that the pulls queued in the
+// order of the nodes are spread across the zones (and their registry mirrors) rather than going to all
+// the nodes of one zone first. Zones are taken in sorted order and the nodes of a zone in the order of
+// their names. Nodes without a zone label are taken as one more zone
+func zoneBalanced(nodes []*corev1.Node) []*corev1.Node {
+	byZone := map[string][]*corev1.Node{}
+	for _, n := range nodes {
+		zone := n.Labels[zoneLabelKey]
+		byZone[zone] = append(byZone[zone], n)
+	}
+	zones := make([]string, 0, len(byZone))
+	for zone, zoneNodes := range byZone {
+		zones = append(zones, zone)
+		sort.Slice(zoneNodes, func(i, j int) bool { return zoneNodes[i].Name < zoneNodes[j].Name })
+	}
+	sort.Strings(zones)
+	balanced := make([]*corev1.Node, 0, len(nodes))
+	for i := 0; len(balanced) < len(nodes); i++ {
+		for _, zone := range zones {
+			if i < len(byZone[zone]) {
+				balanced = append(balanced, byZone[zone][i])
+			}
+		}
+	}
+	return balanced
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestZoneBalanced(t *testing.T) {
+	node := func(name, zone string) *corev1.Node {
+		n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if zone != "" {
+			n.Labels = map[string]string{zoneLabelKey: zone}
+		}
+		return n
+	}
+	tests := []struct {
+		name     string
+		nodes    []*corev1.Node
+		expected []string
+	}{
+		{name: "#1: No nodes", nodes: nil, expected: []string{}},
+		{name: "#2: Single zone", nodes: []*corev1.Node{node("node2", "a"), node("node1", "a")},
+			expected: []string{"node1", "node2"}},
+		{name: "#3: Zones of different sizes", nodes: []*corev1.Node{
+			node("a1", "a"), node("a2", "a"), node("a3", "a"), node("b1", "b"), node("c1", "c"), node("c2", "c")},
+			expected: []string{"a1", "b1", "c1", "a2", "c2", "a3"}},
+		{name: "#4: Nodes without a zone", nodes: []*corev1.Node{
+			node("a1", "a"), node("a2", "a"), node("x1", ""), node("x2", "")},
+			expected: []string{"x1", "a1", "x2", "a2"}},
+	}
+	for _, test := range tests {
+		actual := []string{}
+		for _, n := range zoneBalanced(test.nodes) {
+			actual = append(actual, n.Name)
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Test: %s failed: expectedNodes=%v, actualNodes=%v", test.name, test.expected, actual)
+		}
+	}
+}
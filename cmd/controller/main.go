@@ -17,13 +17,24 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
+	"net/http"
+	"net/http/pprof"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 	kubeinformers "k8s.io/client-go/informers"
+	appsinformers "k8s.io/client-go/informers/apps/v1"
+	batchinformers "k8s.io/client-go/informers/batch/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 
@@ -32,12 +43,16 @@ import (
 	"github.com/senthilrch/kube-fledged/cmd/controller/app"
 	clientset "github.com/senthilrch/kube-fledged/pkg/client/clientset/versioned"
 	informers "github.com/senthilrch/kube-fledged/pkg/client/informers/externalversions"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	"github.com/senthilrch/kube-fledged/pkg/metrics"
 	"github.com/senthilrch/kube-fledged/pkg/signals"
+	"github.com/senthilrch/kube-fledged/pkg/tracing"
 )
 
 var (
 	imageCacheRefreshFrequency time.Duration
 	imagePullDeadlineDuration  time.Duration
+	imagePullDeadlineMax       time.Duration
 	criClientImage             string
 	busyboxImage               string
 	imagePullPolicy            string
@@ -45,11 +60,66 @@ var (
 	serviceAccountName         string
 	imageDeleteJobHostNetwork  bool
 	jobPriorityClassName       string
+	jobSchedulerName           string
+	staleAfter                 time.Duration
 	kubeconfig                 string
 	masterURL                  string
 	//Default value for when `--job-retention-policy` flag is not set
-	canDeleteJob  bool = true
-	criSocketPath string
+	canDeleteJob                    bool = true
+	criSocketPath                   string
+	imageCacheLabelSelector         string
+	imageDigestVerification         bool
+	informerResyncPeriod            time.Duration
+	metricsAddr                     string
+	enablePprof                     bool
+	startupDelay                    time.Duration
+	pprofAddr                       string
+	reportCacheHits                 bool
+	pullConcurrencyInitial          int
+	pullConcurrencyMax              int
+	pullConcurrencyCPUsPerPull      int
+	minFreeDisk                     string
+	maxCacheBytesPerNode            string
+	deleteJobCRIClientArgs          string
+	deleteJobCRIClientEnv           string
+	customPullerImage               string
+	customPullerCommand             string
+	statusConfigMap                 string
+	maxParallelDeletesPerNode       int
+	maxParallelDeletes              int
+	maxImagesPerCache               int
+	maxParallelVerifiesPerNode      int
+	defaultImageRegistry            string
+	registryRewrites                string
+	watchNamespace                  string
+	maintenanceKey                  string
+	refreshLease                    string
+	managedImagesAnnotation         string
+	quietHours                      string
+	maxConcurrentStatusUpdates      int
+	nodeBaseImages                  string
+	jobPodAnnotations               string
+	baselineImages                  string
+	helperImagePullPolicy           string
+	updateDebounceWindow            time.Duration
+	jobAutomountServiceAccountToken bool
+	jobSecurityContext              string
+	jobRunAsUser                    int64
+	workloadImageCaches             bool
+	watchImagesFromConfigMaps       bool
+	watchdogWindow                  time.Duration
+	watchdogCrash                   bool
+	planAddr                        string
+	coverageDump                    bool
+	purgeAll                        bool
+	rateLimitBackoff                time.Duration
+	rateLimitPause                  time.Duration
+	otlpEndpoint                    string
+	otlpInsecure                    bool
+	supportedRuntimes               string
+	imagePullBandwidth              string
+	imagePullDeadlineBase           time.Duration
+	zoneBalancedPulls               bool
 )
 
 func main() {
@@ -73,15 +143,329 @@ func main() {
 		glog.Fatalf("Error building fledged clientset: %s", err.Error())
 	}
 
-	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Second*30)
-	fledgedInformerFactory := informers.NewSharedInformerFactory(fledgedClient, time.Second*30)
+	switch flag.Arg(0) {
+	case "":
+	case "list-images":
+		if err := app.ListImages(kubeClient, fledgedClient, imageCacheLabelSelector, os.Stdout); err != nil {
+			glog.Fatalf("Error listing images: %s", err.Error())
+		}
+		return
+	default:
+		glog.Fatalf("Unknown command %q: the only command is list-images", flag.Arg(0))
+	}
+
+	if _, err := labels.Parse(imageCacheLabelSelector); err != nil {
+		glog.Fatalf("Error parsing imagecache label selector: %s", err.Error())
+	}
+
+	switch corev1.PullPolicy(helperImagePullPolicy) {
+	case corev1.PullAlways, corev1.PullIfNotPresent, corev1.PullNever:
+	default:
+		glog.Fatalf("Invalid helper image pull policy %q: possible values are 'Always', 'IfNotPresent' and 'Never'", helperImagePullPolicy)
+	}
+	if pullConcurrencyMax < 0 || pullConcurrencyInitial < 1 {
+		glog.Fatalf("Invalid pull concurrency: initial %d must be at least 1 and max %d cannot be negative", pullConcurrencyInitial, pullConcurrencyMax)
+	}
+	if pullConcurrencyCPUsPerPull < 0 {
+		glog.Fatalf("Pull concurrency cpus per pull cannot be negative: %d", pullConcurrencyCPUsPerPull)
+	}
+	var minFreeDiskBytes int64
+	if minFreeDisk != "" {
+		q, err := resource.ParseQuantity(minFreeDisk)
+		if err != nil {
+			glog.Fatalf("Invalid minimum free disk %q: %s", minFreeDisk, err.Error())
+		}
+		minFreeDiskBytes = q.Value()
+	}
+	var maxCacheBytesBudget int64
+	if maxCacheBytesPerNode != "" {
+		q, err := resource.ParseQuantity(maxCacheBytesPerNode)
+		if err != nil || q.Sign() < 0 {
+			glog.Fatalf("Invalid maximum cache bytes per node %q", maxCacheBytesPerNode)
+		}
+		maxCacheBytesBudget = q.Value()
+	}
+	var baselineImageList []string
+	seen := map[string]bool{}
+	for _, image := range strings.Split(baselineImages, ",") {
+		if image = strings.TrimSpace(image); image != "" && !seen[image] {
+			seen[image] = true
+			baselineImageList = append(baselineImageList, image)
+		}
+	}
+	var criClientArgs []string
+	for _, arg := range strings.Split(deleteJobCRIClientArgs, ",") {
+		if arg = strings.TrimSpace(arg); arg != "" {
+			criClientArgs = append(criClientArgs, arg)
+		}
+	}
+	var criClientEnv []corev1.EnvVar
+	for _, env := range strings.Split(deleteJobCRIClientEnv, ",") {
+		if env = strings.TrimSpace(env); env == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(env, "=")
+		if !ok || name == "" {
+			glog.Fatalf("Invalid delete job cri client env %q: must be NAME=VALUE", env)
+		}
+		criClientEnv = append(criClientEnv, corev1.EnvVar{Name: name, Value: value})
+	}
+	var customPullerCommandList []string
+	for _, arg := range strings.Split(customPullerCommand, ",") {
+		if arg = strings.TrimSpace(arg); arg != "" {
+			customPullerCommandList = append(customPullerCommandList, arg)
+		}
+	}
+	if customPullerImage != "" && len(customPullerCommandList) == 0 {
+		glog.Fatalf("--custom-puller-command is required with --custom-puller-image")
+	}
+	jobPodAnnotationMap := map[string]string{}
+	for _, annotation := range strings.Split(jobPodAnnotations, ",") {
+		if annotation = strings.TrimSpace(annotation); annotation == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(annotation, "=")
+		if !ok || key == "" {
+			glog.Fatalf("Invalid job pod annotation %q: must be KEY=VALUE", annotation)
+		}
+		jobPodAnnotationMap[key] = value
+	}
+	registryRewriteMap := map[string]string{}
+	for _, rule := range strings.Split(registryRewrites, ",") {
+		if rule = strings.TrimSpace(rule); rule == "" {
+			continue
+		}
+		registry, target, ok := strings.Cut(rule, "=")
+		if !ok || registry == "" || target == "" {
+			glog.Fatalf("Invalid registry rewrite %q: must be REGISTRY=TARGET", rule)
+		}
+		registryRewriteMap[registry] = target
+	}
+	var imagePullBandwidthBytes int64
+	if imagePullBandwidth != "" {
+		q, err := resource.ParseQuantity(imagePullBandwidth)
+		if err != nil || q.Sign() < 0 {
+			glog.Fatalf("Invalid image pull bandwidth %q: must be a non-negative quantity e.g. 20Mi", imagePullBandwidth)
+		}
+		imagePullBandwidthBytes = q.Value()
+	}
+	if imagePullDeadlineBase < 0 {
+		glog.Fatalf("Image pull deadline base cannot be negative: %s", imagePullDeadlineBase)
+	}
+	if jobSchedulerName != "" {
+		if errs := validation.IsDNS1123Subdomain(jobSchedulerName); len(errs) > 0 {
+			glog.Fatalf("Invalid job scheduler name %q: %s", jobSchedulerName, strings.Join(errs, ", "))
+		}
+	}
+	var nodeBaseImageList []string
+	for _, image := range strings.Split(nodeBaseImages, ",") {
+		if image = strings.TrimSpace(image); image != "" {
+			nodeBaseImageList = append(nodeBaseImageList, image)
+		}
+	}
+	var supportedRuntimeList []string
+	for _, runtime := range strings.Split(supportedRuntimes, ",") {
+		if runtime = strings.TrimSpace(runtime); runtime != "" {
+			supportedRuntimeList = append(supportedRuntimeList, runtime)
+		}
+	}
+	if maxParallelDeletesPerNode < 0 {
+		glog.Fatalf("Max parallel deletes per node cannot be negative: %d", maxParallelDeletesPerNode)
+	}
+	if maxParallelDeletes < 0 {
+		glog.Fatalf("Max parallel deletes cannot be negative: %d", maxParallelDeletes)
+	}
+	if maxImagesPerCache < 0 {
+		glog.Fatalf("Max images per cache cannot be negative: %d", maxImagesPerCache)
+	}
+	if maxConcurrentStatusUpdates < 0 {
+		glog.Fatalf("Max concurrent status updates cannot be negative: %d", maxConcurrentStatusUpdates)
+	}
+	if maxParallelVerifiesPerNode < 0 {
+		glog.Fatalf("Max parallel verifies per node cannot be negative: %d", maxParallelVerifiesPerNode)
+	}
+	if updateDebounceWindow < 0 {
+		glog.Fatalf("Update debounce window cannot be negative: %s", updateDebounceWindow)
+	}
+	if rateLimitBackoff < 0 || rateLimitPause < 0 {
+		glog.Fatalf("Rate limit backoff and pause cannot be negative: %s, %s", rateLimitBackoff, rateLimitPause)
+	}
+	var jobRunAsUserID *int64
+	switch jobSecurityContext {
+	case "restricted":
+		if jobRunAsUser <= 0 {
+			glog.Fatalf("Invalid job run as user %d: the restricted job security context requires a non-root user", jobRunAsUser)
+		}
+		jobRunAsUserID = &jobRunAsUser
+	case "none":
+	default:
+		glog.Fatalf("Invalid job security context %q: possible values are 'restricted' and 'none'", jobSecurityContext)
+	}
+	if startupDelay < 0 {
+		glog.Fatalf("Startup delay cannot be negative: %s", startupDelay)
+	}
+	if staleAfter < 0 {
+		glog.Fatalf("Stale threshold cannot be negative: %s", staleAfter)
+	}
+	if watchdogWindow < 0 {
+		glog.Fatalf("Watchdog window cannot be negative: %s", watchdogWindow)
+	}
+	if imagePullDeadlineMax != 0 && imagePullDeadlineMax < imagePullDeadlineDuration {
+		glog.Fatalf("Max image pull deadline %s cannot be less than the image pull deadline %s", imagePullDeadlineMax, imagePullDeadlineDuration)
+	}
+	if purgeAll {
+		// the purged image caches must not be pulled again by refreshes
+		imageCacheRefreshFrequency = 0
+	}
+	refreshLeaseNamespace, refreshLeaseName := fledgedNameSpace, refreshLease
+	if namespace, name, ok := strings.Cut(refreshLease, "/"); ok {
+		refreshLeaseNamespace, refreshLeaseName = namespace, name
+		if namespace == "" || name == "" {
+			glog.Fatalf("Invalid refresh lease %q: must be NAME or NAMESPACE/NAME", refreshLease)
+		}
+	}
+	if refreshLeaseName != "" && imageCacheRefreshFrequency == 0 && !purgeAll {
+		glog.Fatalf("Refresh lease %s cannot be renewed with image cache refresh disabled", refreshLease)
+	}
+	quietHoursList, err := app.ParseQuietHours(quietHours)
+	if err != nil {
+		glog.Fatalf("Invalid quiet hours: %v", err)
+	}
+	if informerResyncPeriod < 0 {
+		glog.Fatalf("Informer resync period cannot be negative: %s", informerResyncPeriod)
+	}
+
+	if watchNamespace != "" && baselineImageList != nil && watchNamespace != fledgedNameSpace {
+		glog.Fatalf("Baseline images are cached by an image cache in namespace %s, which is not the watched namespace %s", fledgedNameSpace, watchNamespace)
+	}
 
+	// the informers of namespaced resources only watch the watched namespace. Nodes are cluster-scoped
+	// and always watched in the whole cluster
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, informerResyncPeriod,
+		kubeinformers.WithNamespace(watchNamespace))
+	fledgedInformerFactory := informers.NewSharedInformerFactoryWithOptions(fledgedClient, informerResyncPeriod,
+		informers.WithNamespace(watchNamespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = imageCacheLabelSelector
+		}))
+
+	if metricsAddr != "" {
+		// the workqueues created by the controller report their depth and latency
+		metrics.RegisterWorkqueueMetrics()
+	}
+	var deploymentInformer appsinformers.DeploymentInformer
+	var statefulSetInformer appsinformers.StatefulSetInformer
+	if workloadImageCaches {
+		deploymentInformer = kubeInformerFactory.Apps().V1().Deployments()
+		statefulSetInformer = kubeInformerFactory.Apps().V1().StatefulSets()
+	}
+	// unless they are watched, the ConfigMaps referenced in imagesFrom are read at each reconcile, not to cache
+	// all the ConfigMaps in memory
+	var configMapInformer coreinformers.ConfigMapInformer
+	if watchImagesFromConfigMaps {
+		configMapInformer = kubeInformerFactory.Core().V1().ConfigMaps()
+	}
+	// image caches are not pre-warmed while all of them are purged
+	var cronJobInformer batchinformers.CronJobInformer
+	if !purgeAll {
+		cronJobInformer = kubeInformerFactory.Batch().V1().CronJobs()
+	}
 	controller := app.NewController(kubeClient, fledgedClient, fledgedNameSpace,
 		kubeInformerFactory.Core().V1().Nodes(),
 		fledgedInformerFactory.Kubefledged().V1alpha2().ImageCaches(),
-		imageCacheRefreshFrequency, imagePullDeadlineDuration, criClientImage,
-		busyboxImage, imagePullPolicy, serviceAccountName, imageDeleteJobHostNetwork,
-		jobPriorityClassName, canDeleteJob, criSocketPath)
+		configMapInformer,
+		deploymentInformer, statefulSetInformer, cronJobInformer,
+		app.ControllerConfig{
+			ImageManagerConfig: images.ImageManagerConfig{
+				ImagePullDeadlineDuration:    imagePullDeadlineDuration,
+				ImagePullDeadlineMax:         imagePullDeadlineMax,
+				ImagePullDeadlineBase:        imagePullDeadlineBase,
+				ImagePullBandwidth:           imagePullBandwidthBytes,
+				ImagePullPolicy:              imagePullPolicy,
+				CRIClientImage:               criClientImage,
+				BusyboxImage:                 busyboxImage,
+				HelperImagePullPolicy:        helperImagePullPolicy,
+				ServiceAccountName:           serviceAccountName,
+				ImageDeleteJobHostNetwork:    imageDeleteJobHostNetwork,
+				JobPriorityClassName:         jobPriorityClassName,
+				JobSchedulerName:             jobSchedulerName,
+				AutomountServiceAccountToken: jobAutomountServiceAccountToken,
+				JobRunAsUser:                 jobRunAsUserID,
+				JobPodAnnotations:            jobPodAnnotationMap,
+				CanDeleteJob:                 canDeleteJob,
+				CRISocketPath:                criSocketPath,
+				ImageDigestVerification:      imageDigestVerification,
+				PullConcurrencyInitial:       pullConcurrencyInitial,
+				PullConcurrencyMax:           pullConcurrencyMax,
+				PullConcurrencyCPUsPerPull:   pullConcurrencyCPUsPerPull,
+				MaxParallelDeletesPerNode:    maxParallelDeletesPerNode,
+				MaxParallelDeletes:           maxParallelDeletes,
+				MaxParallelVerifiesPerNode:   maxParallelVerifiesPerNode,
+				MaxConcurrentStatusUpdates:   maxConcurrentStatusUpdates,
+				MinFreeDisk:                  minFreeDiskBytes,
+				MaxCacheBytesPerNode:         maxCacheBytesBudget,
+				DeleteJobCRIClientArgs:       criClientArgs,
+				DeleteJobCRIClientEnv:        criClientEnv,
+				CustomPullerImage:            customPullerImage,
+				CustomPullerCommand:          customPullerCommandList,
+				RateLimitBackoff:             rateLimitBackoff,
+				RateLimitPause:               rateLimitPause,
+				DefaultImageRegistry:         defaultImageRegistry,
+				RegistryRewrites:             registryRewriteMap,
+				WatchNamespace:               watchNamespace,
+				ManagedImagesAnnotation:      managedImagesAnnotation,
+			},
+			ImageCacheRefreshFrequency: imageCacheRefreshFrequency,
+			ImageCacheLabelSelector:    imageCacheLabelSelector,
+			BaselineImages:             baselineImageList,
+			UpdateDebounceWindow:       updateDebounceWindow,
+			WatchdogWindow:             watchdogWindow,
+			WatchdogCrash:              watchdogCrash,
+			StartupDelay:               startupDelay,
+			StatusConfigMap:            statusConfigMap,
+			SupportedRuntimes:          supportedRuntimeList,
+			ZoneBalancedPulls:          zoneBalancedPulls,
+			MaintenanceKey:             maintenanceKey,
+			RefreshLeaseNamespace:      refreshLeaseNamespace,
+			RefreshLeaseName:           refreshLeaseName,
+			QuietHours:                 quietHoursList,
+			NodeBaseImages:             nodeBaseImageList,
+			StaleAfter:                 staleAfter,
+			MaxImagesPerCache:          maxImagesPerCache,
+		})
+
+	if reportCacheHits {
+		if metricsAddr == "" {
+			glog.Warning("Cache hits are reported as metrics but --metrics-addr is not set")
+		}
+		app.NewCacheHitReporter(kubeInformerFactory.Core().V1().Pods(),
+			kubeInformerFactory.Core().V1().Nodes().Lister(),
+			fledgedInformerFactory.Kubefledged().V1alpha2().ImageCaches().Lister())
+	}
+	if metricsAddr != "" {
+		go metrics.Serve(metricsAddr)
+	}
+	if otlpEndpoint != "" {
+		shutdownTracing, err := tracing.Setup(context.Background(), "kubefledged-controller", otlpEndpoint, otlpInsecure)
+		if err != nil {
+			glog.Fatalf("Error setting up the export of traces to %s: %s", otlpEndpoint, err.Error())
+		}
+		defer shutdownTracing(context.Background())
+		glog.Infof("Exporting traces to %s", otlpEndpoint)
+	}
+	if enablePprof {
+		go servePprof(pprofAddr)
+	}
+	if coverageDump {
+		signals.SetupDumpHandler(stopCh, func() {
+			if err := controller.DumpCoverage(os.Stdout); err != nil {
+				glog.Errorf("Error dumping the coverage of the image caches: %v", err)
+			}
+		})
+	}
+	if planAddr != "" {
+		go controller.ServePlan(planAddr)
+	}
 
 	glog.Info("Starting pre-flight checks")
 	if err = controller.PreFlightChecks(); err != nil {
@@ -92,6 +476,9 @@ func main() {
 	go kubeInformerFactory.Start(stopCh)
 	go fledgedInformerFactory.Start(stopCh)
 
+	if purgeAll {
+		go controller.PurgeAll(stopCh)
+	}
 	if err = controller.Run(1, stopCh); err != nil {
 		glog.Fatalf("Error running controller: %s", err.Error())
 	}
@@ -105,8 +492,20 @@ func init() {
 		"The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
 
 	flag.DurationVar(&imagePullDeadlineDuration, "image-pull-deadline-duration", time.Minute*5, "Maximum duration allowed for pulling an image. After this duration, image pull is considered to have failed")
+	flag.DurationVar(&imagePullDeadlineMax, "image-pull-deadline-max-duration", 0, "Maximum duration to which the image pull deadline is extended while images are still being pulled e.g. 30m. Past --image-pull-deadline-duration, the pulls are checked every 30s and the deadline is extended as long as the kubelet is still pulling an image, i.e. its latest event is Pulling rather than Failed or BackOff. Default value of 0s never extends the deadline")
+	flag.StringVar(&imagePullBandwidth, "image-pull-bandwidth", "", "Expected bandwidth per second of the image pulls e.g. 20Mi. The deadline of the pull of an image whose size is known, from \"imageSizes\" of its image list or from the node status, is then --image-pull-deadline-base-duration plus the time the image takes to be pulled at this bandwidth, up to 1h. Default is the static --image-pull-deadline-duration for all the pulls")
+	flag.DurationVar(&imagePullDeadlineBase, "image-pull-deadline-base-duration", time.Minute, "Base of the pull deadlines proportional to the size of the images, when --image-pull-bandwidth is set. Default value: 1m")
+	flag.DurationVar(&informerResyncPeriod, "informer-resync-period", time.Second*30, "Period at which the informers resync nodes, configmaps and image caches. A shorter period reacts sooner to missed events at the cost of more reconciles and API load. Setting this flag to 0s disables resync")
 	flag.DurationVar(&imageCacheRefreshFrequency, "image-cache-refresh-frequency", time.Minute*15, "The image cache is refreshed periodically to ensure the cache is up to date. Setting this flag to 0s will disable refresh")
 	flag.StringVar(&imagePullPolicy, "image-pull-policy", "IfNotPresent", "Image pull policy for pulling images into the cache. Possible values are 'IfNotPresent' and 'Always'. Default value is 'IfNotPresent'. Images with no or ':latest' tag are always pulled")
+	flag.StringVar(&defaultImageRegistry, "default-image-registry", "docker.io", "Registry of the images of the image lists whose name has no registry, to which image names are normalized before being compared with the images in the node status e.g. nginx is docker.io/library/nginx:latest. Default value is 'docker.io'")
+	flag.StringVar(&registryRewrites, "registry-rewrites", "", "Comma-separated list of REGISTRY=TARGET rules rewriting the images pulled by the jobs, e.g. docker.io=mirror.example.com/docker.io pulls nginx:1.23 as mirror.example.com/docker.io/library/nginx:1.23 through a pull-through cache. The status of the image caches keeps the original images. Default is no rewrites")
+	flag.StringVar(&nodeBaseImages, "node-base-images", "", "Comma-separated list of images all the nodes ship with in their node image e.g. registry.k8s.io/pause:3.9. Such images are neither pulled nor deleted by the image caches. The images of the kubefledged.io/base-images annotation of a node are also skipped on that node. Default is no base images")
+	flag.StringVar(&maintenanceKey, "maintenance-key", "", "Key of the taint or annotation of nodes under maintenance e.g. node.kubernetes.io/unschedulable for cordoned nodes. No jobs are created on nodes under maintenance, and their cached images are kept. Image caches are refreshed once the taint or annotation is removed. Default is no maintenance key")
+	flag.StringVar(&managedImagesAnnotation, "managed-images-annotation", "", "Key of the annotation of the nodes recording the images pulled to them by kubefledged e.g. kubefledged.io/managed-images. Images are added on a successful pull and removed on a successful delete, and only the images it records are deleted from a node. Default is no record, and images are deleted whoever pulled them")
+	flag.StringVar(&quietHours, "quiet-hours", "", "Comma-separated list of daily time ranges HH:MM-HH:MM, in the time zone of the controller, in which image caches are not refreshed periodically nor pre-warmed e.g. 01:00-05:00 during nightly backups. The refreshes due are made once the quiet hours end. Creates, updates and on-demand refreshes of image caches are not deferred. A range ending before it starts spans midnight e.g. 22:00-06:00. Default is no quiet hours")
+	flag.StringVar(&refreshLease, "refresh-lease", "", "Lease renewed by the controller each time it checks which image caches are due for a periodic refresh, as NAME in the namespace of kubefledged or NAMESPACE/NAME, so that monitoring can alert when refreshes stop. Its lease duration is twice --image-cache-refresh-frequency. Default is no lease")
+	flag.StringVar(&watchNamespace, "watch-namespace", "", "Namespace whose image caches are watched by the controller, which then only manages the jobs, configmaps and workloads of that namespace e.g. for least-privilege RBAC. Nodes are still watched cluster-wide. Default is all namespaces")
 	if fledgedNameSpace = os.Getenv("KUBEFLEDGED_NAMESPACE"); fledgedNameSpace == "" {
 		fledgedNameSpace = "kube-fledged"
 	}
@@ -119,6 +518,7 @@ func init() {
 	flag.StringVar(&serviceAccountName, "service-account-name", "", "serviceAccountName used in Jobs created for pulling/deleting images. Optional flag. If not specified the default service account of the namespace is used")
 	flag.BoolVar(&imageDeleteJobHostNetwork, "image-delete-job-host-network", false, "whether the pod for the image delete job should be run with 'HostNetwork: true'. Default value: false")
 	flag.StringVar(&jobPriorityClassName, "job-priority-class-name", "", "priorityClassName of jobs created by kubefledged-controller")
+	flag.StringVar(&jobSchedulerName, "job-scheduler-name", "", "schedulerName of jobs created by kubefledged-controller, unless overridden by the schedulerName of the image cache. Default is the default scheduler")
 	flag.Func("job-retention-policy", "sets the retention behavior of finished Image Manager Jobs (default: 'delete')",
 		func(val string) error {
 			const (
@@ -142,5 +542,62 @@ func init() {
 			}
 		},
 	)
+	flag.StringVar(&imageCacheLabelSelector, "imagecache-label-selector", "", "Label selector to filter the ImageCaches processed by this controller e.g. team=platform. ImageCaches not matching the selector are ignored. Default is to process all ImageCaches")
+	flag.BoolVar(&imageDigestVerification, "image-digest-verification", false, "whether the digest of images pinned by digest (image@sha256:...) should be verified on the node after the image is pulled. The verified digest is reported in the status of the image cache. Default value: false")
+	flag.IntVar(&pullConcurrencyInitial, "pull-concurrency-initial", 1, "Initial no. of image pull jobs allowed to run concurrently on a node. The limit doubles after every successful pull, up to --pull-concurrency-max, and is halved after every failed pull")
+	flag.IntVar(&pullConcurrencyMax, "pull-concurrency-max", 0, "Maximum no. of image pull jobs allowed to run concurrently on a node. Default value of 0 means no limit")
+	flag.IntVar(&pullConcurrencyCPUsPerPull, "pull-concurrency-cpus-per-pull", 0, "Allocatable cpus of a node per image pull job allowed to run concurrently on it e.g. 2 allows 1 pull per 2 cpus, and at least 1 pull. The maximum of each node then scales with its allocatable cpus, bounded by --pull-concurrency-max if set. Default value of 0 applies --pull-concurrency-max to all nodes")
+	flag.DurationVar(&updateDebounceWindow, "update-debounce-window", 0, "Window within which successive updates of an image cache are coalesced into a single reconcile of the latest spec e.g. 10s. Default value of 0s reconciles every update")
+	flag.StringVar(&helperImagePullPolicy, "helper-image-pull-policy", "IfNotPresent", "Image pull policy of the busybox and cri-client helper images run by the jobs pulling, deleting and verifying images. Possible values are 'IfNotPresent', 'Always' and 'Never'. Default value is 'IfNotPresent'")
+	flag.BoolVar(&jobAutomountServiceAccountToken, "job-automount-service-account-token", false, "Whether the service account token is mounted in the pods of the jobs pulling, deleting and verifying images. These pods never call the API server. Default value: false")
+	flag.StringVar(&jobSecurityContext, "job-security-context", "restricted", "Security context of the pods of the jobs pulling, deleting and verifying images. 'restricted' complies with the restricted Pod Security Standard: pods run as --job-run-as-user with the RuntimeDefault seccomp profile, and drop all capabilities. Delete and verify jobs run as root, since they connect to the CRI socket. 'none' sets no security context. Default value: 'restricted'")
+	flag.Int64Var(&jobRunAsUser, "job-run-as-user", 65534, "Non-root user the pods of the jobs pulling images run as, when --job-security-context is 'restricted'. Default value: 65534")
+	flag.BoolVar(&watchImagesFromConfigMaps, "watch-images-from-configmaps", false, "Whether the ConfigMaps are watched, so that the image caches whose imagesFrom references a ConfigMap are refreshed as soon as its data changes. The controller then caches all the ConfigMaps of --watch-namespace (of the cluster if not set) in memory. Otherwise the ConfigMaps are read at each reconcile, and their changes are picked up at the next refresh. Default value: false")
+	flag.BoolVar(&workloadImageCaches, "workload-image-caches", false, "Whether the images of deployments and statefulsets annotated with kubefledged.io/cache: \"true\" are cached by the image cache kubefledged-workloads, which the controller maintains in the namespace of the workloads. Default value: false")
+	flag.DurationVar(&staleAfter, "stale-after", 0, "Duration after the last successful refresh of an image cache after which it is stale e.g. 48h. Stale image caches have the Stale condition and the kubefledged_cache_stale metric set to 1. Default value of 0s is 3 times the refresh frequency of each image cache")
+	flag.DurationVar(&watchdogWindow, "watchdog-window", 0, "Window within which the controller workers must make progress while image caches are waiting in the workqueue e.g. 10m. Stalls are logged and counted in the kubefledged_watchdog_stalls_total metric. Default value of 0s disables the watchdog")
+	flag.DurationVar(&startupDelay, "startup-delay", 0, "Delay after startup before image caches are first refreshed e.g. 10m, letting the cluster stabilize after an upgrade. Informer caches are synced and changes to image caches are processed during the delay. Default value of 0s refreshes right after startup")
+	flag.BoolVar(&watchdogCrash, "watchdog-crash", false, "Whether the controller exits when the watchdog detects a stall, so that it is restarted. Default value: false")
+	flag.StringVar(&baselineImages, "baseline-images", "", "Comma-separated list of images cached on all the nodes by the image cache kubefledged-baseline, which the controller creates in its namespace. Other image caches do not pull or delete these images. Default is no baseline images")
+	flag.StringVar(&jobPodAnnotations, "job-pod-annotations", "sidecar.istio.io/inject=false,linkerd.io/inject=disabled", "Comma-separated list of KEY=VALUE annotations of the pods of the jobs which pull, delete and verify images. The default keeps Istio and Linkerd from injecting sidecars, which would keep the pods from completing. Set to empty for no annotations")
+	flag.StringVar(&supportedRuntimes, "supported-runtimes", "", "Comma-separated list of the container runtimes of the nodes images are cached on e.g. containerd,cri-o, as in the prefix of the container runtime version of the nodes. Nodes with other runtimes are skipped, and listed in the status and a Warning event of the image caches. Default is all runtimes")
+	flag.BoolVar(&zoneBalancedPulls, "zone-balanced-pulls", false, "Whether the pulls of an image list are queued round-robin across the zones of its nodes (label topology.kubernetes.io/zone), so that they are spread across the zones and their registry mirrors rather than going to all the nodes of one zone first. Default value: false")
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", "", "Endpoint (host:port) of an OpenTelemetry collector to which traces of the reconciles of image caches are exported via OTLP over gRPC e.g. otel-collector.monitoring:4317. Traces are not exported if not specified")
+	flag.BoolVar(&otlpInsecure, "otlp-insecure", false, "Whether traces are exported to --otlp-endpoint without TLS. Default value: false")
+	flag.DurationVar(&rateLimitBackoff, "rate-limit-backoff", 0, "Backoff before a pull rate-limited by the registry (e.g. HTTP 429 toomanyrequests) is retried e.g. 1m. The backoff doubles on every retry, up to 3 retries, after which the pull is reported as failed. Default value of 0s disables retries of rate-limited pulls")
+	flag.DurationVar(&rateLimitPause, "rate-limit-pause", 0, "Pause of all pulls from a registry after it rate-limited a pull e.g. 5m. Pulls from the registry during the pause are requeued. Applies only with --rate-limit-backoff. Default value of 0s pauses no pulls")
+	flag.IntVar(&maxParallelDeletesPerNode, "max-parallel-deletes-per-node", 0, "Maximum no. of image delete jobs of purges running concurrently on a node, independently of the pull concurrency. Deletes over the limit are requeued. Default is no limit")
+	flag.IntVar(&maxImagesPerCache, "max-images-per-cache", 0, "Maximum no. of images of an image cache, across all its image lists, once the images read from imagesFrom and the tags of repositories are added. Reconciles of image caches with more images fail with reason TooManyImages. Set it to the --max-images-per-cache of kubefledged-webhook-server. Default is no limit")
+	flag.IntVar(&maxParallelDeletes, "max-parallel-deletes", 0, "Maximum no. of image delete jobs of purges running concurrently across all the nodes, along with --max-parallel-deletes-per-node, so that the purge of a large image cache is paced. Deletes over the limit are requeued, and the image pull deadline of the purge starts once all its deletes have started. Default is no limit")
+	flag.IntVar(&maxConcurrentStatusUpdates, "max-concurrent-status-updates", 0, "Maximum no. of image caches whose jobs are polled for their status update at the same time. Status updates over the limit wait for a slot, and the image pull deadline of an image cache starts once its status update gets one. Default is no limit")
+	flag.IntVar(&maxParallelVerifiesPerNode, "max-parallel-verifies-per-node", 0, "Maximum no. of digest verification jobs running concurrently on a node, when --image-digest-verification is set. Verifications are queued after the pulls and run independently of the pull concurrency. Verifications over the limit are requeued. Default is no limit")
+	flag.StringVar(&statusConfigMap, "status-configmap", "", "Name of a ConfigMap in the namespace of kubefledged to which a JSON summary of the coverage of all the image caches is written after each reconcile. Default is no status ConfigMap")
+	flag.StringVar(&customPullerImage, "custom-puller-image", "", "Image of a custom puller which pulls the images to the nodes through the cri socket, instead of the pull jobs running the images. Default is no custom puller")
+	flag.StringVar(&customPullerCommand, "custom-puller-command", "", "Comma-separated command of the custom puller e.g. /puller,--report. The image to pull is passed as the last arg. Required with --custom-puller-image")
+	flag.StringVar(&deleteJobCRIClientArgs, "delete-job-cri-client-args", "", "Comma-separated list of extra args passed to the cri client (crictl or docker) of the jobs deleting images, before its rmi/image rm command e.g. --timeout=30s,--debug. Default is no extra args")
+	flag.StringVar(&deleteJobCRIClientEnv, "delete-job-cri-client-env", "", "Comma-separated list of NAME=VALUE env variables set on the cri client container of the jobs deleting images e.g. DOCKER_API_VERSION=1.41. Default is no extra env")
+	flag.StringVar(&maxCacheBytesPerNode, "max-cache-bytes-per-node", "", "Maximum disk the images of all the image caches may take up on a node e.g. 50Gi. Once the cached images of a node take up this much, further pulls to it fail with reason BudgetExceeded. Default is no limit")
+	flag.StringVar(&minFreeDisk, "min-free-disk", "", "Minimum free disk a node must have for images to be pulled to it e.g. 10Gi. Pulls to nodes under disk pressure or with less free disk fail with reason InsufficientDisk. The free disk is read from the kubelet stats summary of the node. Default is no disk check")
+	flag.StringVar(&metricsAddr, "metrics-addr", "", "Address on which prometheus metrics are served at /metrics e.g. :8080. Metrics are not served if not specified")
+	flag.BoolVar(&coverageDump, "coverage-dump", false, "Whether the coverage of every image cache on each of its nodes is written to stdout as a table when the controller receives SIGUSR1, e.g. with kubectl exec. Default value: false")
+	flag.StringVar(&planAddr, "plan-addr", "", "Address on which the plan of all the image caches (the nodes each image should be on, and its status on each node) is served at /plan as YAML, or as JSON with ?format=json e.g. localhost:8081. The plan is not served if not specified")
+	flag.BoolVar(&purgeAll, "purge-all", false, "Whether the images of all the image caches are purged from all the nodes on startup, to remove the cached images before kubefledged is uninstalled. Image caches are neither refreshed nor pre-warmed, and the progress is logged until all of them are purged. Default value: false")
+	flag.BoolVar(&enablePprof, "enable-pprof", false, "whether the pprof profiling endpoints are served at /debug/pprof/ on --pprof-addr. Default value: false")
+	flag.StringVar(&pprofAddr, "pprof-addr", "localhost:6060", "Address on which the pprof profiling endpoints are served, when --enable-pprof is set. Default value is 'localhost:6060', reachable only from within the pod")
+	flag.BoolVar(&reportCacheHits, "report-cache-hits", false, "whether pods getting scheduled should be watched to count the images already cached on their node (metric kubefledged_cache_hits_total). Default value: false")
 	flag.StringVar(&criSocketPath, "cri-socket-path", "", "path to the cri socket on the node e.g. /run/containerd/containerd.sock (default: /var/run/docker.sock, /run/containerd/containerd.sock, /var/run/crio/crio.sock)")
 }
+
+// servePprof serves the pprof profiling endpoints on the given address at /debug/pprof/. It blocks until the server fails
+func servePprof(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	glog.Infof("Serving pprof on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		glog.Errorf("Error serving pprof: %v", err)
+	}
+}
@@ -18,12 +18,17 @@ package app
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/golang/glog"
+	"github.com/senthilrch/kube-fledged/pkg/metrics"
 	"github.com/senthilrch/kube-fledged/pkg/webhook"
 
 	admissionv1 "k8s.io/api/admission/v1"
@@ -34,6 +39,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	// TODO: try this library to see if it generates correct json patch
 	// https://github.com/mattbaird/jsonpatch
 )
@@ -72,17 +79,61 @@ type Config struct {
 }
 
 func configTLS(config Config) *tls.Config {
-	sCert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
-	if err != nil {
+	loader := &certLoader{config: config}
+	if err := loader.load(); err != nil {
 		glog.Fatal(err)
 	}
 	return &tls.Config{
-		Certificates: []tls.Certificate{sCert},
+		GetCertificate: loader.getCertificate,
 		// TODO: uses mutual tls after we agree on what cert the apiserver should use.
 		// ClientAuth:   tls.RequireAndVerifyClientCert,
 	}
 }
 
+// certLoader loads the server cert and key, and reloads them when the cert file is modified e.g. when
+// the secret holding them is renewed
+type certLoader struct {
+	config  Config
+	cert    *tls.Certificate
+	modTime time.Time
+	lock    sync.Mutex
+}
+
+// load loads the cert and key if the cert file was modified since they were last loaded, and records
+// the expiry of the cert
+func (l *certLoader) load() error {
+	info, err := os.Stat(l.config.CertFile)
+	if err != nil {
+		return err
+	}
+	if l.cert != nil && info.ModTime().Equal(l.modTime) {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(l.config.CertFile, l.config.KeyFile)
+	if err != nil {
+		return err
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return err
+	}
+	l.cert, l.modTime = &cert, info.ModTime()
+	metrics.SetWebhookCertExpiry(leaf.NotAfter)
+	glog.Infof("Loaded server cert %s expiring at %s", l.config.CertFile, leaf.NotAfter)
+	return nil
+}
+
+// getCertificate returns the server cert, reloaded if modified. The cert already loaded is kept
+// if reloading it fails
+func (l *certLoader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if err := l.load(); err != nil {
+		glog.Errorf("Error reloading server cert: %v", err)
+	}
+	return l.cert, nil
+}
+
 func newDelegateToV1AdmitHandler(f admitv1Func) admitHandler {
 	return admitHandler{
 		v1beta1: delegateV1beta1AdmitToV1(f),
@@ -253,11 +304,41 @@ func mutateImageCache(w http.ResponseWriter, r *http.Request) {
 }
 
 // StartWebhookServer starts a new wwebhook server for kube-fledged
-func StartWebhookServer(certFile string, keyFile string, port int) error {
+func StartWebhookServer(certFile string, keyFile string, port int, maxImagesPerCache int, failurePolicy string, metricsAddr string,
+	allowedRegistries []string, deniedRegistries []string, registryPolicyConfigMap string) error {
 	config := Config{
 		CertFile: certFile,
 		KeyFile:  keyFile,
 	}
+	webhook.MaxImagesPerCache = maxImagesPerCache
+	switch webhook.FailurePolicy(failurePolicy) {
+	case webhook.FailurePolicyFail, webhook.FailurePolicyIgnore:
+		webhook.LookupFailurePolicy = webhook.FailurePolicy(failurePolicy)
+	default:
+		return fmt.Errorf("invalid failure policy '%s': must be '%s' or '%s'", failurePolicy, webhook.FailurePolicyFail, webhook.FailurePolicyIgnore)
+	}
+	var err error
+	if webhook.ImageRegistryPolicy.Allowed, err = webhook.ParseRegistryPatterns(allowedRegistries); err != nil {
+		return err
+	}
+	if webhook.ImageRegistryPolicy.Denied, err = webhook.ParseRegistryPatterns(deniedRegistries); err != nil {
+		return err
+	}
+	webhook.RegistryPolicyConfigMap.Namespace = os.Getenv("KUBEFLEDGED_NAMESPACE")
+	webhook.RegistryPolicyConfigMap.Name = registryPolicyConfigMap
+
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		glog.Errorf("Error building in-cluster config: %v", err)
+		return err
+	}
+	// rate limit the lookups so that the webhook does not add to the load of a stressed api server
+	cfg.QPS = 20
+	cfg.Burst = 40
+	if webhook.KubeClient, err = kubernetes.NewForConfig(cfg); err != nil {
+		glog.Errorf("Error building kubernetes clientset: %v", err)
+		return err
+	}
 
 	http.HandleFunc("/validate-image-cache", validateImageCache)
 	http.HandleFunc("/mutate-image-cache", mutateImageCache)
@@ -266,8 +347,12 @@ func StartWebhookServer(certFile string, keyFile string, port int) error {
 		Addr:      fmt.Sprintf(":%d", port),
 		TLSConfig: configTLS(config),
 	}
+	if metricsAddr != "" {
+		metrics.RegisterWebhookMetrics()
+		go metrics.Serve(metricsAddr)
+	}
 	glog.Infof("Wehook server listening on :%d", port)
-	err := server.ListenAndServeTLS("", "")
+	err = server.ListenAndServeTLS("", "")
 	if err != nil {
 		return err
 	}
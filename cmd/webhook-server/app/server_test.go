@@ -0,0 +1,108 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/senthilrch/kube-fledged/pkg/metrics"
+)
+
+// writeTestCert writes a self-signed cert expiring at notAfter, and its key, to the files of the config
+func writeTestCert(t *testing.T, config Config, notAfter time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "kubefledged-webhook-server.kube-fledged.svc"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Error creating cert: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Error marshalling key: %v", err)
+	}
+	if err := os.WriteFile(config.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("Error writing cert: %v", err)
+	}
+	if err := os.WriteFile(config.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatalf("Error writing key: %v", err)
+	}
+}
+
+// certExpiry returns the value of the kubefledged_webhook_cert_expiry_seconds gauge
+func certExpiry(t *testing.T) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Error gathering metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() == "kubefledged_webhook_cert_expiry_seconds" {
+			return family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	t.Fatalf("Metric kubefledged_webhook_cert_expiry_seconds not registered")
+	return 0
+}
+
+func TestCertLoaderExpiryMetric(t *testing.T) {
+	metrics.RegisterWebhookMetrics()
+	dir := t.TempDir()
+	config := Config{CertFile: filepath.Join(dir, "tls.crt"), KeyFile: filepath.Join(dir, "tls.key")}
+	tests := []struct {
+		name     string
+		validFor time.Duration
+	}{
+		{name: "#1: Cert loaded", validFor: 48 * time.Hour},
+		{name: "#2: Renewed cert reloaded", validFor: 90 * 24 * time.Hour},
+		{name: "#3: Expired cert reloaded", validFor: -time.Hour},
+	}
+	loader := &certLoader{config: config}
+	for i, test := range tests {
+		writeTestCert(t, config, time.Now().Add(test.validFor))
+		// the cert is only reloaded if the modification time of its file changed
+		modTime := time.Now().Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(config.CertFile, modTime, modTime); err != nil {
+			t.Fatalf("Error setting modification time of the cert: %v", err)
+		}
+		if cert, err := loader.getCertificate(nil); err != nil || cert == nil {
+			t.Errorf("Test: %s failed: expectedError=nil, actualError=%v", test.name, err)
+			continue
+		}
+		// the cert expires at a whole second, at most one second before validFor
+		if expiry := certExpiry(t); expiry > test.validFor.Seconds() || expiry < test.validFor.Seconds()-2 {
+			t.Errorf("Test: %s failed: expectedExpiry=%.0f, actualExpiry=%.0f", test.name, test.validFor.Seconds(), expiry)
+		}
+	}
+}
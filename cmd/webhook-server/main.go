@@ -18,21 +18,34 @@ package main
 
 import (
 	"flag"
+	"strings"
 
 	"github.com/senthilrch/kube-fledged/cmd/webhook-server/app"
 )
 
 var (
-	certFile   string
-	keyFile    string
-	port       int
-	initServer bool
+	certFile                string
+	keyFile                 string
+	port                    int
+	initServer              bool
+	maxImagesPerCache       int
+	failurePolicy           string
+	metricsAddr             string
+	allowedRegistries       string
+	deniedRegistries        string
+	registryPolicyConfigMap string
 )
 
 func init() {
 	flag.StringVar(&certFile, "cert-file", "", "File containing the default x509 Certificate for HTTPS. (CA cert, if any, concatenated after server cert).")
 	flag.StringVar(&keyFile, "key-file", "", "File containing the default x509 private key matching --cert-file.")
 	flag.IntVar(&port, "port", 443, "Secure port that the webhook server listens on")
+	flag.IntVar(&maxImagesPerCache, "max-images-per-cache", 0, "Maximum number of images allowed in an image cache, across all its image lists. Image caches listing more images are rejected. 0 means no limit")
+	flag.StringVar(&failurePolicy, "failure-policy", "Fail", "How image caches are admitted when lookups to the api server keep failing. 'Fail' rejects the image cache, 'Ignore' admits it without the validations that needed the lookup")
+	flag.StringVar(&metricsAddr, "metrics-addr", "", "Address on which prometheus metrics, such as the seconds until the server cert expires, are served at /metrics e.g. :8080. Metrics are not served if not specified")
+	flag.StringVar(&allowedRegistries, "allowed-registries", "", "Comma-separated list of regular expressions matching the whole host of the registries from which images may be cached e.g. registry.example.com,.*\\.azurecr\\.io. Image caches with images of other registries are rejected. Default is all registries allowed")
+	flag.StringVar(&deniedRegistries, "denied-registries", "", "Comma-separated list of regular expressions matching the whole host of the registries from which images may not be cached e.g. docker.io. Image caches with images of such registries are rejected. Default is no registries denied")
+	flag.StringVar(&registryPolicyConfigMap, "registry-policy-configmap", "", "Name of a ConfigMap in the namespace of the webhook server whose allowed-registries and denied-registries keys, one regular expression per line, are added to --allowed-registries and --denied-registries. It is read on every validation. Default is no ConfigMap")
 	flag.BoolVar(&initServer, "init-server", false, "True means only init tasks for the server will be performed. Server is not started")
 }
 
@@ -50,7 +63,16 @@ func main() {
 		}
 		return
 	}
-	if err := app.StartWebhookServer(certFile, keyFile, port); err != nil {
+	if err := app.StartWebhookServer(certFile, keyFile, port, maxImagesPerCache, failurePolicy, metricsAddr,
+		splitList(allowedRegistries), splitList(deniedRegistries), registryPolicyConfigMap); err != nil {
 		panic(err)
 	}
 }
+
+// splitList returns the comma-separated items of the list
+func splitList(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
@@ -18,6 +18,7 @@ package v1alpha2
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -38,14 +39,116 @@ type ImageCache struct {
 
 // CacheSpecImages specifies the Images to be cached
 type CacheSpecImages struct {
-	Images       []string          `json:"images"`
-	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	Images []string `json:"images,omitempty"`
+	// ImagesFrom refers to a key in a ConfigMap holding a newline-separated list of images.
+	// These images are merged with the images listed in Images
+	ImagesFrom *corev1.ConfigMapKeySelector `json:"imagesFrom,omitempty"`
+	// Repositories are repositories of which the tags listed by the registry are cached.
+	// These images are merged with the images listed in Images
+	Repositories []CacheSpecRepository `json:"repositories,omitempty"`
+	NodeSelector map[string]string     `json:"nodeSelector,omitempty"`
+	// RetargetOnNodeDeletion is for image lists targeting a pool of nodes rather than specific hosts.
+	// A pull in flight on a node which is deleted is retargeted to another ready node of the pool
+	// the image is not yet pulled to, e.g. the node replacing it
+	RetargetOnNodeDeletion bool `json:"retargetOnNodeDeletion,omitempty"`
+	// WorkloadRef restricts the nodes of the image list, matching NodeSelector, to the nodes on which
+	// the pods of a workload can be scheduled as per their node selector, required node affinity and
+	// tolerations, so that the images are only cached where the workload can land
+	WorkloadRef *CacheSpecWorkloadRef `json:"workloadRef,omitempty"`
+	// NodeNames restricts the nodes of the image list, matching NodeSelector, to the named nodes.
+	// Named nodes which do not exist are skipped
+	NodeNames []string `json:"nodeNames,omitempty"`
+	// ImageSizes are the expected sizes of images of the image list e.g. 6Gi. The deadline of the pull
+	// of an image with a size is proportional to its size, if the image pull bandwidth of the controller is set
+	ImageSizes map[string]resource.Quantity `json:"imageSizes,omitempty"`
+}
+
+// CacheSpecWorkloadRef refers to a workload in the namespace of the image cache
+type CacheSpecWorkloadRef struct {
+	// Kind is the kind of the workload: Deployment, StatefulSet or DaemonSet
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// CacheSpecRepository specifies a repository of which the tags matching any of IncludeTags
+// (all the tags if empty) and none of ExcludeTags are cached. Patterns are glob patterns e.g. "v1.*"
+type CacheSpecRepository struct {
+	Repository  string   `json:"repository"`
+	IncludeTags []string `json:"includeTags,omitempty"`
+	ExcludeTags []string `json:"excludeTags,omitempty"`
 }
 
 // ImageCacheSpec is the spec for a ImageCache resource
 type ImageCacheSpec struct {
 	CacheSpec        []CacheSpecImages             `json:"cacheSpec"`
 	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	// RuntimeClassName is set on the pods pulling the images, so that the images are
+	// pulled into the image store of that runtime
+	RuntimeClassName *string `json:"runtimeClassName,omitempty"`
+	// SchedulerName is the scheduler of the pods of the jobs pulling and deleting the images, overriding
+	// the job scheduler name of the controller
+	SchedulerName string `json:"schedulerName,omitempty"`
+	// CABundle refers to a key in a ConfigMap holding the PEM encoded CA certificates of the
+	// registries of the images. It is installed on the nodes for the container runtime to trust
+	CABundle *corev1.ConfigMapKeySelector `json:"caBundle,omitempty"`
+	// Mirrors are registry hosts from which the images are pulled, in order, when pulling
+	// an image from its own registry fails
+	Mirrors []string `json:"mirrors,omitempty"`
+	// RefreshMode is the mode in which the image cache is refreshed. In "pull" mode, a refresh
+	// pulls the images as per the image pull policy. In "verify" mode, a refresh only pulls the
+	// images which are missing in the nodes. Defaults to "pull"
+	RefreshMode ImageCacheRefreshMode `json:"refreshMode,omitempty"`
+	// RefreshFrequency is how often the image cache is refreshed, overriding the image cache refresh
+	// frequency of the controller
+	RefreshFrequency *metav1.Duration `json:"refreshFrequency,omitempty"`
+	// ReconcileMode is the mode in which the nodes are reconciled with the image lists. In "additive" mode,
+	// images removed from the image lists are deleted from the nodes only by the update removing them. In
+	// "declarative" mode, every reconcile also deletes from the nodes the images the image cache pulled which
+	// are no longer in its image lists. Defaults to "additive"
+	ReconcileMode ImageCacheReconcileMode `json:"reconcileMode,omitempty"`
+	// ShadowUntil, if set, shadows the deletions of declarative reconcile mode until this time: the images
+	// which would be deleted are logged and listed in the status, but are not deleted
+	ShadowUntil *metav1.Time `json:"shadowUntil,omitempty"`
+	// MinCoveragePercent, if set, is the percentage of the nodes of the image lists on which all the images
+	// must be cached for the SufficientCoverage condition to be true
+	MinCoveragePercent *int32 `json:"minCoveragePercent,omitempty"`
+	// JobDeadlineSeconds is the duration the jobs pulling and deleting the images may take, overriding
+	// the image pull deadline of the controller
+	JobDeadlineSeconds *int64 `json:"jobDeadlineSeconds,omitempty"`
+	// JobTTLSeconds is set as ttlSecondsAfterFinished on the jobs pulling and deleting the images,
+	// so that jobs retained by the controller are cleaned up
+	JobTTLSeconds *int32 `json:"jobTTLSeconds,omitempty"`
+	// PreWarm pulls the images shortly before each scheduled run of a CronJob, instead of refreshing
+	// the image cache periodically
+	PreWarm *ImageCachePreWarm `json:"preWarm,omitempty"`
+	// JobTemplate refers to a PodTemplate in the namespace of the image cache, used as the base of the pods
+	// of the jobs pulling and deleting the images e.g. for their tolerations, security context or DNS config
+	JobTemplate *corev1.LocalObjectReference `json:"jobTemplate,omitempty"`
+	// FailurePolicy is how the failures of some images are reported in the status of a reconcile pulling the
+	// images. With "all", the image cache fails if any image fails on any node. With "partial", the image cache
+	// succeeds as long as some images were cached, and the failures are listed. Defaults to "all"
+	FailurePolicy ImageCacheFailurePolicy `json:"failurePolicy,omitempty"`
+	// MaxImageAge, if set, is the age after which an image pulled to a node is pulled again by the next
+	// refresh with the Always pull policy, so that the container runtime compares its digest with the registry
+	// and fetches the image if its tag was rebuilt
+	MaxImageAge *metav1.Duration `json:"maxImageAge,omitempty"`
+	// ValidateRunnable, if set, makes the jobs pulling the images run RunnableCommand in the images instead
+	// of a command of their own, so that images which cannot run on the nodes e.g. built for another
+	// architecture, fail to be cached
+	ValidateRunnable bool `json:"validateRunnable,omitempty"`
+	// RunnableCommand is the command run in the images when ValidateRunnable is set. Defaults to ["true"]
+	RunnableCommand []string `json:"runnableCommand,omitempty"`
+}
+
+// ImageCachePreWarm specifies the CronJob ahead of whose scheduled runs the images are pulled
+type ImageCachePreWarm struct {
+	// CronJob is the name of a CronJob in the namespace of the image cache
+	CronJob string `json:"cronJob"`
+	// LeadMinutes is how long before each scheduled run of the CronJob the images are pulled. Defaults to 10
+	LeadMinutes *int32 `json:"leadMinutes,omitempty"`
+	// PurgeAfterMinutes, if set, is how long after each scheduled run of the CronJob, for which the images
+	// were pulled, the image cache is purged
+	PurgeAfterMinutes *int32 `json:"purgeAfterMinutes,omitempty"`
 }
 
 // ImageCacheStatus is the status for a ImageCache resource
@@ -56,6 +159,29 @@ type ImageCacheStatus struct {
 	Failures       map[string]NodeReasonMessageList `json:"failures,omitempty"`
 	StartTime      *metav1.Time                     `json:"startTime"`
 	CompletionTime *metav1.Time                     `json:"completionTime,omitempty"`
+	// VerifiedDigests has the digest confirmed on the nodes for each digest-pinned image,
+	// when the controller is started with digest verification enabled
+	VerifiedDigests map[string]string `json:"verifiedDigests,omitempty"`
+	// Conditions are the Ready, Progressing and Degraded conditions of the image cache
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// PulledFromMirrors has the mirror from which each image was pulled, for the images
+	// which could not be pulled from their own registry
+	PulledFromMirrors map[string]string `json:"pulledFromMirrors,omitempty"`
+	// CachedImages are the images pulled by the last reconcile of an image cache in declarative reconcile
+	// mode. They are the only images deleted from the nodes once removed from the image lists
+	CachedImages []string `json:"cachedImages,omitempty"`
+	// ShadowedDeletions has the nodes from which each image would have been deleted by the last reconcile,
+	// had its deletions not been shadowed by shadowUntil
+	ShadowedDeletions map[string][]string `json:"shadowedDeletions,omitempty"`
+	// CoveragePercent is the percentage of the nodes of the image lists on which all the images were cached
+	// by the last reconcile, rounded down
+	CoveragePercent *int32 `json:"coveragePercent,omitempty"`
+	// ObservedGeneration is the generation of the spec reconciled by the last create or update of the image
+	// cache. Updates are skipped when the generation of the spec is already reconciled
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastSuccessfulRefreshTime is the completion time of the last create, update or refresh of the image cache
+	// which succeeded. The Stale condition is set once it is older than the stale threshold of the controller
+	LastSuccessfulRefreshTime *metav1.Time `json:"lastSuccessfulRefreshTime,omitempty"`
 }
 
 // NodeReasonMessage has failure reason and message for a node
@@ -91,6 +217,44 @@ const (
 	ImageCacheActioneNoImagesPulledOrDeleted ImageCacheActionStatus = "NoImagesPulledOrDeleted"
 )
 
+// ImageCacheRefreshMode defines the mode in which an image cache is refreshed
+type ImageCacheRefreshMode string
+
+// List of constants for ImageCacheRefreshMode
+const (
+	ImageCacheRefreshModePull   ImageCacheRefreshMode = "pull"
+	ImageCacheRefreshModeVerify ImageCacheRefreshMode = "verify"
+)
+
+// ImageCacheReconcileMode defines the mode in which the nodes are reconciled with an image cache
+type ImageCacheReconcileMode string
+
+// List of constants for ImageCacheReconcileMode
+const (
+	ImageCacheReconcileModeAdditive    ImageCacheReconcileMode = "additive"
+	ImageCacheReconcileModeDeclarative ImageCacheReconcileMode = "declarative"
+)
+
+// ImageCacheFailurePolicy defines how the failures of some images are reported in the status of an image cache
+type ImageCacheFailurePolicy string
+
+// List of constants for ImageCacheFailurePolicy
+const (
+	ImageCacheFailurePolicyAll     ImageCacheFailurePolicy = "all"
+	ImageCacheFailurePolicyPartial ImageCacheFailurePolicy = "partial"
+)
+
+// List of constants for ImageCache condition types
+const (
+	ImageCacheConditionReady       = "Ready"
+	ImageCacheConditionProgressing = "Progressing"
+	ImageCacheConditionDegraded    = "Degraded"
+	// ImageCacheConditionSufficientCoverage is only set on the image caches with a min coverage percent
+	ImageCacheConditionSufficientCoverage = "SufficientCoverage"
+	// ImageCacheConditionStale is only set on the image caches with a stale threshold and a successful refresh
+	ImageCacheConditionStale = "Stale"
+)
+
 // List of constants for ImageCacheReason
 const (
 	ImageCacheReasonImageCacheCreate               = "ImageCacheCreate"
@@ -108,6 +272,26 @@ const (
 	ImageCacheReasonCacheSpecValidationFailed      = "CacheSpecValidationFailed"
 	ImageCacheReasonOldImageCacheNotFound          = "OldImageCacheNotFound"
 	ImageCacheReasonNotSupportedUpdates            = "NotSupportedUpdates"
+	ImageCacheReasonNodeNotReady                   = "NodeNotReady"
+	ImageCacheReasonImageDigestVerificationFailed  = "ImageDigestVerificationFailed"
+	ImageCacheReasonImagesFromConfigMapFailed      = "ImagesFromConfigMapFailed"
+	ImageCacheReasonInsufficientDisk               = "InsufficientDisk"
+	ImageCacheReasonNodeDeleted                    = "NodeDeleted"
+	ImageCacheReasonRepositoryTagsListFailed       = "RepositoryTagsListFailed"
+	ImageCacheReasonBudgetExceeded                 = "BudgetExceeded"
+	ImageCacheReasonWorkloadRefFailed              = "WorkloadRefFailed"
+	ImageCacheReasonRateLimited                    = "RateLimited"
+	ImageCacheReasonUnsupportedRuntime             = "UnsupportedContainerRuntime"
+	ImageCacheReasonShadowedDeletions              = "ShadowedDeletions"
+	ImageCacheReasonCoverageReached                = "CoverageReached"
+	ImageCacheReasonCoverageBelowMinimum           = "CoverageBelowMinimum"
+	ImageCacheReasonJobTemplateNotFound            = "JobTemplateNotFound"
+	ImageCacheReasonImageNotManaged                = "ImageNotManaged"
+	ImageCacheReasonImagePullDeadlineExceeded      = "ImagePullDeadlineExceeded"
+	ImageCacheReasonRefreshOverdue                 = "RefreshOverdue"
+	ImageCacheReasonRefreshedRecently              = "RefreshedRecently"
+	ImageCacheReasonJobNotCreated                  = "JobNotCreated"
+	ImageCacheReasonTooManyImages                  = "TooManyImages"
 )
 
 // List of constants for ImageCacheMessage
@@ -127,4 +311,14 @@ const (
 	ImageCacheMessageOldImageCacheNotFound          = "Unable to fetch the previous version of Image cache spec before update action."
 	ImageCacheMessageNotSupportedUpdates            = "The updates performed to image cache spec is not supported. Only addition or removal of images in a image list is supported."
 	ImageCacheMessageNoImagesPulledOrDeleted        = "No images were pulled or deleted because nodeSelector specified did not match any nodes"
+	ImageCacheMessageImagesFromConfigMapFailed      = "Unable to read the list of images from the ConfigMap referenced in \"imagesFrom\""
+	ImageCacheMessageRepositoryTagsListFailed       = "Unable to list the tags of a repository specified in \"repositories\". Retry after some time"
+	ImageCacheMessageWorkloadRefFailed              = "Unable to read the workload referenced in \"workloadRef\""
+	ImageCacheMessageNodesNotFound                  = "Nodes listed in \"nodeNames\" not found, so skipped"
+	ImageCacheMessageRateLimited                    = "Image pull rate-limited by the registry, and not retried before the image pull deadline"
+	ImageCacheMessageUnsupportedRuntime             = "Nodes with a container runtime not in --supported-runtimes skipped"
+	ImageCacheMessageNodesUnderMaintenance          = "Nodes under maintenance skipped until the maintenance ends"
+	ImageCacheMessageShadowedDeletions              = "Shadow mode: images no longer in the image lists not deleted. Please see \"shadowedDeletions\" section"
+	// ImageCacheMessageTooManyImages is formatted with the no. of images and the maximum
+	ImageCacheMessageTooManyImages = "No. of images (%d), including those read from \"imagesFrom\" and the tags of \"repositories\", exceeds the maximum allowed per image cache (%d). Split the images into multiple image caches"
 )
@@ -23,6 +23,8 @@ package v1alpha2
 
 import (
 	v1 "k8s.io/api/core/v1"
+	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -34,6 +36,18 @@ func (in *CacheSpecImages) DeepCopyInto(out *CacheSpecImages) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ImagesFrom != nil {
+		in, out := &in.ImagesFrom, &out.ImagesFrom
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Repositories != nil {
+		in, out := &in.Repositories, &out.Repositories
+		*out = make([]CacheSpecRepository, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.NodeSelector != nil {
 		in, out := &in.NodeSelector, &out.NodeSelector
 		*out = make(map[string]string, len(*in))
@@ -41,6 +55,23 @@ func (in *CacheSpecImages) DeepCopyInto(out *CacheSpecImages) {
 			(*out)[key] = val
 		}
 	}
+	if in.WorkloadRef != nil {
+		in, out := &in.WorkloadRef, &out.WorkloadRef
+		*out = new(CacheSpecWorkloadRef)
+		**out = **in
+	}
+	if in.NodeNames != nil {
+		in, out := &in.NodeNames, &out.NodeNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ImageSizes != nil {
+		in, out := &in.ImageSizes, &out.ImageSizes
+		*out = make(map[string]resource.Quantity, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 	return
 }
 
@@ -54,6 +85,48 @@ func (in *CacheSpecImages) DeepCopy() *CacheSpecImages {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheSpecRepository) DeepCopyInto(out *CacheSpecRepository) {
+	*out = *in
+	if in.IncludeTags != nil {
+		in, out := &in.IncludeTags, &out.IncludeTags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeTags != nil {
+		in, out := &in.ExcludeTags, &out.ExcludeTags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheSpecRepository.
+func (in *CacheSpecRepository) DeepCopy() *CacheSpecRepository {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheSpecRepository)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheSpecWorkloadRef) DeepCopyInto(out *CacheSpecWorkloadRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheSpecWorkloadRef.
+func (in *CacheSpecWorkloadRef) DeepCopy() *CacheSpecWorkloadRef {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheSpecWorkloadRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ImageCache) DeepCopyInto(out *ImageCache) {
 	*out = *in
@@ -115,6 +188,32 @@ func (in *ImageCacheList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageCachePreWarm) DeepCopyInto(out *ImageCachePreWarm) {
+	*out = *in
+	if in.LeadMinutes != nil {
+		in, out := &in.LeadMinutes, &out.LeadMinutes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PurgeAfterMinutes != nil {
+		in, out := &in.PurgeAfterMinutes, &out.PurgeAfterMinutes
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageCachePreWarm.
+func (in *ImageCachePreWarm) DeepCopy() *ImageCachePreWarm {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageCachePreWarm)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ImageCacheSpec) DeepCopyInto(out *ImageCacheSpec) {
 	*out = *in
@@ -130,6 +229,65 @@ func (in *ImageCacheSpec) DeepCopyInto(out *ImageCacheSpec) {
 		*out = make([]v1.LocalObjectReference, len(*in))
 		copy(*out, *in)
 	}
+	if in.RuntimeClassName != nil {
+		in, out := &in.RuntimeClassName, &out.RuntimeClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Mirrors != nil {
+		in, out := &in.Mirrors, &out.Mirrors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.JobDeadlineSeconds != nil {
+		in, out := &in.JobDeadlineSeconds, &out.JobDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.JobTTLSeconds != nil {
+		in, out := &in.JobTTLSeconds, &out.JobTTLSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PreWarm != nil {
+		in, out := &in.PreWarm, &out.PreWarm
+		*out = new(ImageCachePreWarm)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ShadowUntil != nil {
+		in, out := &in.ShadowUntil, &out.ShadowUntil
+		*out = (*in).DeepCopy()
+	}
+	if in.MinCoveragePercent != nil {
+		in, out := &in.MinCoveragePercent, &out.MinCoveragePercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.JobTemplate != nil {
+		in, out := &in.JobTemplate, &out.JobTemplate
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.RefreshFrequency != nil {
+		in, out := &in.RefreshFrequency, &out.RefreshFrequency
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaxImageAge != nil {
+		in, out := &in.MaxImageAge, &out.MaxImageAge
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RunnableCommand != nil {
+		in, out := &in.RunnableCommand, &out.RunnableCommand
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -169,6 +327,56 @@ func (in *ImageCacheStatus) DeepCopyInto(out *ImageCacheStatus) {
 		in, out := &in.CompletionTime, &out.CompletionTime
 		*out = (*in).DeepCopy()
 	}
+	if in.VerifiedDigests != nil {
+		in, out := &in.VerifiedDigests, &out.VerifiedDigests
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PulledFromMirrors != nil {
+		in, out := &in.PulledFromMirrors, &out.PulledFromMirrors
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.CachedImages != nil {
+		in, out := &in.CachedImages, &out.CachedImages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ShadowedDeletions != nil {
+		in, out := &in.ShadowedDeletions, &out.ShadowedDeletions
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.CoveragePercent != nil {
+		in, out := &in.CoveragePercent, &out.CoveragePercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.LastSuccessfulRefreshTime != nil {
+		in, out := &in.LastSuccessfulRefreshTime, &out.LastSuccessfulRefreshTime
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -0,0 +1,129 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Env variables set on the container of a custom puller. Together with the image to pull, passed as the
+// last arg of its command, they are the contract between the controller and the custom puller
+const (
+	// customPullerEnvImage is the image to pull
+	customPullerEnvImage = "KUBEFLEDGED_IMAGE"
+	// customPullerEnvImagePullPolicy is the pull policy of the image, Always or IfNotPresent
+	customPullerEnvImagePullPolicy = "KUBEFLEDGED_IMAGE_PULL_POLICY"
+	// customPullerEnvImagePullSecrets is a comma-separated list of the image pull secrets of the image cache
+	customPullerEnvImagePullSecrets = "KUBEFLEDGED_IMAGE_PULL_SECRETS"
+	// customPullerEnvImageCache is the namespace/name of the image cache
+	customPullerEnvImageCache = "KUBEFLEDGED_IMAGE_CACHE"
+	// customPullerEnvNode is the hostname of the node the image is pulled to
+	customPullerEnvNode = "KUBEFLEDGED_NODE"
+	// customPullerEnvContainerRuntime is the container runtime version of the node e.g. containerd://1.6.0
+	customPullerEnvContainerRuntime = "KUBEFLEDGED_CONTAINER_RUNTIME"
+	// customPullerEnvCRISocket is the path of the cri socket of the node, which is mounted at the same path
+	customPullerEnvCRISocket = "KUBEFLEDGED_CRI_SOCKET"
+)
+
+// customPuller is an image and command which pull the images to the nodes instead of the pull jobs running
+// the images. The image is pulled if the command exits with status 0. Otherwise the termination message
+// of the container is the message of the failure
+type customPuller struct {
+	image   string
+	command []string
+}
+
+// runtimeSocketPath returns the path of the cri socket of the container runtime, unless criSocketPath is set
+func runtimeSocketPath(containerRuntimeVersion string, criSocketPath string) string {
+	switch {
+	case criSocketPath != "":
+		return criSocketPath
+	case strings.Contains(containerRuntimeVersion, "containerd"):
+		return "/run/containerd/containerd.sock"
+	case strings.Contains(containerRuntimeVersion, "crio") || strings.Contains(containerRuntimeVersion, "cri-o"):
+		return "/var/run/crio/crio.sock"
+	}
+	return "/var/run/docker.sock"
+}
+
+// setOn replaces the container of the pull job running the image, with the container of the custom puller
+// pulling the image through the cri socket of the node. The custom puller runs as root to connect to the socket
+func (p *customPuller) setOn(job *batchv1.Job, imagecache string, image string, node *corev1.Node, criSocketPath string,
+	helperImagePullPolicy corev1.PullPolicy, runAsUser *int64) {
+	podSpec := &job.Spec.Template.Spec
+	pullPolicy := podSpec.Containers[0].ImagePullPolicy
+	containerRuntimeVersion := node.Status.NodeInfo.ContainerRuntimeVersion
+	socketPath := runtimeSocketPath(containerRuntimeVersion, nodeCRISocketPath(node, criSocketPath))
+	var pullSecrets []string
+	for _, secret := range podSpec.ImagePullSecrets {
+		pullSecrets = append(pullSecrets, secret.Name)
+	}
+
+	// the busybox init container only provides the echo binary run by the image
+	var initContainers []corev1.Container
+	for _, container := range podSpec.InitContainers {
+		if container.Name != "busybox" {
+			initContainers = append(initContainers, container)
+		}
+	}
+	podSpec.InitContainers = initContainers
+	var volumes []corev1.Volume
+	for _, volume := range podSpec.Volumes {
+		if volume.Name != "tmp-bin" {
+			volumes = append(volumes, volume)
+		}
+	}
+	hostpathtype := corev1.HostPathSocket
+	podSpec.Volumes = append(volumes, corev1.Volume{
+		Name: "runtime-sock",
+		VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{
+				Path: socketPath,
+				Type: &hostpathtype,
+			},
+		},
+	})
+	podSpec.Containers = []corev1.Container{
+		{
+			Name:    "imagepuller",
+			Image:   p.image,
+			Command: append(append([]string{}, p.command...), image),
+			Env: []corev1.EnvVar{
+				{Name: customPullerEnvImage, Value: image},
+				{Name: customPullerEnvImagePullPolicy, Value: string(pullPolicy)},
+				{Name: customPullerEnvImagePullSecrets, Value: strings.Join(pullSecrets, ",")},
+				{Name: customPullerEnvImageCache, Value: imagecache},
+				{Name: customPullerEnvNode, Value: node.Labels["kubernetes.io/hostname"]},
+				{Name: customPullerEnvContainerRuntime, Value: containerRuntimeVersion},
+				{Name: customPullerEnvCRISocket, Value: socketPath},
+			},
+			VolumeMounts: []corev1.VolumeMount{
+				{
+					Name:      "runtime-sock",
+					MountPath: socketPath,
+				},
+			},
+			ImagePullPolicy: helperImagePullPolicy,
+		},
+	}
+	if runAsUser != nil {
+		setJobSecurityContext(job, *runAsUser, "ca-bundle", "imagepuller")
+	}
+}
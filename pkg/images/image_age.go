@@ -0,0 +1,66 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// imagePullKey identifies the pull of an image to a node
+type imagePullKey struct {
+	image string
+	node  string
+}
+
+// recordImagePull records the time at which the image of the request was pulled to its node
+func (m *ImageManager) recordImagePull(iwr ImageWorkRequest, pulledAt time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.imagePulledAt[imagePullKey{image: iwr.Image, node: iwr.Node.Name}] = pulledAt
+}
+
+// forgetImagePull forgets the pull of the image of the request to its node, once the image is deleted
+func (m *ImageManager) forgetImagePull(iwr ImageWorkRequest) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.imagePulledAt, imagePullKey{image: iwr.Image, node: iwr.Node.Name})
+}
+
+// imageExpired returns true if the image of the request was pulled to its node longer than the maximum image
+// age of its image cache ago. The pulls are tracked by the image manager, so images whose pull is not known
+// e.g. pulled before the controller restarted, are expired as well
+func (m *ImageManager) imageExpired(iwr ImageWorkRequest, now time.Time) bool {
+	if iwr.Imagecache == nil || iwr.Imagecache.Spec.MaxImageAge == nil || iwr.Imagecache.Spec.MaxImageAge.Duration <= 0 ||
+		iwr.WorkType == ImageCachePurge {
+		return false
+	}
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	pulledAt, ok := m.imagePulledAt[imagePullKey{image: iwr.Image, node: iwr.Node.Name}]
+	return !ok || now.Sub(pulledAt) >= iwr.Imagecache.Spec.MaxImageAge.Duration
+}
+
+// pullPolicy returns the image pull policy of the job pulling the image of the request. Expired images are
+// pulled with the Always pull policy, so that the container runtime compares their digest with the registry
+func (m *ImageManager) pullPolicy(iwr ImageWorkRequest) string {
+	if m.imageExpired(iwr, time.Now()) {
+		return string(corev1.PullAlways)
+	}
+	return m.imagePullPolicy
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"testing"
+	"time"
+
+	fledgedv1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestImageExpired(t *testing.T) {
+	now := time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)
+	week := &metav1.Duration{Duration: 7 * 24 * time.Hour}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	imagemanager, _ := newTestImageManager(fakeclientset.NewSimpleClientset(), "IfNotPresent", "", false, "", true, "")
+	imagemanager.recordImagePull(ImageWorkRequest{Image: "foo:v1", Node: node}, now.Add(-8*24*time.Hour))
+	imagemanager.recordImagePull(ImageWorkRequest{Image: "bar:v1", Node: node}, now.Add(-24*time.Hour))
+
+	tests := []struct {
+		name        string
+		image       string
+		workType    WorkType
+		maxImageAge *metav1.Duration
+		expected    bool
+	}{
+		{name: "#1: No maximum image age", image: "foo:v1", workType: ImageCacheRefresh, expected: false},
+		{name: "#2: Image older than the maximum age", image: "foo:v1", workType: ImageCacheRefresh, maxImageAge: week, expected: true},
+		{name: "#3: Image younger than the maximum age", image: "bar:v1", workType: ImageCacheRefresh, maxImageAge: week, expected: false},
+		{name: "#4: Pull of the image not known", image: "baz:v1", workType: ImageCacheRefresh, maxImageAge: week, expected: true},
+		{name: "#5: Purge", image: "foo:v1", workType: ImageCachePurge, maxImageAge: week, expected: false},
+	}
+	for _, test := range tests {
+		iwr := ImageWorkRequest{Image: test.image, Node: node, WorkType: test.workType,
+			Imagecache: &fledgedv1alpha2.ImageCache{Spec: fledgedv1alpha2.ImageCacheSpec{MaxImageAge: test.maxImageAge}}}
+		if actual := imagemanager.imageExpired(iwr, now); actual != test.expected {
+			t.Errorf("Test: %s failed: expected=%t, actual=%t", test.name, test.expected, actual)
+		}
+	}
+
+	iwr := ImageWorkRequest{Image: "foo:v1", Node: node, WorkType: ImageCacheRefresh,
+		Imagecache: &fledgedv1alpha2.ImageCache{Spec: fledgedv1alpha2.ImageCacheSpec{MaxImageAge: week}}}
+	if actual := imagemanager.pullPolicy(iwr); actual != string(corev1.PullAlways) {
+		t.Errorf("Test: expired image: expectedPullPolicy=%s, actualPullPolicy=%s", corev1.PullAlways, actual)
+	}
+	imagemanager.forgetImagePull(iwr)
+	if _, ok := imagemanager.imagePulledAt[imagePullKey{image: "foo:v1", node: "node1"}]; ok {
+		t.Errorf("Test: pull of a deleted image not forgotten")
+	}
+}
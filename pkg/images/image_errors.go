@@ -0,0 +1,74 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Errors returned by the image manager. Callers can test for them using errors.Is
+var (
+	// ErrImageCacheNil is returned when a work request does not reference an image cache
+	ErrImageCacheNil = errors.New("imagecache pointer is nil")
+	// ErrImageNotPullable is returned when the image reference cannot be used to create a pull job
+	ErrImageNotPullable = errors.New("image not pullable")
+	// ErrRuntimeUnsupported is returned when the container runtime of the node is not supported
+	ErrRuntimeUnsupported = errors.New("container runtime not supported")
+	// ErrNodeNotReady is returned when the target node is not ready
+	ErrNodeNotReady = errors.New("node not ready")
+	// ErrInsufficientDisk is returned when the target node lacks the free disk to pull the image
+	ErrInsufficientDisk = errors.New("insufficient disk")
+	// ErrBudgetExceeded is returned when the images cached on the target node take up the maximum bytes per node
+	ErrBudgetExceeded = errors.New("cache budget exceeded")
+	// ErrJobTemplateNotFound is returned when the PodTemplate referenced by the jobTemplate of the image cache does not exist
+	ErrJobTemplateNotFound = errors.New("job template not found")
+	// ErrImageNotManaged is returned when the image to delete was not pulled to the target node by kubefledged
+	ErrImageNotManaged = errors.New("image not managed")
+	// ErrTagsNotListable is returned when the registry of a repository does not support listing its tags
+	ErrTagsNotListable = errors.New("tags not listable")
+)
+
+// ImageWorkError records a failed image pull/delete along with the image and node involved.
+// Callers can extract it using errors.As
+type ImageWorkError struct {
+	WorkType WorkType
+	Image    string
+	Node     string
+	Err      error
+}
+
+func (e *ImageWorkError) Error() string {
+	if e.WorkType == ImageCachePurge {
+		return fmt.Sprintf("error deleting image '%s' from node '%s': %s", e.Image, e.Node, e.Err.Error())
+	}
+	return fmt.Sprintf("error pulling image '%s' to node '%s': %s", e.Image, e.Node, e.Err.Error())
+}
+
+// Unwrap returns the underlying error
+func (e *ImageWorkError) Unwrap() error {
+	return e.Err
+}
+
+// IsTransientAPIError returns true if the error of a request to the api server is likely to go away when the
+// request is retried
+func IsTransientAPIError(err error) bool {
+	return apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) || apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServiceUnavailable(err) || apierrors.IsInternalError(err) || apierrors.IsUnexpectedServerError(err)
+}
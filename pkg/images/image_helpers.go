@@ -17,8 +17,8 @@ limitations under the License.
 package images
 
 import (
-	"encoding/json"
 	"fmt"
+	"path"
 	"strings"
 	"time"
 
@@ -33,12 +33,17 @@ import (
 // newImagePullJob constructs a job manifest for pulling an image to a node
 func newImagePullJob(imagecache *fledgedv1alpha2.ImageCache, image string, node *corev1.Node,
 	imagePullPolicy string, busyboxImage string, serviceAccountName string,
-	jobPriorityClassName string) (*batchv1.Job, error) {
+	jobPriorityClassName string, jobSchedulerName string, helperImagePullPolicy corev1.PullPolicy,
+	automountServiceAccountToken bool, runAsUser *int64) (*batchv1.Job, error) {
 	var pullPolicy corev1.PullPolicy = corev1.PullIfNotPresent
 	hostname := node.Labels["kubernetes.io/hostname"]
 	if imagecache == nil {
 		glog.Error("imagecache pointer is nil")
-		return nil, fmt.Errorf("imagecache pointer is nil")
+		return nil, ErrImageCacheNil
+	}
+	if image == "" || strings.ContainsAny(image, " \t\n") {
+		glog.Errorf("image reference '%s' is not valid", image)
+		return nil, fmt.Errorf("%w: '%s'", ErrImageNotPullable, image)
 	}
 	if imagePullPolicy == string(corev1.PullAlways) {
 		pullPolicy = corev1.PullAlways
@@ -84,6 +89,7 @@ func newImagePullJob(imagecache *fledgedv1alpha2.ImageCache, image string, node
 					NodeSelector: map[string]string{
 						"kubernetes.io/hostname": hostname,
 					},
+					Affinity: hostnameAffinity(hostname),
 					InitContainers: []corev1.Container{
 						{
 							Name:    "busybox",
@@ -95,7 +101,7 @@ func newImagePullJob(imagecache *fledgedv1alpha2.ImageCache, image string, node
 									MountPath: "/tmp/bin",
 								},
 							},
-							ImagePullPolicy: corev1.PullIfNotPresent,
+							ImagePullPolicy: helperImagePullPolicy,
 						},
 					},
 					Containers: []corev1.Container{
@@ -120,8 +126,9 @@ func newImagePullJob(imagecache *fledgedv1alpha2.ImageCache, image string, node
 							},
 						},
 					},
-					RestartPolicy:    corev1.RestartPolicyNever,
-					ImagePullSecrets: imagecache.Spec.ImagePullSecrets,
+					AutomountServiceAccountToken: &automountServiceAccountToken,
+					RestartPolicy:                corev1.RestartPolicyNever,
+					ImagePullSecrets:             imagecache.Spec.ImagePullSecrets,
 					Tolerations: []corev1.Toleration{
 						{
 							Operator: corev1.TolerationOpExists,
@@ -131,24 +138,300 @@ func newImagePullJob(imagecache *fledgedv1alpha2.ImageCache, image string, node
 			},
 		},
 	}
+	setJobLifecycle(job, imagecache)
+	if imagecache.Spec.ValidateRunnable {
+		setRunnableCommand(job, imagecache.Spec.RunnableCommand)
+	}
 	if serviceAccountName != "" {
 		job.Spec.Template.Spec.ServiceAccountName = serviceAccountName
 	}
 	if jobPriorityClassName != "" {
 		job.Spec.Template.Spec.PriorityClassName = jobPriorityClassName
 	}
+	setJobSchedulerName(job, imagecache, jobSchedulerName)
+	if imagecache.Spec.RuntimeClassName != nil && *imagecache.Spec.RuntimeClassName != "" {
+		job.Spec.Template.Spec.RuntimeClassName = imagecache.Spec.RuntimeClassName
+	}
+	if imagecache.Spec.CABundle != nil {
+		addCABundle(job, imagecache.Spec.CABundle, image, node.Status.NodeInfo.ContainerRuntimeVersion,
+			busyboxImage, helperImagePullPolicy)
+	}
+	if runAsUser != nil {
+		// the ca-bundle init container writes to the certs directory of the container runtime
+		setJobSecurityContext(job, *runAsUser, "ca-bundle")
+	}
 	return job, nil
 }
 
-// newImageDeleteJob constructs a job manifest to delete an image from a node
+// addCABundle adds an init container to the pull job which installs the CA bundle in the certs directory of the
+// container runtime for the registry of the image. Init containers complete before the image is pulled, so the
+// runtime trusts the registry when pulling the image
+func addCABundle(job *batchv1.Job, caBundle *corev1.ConfigMapKeySelector, image string,
+	containerRuntimeVersion string, busyboxImage string, helperImagePullPolicy corev1.PullPolicy) {
+	registryCertsDir := "/etc/certs.d/" + registryHost(image)
+	hostpathtype := corev1.HostPathDirectoryOrCreate
+	podSpec := &job.Spec.Template.Spec
+	podSpec.InitContainers = append([]corev1.Container{
+		{
+			Name:    "ca-bundle",
+			Image:   busyboxImage,
+			Command: []string{"sh", "-c", "mkdir -p " + registryCertsDir + " && cp /etc/ca-bundle/ca.crt " + registryCertsDir + "/ca.crt"},
+			VolumeMounts: []corev1.VolumeMount{
+				{
+					Name:      "ca-bundle",
+					MountPath: "/etc/ca-bundle",
+					ReadOnly:  true,
+				},
+				{
+					Name:      "certs-d",
+					MountPath: "/etc/certs.d",
+				},
+			},
+			ImagePullPolicy: helperImagePullPolicy,
+		},
+	}, podSpec.InitContainers...)
+	podSpec.Volumes = append(podSpec.Volumes,
+		corev1.Volume{
+			Name: "ca-bundle",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: caBundle.LocalObjectReference,
+					Items:                []corev1.KeyToPath{{Key: caBundle.Key, Path: "ca.crt"}},
+					Optional:             caBundle.Optional,
+				},
+			},
+		},
+		corev1.Volume{
+			Name: "certs-d",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: runtimeCertsDir(containerRuntimeVersion),
+					Type: &hostpathtype,
+				},
+			},
+		})
+}
+
+// setJobLifecycle applies the job deadline and TTL of the image cache to the job
+func setJobLifecycle(job *batchv1.Job, imagecache *fledgedv1alpha2.ImageCache) {
+	if imagecache.Spec.JobDeadlineSeconds != nil {
+		activeDeadlineSeconds := *imagecache.Spec.JobDeadlineSeconds
+		job.Spec.ActiveDeadlineSeconds = &activeDeadlineSeconds
+	}
+	if imagecache.Spec.JobTTLSeconds != nil {
+		ttlSecondsAfterFinished := *imagecache.Spec.JobTTLSeconds
+		job.Spec.TTLSecondsAfterFinished = &ttlSecondsAfterFinished
+	}
+}
+
+// setJobSchedulerName sets the scheduler of the pod of the job: the scheduler name of the image cache, or else
+// jobSchedulerName. The pod is left to the default scheduler if neither is set
+func setJobSchedulerName(job *batchv1.Job, imagecache *fledgedv1alpha2.ImageCache, jobSchedulerName string) {
+	if imagecache.Spec.SchedulerName != "" {
+		jobSchedulerName = imagecache.Spec.SchedulerName
+	}
+	if jobSchedulerName != "" {
+		job.Spec.Template.Spec.SchedulerName = jobSchedulerName
+	}
+}
+
+// setJobPodAnnotations adds the annotations to the pod of the job e.g. to keep service meshes from injecting
+// sidecars, which never terminate and so keep the pod from completing
+func setJobPodAnnotations(job *batchv1.Job, annotations map[string]string) {
+	if len(annotations) == 0 {
+		return
+	}
+	podMeta := &job.Spec.Template.ObjectMeta
+	if podMeta.Annotations == nil {
+		podMeta.Annotations = make(map[string]string, len(annotations))
+	}
+	for key, value := range annotations {
+		podMeta.Annotations[key] = value
+	}
+}
+
+// setJobSecurityContext sets a security context complying with the restricted Pod Security Standard on the pod
+// of the job. The pod runs as runAsUser with the RuntimeDefault seccomp profile, and its containers drop all
+// capabilities and can't escalate privileges. The containers in rootContainers run as root
+func setJobSecurityContext(job *batchv1.Job, runAsUser int64, rootContainers ...string) {
+	runAsNonRoot := runAsUser != 0
+	podSpec := &job.Spec.Template.Spec
+	podSpec.SecurityContext = &corev1.PodSecurityContext{
+		RunAsNonRoot:   &runAsNonRoot,
+		RunAsUser:      &runAsUser,
+		SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for i := range containers {
+			allowPrivilegeEscalation := false
+			securityContext := &corev1.SecurityContext{
+				AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+				Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+			}
+			for _, name := range rootContainers {
+				if containers[i].Name == name {
+					rootUser, runAsNonRoot := int64(0), false
+					securityContext.RunAsUser = &rootUser
+					securityContext.RunAsNonRoot = &runAsNonRoot
+				}
+			}
+			containers[i].SecurityContext = securityContext
+		}
+	}
+}
+
+// runtimeCertsDir returns the directory on the node in which the container runtime looks up the CA certificates
+// of registries
+func runtimeCertsDir(containerRuntimeVersion string) string {
+	if strings.Contains(containerRuntimeVersion, "containerd") {
+		return "/etc/containerd/certs.d"
+	}
+	if strings.Contains(containerRuntimeVersion, "crio") || strings.Contains(containerRuntimeVersion, "cri-o") {
+		return "/etc/containers/certs.d"
+	}
+	return "/etc/docker/certs.d"
+}
+
+// mirrorOf returns the mirror the work request pulls the image from. It is empty for the registry of the image
+func mirrorOf(iwr ImageWorkRequest) string {
+	if iwr.mirror == 0 || iwr.Imagecache == nil || iwr.mirror > len(iwr.Imagecache.Spec.Mirrors) {
+		return ""
+	}
+	return iwr.Imagecache.Spec.Mirrors[iwr.mirror-1]
+}
+
+// retargetOnNodeDeletion returns true if the pulls of the image list of the work request are retargeted to
+// another node when their node is deleted
+func retargetOnNodeDeletion(iwr ImageWorkRequest) bool {
+	if iwr.Imagecache == nil || iwr.ImageList < 0 || iwr.ImageList >= len(iwr.Imagecache.Spec.CacheSpec) {
+		return false
+	}
+	return iwr.Imagecache.Spec.CacheSpec[iwr.ImageList].RetargetOnNodeDeletion
+}
+
+// Categories of the failures of image pulls, used as the reasons of the events of failed images
+const (
+	ImagePullFailureAuth         = "ImagePullAuthError"
+	ImagePullFailureNotFound     = "ImageNotFound"
+	ImagePullFailureDiskPressure = "DiskPressure"
+	ImagePullFailureOther        = "ImagePullFailed"
+)
+
+// FailureCategory returns the category of the failure of a pull, parsed from the reason and the message of its
+// result. These have the reason the pod of the pull job was waiting or terminated for, and the messages of its events
+func FailureCategory(iwres ImageWorkResult) string {
+	reason, message := iwres.Reason, strings.ToLower(iwres.Message)
+	containsAny := func(substrs ...string) bool {
+		for _, substr := range substrs {
+			if strings.Contains(message, substr) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case reason == fledgedv1alpha2.ImageCacheReasonInsufficientDisk ||
+		reason == fledgedv1alpha2.ImageCacheReasonBudgetExceeded ||
+		containsAny("no space left on device", "disk pressure", "diskpressure"):
+		return ImagePullFailureDiskPressure
+	// registries such as docker.io deny the pulls of repositories which do not exist, so these are
+	// told apart from authentication errors first
+	case reason == "InvalidImageName" || containsAny("not found", "manifest unknown", "does not exist"):
+		return ImagePullFailureNotFound
+	case containsAny("unauthorized", "authentication required", "access denied", "denied:", "forbidden"):
+		return ImagePullFailureAuth
+	}
+	return ImagePullFailureOther
+}
+
+// imageToPull returns the image of the work request on the mirror it is pulled from
+func imageToPull(iwr ImageWorkRequest) string {
+	if mirror := mirrorOf(iwr); mirror != "" {
+		return imageOnMirror(iwr.Image, mirror)
+	}
+	return iwr.Image
+}
+
+// imageOnMirror replaces the registry host of the image with the mirror. Images without a registry host are on docker.io
+func imageOnMirror(image, mirror string) string {
+	i := strings.Index(image, "/")
+	if i == -1 {
+		return mirror + "/library/" + image
+	}
+	if host := image[:i]; strings.ContainsAny(host, ".:") || host == "localhost" {
+		return mirror + "/" + image[i+1:]
+	}
+	return mirror + "/" + image
+}
+
+// isImagePullFailing returns true if the pod is waiting for an image which could not be pulled
+func isImagePullFailing(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodPending {
+		return false
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting != nil &&
+			(status.State.Waiting.Reason == "ErrImagePull" || status.State.Waiting.Reason == "ImagePullBackOff") {
+			return true
+		}
+	}
+	return false
+}
+
+// RegistryHost returns the registry host of the image e.g. docker.io for nginx:1.23. See registryHost
+func RegistryHost(image string) string {
+	return registryHost(image)
+}
+
+// registryHost returns the registry host of the image. Images not starting with a registry host are on docker.io
+func registryHost(image string) string {
+	i := strings.Index(image, "/")
+	if i == -1 {
+		return "docker.io"
+	}
+	if host := image[:i]; strings.ContainsAny(host, ".:") || host == "localhost" {
+		return host
+	}
+	return "docker.io"
+}
+
+// shellArgs returns the args quoted for a shell command line, each preceded by a space
+func shellArgs(args []string) string {
+	var quoted string
+	for _, arg := range args {
+		quoted += " '" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return quoted
+}
+
+// nodeCRISocketPath returns the path of the cri socket of the node from its kubefledged.io/cri-socket annotation,
+// which overrides criSocketPath. An annotation which is not an absolute path is ignored
+func nodeCRISocketPath(node *corev1.Node, criSocketPath string) string {
+	socketPath, ok := node.Annotations[nodeCRISocketAnnotationKey]
+	if !ok {
+		return criSocketPath
+	}
+	if !path.IsAbs(socketPath) {
+		glog.Warningf("Annotation %s=%q of node %s ignored: not an absolute path", nodeCRISocketAnnotationKey, socketPath, node.Name)
+		return criSocketPath
+	}
+	return socketPath
+}
+
+// newImageDeleteJob constructs a job manifest to delete an image from a node. The cri socket of the node
+// annotated with kubefledged.io/cri-socket is mounted, instead of criSocketPath or the one of its runtime
 func newImageDeleteJob(imagecache *fledgedv1alpha2.ImageCache, image string, node *corev1.Node,
 	containerRuntimeVersion string, dockerclientimage string, serviceAccountName string,
-	imageDeleteJobHostNetwork bool, jobPriorityClassName string, criSocketPath string) (*batchv1.Job, error) {
+	imageDeleteJobHostNetwork bool, jobPriorityClassName string, jobSchedulerName string, criSocketPath string,
+	helperImagePullPolicy corev1.PullPolicy, automountServiceAccountToken bool, runAsUser *int64,
+	criClientArgs []string, criClientEnv []corev1.EnvVar) (*batchv1.Job, error) {
 	hostname := node.Labels["kubernetes.io/hostname"]
+	criSocketPath = nodeCRISocketPath(node, criSocketPath)
 	socketPath := criSocketPath
+	extraArgs := shellArgs(criClientArgs)
 	if imagecache == nil {
 		glog.Error("imagecache pointer is nil")
-		return nil, fmt.Errorf("imagecache pointer is nil")
+		return nil, ErrImageCacheNil
 	}
 
 	labels := map[string]string{
@@ -192,14 +475,15 @@ func newImageDeleteJob(imagecache *fledgedv1alpha2.ImageCache, image string, nod
 							Name:    "docker-cri-client",
 							Image:   dockerclientimage,
 							Command: []string{"/bin/bash"},
-							Args:    []string{"-c", "exec /usr/bin/docker image rm -f " + image + " > /dev/termination-log 2>&1"},
+							Args:    []string{"-c", "exec /usr/bin/docker" + extraArgs + " image rm -f " + image + " > /dev/termination-log 2>&1"},
+							Env:     criClientEnv,
 							VolumeMounts: []corev1.VolumeMount{
 								{
 									Name:      "runtime-sock",
 									MountPath: "/var/run/docker.sock",
 								},
 							},
-							ImagePullPolicy: corev1.PullIfNotPresent,
+							ImagePullPolicy: helperImagePullPolicy,
 						},
 					},
 					Volumes: []corev1.Volume{
@@ -213,9 +497,10 @@ func newImageDeleteJob(imagecache *fledgedv1alpha2.ImageCache, image string, nod
 							},
 						},
 					},
-					RestartPolicy:    corev1.RestartPolicyNever,
-					ImagePullSecrets: imagecache.Spec.ImagePullSecrets,
-					HostNetwork:      imageDeleteJobHostNetwork,
+					AutomountServiceAccountToken: &automountServiceAccountToken,
+					RestartPolicy:                corev1.RestartPolicyNever,
+					ImagePullSecrets:             imagecache.Spec.ImagePullSecrets,
+					HostNetwork:                  imageDeleteJobHostNetwork,
 					Tolerations: []corev1.Toleration{
 						{
 							Operator: corev1.TolerationOpExists,
@@ -229,7 +514,7 @@ func newImageDeleteJob(imagecache *fledgedv1alpha2.ImageCache, image string, nod
 		if criSocketPath == "" {
 			socketPath = "/run/containerd/containerd.sock"
 		}
-		deleteCommand := "exec /usr/bin/crictl --runtime-endpoint=unix://" + socketPath + " --image-endpoint=unix://" + socketPath + " rmi " + image + " > /dev/termination-log 2>&1"
+		deleteCommand := "exec /usr/bin/crictl --runtime-endpoint=unix://" + socketPath + " --image-endpoint=unix://" + socketPath + extraArgs + " rmi " + image + " > /dev/termination-log 2>&1"
 		job.Spec.Template.Spec.Containers[0].Args = []string{"-c", deleteCommand}
 		job.Spec.Template.Spec.Containers[0].VolumeMounts[0].MountPath = socketPath
 		job.Spec.Template.Spec.Volumes[0].VolumeSource.HostPath.Path = socketPath
@@ -238,7 +523,7 @@ func newImageDeleteJob(imagecache *fledgedv1alpha2.ImageCache, image string, nod
 		if criSocketPath == "" {
 			socketPath = "/var/run/crio/crio.sock"
 		}
-		deleteCommand := "exec /usr/bin/crictl --runtime-endpoint=unix://" + socketPath + " --image-endpoint=unix://" + socketPath + " rmi " + image + " > /dev/termination-log 2>&1"
+		deleteCommand := "exec /usr/bin/crictl --runtime-endpoint=unix://" + socketPath + " --image-endpoint=unix://" + socketPath + extraArgs + " rmi " + image + " > /dev/termination-log 2>&1"
 		job.Spec.Template.Spec.Containers[0].Args = []string{"-c", deleteCommand}
 		job.Spec.Template.Spec.Containers[0].VolumeMounts[0].MountPath = socketPath
 		job.Spec.Template.Spec.Volumes[0].VolumeSource.HostPath.Path = socketPath
@@ -250,41 +535,255 @@ func newImageDeleteJob(imagecache *fledgedv1alpha2.ImageCache, image string, nod
 		job.Spec.Template.Spec.Containers[0].VolumeMounts[0].MountPath = socketPath
 		job.Spec.Template.Spec.Volumes[0].VolumeSource.HostPath.Path = socketPath
 	}
+	if containerRuntimeVersion != "" && !isSupportedRuntime(containerRuntimeVersion) {
+		glog.Errorf("container runtime '%s' of node %s is not supported", containerRuntimeVersion, hostname)
+		return nil, fmt.Errorf("%w: %s", ErrRuntimeUnsupported, containerRuntimeVersion)
+	}
+	setJobLifecycle(job, imagecache)
 	if serviceAccountName != "" {
 		job.Spec.Template.Spec.ServiceAccountName = serviceAccountName
 	}
 	if jobPriorityClassName != "" {
 		job.Spec.Template.Spec.PriorityClassName = jobPriorityClassName
 	}
+	setJobSchedulerName(job, imagecache, jobSchedulerName)
+	if runAsUser != nil {
+		// the CRI socket can only be connected to as root
+		setJobSecurityContext(job, 0)
+	}
 	return job, nil
 }
 
-func checkIfImageNeedsToBePulled(imagePullPolicy string, image string, node *corev1.Node) (bool, error) {
-	if imagePullPolicy == string(corev1.PullIfNotPresent) {
-		if !strings.Contains(image, ":") && !strings.Contains(image, "@sha") {
-			return true, nil
+// newImageVerifyJob constructs a job manifest to confirm that the image present in a node has the
+// digest the image is pinned to. The verified digest is written to the termination log of the job's pod
+func newImageVerifyJob(imagecache *fledgedv1alpha2.ImageCache, image string, node *corev1.Node,
+	containerRuntimeVersion string, criclientimage string, serviceAccountName string,
+	jobPriorityClassName string, jobSchedulerName string, criSocketPath string, helperImagePullPolicy corev1.PullPolicy,
+	automountServiceAccountToken bool, runAsUser *int64) (*batchv1.Job, error) {
+	hostname := node.Labels["kubernetes.io/hostname"]
+	if imagecache == nil {
+		glog.Error("imagecache pointer is nil")
+		return nil, ErrImageCacheNil
+	}
+	digest := imageDigest(image)
+	if digest == "" {
+		glog.Errorf("image reference '%s' is not pinned to a digest", image)
+		return nil, fmt.Errorf("%w: '%s' is not pinned to a digest", ErrImageNotPullable, image)
+	}
+	if containerRuntimeVersion != "" && !isSupportedRuntime(containerRuntimeVersion) {
+		glog.Errorf("container runtime '%s' of node %s is not supported", containerRuntimeVersion, hostname)
+		return nil, fmt.Errorf("%w: %s", ErrRuntimeUnsupported, containerRuntimeVersion)
+	}
+
+	labels := map[string]string{
+		"app":         "kubefledged",
+		"kubefledged": "kubefledged-image-manager",
+		"imagecache":  imagecache.Name,
+		"controller":  controllerAgentName,
+		verifyLabel:   "true",
+	}
+
+	socketPath := nodeCRISocketPath(node, criSocketPath)
+	inspectCommand := "/usr/bin/docker image inspect --format '{{json .RepoDigests}}' " + image
+	if strings.Contains(containerRuntimeVersion, "containerd") || strings.Contains(containerRuntimeVersion, "crio") ||
+		strings.Contains(containerRuntimeVersion, "cri-o") {
+		if socketPath == "" {
+			socketPath = "/run/containerd/containerd.sock"
+			if !strings.Contains(containerRuntimeVersion, "containerd") {
+				socketPath = "/var/run/crio/crio.sock"
+			}
+		}
+		inspectCommand = "/usr/bin/crictl --runtime-endpoint=unix://" + socketPath + " --image-endpoint=unix://" + socketPath + " inspecti " + image
+	}
+	if socketPath == "" {
+		socketPath = "/var/run/docker.sock"
+	}
+	verifyCommand := "out=$(" + inspectCommand + " 2>&1) || { echo \"$out\" > /dev/termination-log; exit 1; }; " +
+		"echo \"$out\" | grep -q '" + digest + "' || { echo 'digest mismatch: " + digest + " not found in repo digests of " + image + "' > /dev/termination-log; exit 1; }; " +
+		"echo -n '" + digest + "' > /dev/termination-log"
+
+	hostpathtype := corev1.HostPathSocket
+	backoffLimit := int32(0)
+	activeDeadlineSeconds := int64((time.Hour).Seconds())
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: imagecache.Name + "-",
+			Namespace:    imagecache.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(imagecache, schema.GroupVersionKind{
+					Group:   fledgedv1alpha2.SchemeGroupVersion.Group,
+					Version: fledgedv1alpha2.SchemeGroupVersion.Version,
+					Kind:    "ImageCache",
+				}),
+			},
+			Labels: labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &activeDeadlineSeconds,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: imagecache.Namespace,
+					Labels:    labels,
+				},
+				Spec: corev1.PodSpec{
+					NodeSelector: map[string]string{
+						"kubernetes.io/hostname": hostname,
+					},
+					Containers: []corev1.Container{
+						{
+							Name:    "digest-verifier",
+							Image:   criclientimage,
+							Command: []string{"/bin/bash"},
+							Args:    []string{"-c", verifyCommand},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "runtime-sock",
+									MountPath: socketPath,
+								},
+							},
+							ImagePullPolicy: helperImagePullPolicy,
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "runtime-sock",
+							VolumeSource: corev1.VolumeSource{
+								HostPath: &corev1.HostPathVolumeSource{
+									Path: socketPath,
+									Type: &hostpathtype,
+								},
+							},
+						},
+					},
+					AutomountServiceAccountToken: &automountServiceAccountToken,
+					RestartPolicy:                corev1.RestartPolicyNever,
+					ImagePullSecrets:             imagecache.Spec.ImagePullSecrets,
+					Tolerations: []corev1.Toleration{
+						{
+							Operator: corev1.TolerationOpExists,
+						},
+					},
+				},
+			},
+		},
+	}
+	setJobLifecycle(job, imagecache)
+	if serviceAccountName != "" {
+		job.Spec.Template.Spec.ServiceAccountName = serviceAccountName
+	}
+	if jobPriorityClassName != "" {
+		job.Spec.Template.Spec.PriorityClassName = jobPriorityClassName
+	}
+	setJobSchedulerName(job, imagecache, jobSchedulerName)
+	if runAsUser != nil {
+		// the CRI socket can only be connected to as root
+		setJobSecurityContext(job, 0)
+	}
+	return job, nil
+}
+
+// imageDigest returns the sha256 digest an image reference is pinned to, or an empty string
+func imageDigest(image string) string {
+	if i := strings.Index(image, "@sha256:"); i >= 0 {
+		return image[i+1:]
+	}
+	return ""
+}
+
+// hostnameAffinity returns a node affinity which requires the pod to be scheduled to the node with the given hostname
+func hostnameAffinity(hostname string) *corev1.Affinity {
+	return &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{
+								Key:      "kubernetes.io/hostname",
+								Operator: corev1.NodeSelectorOpIn,
+								Values:   []string{hostname},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// isSupportedRuntime returns true if an image delete job can be constructed for the container runtime
+func isSupportedRuntime(containerRuntimeVersion string) bool {
+	for _, runtime := range []string{"containerd", "crio", "cri-o", "docker"} {
+		if strings.Contains(containerRuntimeVersion, runtime) {
+			return true
 		}
-		if strings.Contains(image, ":latest") {
-			return true, nil
+	}
+	return false
+}
+
+// IsNodeReady returns false only if the node reports a Ready condition that is not true. A node which reports
+// no Ready condition yet, e.g. as it is just registering, is taken as ready
+func IsNodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
 		}
-		imageAlreadyPresent, err := imageAlreadyPresentInNode(image, node)
-		if err != nil {
-			return false, err
+	}
+	return true
+}
+
+// defaultRunnableCommand is the command run in the images to validate that they can run, if the image
+// cache specifies none
+var defaultRunnableCommand = []string{"true"}
+
+// setRunnableCommand makes the container of the pull job run the command in the image, instead of the echo
+// binary copied by the busybox init container, so that the pod fails if the image cannot run on the node
+func setRunnableCommand(job *batchv1.Job, command []string) {
+	if len(command) == 0 {
+		command = defaultRunnableCommand
+	}
+	podSpec := &job.Spec.Template.Spec
+	var initContainers []corev1.Container
+	for _, container := range podSpec.InitContainers {
+		if container.Name != "busybox" {
+			initContainers = append(initContainers, container)
 		}
-		if imageAlreadyPresent {
-			return false, nil
+	}
+	podSpec.InitContainers = initContainers
+	var volumes []corev1.Volume
+	for _, volume := range podSpec.Volumes {
+		if volume.Name != "tmp-bin" {
+			volumes = append(volumes, volume)
 		}
 	}
-	return true, nil
+	podSpec.Volumes = volumes
+	podSpec.Containers[0].Command = command
+	podSpec.Containers[0].VolumeMounts = nil
 }
 
-func imageAlreadyPresentInNode(image string, node *corev1.Node) (bool, error) {
-	imagesByteSlice, err := json.Marshal(node.Status.Images)
-	if err != nil {
-		return false, err
+// checkIfImageNeedsToBePulled returns true if the image needs to be pulled to the node. With the IfNotPresent
+// pull policy, images are compared with the node status by their normalized reference, and images whose
+// normalized tag is latest are always pulled
+func checkIfImageNeedsToBePulled(imagePullPolicy string, image string, node *corev1.Node, index *nodeImageIndex) bool {
+	if imagePullPolicy == string(corev1.PullIfNotPresent) {
+		if strings.HasSuffix(index.normalize(image), ":latest") {
+			return true
+		}
+		if index.contains(node, image) {
+			return false
+		}
 	}
-	if strings.Contains(string(imagesByteSlice), image) {
-		return true, nil
+	return true
+}
+
+// imageNeedsToBePulled returns true if the image of the work request needs to be pulled. Refreshes of image
+// caches in verify refresh mode pull the image only if it is not present in the node, whatever the pull policy
+func imageNeedsToBePulled(imagePullPolicy string, iwr ImageWorkRequest, index *nodeImageIndex) bool {
+	if iwr.WorkType == ImageCacheRefresh && iwr.Imagecache != nil &&
+		iwr.Imagecache.Spec.RefreshMode == fledgedv1alpha2.ImageCacheRefreshModeVerify {
+		return !index.contains(iwr.Node, iwr.Image)
 	}
-	return false, nil
+	return checkIfImageNeedsToBePulled(imagePullPolicy, iwr.Image, iwr.Node, index)
 }
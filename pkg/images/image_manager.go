@@ -18,6 +18,7 @@ package images
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -25,26 +26,35 @@ import (
 
 	"github.com/golang/glog"
 	fledgedv1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	"github.com/senthilrch/kube-fledged/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
-	"k8s.io/apiserver/pkg/storage/names"
 	kubeinformers "k8s.io/client-go/informers"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/client-go/util/workqueue"
 )
 
 const controllerAgentName = "fledged"
 const fakeJobPrefix = "fakejob-"
 
+// verifyLabel is set on the pods of jobs which verify the digest of a pulled image
+const verifyLabel = "kubefledged-verify-digest"
+
+// nodeCRISocketAnnotationKey is set on the nodes whose cri socket is not at the path of the container runtime
+const nodeCRISocketAnnotationKey = "kubefledged.io/cri-socket"
+
 const (
 	// ImageWorkResultStatusSucceeded means image pull/delete succeeded
 	ImageWorkResultStatusSucceeded = "succeeded"
@@ -56,28 +66,72 @@ const (
 	ImageWorkResultStatusAlreadyPulled = "alreadypulled"
 	//ImageWorkResultStatusUnknown  means status of image pull/delete unknown
 	ImageWorkResultStatusUnknown = "unknown"
+	// ImageWorkResultStatusNodeDeleted means the node was deleted before the image pull/delete completed
+	ImageWorkResultStatusNodeDeleted = "nodedeleted"
 )
 
+// pullLimiterRequeueDelay is the delay after which a request held back by the pull or delete limiter is retried
+const pullLimiterRequeueDelay = time.Second
+
+// listRetryBackoff is the jittered backoff used when listing pods and events fails transiently
+var listRetryBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 200 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.5,
+}
+
 // ImageManager provides the functionalities for pulling and deleting images
 type ImageManager struct {
-	fledgedNameSpace          string
-	workqueue                 workqueue.RateLimitingInterface
-	imageworkqueue            workqueue.RateLimitingInterface
-	kubeclientset             kubernetes.Interface
-	imageworkstatus           map[string]ImageWorkResult
-	kubeInformerFactory       kubeinformers.SharedInformerFactory
-	podsLister                corelisters.PodLister
-	podsSynced                cache.InformerSynced
-	imagePullDeadlineDuration time.Duration
-	criClientImage            string
-	busyboxImage              string
-	imagePullPolicy           string
-	serviceAccountName        string
-	imageDeleteJobHostNetwork bool
-	jobPriorityClassName      string
-	canDeleteJob              bool
-	criSocketPath             string
-	lock                      sync.RWMutex
+	fledgedNameSpace             string
+	workqueue                    workqueue.RateLimitingInterface
+	imageworkqueue               workqueue.RateLimitingInterface
+	verifyqueue                  workqueue.RateLimitingInterface
+	kubeclientset                kubernetes.Interface
+	imageworkstatus              map[string]ImageWorkResult
+	kubeInformerFactory          kubeinformers.SharedInformerFactory
+	podsLister                   corelisters.PodLister
+	podsSynced                   cache.InformerSynced
+	imagePullDeadlineDuration    time.Duration
+	imagePullDeadlineMax         time.Duration
+	criClientImage               string
+	busyboxImage                 string
+	imagePullPolicy              string
+	serviceAccountName           string
+	imageDeleteJobHostNetwork    bool
+	jobPriorityClassName         string
+	jobSchedulerName             string
+	canDeleteJob                 bool
+	jobReaper                    *jobReaper
+	criSocketPath                string
+	imageDigestVerification      bool
+	pullLimiter                  *pullLimiter
+	deleteLimiter                *pullLimiter
+	verifyLimiter                *pullLimiter
+	jobPodAnnotations            map[string]string
+	minFreeDisk                  int64
+	freeDisk                     func(node *corev1.Node) (int64, error)
+	maxCacheBytesPerNode         int64
+	cachedImages                 func() (images, repositories []string)
+	deleteJobCRIClientArgs       []string
+	deleteJobCRIClientEnv        []corev1.EnvVar
+	customPuller                 *customPuller
+	helperImagePullPolicy        corev1.PullPolicy
+	automountServiceAccountToken bool
+	jobRunAsUser                 *int64
+	deferredRequests             map[string]int
+	nodeImages                   *nodeImageIndex
+	rateLimitBackoff             time.Duration
+	rateLimitPause               time.Duration
+	registryPausedUntil          map[string]time.Time
+	imagePullBandwidth           int64
+	imagePullDeadlineBase        time.Duration
+	registryRewrites             map[string]string
+	imagePulledAt                map[imagePullKey]time.Time
+	managedImagesAnnotation      string
+	statusUpdates                statusUpdatePool
+	adoptedJobs                  map[adoptedJobKey][]*batchv1.Job
+	lock                         sync.RWMutex
 }
 
 // ImageWorkRequest has image name, node name, work type and imagecache
@@ -87,6 +141,15 @@ type ImageWorkRequest struct {
 	ContainerRuntimeVersion string
 	WorkType                WorkType
 	Imagecache              *fledgedv1alpha2.ImageCache
+	deferred                bool
+	// ImageList is the index in the cache spec of the image list of the image
+	ImageList int
+	// mirror is the no. of the mirror of the image cache the image is pulled from. 0 is the registry of the image
+	mirror int
+	// rateLimitRetries is the no. of times the pull was retried after being rate-limited by the registry
+	rateLimitRetries int
+	// Parent is the span of the reconcile which queued the request
+	Parent tracing.Parent
 }
 
 // ImageWorkResult stores the result of pulling and deleting image
@@ -95,6 +158,16 @@ type ImageWorkResult struct {
 	Status           string
 	Reason           string
 	Message          string
+	Digest           string
+	Mirror           string
+	verifying        bool
+	// pendingVerification is set, along with verifying, on the result of a succeeded pull job while the
+	// verification of the digest of its image waits on the verify queue
+	pendingVerification bool
+	// backingOff is set on the result of a pull job rate-limited by the registry, while its retry is pending
+	backingOff bool
+	// deadline is the time after which a pending pull job is failed, if its deadline is sized to its image
+	deadline time.Time
 }
 
 // WorkType refers to type of work to be done by sync handler
@@ -108,6 +181,8 @@ const (
 	ImageCacheStatusUpdate WorkType = "statusupdate"
 	ImageCacheRefresh      WorkType = "refresh"
 	ImageCachePurge        WorkType = "purge"
+	// ImageCacheWorkloadSync syncs the image cache synthesized from the workloads of a namespace
+	ImageCacheWorkloadSync WorkType = "workloadsync"
 )
 
 // WorkQueueKey is an item in the sync handler's work queue
@@ -116,20 +191,67 @@ type WorkQueueKey struct {
 	ObjKey        string
 	Status        *map[string]ImageWorkResult
 	OldImageCache *fledgedv1alpha2.ImageCache
+	// Parent is the span of the reconcile whose image work the status update reports
+	Parent tracing.Parent
+	// DeadlineExceeded is true if the image pull deadline was reached before all the jobs of the status
+	// update were done
+	DeadlineExceeded bool
+}
+
+// ImageManagerConfig has the settings of the image manager, which are the flags of kubefledged-controller of
+// the same names unless noted
+type ImageManagerConfig struct {
+	ImagePullDeadlineDuration time.Duration
+	ImagePullDeadlineMax      time.Duration
+	ImagePullDeadlineBase     time.Duration
+	ImagePullBandwidth        int64
+	ImagePullPolicy           string
+	// CRIClientImage and BusyboxImage are the images of the helper containers of the jobs
+	CRIClientImage               string
+	BusyboxImage                 string
+	HelperImagePullPolicy        string
+	ServiceAccountName           string
+	ImageDeleteJobHostNetwork    bool
+	JobPriorityClassName         string
+	JobSchedulerName             string
+	AutomountServiceAccountToken bool
+	JobRunAsUser                 *int64
+	JobPodAnnotations            map[string]string
+	// CanDeleteJob is false if the jobs are retained, as per --job-retention-policy
+	CanDeleteJob               bool
+	CRISocketPath              string
+	ImageDigestVerification    bool
+	PullConcurrencyInitial     int
+	PullConcurrencyMax         int
+	PullConcurrencyCPUsPerPull int
+	MaxParallelDeletesPerNode  int
+	MaxParallelDeletes         int
+	MaxParallelVerifiesPerNode int
+	MaxConcurrentStatusUpdates int
+	// MinFreeDisk and MaxCacheBytesPerNode are in bytes
+	MinFreeDisk             int64
+	MaxCacheBytesPerNode    int64
+	DeleteJobCRIClientArgs  []string
+	DeleteJobCRIClientEnv   []corev1.EnvVar
+	CustomPullerImage       string
+	CustomPullerCommand     []string
+	RateLimitBackoff        time.Duration
+	RateLimitPause          time.Duration
+	DefaultImageRegistry    string
+	RegistryRewrites        map[string]string
+	WatchNamespace          string
+	ManagedImagesAnnotation string
 }
 
-// NewImageManager returns a new image manager object
+// NewImageManager returns a new image manager object. cachedImages returns the images and the repositories of
+// all the image caches
 func NewImageManager(
 	workqueue workqueue.RateLimitingInterface,
 	imageworkqueue workqueue.RateLimitingInterface,
 	kubeclientset kubernetes.Interface,
 	namespace string,
-	imagePullDeadlineDuration time.Duration,
-	criClientImage, busyboxImage, imagePullPolicy, serviceAccountName string,
-	imageDeleteJobHostNetwork bool,
-	jobPriorityClassName string,
-	canDeleteJob bool,
-	criSocketPath string) (*ImageManager, coreinformers.PodInformer) {
+	cachedImages func() (images, repositories []string),
+	config ImageManagerConfig) (*ImageManager, coreinformers.PodInformer) {
 
 	appEqKubefledged, _ := labels.NewRequirement("app", selection.Equals, []string{"kubefledged"})
 	kubefledgedEqImagemanager, _ := labels.NewRequirement("kubefledged", selection.Equals, []string{"kubefledged-image-manager"})
@@ -139,29 +261,65 @@ func NewImageManager(
 	kubeInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(
 		kubeclientset,
 		time.Second*30,
+		kubeinformers.WithNamespace(config.WatchNamespace),
 		kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
 			options.LabelSelector = labelSelector.String()
 		}))
 	podInformer := kubeInformerFactory.Core().V1().Pods()
 
 	imagemanager := &ImageManager{
-		fledgedNameSpace:          namespace,
-		workqueue:                 workqueue,
-		imageworkqueue:            imageworkqueue,
-		kubeclientset:             kubeclientset,
-		imageworkstatus:           make(map[string]ImageWorkResult),
-		kubeInformerFactory:       kubeInformerFactory,
-		podsLister:                podInformer.Lister(),
-		podsSynced:                podInformer.Informer().HasSynced,
-		imagePullDeadlineDuration: imagePullDeadlineDuration,
-		criClientImage:            criClientImage,
-		busyboxImage:              busyboxImage,
-		imagePullPolicy:           imagePullPolicy,
-		serviceAccountName:        serviceAccountName,
-		imageDeleteJobHostNetwork: imageDeleteJobHostNetwork,
-		jobPriorityClassName:      jobPriorityClassName,
-		canDeleteJob:              canDeleteJob,
-		criSocketPath:             criSocketPath,
+		fledgedNameSpace:             namespace,
+		workqueue:                    workqueue,
+		imageworkqueue:               imageworkqueue,
+		verifyqueue:                  newVerifyQueue(),
+		kubeclientset:                kubeclientset,
+		imageworkstatus:              make(map[string]ImageWorkResult),
+		adoptedJobs:                  make(map[adoptedJobKey][]*batchv1.Job),
+		kubeInformerFactory:          kubeInformerFactory,
+		podsLister:                   podInformer.Lister(),
+		podsSynced:                   podInformer.Informer().HasSynced,
+		imagePullDeadlineDuration:    config.ImagePullDeadlineDuration,
+		imagePullDeadlineMax:         config.ImagePullDeadlineMax,
+		criClientImage:               config.CRIClientImage,
+		busyboxImage:                 config.BusyboxImage,
+		imagePullPolicy:              config.ImagePullPolicy,
+		serviceAccountName:           config.ServiceAccountName,
+		imageDeleteJobHostNetwork:    config.ImageDeleteJobHostNetwork,
+		jobPriorityClassName:         config.JobPriorityClassName,
+		jobSchedulerName:             config.JobSchedulerName,
+		canDeleteJob:                 config.CanDeleteJob,
+		jobReaper:                    newJobReaper(),
+		criSocketPath:                config.CRISocketPath,
+		imageDigestVerification:      config.ImageDigestVerification,
+		pullLimiter:                  newPullLimiter(config.PullConcurrencyInitial, config.PullConcurrencyMax, config.PullConcurrencyCPUsPerPull),
+		deleteLimiter:                newDeleteLimiter(config.MaxParallelDeletesPerNode, config.MaxParallelDeletes),
+		verifyLimiter:                newPullLimiter(config.MaxParallelVerifiesPerNode, config.MaxParallelVerifiesPerNode, 0),
+		deferredRequests:             make(map[string]int),
+		nodeImages:                   newNodeImageIndex(config.DefaultImageRegistry),
+		minFreeDisk:                  config.MinFreeDisk,
+		helperImagePullPolicy:        corev1.PullPolicy(config.HelperImagePullPolicy),
+		automountServiceAccountToken: config.AutomountServiceAccountToken,
+		jobRunAsUser:                 config.JobRunAsUser,
+		maxCacheBytesPerNode:         config.MaxCacheBytesPerNode,
+		cachedImages:                 cachedImages,
+		deleteJobCRIClientArgs:       config.DeleteJobCRIClientArgs,
+		deleteJobCRIClientEnv:        config.DeleteJobCRIClientEnv,
+		jobPodAnnotations:            config.JobPodAnnotations,
+		rateLimitBackoff:             config.RateLimitBackoff,
+		rateLimitPause:               config.RateLimitPause,
+		registryPausedUntil:          make(map[string]time.Time),
+		imagePullBandwidth:           config.ImagePullBandwidth,
+		imagePullDeadlineBase:        config.ImagePullDeadlineBase,
+		registryRewrites:             config.RegistryRewrites,
+		imagePulledAt:                make(map[imagePullKey]time.Time),
+		managedImagesAnnotation:      config.ManagedImagesAnnotation,
+		statusUpdates:                newStatusUpdatePool(config.MaxConcurrentStatusUpdates),
+	}
+	if config.CustomPullerImage != "" {
+		imagemanager.customPuller = &customPuller{image: config.CustomPullerImage, command: config.CustomPullerCommand}
+	}
+	imagemanager.freeDisk = func(node *corev1.Node) (int64, error) {
+		return nodeFreeDisk(kubeclientset, node)
 	}
 	podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		//AddFunc: ,
@@ -177,6 +335,10 @@ func NewImageManager(
 			if (newPod.Status.Phase == corev1.PodSucceeded || newPod.Status.Phase == corev1.PodFailed) &&
 				(oldPod.Status.Phase != corev1.PodSucceeded && oldPod.Status.Phase != corev1.PodFailed) {
 				imagemanager.handlePodStatusChange(newPod)
+			} else if isImagePullFailing(newPod) {
+				if !imagemanager.failoverToMirror(newPod) {
+					imagemanager.backoffRateLimitedPull(newPod)
+				}
 			}
 		},
 		//DeleteFunc: ,
@@ -194,10 +356,37 @@ func (m *ImageManager) handlePodStatusChange(pod *corev1.Pod) {
 	if !ok {
 		return
 	}
+	if pod.Status.Phase == corev1.PodFailed && m.backoffRateLimitedPull(pod) {
+		return
+	}
+	if iwres.Status == ImageWorkResultStatusJobCreated {
+		m.releaseJobSlot(iwres, pod.Status.Phase == corev1.PodSucceeded)
+	}
 
 	if pod.Status.Phase == corev1.PodSucceeded {
-		iwres.Status = ImageWorkResultStatusSucceeded
+		if pod.Labels[verifyLabel] != "true" && iwres.ImageWorkRequest.WorkType != ImageCachePurge {
+			m.recordPullMetrics(pod, iwres.ImageWorkRequest)
+			m.recordImagePull(iwres.ImageWorkRequest, time.Now())
+			m.recordManagedImage(iwres.ImageWorkRequest, true)
+		}
 		if iwres.ImageWorkRequest.WorkType == ImageCachePurge {
+			m.forgetImagePull(iwres.ImageWorkRequest)
+			m.recordManagedImage(iwres.ImageWorkRequest, false)
+		}
+		// A digest-pinned image is only reported as cached once its digest is verified on the node
+		if m.imageDigestVerification && pod.Labels[verifyLabel] != "true" &&
+			iwres.ImageWorkRequest.WorkType != ImageCachePurge && imageDigest(iwres.ImageWorkRequest.Image) != "" {
+			m.queueImageVerification(pod.Labels["job-name"], iwres)
+			return
+		}
+		iwres.Status = ImageWorkResultStatusSucceeded
+		iwres.Mirror = mirrorOf(iwres.ImageWorkRequest)
+		if pod.Labels[verifyLabel] == "true" {
+			if len(pod.Status.ContainerStatuses) == 1 && pod.Status.ContainerStatuses[0].State.Terminated != nil {
+				iwres.Digest = strings.TrimSpace(pod.Status.ContainerStatuses[0].State.Terminated.Message)
+			}
+			glog.Infof("Job %s succeeded (verify:- %s --> %s, digest: %s)", pod.Labels["job-name"], iwres.ImageWorkRequest.Image, iwres.ImageWorkRequest.Node.Labels["kubernetes.io/hostname"], iwres.Digest)
+		} else if iwres.ImageWorkRequest.WorkType == ImageCachePurge {
 			glog.Infof("Job %s succeeded (delete:- %s --> %s, runtime: %s)", pod.Labels["job-name"], iwres.ImageWorkRequest.Image, iwres.ImageWorkRequest.Node.Labels["kubernetes.io/hostname"], iwres.ImageWorkRequest.ContainerRuntimeVersion)
 		} else {
 			glog.Infof("Job %s succeeded (pull:- %s --> %s, runtime: %s)", pod.Labels["job-name"], iwres.ImageWorkRequest.Image, iwres.ImageWorkRequest.Node.Labels["kubernetes.io/hostname"], iwres.ImageWorkRequest.ContainerRuntimeVersion)
@@ -214,7 +403,10 @@ func (m *ImageManager) handlePodStatusChange(pod *corev1.Pod) {
 			iwres.Reason = fledgedv1alpha2.ImageCacheReasonImagePullStatusUnknown
 			iwres.Message = fledgedv1alpha2.ImageCacheMessageImagePullStatusUnknown
 		}
-		if iwres.ImageWorkRequest.WorkType == ImageCachePurge {
+		if pod.Labels[verifyLabel] == "true" {
+			iwres.Reason = fledgedv1alpha2.ImageCacheReasonImageDigestVerificationFailed
+			glog.Infof("Job %s failed (verify: %s --> %s)", pod.Labels["job-name"], iwres.ImageWorkRequest.Image, iwres.ImageWorkRequest.Node.Labels["kubernetes.io/hostname"])
+		} else if iwres.ImageWorkRequest.WorkType == ImageCachePurge {
 			glog.Infof("Job %s failed (delete: %s --> %s)", pod.Labels["job-name"], iwres.ImageWorkRequest.Image, iwres.ImageWorkRequest.Node.Labels["kubernetes.io/hostname"])
 		} else {
 			glog.Infof("Job %s failed (pull: %s --> %s)", pod.Labels["job-name"], iwres.ImageWorkRequest.Image, iwres.ImageWorkRequest.Node.Labels["kubernetes.io/hostname"])
@@ -225,21 +417,272 @@ func (m *ImageManager) handlePodStatusChange(pod *corev1.Pod) {
 	m.lock.Unlock()
 }
 
+// startImageVerification replaces the result of a succeeded pull job with a job which verifies the digest of the pulled image
+func (m *ImageManager) startImageVerification(pullJob string, iwres ImageWorkResult) error {
+	job, err := m.verifyImage(iwres.ImageWorkRequest)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if err != nil {
+		glog.Errorf("Error creating digest verification job (%s --> %s): %v", iwres.ImageWorkRequest.Image, iwres.ImageWorkRequest.Node.Labels["kubernetes.io/hostname"], err)
+		iwres.Status = ImageWorkResultStatusFailed
+		iwres.Reason = fledgedv1alpha2.ImageCacheReasonImageDigestVerificationFailed
+		iwres.Message = err.Error()
+		m.imageworkstatus[pullJob] = iwres
+		return err
+	}
+	glog.Infof("Job %s created (verify:- %s --> %s, runtime: %s)", job.Name, iwres.ImageWorkRequest.Image, iwres.ImageWorkRequest.Node.Labels["kubernetes.io/hostname"], iwres.ImageWorkRequest.ContainerRuntimeVersion)
+	delete(m.imageworkstatus, pullJob)
+	m.imageworkstatus[job.Name] = ImageWorkResult{ImageWorkRequest: iwres.ImageWorkRequest, Status: ImageWorkResultStatusJobCreated, verifying: true}
+	if m.canDeleteJob {
+		m.jobReaper.add(iwres.ImageWorkRequest.Imagecache.Namespace, pullJob)
+	}
+	return nil
+}
+
+// failoverToMirror replaces the pull job of the pod, which fails to pull the image, with a job pulling the image
+// from the next mirror of the image cache. Nothing is done if there is no mirror left to try. It returns
+// false if the pull is not failed over
+func (m *ImageManager) failoverToMirror(pod *corev1.Pod) bool {
+	pullJob := pod.Labels["job-name"]
+	m.lock.RLock()
+	iwres, ok := m.imageworkstatus[pullJob]
+	m.lock.RUnlock()
+	if !ok || iwres.Status != ImageWorkResultStatusJobCreated || iwres.verifying ||
+		iwres.ImageWorkRequest.WorkType == ImageCachePurge ||
+		iwres.ImageWorkRequest.mirror >= len(iwres.ImageWorkRequest.Imagecache.Spec.Mirrors) {
+		return false
+	}
+	iwr := iwres.ImageWorkRequest
+	iwr.mirror++
+	job, err := m.pullImage(iwr)
+	if err != nil {
+		glog.Errorf("Error creating job to pull %s from mirror %s: %v", iwr.Image, mirrorOf(iwr), err)
+		return false
+	}
+	glog.Infof("Job %s created (pull:- %s --> %s, mirror: %s)", job.Name, iwr.Image, iwr.Node.Labels["kubernetes.io/hostname"], mirrorOf(iwr))
+	m.lock.Lock()
+	delete(m.imageworkstatus, pullJob)
+	m.imageworkstatus[job.Name] = ImageWorkResult{ImageWorkRequest: iwr, Status: ImageWorkResultStatusJobCreated, deadline: m.sizedPullDeadline(iwr)}
+	m.lock.Unlock()
+	// the failing job would otherwise keep retrying the pull until its deadline. It is left in
+	// place for debugging if RetentionPolicy is Retain
+	if !m.canDeleteJob {
+		return true
+	}
+	deletePropagation := metav1.DeletePropagationBackground
+	if err := m.kubeclientset.BatchV1().Jobs(iwr.Imagecache.Namespace).
+		Delete(context.TODO(), pullJob, metav1.DeleteOptions{PropagationPolicy: &deletePropagation}); err != nil {
+		glog.Warningf("Error deleting job %s: %v", pullJob, err)
+	}
+	return true
+}
+
+// HandleNodeDeletion abandons the jobs in flight on a deleted node, whose pods would otherwise wait to be
+// scheduled until the image pull deadline. The jobs are deleted, unless RetentionPolicy is Retain, and their
+// results are recorded with reason NodeDeleted, which is not reported as a failure. The pulls of image lists
+// with retargetOnNodeDeletion are returned instead. They hold back the status update of their image cache
+// until they are passed to RetargetImageWork
+func (m *ImageManager) HandleNodeDeletion(node *corev1.Node) (retargets []ImageWorkRequest) {
+	abandonedJobs := map[string]string{}
+	m.lock.Lock()
+	for job, iwres := range m.imageworkstatus {
+		if iwres.Status != ImageWorkResultStatusJobCreated || iwres.ImageWorkRequest.Node == nil ||
+			iwres.ImageWorkRequest.Node.Name != node.Name {
+			continue
+		}
+		delete(m.imageworkstatus, job)
+		abandonedJobs[job] = iwres.ImageWorkRequest.Imagecache.Namespace
+		if iwr := iwres.ImageWorkRequest; !iwres.verifying && iwr.WorkType != ImageCachePurge && retargetOnNodeDeletion(iwr) {
+			glog.Infof("Job %s abandoned (%s:- %s --> %s): node deleted, retargeting", job, iwr.WorkType, iwr.Image, node.Labels["kubernetes.io/hostname"])
+			m.deferredRequests[cacheKey(iwr.Imagecache)]++
+			iwr.deferred = true
+			retargets = append(retargets, iwr)
+			continue
+		}
+		glog.Infof("Job %s abandoned (%s:- %s --> %s): node deleted", job, iwres.ImageWorkRequest.WorkType, iwres.ImageWorkRequest.Image, node.Labels["kubernetes.io/hostname"])
+		iwres.Status = ImageWorkResultStatusNodeDeleted
+		iwres.Reason = fledgedv1alpha2.ImageCacheReasonNodeDeleted
+		iwres.Message = fmt.Sprintf("Node %s was deleted before job %s completed", node.Name, job)
+		m.imageworkstatus[fakeJobName(iwres.ImageWorkRequest)] = iwres
+	}
+	m.lock.Unlock()
+	if m.pullLimiter != nil {
+		m.pullLimiter.forget(node.Name)
+	}
+	if m.deleteLimiter != nil {
+		m.deleteLimiter.forget(node.Name)
+	}
+	if m.verifyLimiter != nil {
+		m.verifyLimiter.forget(node.Name)
+	}
+	// delete the jobs if RetentionPolicy is not Retain
+	if !m.canDeleteJob {
+		return
+	}
+	deletePropagation := metav1.DeletePropagationBackground
+	for job, namespace := range abandonedJobs {
+		if err := m.kubeclientset.BatchV1().Jobs(namespace).
+			Delete(context.TODO(), job, metav1.DeleteOptions{PropagationPolicy: &deletePropagation}); err != nil && !apierrors.IsNotFound(err) {
+			glog.Warningf("Error deleting job %s: %v", job, err)
+		}
+	}
+	return
+}
+
+// RetargetImageWork places a pull returned by HandleNodeDeletion back on the imageworkqueue, to pull the image
+// to the node. If node is nil, the result of the pull is recorded with reason NodeDeleted
+func (m *ImageManager) RetargetImageWork(iwr ImageWorkRequest, node *corev1.Node) {
+	if node == nil {
+		m.lock.Lock()
+		m.imageworkstatus[fakeJobName(iwr)] = ImageWorkResult{
+			ImageWorkRequest: iwr,
+			Status:           ImageWorkResultStatusNodeDeleted,
+			Reason:           fledgedv1alpha2.ImageCacheReasonNodeDeleted,
+			Message:          fmt.Sprintf("Node %s was deleted and no other node was available to pull the image to", iwr.Node.Name),
+		}
+		m.lock.Unlock()
+		m.undeferImageWorkRequest(iwr)
+		return
+	}
+	glog.Infof("Pull of %s retargeted from node %s to node %s", iwr.Image, iwr.Node.Labels["kubernetes.io/hostname"], node.Labels["kubernetes.io/hostname"])
+	iwr.Node = node
+	iwr.ContainerRuntimeVersion = node.Status.NodeInfo.ContainerRuntimeVersion
+	m.imageworkqueue.AddRateLimited(iwr)
+}
+
+// InvalidateNodeImages drops the indexed images of the node, when the node is updated or deleted
+func (m *ImageManager) InvalidateNodeImages(nodeName string) {
+	m.nodeImages.invalidate(nodeName)
+}
+
+// NodesWithImageWork returns the names of the nodes which have a result or a job in flight for the image of the imagecache
+func (m *ImageManager) NodesWithImageWork(imagecache *fledgedv1alpha2.ImageCache, image string) map[string]bool {
+	nodes := map[string]bool{}
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	for _, iwres := range m.imageworkstatus {
+		iwr := iwres.ImageWorkRequest
+		if iwr.Image == image && iwr.Node != nil && iwr.Imagecache != nil && cacheKey(iwr.Imagecache) == cacheKey(imagecache) {
+			nodes[iwr.Node.Name] = true
+		}
+	}
+	return nodes
+}
+
+// JobsInFlight returns the no. of jobs in flight of each image cache on each node, by the namespace/name
+// of the image cache and the name of the node
+func (m *ImageManager) JobsInFlight() map[string]map[string]int {
+	jobs := map[string]map[string]int{}
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	for _, iwres := range m.imageworkstatus {
+		iwr := iwres.ImageWorkRequest
+		if iwres.Status != ImageWorkResultStatusJobCreated || iwr.Node == nil || iwr.Imagecache == nil {
+			continue
+		}
+		key := cacheKey(iwr.Imagecache)
+		if jobs[key] == nil {
+			jobs[key] = map[string]int{}
+		}
+		jobs[key][iwr.Node.Name]++
+	}
+	return jobs
+}
+
+// pendingJobEvents identifies the failure events of the pod of a job which has not completed in time
+type pendingJobEvents struct {
+	job       string
+	namespace string
+	pod       string
+}
+
+// updatePendingImageWorkResults resolves the results of jobs which have not yet reported completion.
+// The pods of the jobs are read from the informer cache while holding the lock. The failure events of
+// their pods are listed with a jittered backoff after the lock is released, so that a slow API server
+// does not stall the other users of the lock. If listing the events keeps failing, the results gathered
+// from the pods are retained and the first listing error is returned once all the jobs have been processed.
 func (m *ImageManager) updatePendingImageWorkResults(imageCacheName string) error {
+	pending, err := m.resolvePendingImageWorkResults(imageCacheName)
+	if err != nil {
+		return err
+	}
+	var listErr error
+	messages := map[string]string{}
+	for _, p := range pending {
+		fieldSelector := fields.Set{
+			"involvedObject.kind":      "Pod",
+			"involvedObject.name":      p.pod,
+			"involvedObject.namespace": p.namespace,
+			"reason":                   "Failed",
+		}.AsSelector().String()
+
+		var eventlist *corev1.EventList
+		err := retry.OnError(listRetryBackoff, IsTransientAPIError, func() (err error) {
+			eventlist, err = m.kubeclientset.CoreV1().Events(p.namespace).
+				List(context.TODO(), metav1.ListOptions{FieldSelector: fieldSelector})
+			return
+		})
+		if err != nil {
+			// the result gathered from the pod is kept, only the event messages are missing
+			glog.Errorf("Error listing events for pod (%s): %v", p.pod, err)
+			if listErr == nil {
+				listErr = err
+			}
+			continue
+		}
+		for _, v := range eventlist.Items {
+			messages[p.job] = messages[p.job] + ":" + v.Message
+		}
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for job, message := range messages {
+		if iwres, ok := m.imageworkstatus[job]; ok && iwres.Status == ImageWorkResultStatusFailed {
+			iwres.Message = iwres.Message + message
+			m.imageworkstatus[job] = iwres
+		}
+	}
+	glog.V(4).Infof("imageworkstatus map: %+v", m.imageworkstatus)
+	return listErr
+}
+
+// resolvePendingImageWorkResults fails the jobs of the image cache which have not yet reported completion with
+// the state of their pod. It returns the jobs whose pod's failure events are to be added to their result.
+func (m *ImageManager) resolvePendingImageWorkResults(imageCacheName string) ([]pendingJobEvents, error) {
+	var pending []pendingJobEvents
 	m.lock.Lock()
 	defer m.lock.Unlock()
 	for job, iwres := range m.imageworkstatus {
 		if iwres.ImageWorkRequest.Imagecache.Name == imageCacheName {
 			if iwres.Status == ImageWorkResultStatusJobCreated {
+				// the job has not completed in time, its pull is counted as failed
+				m.releaseJobSlot(iwres, false)
+				if iwres.pendingVerification {
+					glog.Warningf("Job %s not verified in time (verify: %s --> %s)", job, iwres.ImageWorkRequest.Image, iwres.ImageWorkRequest.Node.Labels["kubernetes.io/hostname"])
+					iwres.Status = ImageWorkResultStatusFailed
+					iwres.Reason = fledgedv1alpha2.ImageCacheReasonImageDigestVerificationFailed
+					iwres.Message = "Digest verification not started in time: too many verifications queued"
+					m.imageworkstatus[job] = iwres
+					continue
+				}
+				if iwres.backingOff {
+					glog.Warningf("Job %s rate-limited (pull: %s --> %s), not retried in time", job, iwres.ImageWorkRequest.Image, iwres.ImageWorkRequest.Node.Labels["kubernetes.io/hostname"])
+					iwres.Status = ImageWorkResultStatusFailed
+					iwres.Reason = fledgedv1alpha2.ImageCacheReasonRateLimited
+					iwres.Message = fledgedv1alpha2.ImageCacheMessageRateLimited
+					m.imageworkstatus[job] = iwres
+					continue
+				}
 				pods, err := m.podsLister.Pods(iwres.ImageWorkRequest.Imagecache.Namespace).
 					List(labels.Set(map[string]string{"job-name": job}).AsSelector())
 				if err != nil {
 					glog.Errorf("Error listing Pods: %v", err)
-					return err
+					return nil, err
 				}
 				if len(pods) > 1 {
 					glog.Errorf("More than one pod matched job %s", job)
-					return fmt.Errorf("more than one pod matched job %s", job)
+					return nil, fmt.Errorf("more than one pod matched job %s", job)
 				}
 				if len(pods) == 0 {
 					glog.Warningf("No pods matched job %s", job)
@@ -275,61 +718,71 @@ func (m *ImageManager) updatePendingImageWorkResults(imageCacheName string) erro
 						}
 					}
 					if iwres.ImageWorkRequest.WorkType != ImageCachePurge {
-						fieldSelector := fields.Set{
-							"involvedObject.kind":      "Pod",
-							"involvedObject.name":      pods[0].Name,
-							"involvedObject.namespace": iwres.ImageWorkRequest.Imagecache.Namespace,
-							"reason":                   "Failed",
-						}.AsSelector().String()
-
-						eventlist, err := m.kubeclientset.CoreV1().Events(iwres.ImageWorkRequest.Imagecache.Namespace).
-							List(context.TODO(), metav1.ListOptions{FieldSelector: fieldSelector})
-						if err != nil {
-							glog.Errorf("Error listing events for pod (%s): %v", pods[0].Name, err)
-							return err
-						}
-
-						for _, v := range eventlist.Items {
-							iwres.Message = iwres.Message + ":" + v.Message
-						}
+						pending = append(pending, pendingJobEvents{
+							job:       job,
+							namespace: iwres.ImageWorkRequest.Imagecache.Namespace,
+							pod:       pods[0].Name,
+						})
 					}
 				}
 				m.imageworkstatus[job] = iwres
 			}
 		}
 	}
-	glog.V(4).Infof("imageworkstatus map: %+v", m.imageworkstatus)
-	return nil
+	return pending, nil
 }
 
-func (m *ImageManager) updateImageCacheStatus(imageCache *fledgedv1alpha2.ImageCache, errCh chan<- error) {
-	wait.Poll(time.Second, m.imagePullDeadlineDuration,
-		func() (done bool, err error) {
-			m.lock.RLock()
-			defer m.lock.RUnlock()
-			done, err = true, nil
-			for _, iwres := range m.imageworkstatus {
-				if iwres.ImageWorkRequest.Imagecache.Name == imageCache.Name {
-					if iwres.Status == ImageWorkResultStatusJobCreated {
-						done, err = false, nil
-						return
-					}
+func (m *ImageManager) updateImageCacheStatus(ctx context.Context, imageCache *fledgedv1alpha2.ImageCache, errCh chan<- error) {
+	ctx, span := tracing.Start(ctx, "ImageManager.updateImageCacheStatus",
+		tracing.CacheKey.String(cacheKey(imageCache)))
+	var spanErr error
+	defer func() { tracing.End(span, spanErr) }()
+	jobsDone := func() (done bool, err error) {
+		m.lock.RLock()
+		defer m.lock.RUnlock()
+		// pulls held back or being retargeted are still to be placed in the imageworkstatus map
+		if m.deferredRequests[cacheKey(imageCache)] > 0 {
+			return false, nil
+		}
+		done, err = true, nil
+		now := time.Now()
+		for _, iwres := range m.imageworkstatus {
+			if iwres.ImageWorkRequest.Imagecache.Name == imageCache.Name {
+				// pulls past their deadline sized to their image are failed without waiting for the others
+				if iwres.Status == ImageWorkResultStatusJobCreated && (iwres.deadline.IsZero() || now.Before(iwres.deadline)) {
+					done, err = false, nil
+					return
 				}
 			}
-			return
-		})
-	glog.V(4).Info("wait.Poll exited successfully")
-	err := m.updatePendingImageWorkResults(imageCache.Name)
-	if err != nil {
-		glog.Errorf("Error from updatePendingImageWorkResults(): %v", err)
-		errCh <- err
+		}
 		return
 	}
-	glog.V(4).Info("m.updatePendingImageWorkResults exited successfully")
+	deadlineExceeded := false
+	if err := wait.Poll(time.Second, m.jobDeadline(imageCache), jobsDone); err != nil && !m.waitSizedPullDeadlines(imageCache, jobsDone) {
+		deadlineExceeded = !m.extendPullDeadline(imageCache, jobsDone)
+	}
+	if deadlineExceeded {
+		glog.Warningf("Image pull deadline of image cache %s reached before all its jobs were done", imageCache.Name)
+	} else {
+		glog.V(4).Info("wait.Poll exited successfully")
+	}
+	updateErr := m.updatePendingImageWorkResults(imageCache.Name)
+	if updateErr != nil && !IsTransientAPIError(updateErr) {
+		glog.Errorf("Error from updatePendingImageWorkResults(): %v", updateErr)
+		spanErr = updateErr
+		errCh <- updateErr
+		return
+	}
+	if updateErr != nil {
+		// Listing kept failing even after retrying. Rather than dropping the status update,
+		// queue it with the results collected so far
+		glog.Warningf("Queueing partial status for image cache %s: %v", imageCache.Name, updateErr)
+	} else {
+		glog.V(4).Info("m.updatePendingImageWorkResults exited successfully")
+	}
 	//m.lock.Lock()
 	iwstatus := map[string]ImageWorkResult{}
 	//m.lock.Unlock()
-	deletePropagation := metav1.DeletePropagationBackground
 	var iwstatusLock sync.RWMutex
 	m.lock.Lock()
 	for job, iwres := range m.imageworkstatus {
@@ -339,42 +792,45 @@ func (m *ImageManager) updateImageCacheStatus(imageCache *fledgedv1alpha2.ImageC
 			iwstatusLock.Unlock()
 			imageCache = iwres.ImageWorkRequest.Imagecache
 			delete(m.imageworkstatus, job)
-			// delete the job if RetentionPolicy is not Retain
+			// the results of the job were read above. The job is deleted by the job reaper, off the
+			// status update, if RetentionPolicy is not Retain
 			if !strings.HasPrefix(job, fakeJobPrefix) && m.canDeleteJob {
-				if err := m.kubeclientset.BatchV1().Jobs(imageCache.Namespace).
-					Delete(context.TODO(), job, metav1.DeleteOptions{PropagationPolicy: &deletePropagation}); err != nil {
-					// if for some reason the job cannot be deleted, we'll not retry. rather we continue processing the remaining jobs
-					if strings.Contains(err.Error(), "not found") {
-						glog.Warningf("Error deleting job %s: %s", job, "not found")
-					} else {
-						glog.Errorf("Error deleting job %s: %v", job, err)
-					}
-					//m.lock.Unlock()
-					//errCh <- err
-					//return
-				}
+				m.jobReaper.add(imageCache.Namespace, job)
 			}
 		}
 	}
 	m.lock.Unlock()
 	if imageCache == nil {
 		glog.Errorf("Unable to obtain reference to image cache")
-		errCh <- fmt.Errorf("unable to obtain reference to image cache")
+		spanErr = fmt.Errorf("unable to obtain reference to image cache")
+		errCh <- spanErr
 		return
 	}
 	objKey, err := cache.MetaNamespaceKeyFunc(imageCache)
 	if err != nil {
 		glog.Errorf("Error from cache.MetaNamespaceKeyFunc(imageCache): %v", err)
+		spanErr = err
 		errCh <- err
 		return
 	}
 	m.workqueue.AddRateLimited(WorkQueueKey{
-		WorkType: ImageCacheStatusUpdate,
-		Status:   &iwstatus,
-		ObjKey:   objKey,
+		WorkType:         ImageCacheStatusUpdate,
+		Status:           &iwstatus,
+		ObjKey:           objKey,
+		Parent:           tracing.ParentOf(ctx),
+		DeadlineExceeded: deadlineExceeded,
 	})
 
-	errCh <- nil
+	spanErr = updateErr
+	errCh <- updateErr
+}
+
+// jobDeadline returns how long the jobs of the image cache are waited for before their results are resolved
+func (m *ImageManager) jobDeadline(imageCache *fledgedv1alpha2.ImageCache) time.Duration {
+	if imageCache.Spec.JobDeadlineSeconds != nil {
+		return time.Duration(*imageCache.Spec.JobDeadlineSeconds) * time.Second
+	}
+	return m.imagePullDeadlineDuration
 }
 
 // Run starts the Image Manager go routine
@@ -387,10 +843,15 @@ func (m *ImageManager) Run(stopCh <-chan struct{}) error {
 	if ok := cache.WaitForCacheSync(stopCh, m.podsSynced); !ok {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
+	defer m.verifyqueue.ShutDown()
 	go wait.Until(m.runWorker, time.Second, stopCh)
+	go wait.Until(m.runVerifyWorker, time.Second, stopCh)
+	go wait.Until(func() { m.jobReaper.reap(m.kubeclientset) }, jobReapInterval, stopCh)
 	glog.Info("Started image manager")
 	<-stopCh
 	glog.Info("Shutting down image manager")
+	// the jobs completed since the last batch are deleted before exiting
+	m.jobReaper.reap(m.kubeclientset)
 	return nil
 }
 
@@ -441,35 +902,101 @@ func (m *ImageManager) processNextWorkItem() bool {
 		// have been placed in the workqueue by the controller. The controller is waiting for status update
 		if iwr.Image == "" && iwr.Node == nil {
 			m.imageworkqueue.Forget(obj)
-			errCh := make(chan error)
-			go m.updateImageCacheStatus(iwr.Imagecache, errCh)
+			// Requests held back by the pull or delete limiter have not been placed in the imageworkstatus
+			// map yet. Wait for them before starting the status update
+			if m.hasDeferredRequests(iwr.Imagecache) {
+				m.imageworkqueue.AddAfter(obj, pullLimiterRequeueDelay)
+				return nil
+			}
+			// all the image work of the reconcile has been processed, so adopted jobs left unclaimed are not needed
+			m.releaseAdoptedJobs(iwr.Imagecache)
+			// the error of the status update is not read here, so the channel is buffered for its send not to
+			// block the update, which would hold its slot of the pool forever
+			errCh := make(chan error, 1)
+			// the image pull deadline of the image cache starts once its status update gets a slot
+			go m.statusUpdates.run(func() { m.updateImageCacheStatus(iwr.Parent.Context(), iwr.Imagecache, errCh) })
 			return nil
 		}
 		// Run the syncHandler, passing it the namespace/name string of the
 		// ImageCache resource to be synced.
 		var job *batchv1.Job
 		var err error
-		var pull, delete bool
+		var pull, delete, requeued, adopted bool
+		if iwr.deferred {
+			defer func() {
+				if !requeued {
+					m.undeferImageWorkRequest(iwr)
+				}
+			}()
+		}
 		if iwr.WorkType == ImageCachePurge {
+			if m.deleteLimiter != nil && !m.deleteLimiter.acquire(iwr.Node) {
+				if m.deleteLimiter.atTotal() {
+					glog.V(4).Infof("Delete of %s deferred, the limit of %d concurrent deletes across the nodes is reached",
+						iwr.Image, m.deleteLimiter.total)
+				} else {
+					glog.V(4).Infof("Delete of %s deferred, node %s is at its limit of %d concurrent deletes",
+						iwr.Image, iwr.Node.Labels["kubernetes.io/hostname"], m.deleteLimiter.limit(iwr.Node.Name))
+				}
+				requeued = true
+				m.deferImageWorkRequest(obj, iwr)
+				return nil
+			}
 			delete = true
-			job, err = m.deleteImage(iwr)
-			if err != nil {
-				return fmt.Errorf("error deleting image '%s' from node '%s': %s", iwr.Image, iwr.Node.Labels["kubernetes.io/hostname"], err.Error())
+			if job = m.claimAdoptedJob(iwr, jobWorkDelete); job != nil {
+				adopted = true
+				glog.Infof("Job %s claimed (delete:- %s --> %s, runtime: %s)", job.Name, iwr.Image, iwr.Node.Labels["kubernetes.io/hostname"], iwr.ContainerRuntimeVersion)
+			} else {
+				job, err = m.deleteImage(iwr)
+				if err != nil && m.deleteLimiter != nil {
+					m.deleteLimiter.release(iwr.Node.Name, false)
+				}
+				if errors.Is(err, ErrNodeNotReady) || errors.Is(err, ErrJobTemplateNotFound) || errors.Is(err, ErrImageNotManaged) ||
+					errors.Is(err, ErrRuntimeUnsupported) {
+					m.recordImageWorkFailure(iwr, err)
+					m.imageworkqueue.Forget(obj)
+					return nil
+				}
+				if err != nil {
+					return &ImageWorkError{WorkType: iwr.WorkType, Image: iwr.Image, Node: iwr.Node.Labels["kubernetes.io/hostname"], Err: err}
+				}
+				glog.Infof("Job %s created (delete:- %s --> %s, runtime: %s)", job.Name, iwr.Image, iwr.Node.Labels["kubernetes.io/hostname"], iwr.ContainerRuntimeVersion)
 			}
-			glog.Infof("Job %s created (delete:- %s --> %s, runtime: %s)", job.Name, iwr.Image, iwr.Node.Labels["kubernetes.io/hostname"], iwr.ContainerRuntimeVersion)
 		} else {
-			pull = true
-			pull, err = checkIfImageNeedsToBePulled(m.imagePullPolicy, iwr.Image, iwr.Node)
-			if err != nil {
-				glog.Errorf("Error from checkIfImageNeedsToBePulled(): %+v", err)
-				return fmt.Errorf("error from checkIfImageNeedsToBePulled(): %+v", err)
+			pull = imageNeedsToBePulled(m.imagePullPolicy, iwr, m.nodeImages) || m.imageExpired(iwr, time.Now())
+			if pull && m.registryPause(m.pullRegistry(iwr)) > 0 {
+				glog.V(4).Infof("Pull of %s deferred, registry %s is paused after rate-limiting a pull", iwr.Image, m.pullRegistry(iwr))
+				requeued = true
+				m.deferImageWorkRequest(obj, iwr)
+				return nil
 			}
 			if pull {
-				job, err = m.pullImage(iwr)
-				if err != nil {
-					return fmt.Errorf("error pulling image '%s' to node '%s': %s", iwr.Image, iwr.Node.Labels["kubernetes.io/hostname"], err.Error())
+				if m.pullLimiter != nil && !m.pullLimiter.acquire(iwr.Node) {
+					glog.V(4).Infof("Pull of %s deferred, node %s is at its limit of %d concurrent pulls",
+						iwr.Image, iwr.Node.Labels["kubernetes.io/hostname"], m.pullLimiter.limit(iwr.Node.Name))
+					requeued = true
+					m.deferImageWorkRequest(obj, iwr)
+					return nil
+				}
+				if job = m.claimAdoptedJob(iwr, jobWorkPull); job != nil {
+					adopted = true
+					glog.Infof("Job %s claimed (pull:- %s --> %s, runtime: %s)", job.Name, iwr.Image, iwr.Node.Labels["kubernetes.io/hostname"], iwr.ContainerRuntimeVersion)
+				} else {
+					job, err = m.pullImage(iwr)
+					if err != nil && m.pullLimiter != nil {
+						m.pullLimiter.release(iwr.Node.Name, false)
+					}
+					if errors.Is(err, ErrNodeNotReady) || errors.Is(err, ErrInsufficientDisk) ||
+						errors.Is(err, ErrBudgetExceeded) || errors.Is(err, ErrJobTemplateNotFound) {
+						m.recordImageWorkFailure(iwr, err)
+						m.imageworkqueue.Forget(obj)
+						return nil
+					}
+					if err != nil {
+						return &ImageWorkError{WorkType: iwr.WorkType, Image: iwr.Image, Node: iwr.Node.Labels["kubernetes.io/hostname"], Err: err}
+					}
+					glog.Infof("Job %s created (pull:- %s --> %s, runtime: %s)", job.Name, iwr.Image, iwr.Node.Labels["kubernetes.io/hostname"], iwr.ContainerRuntimeVersion)
 				}
-				glog.Infof("Job %s created (pull:- %s --> %s, runtime: %s)", job.Name, iwr.Image, iwr.Node.Labels["kubernetes.io/hostname"], iwr.ContainerRuntimeVersion)
 			} else {
 				glog.Infof("Job not created (image-already-present:- %s --> %s, runtime: %s)", iwr.Image, iwr.Node.Labels["kubernetes.io/hostname"], iwr.ContainerRuntimeVersion)
 			}
@@ -477,13 +1004,18 @@ func (m *ImageManager) processNextWorkItem() bool {
 		// Finally, if no error occurs we Forget this item so it does not
 		// get queued again until another change happens.
 		m.lock.Lock()
-		if pull || delete {
+		if pull {
+			m.imageworkstatus[job.Name] = ImageWorkResult{ImageWorkRequest: iwr, Status: ImageWorkResultStatusJobCreated, deadline: m.sizedPullDeadline(iwr)}
+		} else if delete {
 			m.imageworkstatus[job.Name] = ImageWorkResult{ImageWorkRequest: iwr, Status: ImageWorkResultStatusJobCreated}
 		} else {
-			// generate a random fake job name
-			m.imageworkstatus[names.SimpleNameGenerator.GenerateName(fakeJobPrefix)] = ImageWorkResult{ImageWorkRequest: iwr, Status: ImageWorkResultStatusAlreadyPulled}
+			// generate a fake job name encoding the work
+			m.imageworkstatus[fakeJobName(iwr)] = ImageWorkResult{ImageWorkRequest: iwr, Status: ImageWorkResultStatusAlreadyPulled}
 		}
 		m.lock.Unlock()
+		if adopted {
+			m.resolveAdoptedJob(job)
+		}
 		m.imageworkqueue.Forget(obj)
 		return nil
 	}(obj)
@@ -496,17 +1028,128 @@ func (m *ImageManager) processNextWorkItem() bool {
 	return true
 }
 
+// deferImageWorkRequest places a request held back by the pull or delete limiter back on the imageworkqueue
+func (m *ImageManager) deferImageWorkRequest(obj interface{}, iwr ImageWorkRequest) {
+	m.imageworkqueue.Forget(obj)
+	if !iwr.deferred {
+		m.lock.Lock()
+		m.deferredRequests[cacheKey(iwr.Imagecache)]++
+		m.lock.Unlock()
+		iwr.deferred = true
+	}
+	m.imageworkqueue.AddAfter(iwr, pullLimiterRequeueDelay)
+}
+
+// undeferImageWorkRequest removes a deferred request of the imagecache once it has been processed
+func (m *ImageManager) undeferImageWorkRequest(iwr ImageWorkRequest) {
+	key := cacheKey(iwr.Imagecache)
+	m.lock.Lock()
+	if m.deferredRequests[key]--; m.deferredRequests[key] <= 0 {
+		delete(m.deferredRequests, key)
+	}
+	m.lock.Unlock()
+}
+
+// hasDeferredRequests returns true if requests of the imagecache are held back by the pull or delete limiter
+func (m *ImageManager) hasDeferredRequests(imagecache *fledgedv1alpha2.ImageCache) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.deferredRequests[cacheKey(imagecache)] > 0
+}
+
+// releaseJobSlot gives back the slot held by the pull job of the result in the pull limiter, by the delete
+// job of the result in the delete limiter, or by the verify job of the result in the verify limiter. A pull
+// waiting for its verification has already given back its slot
+func (m *ImageManager) releaseJobSlot(iwres ImageWorkResult, succeeded bool) {
+	limiter := m.pullLimiter
+	switch {
+	case iwres.pendingVerification:
+		return
+	case iwres.verifying:
+		limiter = m.verifyLimiter
+	case iwres.ImageWorkRequest.WorkType == ImageCachePurge:
+		limiter = m.deleteLimiter
+	}
+	if limiter == nil || iwres.ImageWorkRequest.Node == nil {
+		return
+	}
+	limiter.release(iwres.ImageWorkRequest.Node.Name, succeeded)
+}
+
+// cacheKey returns the namespace/name key of the imagecache
+func cacheKey(imagecache *fledgedv1alpha2.ImageCache) string {
+	return imagecache.Namespace + "/" + imagecache.Name
+}
+
+// imageWorkFailureReason returns the reason of the error for which no job of a work request could be created,
+// or fallback if the error has none
+func imageWorkFailureReason(err error, fallback string) string {
+	switch {
+	case errors.Is(err, ErrNodeNotReady):
+		return fledgedv1alpha2.ImageCacheReasonNodeNotReady
+	case errors.Is(err, ErrInsufficientDisk):
+		return fledgedv1alpha2.ImageCacheReasonInsufficientDisk
+	case errors.Is(err, ErrBudgetExceeded):
+		return fledgedv1alpha2.ImageCacheReasonBudgetExceeded
+	case errors.Is(err, ErrJobTemplateNotFound):
+		return fledgedv1alpha2.ImageCacheReasonJobTemplateNotFound
+	case errors.Is(err, ErrImageNotManaged):
+		return fledgedv1alpha2.ImageCacheReasonImageNotManaged
+	case errors.Is(err, ErrRuntimeUnsupported):
+		return fledgedv1alpha2.ImageCacheReasonUnsupportedRuntime
+	}
+	return fallback
+}
+
+// recordImageWorkFailure records a failed result for a work request for which no job could be created
+func (m *ImageManager) recordImageWorkFailure(iwr ImageWorkRequest, err error) {
+	reason := imageWorkFailureReason(err, fledgedv1alpha2.ImageCacheReasonJobNotCreated)
+	glog.Warningf("Job not created (%s:- %s --> %s): %v", iwr.WorkType, iwr.Image, iwr.Node.Labels["kubernetes.io/hostname"], err)
+	m.lock.Lock()
+	m.imageworkstatus[fakeJobName(iwr)] = ImageWorkResult{
+		ImageWorkRequest: iwr,
+		Status:           ImageWorkResultStatusFailed,
+		Reason:           reason,
+		Message:          err.Error(),
+	}
+	m.lock.Unlock()
+}
+
 // pullImage pulls the image to the node
-func (m *ImageManager) pullImage(iwr ImageWorkRequest) (*batchv1.Job, error) {
+func (m *ImageManager) pullImage(iwr ImageWorkRequest) (job *batchv1.Job, err error) {
+	ctx, span := tracing.Start(iwr.Parent.Context(), "ImageManager.pullImage", spanAttributes(iwr)...)
+	defer func() { tracing.End(span, err) }()
+	if iwr.Node != nil && !IsNodeReady(iwr.Node) {
+		return nil, fmt.Errorf("%w: %s", ErrNodeNotReady, iwr.Node.Labels["kubernetes.io/hostname"])
+	}
+	if iwr.Node != nil {
+		if err := m.checkFreeDisk(iwr.Node); err != nil {
+			return nil, err
+		}
+		if err := m.checkCacheBudget(iwr); err != nil {
+			return nil, err
+		}
+	}
 	// Construct the Job manifest
-	newjob, err := newImagePullJob(iwr.Imagecache, iwr.Image, iwr.Node, m.imagePullPolicy,
-		m.busyboxImage, m.serviceAccountName, m.jobPriorityClassName)
+	newjob, err := newImagePullJob(iwr.Imagecache, m.targetImage(iwr), iwr.Node, m.pullPolicy(iwr),
+		m.busyboxImage, m.serviceAccountName, m.jobPriorityClassName, m.jobSchedulerName, m.helperImagePullPolicy,
+		m.automountServiceAccountToken, m.jobRunAsUser)
 	if err != nil {
 		glog.Errorf("Error when constructing job manifest: %v", err)
 		return nil, err
 	}
+	if m.customPuller != nil {
+		m.customPuller.setOn(newjob, cacheKey(iwr.Imagecache), m.targetImage(iwr), iwr.Node, m.criSocketPath,
+			m.helperImagePullPolicy, m.jobRunAsUser)
+	}
+	if err := m.setJobTemplate(ctx, newjob, iwr.Imagecache); err != nil {
+		return nil, err
+	}
+	setJobPodAnnotations(newjob, m.jobPodAnnotations)
+	setJobName(newjob, iwr)
+	setJobWork(newjob, jobWorkPull)
 	// Create a Job to pull the image into the node
-	job, err := m.kubeclientset.BatchV1().Jobs(iwr.Imagecache.Namespace).Create(context.TODO(), newjob, metav1.CreateOptions{})
+	job, err = m.kubeclientset.BatchV1().Jobs(iwr.Imagecache.Namespace).Create(ctx, newjob, metav1.CreateOptions{})
 	if err != nil {
 		glog.Errorf("Error creating job in node %s: %v", iwr.Node, err)
 		return nil, err
@@ -514,20 +1157,73 @@ func (m *ImageManager) pullImage(iwr ImageWorkRequest) (*batchv1.Job, error) {
 	return job, nil
 }
 
+// verifyImage verifies the digest of the image pulled to the node
+func (m *ImageManager) verifyImage(iwr ImageWorkRequest) (*batchv1.Job, error) {
+	// Construct the Job manifest
+	newjob, err := newImageVerifyJob(iwr.Imagecache, m.targetImage(iwr), iwr.Node, iwr.ContainerRuntimeVersion,
+		m.criClientImage, m.serviceAccountName, m.jobPriorityClassName, m.jobSchedulerName, m.criSocketPath, m.helperImagePullPolicy,
+		m.automountServiceAccountToken, m.jobRunAsUser)
+	if err != nil {
+		glog.Errorf("Error when constructing job manifest: %v", err)
+		return nil, err
+	}
+	if err := m.setJobTemplate(context.TODO(), newjob, iwr.Imagecache); err != nil {
+		return nil, err
+	}
+	setJobPodAnnotations(newjob, m.jobPodAnnotations)
+	setJobName(newjob, iwr)
+	setJobWork(newjob, jobWorkVerify)
+	// Create a Job to verify the image in the node
+	job, err := m.kubeclientset.BatchV1().Jobs(iwr.Imagecache.Namespace).Create(context.TODO(), newjob, metav1.CreateOptions{})
+	if err != nil {
+		glog.Errorf("Error creating job in node %s: %v", iwr.Node.Labels["kubernetes.io/hostname"], err)
+		return nil, err
+	}
+	return job, nil
+}
+
 // deleteImage deletes the image from the node
-func (m *ImageManager) deleteImage(iwr ImageWorkRequest) (*batchv1.Job, error) {
+func (m *ImageManager) deleteImage(iwr ImageWorkRequest) (job *batchv1.Job, err error) {
+	ctx, span := tracing.Start(iwr.Parent.Context(), "ImageManager.deleteImage", spanAttributes(iwr)...)
+	defer func() { tracing.End(span, err) }()
+	if iwr.Node != nil && !IsNodeReady(iwr.Node) {
+		return nil, fmt.Errorf("%w: %s", ErrNodeNotReady, iwr.Node.Labels["kubernetes.io/hostname"])
+	}
+	if err := m.checkManagedImage(ctx, iwr); err != nil {
+		return nil, err
+	}
 	// Construct the Job manifest
 	newjob, err := newImageDeleteJob(iwr.Imagecache, iwr.Image, iwr.Node, iwr.ContainerRuntimeVersion,
-		m.criClientImage, m.serviceAccountName, m.imageDeleteJobHostNetwork, m.jobPriorityClassName, m.criSocketPath)
+		m.criClientImage, m.serviceAccountName, m.imageDeleteJobHostNetwork, m.jobPriorityClassName, m.jobSchedulerName, m.criSocketPath,
+		m.helperImagePullPolicy, m.automountServiceAccountToken, m.jobRunAsUser,
+		m.deleteJobCRIClientArgs, m.deleteJobCRIClientEnv)
 	if err != nil {
 		glog.Errorf("Error when constructing job manifest: %v", err)
 		return nil, err
 	}
+	if err := m.setJobTemplate(ctx, newjob, iwr.Imagecache); err != nil {
+		return nil, err
+	}
+	setJobPodAnnotations(newjob, m.jobPodAnnotations)
+	setJobName(newjob, iwr)
+	setJobWork(newjob, jobWorkDelete)
 	// Create a Job to delete the image from the node
-	job, err := m.kubeclientset.BatchV1().Jobs(iwr.Imagecache.Namespace).Create(context.TODO(), newjob, metav1.CreateOptions{})
+	job, err = m.kubeclientset.BatchV1().Jobs(iwr.Imagecache.Namespace).Create(ctx, newjob, metav1.CreateOptions{})
 	if err != nil {
 		glog.Errorf("Error creating job in node %s: %v", iwr.Node, err)
 		return nil, err
 	}
 	return job, nil
 }
+
+// spanAttributes returns the attributes of the spans of the image work request
+func spanAttributes(iwr ImageWorkRequest) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{tracing.ImageKey.String(iwr.Image), tracing.WorkTypeKey.String(string(iwr.WorkType))}
+	if iwr.Imagecache != nil {
+		attrs = append(attrs, tracing.CacheKey.String(cacheKey(iwr.Imagecache)))
+	}
+	if iwr.Node != nil {
+		attrs = append(attrs, tracing.NodeKey.String(iwr.Node.Labels["kubernetes.io/hostname"]))
+	}
+	return attrs
+}
@@ -17,7 +17,11 @@ limitations under the License.
 package images
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
@@ -27,8 +31,10 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	fakeclientset "k8s.io/client-go/kubernetes/fake"
@@ -60,8 +66,18 @@ func newTestImageManager(kubeclientset kubernetes.Interface, imagepullpolicy str
 	imageworkqueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "ImagePullerStatus")
 
 	imagemanager, podInformer := NewImageManager(imagecacheworkqueue, imageworkqueue, kubeclientset,
-		fledgedNameSpace, imagePullDeadlineDuration, criClientImage, busyboxImage, imagePullPolicy,
-		serviceAccountName, imageDeleteJobHostNetwork, jobPriorityClassName, canDeleteJob, socketPath)
+		fledgedNameSpace, nil, ImageManagerConfig{
+			ImagePullDeadlineDuration: imagePullDeadlineDuration,
+			CRIClientImage:            criClientImage,
+			BusyboxImage:              busyboxImage,
+			ImagePullPolicy:           imagePullPolicy,
+			HelperImagePullPolicy:     "IfNotPresent",
+			ServiceAccountName:        serviceAccountName,
+			ImageDeleteJobHostNetwork: imageDeleteJobHostNetwork,
+			JobPriorityClassName:      jobPriorityClassName,
+			CanDeleteJob:              canDeleteJob,
+			CRISocketPath:             socketPath,
+		})
 	imagemanager.podsSynced = func() bool { return true }
 
 	return imagemanager, podInformer
@@ -82,12 +98,20 @@ func TestPullDeleteImage(t *testing.T) {
 			},
 		},
 	}
+	notReadyNode := node
+	notReadyNode.Status.Conditions = []corev1.NodeCondition{
+		{
+			Type:   corev1.NodeReady,
+			Status: corev1.ConditionFalse,
+		},
+	}
 	tests := []struct {
 		name                string
 		action              string
 		iwr                 ImageWorkRequest
 		expectError         bool
 		expectedErrorString string
+		expectedErr         error
 	}{
 		{
 			name:   "#1 Successful creation of image pull job",
@@ -200,6 +224,72 @@ func TestPullDeleteImage(t *testing.T) {
 			expectError:         false,
 			expectedErrorString: "",
 		},
+		{
+			name:   "#10 Unsuccessful - image not pullable",
+			action: "pullimage",
+			iwr: ImageWorkRequest{
+				Image:      "foo bar",
+				Node:       &node,
+				WorkType:   ImageCacheCreate,
+				Imagecache: &defaultImageCache,
+			},
+			expectError:         true,
+			expectedErrorString: "image not pullable",
+			expectedErr:         ErrImageNotPullable,
+		},
+		{
+			name:   "#11 Unsuccessful - node not ready (pull)",
+			action: "pullimage",
+			iwr: ImageWorkRequest{
+				Image:      "foo",
+				Node:       &notReadyNode,
+				WorkType:   ImageCacheCreate,
+				Imagecache: &defaultImageCache,
+			},
+			expectError:         true,
+			expectedErrorString: "node not ready",
+			expectedErr:         ErrNodeNotReady,
+		},
+		{
+			name:   "#12 Unsuccessful - node not ready (delete)",
+			action: "deleteimage",
+			iwr: ImageWorkRequest{
+				Image:      "foo",
+				Node:       &notReadyNode,
+				WorkType:   ImageCachePurge,
+				Imagecache: &defaultImageCache,
+			},
+			expectError:         true,
+			expectedErrorString: "node not ready",
+			expectedErr:         ErrNodeNotReady,
+		},
+		{
+			name:   "#13 Unsuccessful - container runtime not supported",
+			action: "deleteimage",
+			iwr: ImageWorkRequest{
+				Image:                   "foo",
+				Node:                    &node,
+				ContainerRuntimeVersion: "fakeruntime://1.0.0",
+				WorkType:                ImageCachePurge,
+				Imagecache:              &defaultImageCache,
+			},
+			expectError:         true,
+			expectedErrorString: "container runtime not supported",
+			expectedErr:         ErrRuntimeUnsupported,
+		},
+		{
+			name:   "#14 Unsuccessful - imagecache pointer is nil (errors.Is)",
+			action: "pullimage",
+			iwr: ImageWorkRequest{
+				Image:      "foo",
+				Node:       &node,
+				WorkType:   ImageCacheCreate,
+				Imagecache: nil,
+			},
+			expectError:         true,
+			expectedErrorString: "imagecache pointer is nil",
+			expectedErr:         ErrImageCacheNil,
+		},
 	}
 	for _, test := range tests {
 		fakekubeclientset := &fakeclientset.Clientset{}
@@ -228,17 +318,572 @@ func TestPullDeleteImage(t *testing.T) {
 			if err != nil && !strings.HasPrefix(err.Error(), test.expectedErrorString) {
 				t.Errorf("Test: %s failed: expectedError=%s, actualError=%s", test.name, test.expectedErrorString, err.Error())
 			}
+			if test.expectedErr != nil && !errors.Is(err, test.expectedErr) {
+				t.Errorf("Test: %s failed: expected errors.Is(err, %v) to be true, actualError=%v", test.name, test.expectedErr, err)
+			}
 		} else if err != nil {
 			t.Errorf("Test: %s failed. expectedError=nil, actualError=%s", test.name, err.Error())
 		}
 	}
 }
 
+func TestNewImagePullJobAffinity(t *testing.T) {
+	imageCache := &fledgedv1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: fledgedNameSpace,
+		},
+	}
+	job, err := newImagePullJob(imageCache, "foo:v1", &node, "IfNotPresent", "busybox:latest", "", "", "", corev1.PullIfNotPresent, false, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error creating pull job: %v", err)
+	}
+	expectedTerms := []corev1.NodeSelectorTerm{
+		{
+			MatchExpressions: []corev1.NodeSelectorRequirement{
+				{
+					Key:      "kubernetes.io/hostname",
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   []string{"bar"},
+				},
+			},
+		},
+	}
+	affinity := job.Spec.Template.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		t.Fatalf("Pull job has no required node affinity: %+v", affinity)
+	}
+	if terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms; !reflect.DeepEqual(terms, expectedTerms) {
+		t.Errorf("Pull job not pinned to node bar: expectedTerms=%+v, actualTerms=%+v", expectedTerms, terms)
+	}
+	if affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution != nil || affinity.PodAffinity != nil || affinity.PodAntiAffinity != nil {
+		t.Errorf("Pull job has unexpected affinity: %+v", affinity)
+	}
+}
+
+func TestNewImageJobHelperImagePullPolicy(t *testing.T) {
+	imageCache := &fledgedv1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: fledgedNameSpace,
+		},
+	}
+	pullJob, err := newImagePullJob(imageCache, "foo:v1", &node, "Always", "busybox:latest", "", "", "", corev1.PullNever, false, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error creating pull job: %v", err)
+	}
+	if policy := pullJob.Spec.Template.Spec.InitContainers[0].ImagePullPolicy; policy != corev1.PullNever {
+		t.Errorf("Pull job helper image: expectedImagePullPolicy=%s, actualImagePullPolicy=%s", corev1.PullNever, policy)
+	}
+	if policy := pullJob.Spec.Template.Spec.Containers[0].ImagePullPolicy; policy != corev1.PullAlways {
+		t.Errorf("Pull job image: expectedImagePullPolicy=%s, actualImagePullPolicy=%s", corev1.PullAlways, policy)
+	}
+	deleteJob, err := newImageDeleteJob(imageCache, "foo:v1", &node, "containerd://1.6.0", "senthilrch/fledged-docker-client:latest", "", false, "", "", "", corev1.PullAlways, false, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error creating delete job: %v", err)
+	}
+	if policy := deleteJob.Spec.Template.Spec.Containers[0].ImagePullPolicy; policy != corev1.PullAlways {
+		t.Errorf("Delete job helper image: expectedImagePullPolicy=%s, actualImagePullPolicy=%s", corev1.PullAlways, policy)
+	}
+}
+
+func TestNewImageDeleteJobCRIClientArgs(t *testing.T) {
+	imageCache := &fledgedv1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: fledgedNameSpace,
+		},
+	}
+	args := []string{"--timeout=30s", "--debug='on'"}
+	env := []corev1.EnvVar{{Name: "DOCKER_API_VERSION", Value: "1.41"}}
+	tests := []struct {
+		name            string
+		runtime         string
+		expectedCommand string
+	}{
+		{name: "#1: containerd", runtime: "containerd://1.6.0",
+			expectedCommand: `exec /usr/bin/crictl --runtime-endpoint=unix:///run/containerd/containerd.sock --image-endpoint=unix:///run/containerd/containerd.sock '--timeout=30s' '--debug='\''on'\''' rmi foo:v1 > /dev/termination-log 2>&1`},
+		{name: "#2: docker", runtime: "docker://20.10.0",
+			expectedCommand: `exec /usr/bin/docker '--timeout=30s' '--debug='\''on'\''' image rm -f foo:v1 > /dev/termination-log 2>&1`},
+	}
+	for _, test := range tests {
+		deleteJob, err := newImageDeleteJob(imageCache, "foo:v1", &node, test.runtime, "senthilrch/fledged-docker-client:latest", "", false, "", "", "", corev1.PullIfNotPresent, false, nil, args, env)
+		if err != nil {
+			t.Fatalf("Test: %s failed: unexpected error creating delete job: %v", test.name, err)
+		}
+		container := deleteJob.Spec.Template.Spec.Containers[0]
+		if command := container.Args[1]; command != test.expectedCommand {
+			t.Errorf("Test: %s failed: expectedCommand=%s, actualCommand=%s", test.name, test.expectedCommand, command)
+		}
+		if !reflect.DeepEqual(container.Env, env) {
+			t.Errorf("Test: %s failed: expectedEnv=%v, actualEnv=%v", test.name, env, container.Env)
+		}
+	}
+}
+
+func TestNewImageDeleteJobNodeCRISocket(t *testing.T) {
+	imageCache := &fledgedv1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: fledgedNameSpace,
+		},
+	}
+	tests := []struct {
+		name          string
+		annotation    string
+		criSocketPath string
+		expectedPath  string
+	}{
+		{name: "#1: Not annotated", expectedPath: "/run/containerd/containerd.sock"},
+		{name: "#2: Not annotated with cri socket path", criSocketPath: "/run/k3s/containerd/containerd.sock",
+			expectedPath: "/run/k3s/containerd/containerd.sock"},
+		{name: "#3: Annotated", annotation: "/var/snap/microk8s/common/run/containerd.sock", criSocketPath: "/run/k3s/containerd/containerd.sock",
+			expectedPath: "/var/snap/microk8s/common/run/containerd.sock"},
+		{name: "#4: Annotated with a relative path", annotation: "containerd.sock", expectedPath: "/run/containerd/containerd.sock"},
+	}
+	for _, test := range tests {
+		annotatedNode := node.DeepCopy()
+		if test.annotation != "" {
+			annotatedNode.Annotations = map[string]string{nodeCRISocketAnnotationKey: test.annotation}
+		}
+		deleteJob, err := newImageDeleteJob(imageCache, "foo:v1", annotatedNode, "containerd://1.6.0", "senthilrch/fledged-docker-client:latest", "", false, "", "", test.criSocketPath, corev1.PullIfNotPresent, false, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("Test: %s failed: unexpected error creating delete job: %v", test.name, err)
+		}
+		podSpec := deleteJob.Spec.Template.Spec
+		expectedCommand := "exec /usr/bin/crictl --runtime-endpoint=unix://" + test.expectedPath + " --image-endpoint=unix://" + test.expectedPath + " rmi foo:v1 > /dev/termination-log 2>&1"
+		if podSpec.Volumes[0].HostPath.Path != test.expectedPath || podSpec.Containers[0].VolumeMounts[0].MountPath != test.expectedPath ||
+			podSpec.Containers[0].Args[1] != expectedCommand {
+			t.Errorf("Test: %s failed: expectedPath=%s, actualPath=%s, actualCommand=%s", test.name, test.expectedPath,
+				podSpec.Volumes[0].HostPath.Path, podSpec.Containers[0].Args[1])
+		}
+	}
+}
+
+func TestCustomPuller(t *testing.T) {
+	imageCache := &fledgedv1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: fledgedNameSpace,
+		},
+		Spec: fledgedv1alpha2.ImageCacheSpec{
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "secret1"}, {Name: "secret2"}},
+		},
+	}
+	containerdNode := node.DeepCopy()
+	containerdNode.Status.NodeInfo.ContainerRuntimeVersion = "containerd://1.6.0"
+	runAsUser := int64(1000)
+	pullJob, err := newImagePullJob(imageCache, "foo:v1", containerdNode, "Always", "busybox:latest", "", "", "", corev1.PullIfNotPresent, false, &runAsUser)
+	if err != nil {
+		t.Fatalf("Unexpected error creating pull job: %v", err)
+	}
+	puller := &customPuller{image: "puller:v1", command: []string{"/puller", "--report"}}
+	puller.setOn(pullJob, "kube-fledged/foo", "foo:v1", containerdNode, "", corev1.PullIfNotPresent, &runAsUser)
+
+	podSpec := pullJob.Spec.Template.Spec
+	if len(podSpec.InitContainers) != 0 {
+		t.Errorf("Test: busybox init container not removed: %+v", podSpec.InitContainers)
+	}
+	if len(podSpec.Volumes) != 1 || podSpec.Volumes[0].HostPath == nil || podSpec.Volumes[0].HostPath.Path != "/run/containerd/containerd.sock" {
+		t.Errorf("Test: expected only the cri socket volume, actualVolumes=%+v", podSpec.Volumes)
+	}
+	container := podSpec.Containers[0]
+	if expectedCommand := []string{"/puller", "--report", "foo:v1"}; !reflect.DeepEqual(container.Command, expectedCommand) {
+		t.Errorf("Test: expectedCommand=%v, actualCommand=%v", expectedCommand, container.Command)
+	}
+	expectedEnv := []corev1.EnvVar{
+		{Name: customPullerEnvImage, Value: "foo:v1"},
+		{Name: customPullerEnvImagePullPolicy, Value: "Always"},
+		{Name: customPullerEnvImagePullSecrets, Value: "secret1,secret2"},
+		{Name: customPullerEnvImageCache, Value: "kube-fledged/foo"},
+		{Name: customPullerEnvNode, Value: "bar"},
+		{Name: customPullerEnvContainerRuntime, Value: "containerd://1.6.0"},
+		{Name: customPullerEnvCRISocket, Value: "/run/containerd/containerd.sock"},
+	}
+	if !reflect.DeepEqual(container.Env, expectedEnv) {
+		t.Errorf("Test: expectedEnv=%v, actualEnv=%v", expectedEnv, container.Env)
+	}
+	if sc := container.SecurityContext; sc == nil || sc.RunAsUser == nil || *sc.RunAsUser != 0 {
+		t.Errorf("Test: custom puller does not run as root: %+v", sc)
+	}
+}
+
+func TestNewImageJobAutomountServiceAccountToken(t *testing.T) {
+	imageCache := &fledgedv1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: fledgedNameSpace,
+		},
+	}
+	for _, automount := range []bool{false, true} {
+		pullJob, err := newImagePullJob(imageCache, "foo:v1", &node, "IfNotPresent", "busybox:latest", "", "", "", corev1.PullIfNotPresent, automount, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error creating pull job: %v", err)
+		}
+		deleteJob, err := newImageDeleteJob(imageCache, "foo:v1", &node, "containerd://1.6.0", "senthilrch/fledged-docker-client:latest", "", false, "", "", "", corev1.PullIfNotPresent, automount, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error creating delete job: %v", err)
+		}
+		verifyJob, err := newImageVerifyJob(imageCache, "foo@sha256:abc", &node, "containerd://1.6.0", "senthilrch/fledged-docker-client:latest", "", "", "", "", corev1.PullIfNotPresent, automount, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error creating verify job: %v", err)
+		}
+		for kind, job := range map[string]*batchv1.Job{"Pull": pullJob, "Delete": deleteJob, "Verify": verifyJob} {
+			token := job.Spec.Template.Spec.AutomountServiceAccountToken
+			if token == nil || *token != automount {
+				t.Errorf("%s job: expectedAutomountServiceAccountToken=%t, actualAutomountServiceAccountToken=%v", kind, automount, token)
+			}
+		}
+	}
+}
+
+func TestNewImageJobSecurityContext(t *testing.T) {
+	imageCache := &fledgedv1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: fledgedNameSpace,
+		},
+		Spec: fledgedv1alpha2.ImageCacheSpec{
+			CABundle: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "registry-ca"}, Key: "ca.crt"},
+		},
+	}
+	runAsUser := int64(65534)
+	pullJob, err := newImagePullJob(imageCache, "foo:v1", &node, "IfNotPresent", "busybox:latest", "", "", "", corev1.PullIfNotPresent, false, &runAsUser)
+	if err != nil {
+		t.Fatalf("Unexpected error creating pull job: %v", err)
+	}
+	deleteJob, err := newImageDeleteJob(imageCache, "foo:v1", &node, "containerd://1.6.0", "senthilrch/fledged-docker-client:latest", "", false, "", "", "", corev1.PullIfNotPresent, false, &runAsUser, nil, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error creating delete job: %v", err)
+	}
+	tests := []struct {
+		name              string
+		job               *batchv1.Job
+		expectedRunAsUser int64
+		rootContainers    map[string]bool
+	}{
+		{name: "#1: Pull job runs as non-root", job: pullJob, expectedRunAsUser: runAsUser, rootContainers: map[string]bool{"ca-bundle": true}},
+		{name: "#2: Delete job runs as root", job: deleteJob, expectedRunAsUser: 0},
+	}
+	for _, test := range tests {
+		podSecurityContext := test.job.Spec.Template.Spec.SecurityContext
+		if podSecurityContext == nil || *podSecurityContext.RunAsUser != test.expectedRunAsUser ||
+			*podSecurityContext.RunAsNonRoot != (test.expectedRunAsUser != 0) ||
+			podSecurityContext.SeccompProfile.Type != corev1.SeccompProfileTypeRuntimeDefault {
+			t.Errorf("Test: %s failed: unexpected pod security context %+v", test.name, podSecurityContext)
+			continue
+		}
+		containers := append(test.job.Spec.Template.Spec.InitContainers, test.job.Spec.Template.Spec.Containers...)
+		for _, c := range containers {
+			securityContext := c.SecurityContext
+			if securityContext == nil || *securityContext.AllowPrivilegeEscalation ||
+				len(securityContext.Capabilities.Drop) != 1 || securityContext.Capabilities.Drop[0] != "ALL" {
+				t.Errorf("Test: %s failed: unexpected security context %+v of container %s", test.name, securityContext, c.Name)
+				continue
+			}
+			if runsAsRoot := securityContext.RunAsUser != nil && *securityContext.RunAsUser == 0; runsAsRoot != test.rootContainers[c.Name] {
+				t.Errorf("Test: %s failed: container %s: expectedRoot=%t, actualRoot=%t", test.name, c.Name, test.rootContainers[c.Name], runsAsRoot)
+			}
+		}
+	}
+
+	pullJob, err = newImagePullJob(imageCache, "foo:v1", &node, "IfNotPresent", "busybox:latest", "", "", "", corev1.PullIfNotPresent, false, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error creating pull job: %v", err)
+	}
+	if pullJob.Spec.Template.Spec.SecurityContext != nil || pullJob.Spec.Template.Spec.Containers[0].SecurityContext != nil {
+		t.Errorf("Test: security context set on pull job without runAsUser")
+	}
+}
+
+func TestNewImageJobLifecycle(t *testing.T) {
+	jobDeadlineSeconds, jobTTLSeconds := int64(1800), int32(600)
+	tests := []struct {
+		name                 string
+		spec                 fledgedv1alpha2.ImageCacheSpec
+		expectedDeadline     int64
+		expectedTTL          *int32
+		expectedWaitDuration time.Duration
+	}{
+		{name: "#1: Controller defaults", spec: fledgedv1alpha2.ImageCacheSpec{}, expectedDeadline: 3600, expectedTTL: nil, expectedWaitDuration: 10 * time.Millisecond},
+		{name: "#2: Deadline and TTL of the image cache", spec: fledgedv1alpha2.ImageCacheSpec{JobDeadlineSeconds: &jobDeadlineSeconds, JobTTLSeconds: &jobTTLSeconds},
+			expectedDeadline: 1800, expectedTTL: &jobTTLSeconds, expectedWaitDuration: 30 * time.Minute},
+	}
+	for _, test := range tests {
+		imageCache := &fledgedv1alpha2.ImageCache{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: fledgedNameSpace,
+			},
+			Spec: test.spec,
+		}
+		pullJob, err := newImagePullJob(imageCache, "foo:v1", &node, "IfNotPresent", "busybox:latest", "", "", "", corev1.PullIfNotPresent, false, nil)
+		if err != nil {
+			t.Fatalf("Test: %s failed: unexpected error creating pull job: %v", test.name, err)
+		}
+		deleteJob, err := newImageDeleteJob(imageCache, "foo:v1", &node, "containerd://1.6.0", "senthilrch/fledged-docker-client:latest", "", false, "", "", "", corev1.PullIfNotPresent, false, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("Test: %s failed: unexpected error creating delete job: %v", test.name, err)
+		}
+		for kind, job := range map[string]*batchv1.Job{"Pull": pullJob, "Delete": deleteJob} {
+			if *job.Spec.ActiveDeadlineSeconds != test.expectedDeadline {
+				t.Errorf("Test: %s failed: %s job: expectedDeadline=%d, actualDeadline=%d", test.name, kind, test.expectedDeadline, *job.Spec.ActiveDeadlineSeconds)
+			}
+			if !reflect.DeepEqual(job.Spec.TTLSecondsAfterFinished, test.expectedTTL) {
+				t.Errorf("Test: %s failed: %s job: expectedTTL=%v, actualTTL=%v", test.name, kind, test.expectedTTL, job.Spec.TTLSecondsAfterFinished)
+			}
+		}
+		imagemanager, _ := newTestImageManager(&fakeclientset.Clientset{}, "IfNotPresent", "", false, "", false, "")
+		if wait := imagemanager.jobDeadline(imageCache); wait != test.expectedWaitDuration {
+			t.Errorf("Test: %s failed: expectedWaitDuration=%s, actualWaitDuration=%s", test.name, test.expectedWaitDuration, wait)
+		}
+	}
+}
+
+func TestSetJobPodAnnotations(t *testing.T) {
+	imageCache := &fledgedv1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: fledgedNameSpace,
+		},
+	}
+	pullJob, err := newImagePullJob(imageCache, "foo:v1", &node, "Always", "busybox:latest", "", "", "", corev1.PullIfNotPresent, false, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error creating pull job: %v", err)
+	}
+	setJobPodAnnotations(pullJob, nil)
+	if pullJob.Spec.Template.Annotations != nil {
+		t.Errorf("Test: annotations set without job pod annotations: %v", pullJob.Spec.Template.Annotations)
+	}
+	annotations := map[string]string{"sidecar.istio.io/inject": "false", "linkerd.io/inject": "disabled"}
+	setJobPodAnnotations(pullJob, annotations)
+	if !reflect.DeepEqual(pullJob.Spec.Template.Annotations, annotations) {
+		t.Errorf("Test: expectedAnnotations=%v, actualAnnotations=%v", annotations, pullJob.Spec.Template.Annotations)
+	}
+	if pullJob.Annotations != nil {
+		t.Errorf("Test: job pod annotations set on the job: %v", pullJob.Annotations)
+	}
+}
+
+func TestNewImagePullJobCABundle(t *testing.T) {
+	tests := []struct {
+		name             string
+		image            string
+		runtimeVersion   string
+		expectedHostPath string
+		expectedCommand  string
+	}{
+		{
+			name:             "#1: containerd, registry with port",
+			image:            "registry.internal:5000/foo:v1",
+			runtimeVersion:   "containerd://1.6.0",
+			expectedHostPath: "/etc/containerd/certs.d",
+			expectedCommand:  "mkdir -p /etc/certs.d/registry.internal:5000 && cp /etc/ca-bundle/ca.crt /etc/certs.d/registry.internal:5000/ca.crt",
+		},
+		{
+			name:             "#2: cri-o",
+			image:            "registry.internal/team/foo:v1",
+			runtimeVersion:   "cri-o://1.25.0",
+			expectedHostPath: "/etc/containers/certs.d",
+			expectedCommand:  "mkdir -p /etc/certs.d/registry.internal && cp /etc/ca-bundle/ca.crt /etc/certs.d/registry.internal/ca.crt",
+		},
+		{
+			name:             "#3: docker, image without registry host",
+			image:            "team/foo:v1",
+			runtimeVersion:   "docker://20.10.0",
+			expectedHostPath: "/etc/docker/certs.d",
+			expectedCommand:  "mkdir -p /etc/certs.d/docker.io && cp /etc/ca-bundle/ca.crt /etc/certs.d/docker.io/ca.crt",
+		},
+	}
+	for _, test := range tests {
+		imageCache := &fledgedv1alpha2.ImageCache{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: fledgedNameSpace,
+			},
+			Spec: fledgedv1alpha2.ImageCacheSpec{
+				CABundle: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "registry-ca"},
+					Key:                  "ca.pem",
+				},
+			},
+		}
+		n := node.DeepCopy()
+		n.Status.NodeInfo.ContainerRuntimeVersion = test.runtimeVersion
+		job, err := newImagePullJob(imageCache, test.image, n, "IfNotPresent", "busybox:latest", "", "", "", corev1.PullIfNotPresent, false, nil)
+		if err != nil {
+			t.Errorf("Test: %s failed. expectedError=nil, actualError=%s", test.name, err.Error())
+			continue
+		}
+		podSpec := job.Spec.Template.Spec
+		if len(podSpec.InitContainers) != 2 || podSpec.InitContainers[0].Name != "ca-bundle" {
+			t.Errorf("Test: %s failed. ca-bundle init container not added before the other init containers: %+v", test.name, podSpec.InitContainers)
+			continue
+		}
+		if command := podSpec.InitContainers[0].Command[2]; command != test.expectedCommand {
+			t.Errorf("Test: %s failed. expectedCommand=%s, actualCommand=%s", test.name, test.expectedCommand, command)
+		}
+		for _, volume := range podSpec.Volumes {
+			if volume.Name == "certs-d" && volume.HostPath.Path != test.expectedHostPath {
+				t.Errorf("Test: %s failed. expectedHostPath=%s, actualHostPath=%s", test.name, test.expectedHostPath, volume.HostPath.Path)
+			}
+			if volume.Name == "ca-bundle" && (volume.ConfigMap.Name != "registry-ca" || volume.ConfigMap.Items[0].Key != "ca.pem") {
+				t.Errorf("Test: %s failed. ca-bundle volume does not refer to the configmap: %+v", test.name, volume.ConfigMap)
+			}
+		}
+	}
+}
+
+func TestNewImagePullJobRuntimeClass(t *testing.T) {
+	gvisor := "gvisor"
+	empty := ""
+	tests := []struct {
+		name                     string
+		runtimeClassName         *string
+		expectedRuntimeClassName *string
+	}{
+		{
+			name:                     "#1: No runtimeClassName",
+			runtimeClassName:         nil,
+			expectedRuntimeClassName: nil,
+		},
+		{
+			name:                     "#2: Empty runtimeClassName",
+			runtimeClassName:         &empty,
+			expectedRuntimeClassName: nil,
+		},
+		{
+			name:                     "#3: runtimeClassName set",
+			runtimeClassName:         &gvisor,
+			expectedRuntimeClassName: &gvisor,
+		},
+	}
+	for _, test := range tests {
+		imageCache := &fledgedv1alpha2.ImageCache{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: fledgedNameSpace,
+			},
+			Spec: fledgedv1alpha2.ImageCacheSpec{
+				RuntimeClassName: test.runtimeClassName,
+			},
+		}
+		job, err := newImagePullJob(imageCache, "foo:v1", &node, "IfNotPresent", "busybox:latest", "", "", "", corev1.PullIfNotPresent, false, nil)
+		if err != nil {
+			t.Errorf("Test: %s failed. expectedError=nil, actualError=%s", test.name, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(job.Spec.Template.Spec.RuntimeClassName, test.expectedRuntimeClassName) {
+			t.Errorf("Test: %s failed: expectedRuntimeClassName=%v, actualRuntimeClassName=%v", test.name, test.expectedRuntimeClassName, job.Spec.Template.Spec.RuntimeClassName)
+		}
+	}
+}
+
+func TestJobSchedulerName(t *testing.T) {
+	tests := []struct {
+		name                  string
+		jobSchedulerName      string
+		schedulerName         string
+		expectedSchedulerName string
+	}{
+		{name: "#1: Default scheduler", jobSchedulerName: "", schedulerName: "", expectedSchedulerName: ""},
+		{name: "#2: Scheduler of the controller", jobSchedulerName: "batch-scheduler", schedulerName: "", expectedSchedulerName: "batch-scheduler"},
+		{name: "#3: Scheduler of the image cache", jobSchedulerName: "", schedulerName: "gpu-scheduler", expectedSchedulerName: "gpu-scheduler"},
+		{name: "#4: Scheduler of the image cache overrides the controller", jobSchedulerName: "batch-scheduler", schedulerName: "gpu-scheduler", expectedSchedulerName: "gpu-scheduler"},
+	}
+	for _, test := range tests {
+		imageCache := &fledgedv1alpha2.ImageCache{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: fledgedNameSpace},
+			Spec:       fledgedv1alpha2.ImageCacheSpec{SchedulerName: test.schedulerName},
+		}
+		pullJob, err := newImagePullJob(imageCache, "foo:v1", &node, "IfNotPresent", "busybox:latest", "", "", test.jobSchedulerName, corev1.PullIfNotPresent, false, nil)
+		if err != nil {
+			t.Errorf("Test: %s failed. expectedError=nil, actualError=%s", test.name, err.Error())
+			continue
+		}
+		deleteJob, err := newImageDeleteJob(imageCache, "foo:v1", &node, "containerd://1.6.0", "senthilrch/fledged-docker-client:latest", "", false, "", test.jobSchedulerName, "", corev1.PullIfNotPresent, false, nil, nil, nil)
+		if err != nil {
+			t.Errorf("Test: %s failed. expectedError=nil, actualError=%s", test.name, err.Error())
+			continue
+		}
+		if actual := pullJob.Spec.Template.Spec.SchedulerName; actual != test.expectedSchedulerName {
+			t.Errorf("Test: %s failed: expectedPullJobSchedulerName=%s, actualPullJobSchedulerName=%s", test.name, test.expectedSchedulerName, actual)
+		}
+		if actual := deleteJob.Spec.Template.Spec.SchedulerName; actual != test.expectedSchedulerName {
+			t.Errorf("Test: %s failed: expectedDeleteJobSchedulerName=%s, actualDeleteJobSchedulerName=%s", test.name, test.expectedSchedulerName, actual)
+		}
+	}
+}
+
+func TestJobOwnerReference(t *testing.T) {
+	imageCache := &fledgedv1alpha2.ImageCache{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: fledgedNameSpace, UID: "0123-4567"}}
+	pullJob, err := newImagePullJob(imageCache, "foo:v1", &node, "IfNotPresent", "busybox:latest", "", "", "", corev1.PullIfNotPresent, false, nil)
+	if err != nil {
+		t.Fatalf("Test: pull job failed. expectedError=nil, actualError=%s", err.Error())
+	}
+	deleteJob, err := newImageDeleteJob(imageCache, "foo:v1", &node, "containerd://1.6.0", "senthilrch/fledged-docker-client:latest", "", false, "", "", "", corev1.PullIfNotPresent, false, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Test: delete job failed. expectedError=nil, actualError=%s", err.Error())
+	}
+	verifyJob, err := newImageVerifyJob(imageCache, "foo@sha256:"+strings.Repeat("a", 64), &node, "containerd://1.6.0", "senthilrch/fledged-docker-client:latest", "", "", "", "", corev1.PullIfNotPresent, false, nil)
+	if err != nil {
+		t.Fatalf("Test: verify job failed. expectedError=nil, actualError=%s", err.Error())
+	}
+	for name, job := range map[string]*batchv1.Job{"pull": pullJob, "delete": deleteJob, "verify": verifyJob} {
+		owner := metav1.GetControllerOf(job)
+		if owner == nil || owner.Kind != "ImageCache" || owner.APIVersion != fledgedv1alpha2.SchemeGroupVersion.String() ||
+			owner.Name != imageCache.Name || owner.UID != imageCache.UID || owner.BlockOwnerDeletion == nil || !*owner.BlockOwnerDeletion {
+			t.Errorf("Test: %s job failed: expectedOwner=ImageCache %s (%s), actualOwner=%+v", name, imageCache.Name, imageCache.UID, owner)
+		}
+	}
+}
+
+func TestNewImagePullJobValidateRunnable(t *testing.T) {
+	tests := []struct {
+		name             string
+		validateRunnable bool
+		runnableCommand  []string
+		expectedCommand  []string
+		expectedVolumes  int
+	}{
+		{name: "#1: Not validated", validateRunnable: false, runnableCommand: []string{"/app", "--version"},
+			expectedCommand: []string{"/tmp/bin/echo", "Image pulled successfully!"}, expectedVolumes: 1},
+		{name: "#2: Default command", validateRunnable: true,
+			expectedCommand: []string{"true"}, expectedVolumes: 0},
+		{name: "#3: Runnable command", validateRunnable: true, runnableCommand: []string{"/app", "--version"},
+			expectedCommand: []string{"/app", "--version"}, expectedVolumes: 0},
+	}
+	for _, test := range tests {
+		imageCache := &fledgedv1alpha2.ImageCache{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: fledgedNameSpace},
+			Spec:       fledgedv1alpha2.ImageCacheSpec{ValidateRunnable: test.validateRunnable, RunnableCommand: test.runnableCommand},
+		}
+		job, err := newImagePullJob(imageCache, "foo:v1", &node, "IfNotPresent", "busybox:latest", "", "", "", corev1.PullIfNotPresent, false, nil)
+		if err != nil {
+			t.Errorf("Test: %s failed. expectedError=nil, actualError=%s", test.name, err.Error())
+			continue
+		}
+		podSpec := job.Spec.Template.Spec
+		if !reflect.DeepEqual(podSpec.Containers[0].Command, test.expectedCommand) {
+			t.Errorf("Test: %s failed: expectedCommand=%v, actualCommand=%v", test.name, test.expectedCommand, podSpec.Containers[0].Command)
+		}
+		if len(podSpec.Volumes) != test.expectedVolumes || len(podSpec.InitContainers) != test.expectedVolumes {
+			t.Errorf("Test: %s failed: expectedVolumes=%d, actualVolumes=%d, actualInitContainers=%d",
+				test.name, test.expectedVolumes, len(podSpec.Volumes), len(podSpec.InitContainers))
+		}
+	}
+}
+
 func TestHandlePodStatusChange(t *testing.T) {
 	tests := []struct {
-		name     string
-		worktype WorkType
-		pod      corev1.Pod
+		name               string
+		worktype           WorkType
+		image              string
+		digestVerification bool
+		pod                corev1.Pod
+		expectVerifyJob    bool
+		expectedDigest     string
+		expectedReason     string
 	}{
 		{
 			name:     "#1: Create - Pod succeeded",
@@ -308,19 +953,137 @@ func TestHandlePodStatusChange(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:               "#5: Create - Pod succeeded, digest verification job created",
+			worktype:           ImageCacheCreate,
+			image:              "foo@sha256:1234",
+			digestVerification: true,
+			pod: corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"job-name": "fakejob"},
+				},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodSucceeded,
+				},
+			},
+			expectVerifyJob: true,
+		},
+		{
+			name:               "#6: Create - Digest verification pod succeeded",
+			worktype:           ImageCacheCreate,
+			image:              "foo@sha256:1234",
+			digestVerification: true,
+			pod: corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"job-name": "fakejob", verifyLabel: "true"},
+				},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodSucceeded,
+					ContainerStatuses: []corev1.ContainerStatus{
+						{
+							State: corev1.ContainerState{
+								Terminated: &corev1.ContainerStateTerminated{
+									Message: "sha256:1234",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedDigest: "sha256:1234",
+		},
+		{
+			name:               "#7: Create - Digest verification pod failed",
+			worktype:           ImageCacheCreate,
+			image:              "foo@sha256:1234",
+			digestVerification: true,
+			pod: corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"job-name": "fakejob", verifyLabel: "true"},
+				},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodFailed,
+					ContainerStatuses: []corev1.ContainerStatus{
+						{
+							State: corev1.ContainerState{
+								Terminated: &corev1.ContainerStateTerminated{
+									Reason:  "Error",
+									Message: "digest mismatch",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedReason: fledgedv1alpha2.ImageCacheReasonImageDigestVerificationFailed,
+		},
+		{
+			name:               "#8: Create - Pod succeeded, image not pinned to a digest",
+			worktype:           ImageCacheCreate,
+			image:              "foo:v1",
+			digestVerification: true,
+			pod: corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"job-name": "fakejob"},
+				},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodSucceeded,
+				},
+			},
+		},
 	}
 	for _, test := range tests {
 		fakekubeclientset := &fakeclientset.Clientset{}
 		imagemanager, _ := newTestImageManager(fakekubeclientset, "IfNotPresent", "sa-kube-fledged", false, "priority-class-kube-fledged", false, "")
+		imagemanager.imageDigestVerification = test.digestVerification
 		imagemanager.imageworkstatus[test.pod.Labels["job-name"]] = ImageWorkResult{
 			Status: ImageWorkResultStatusJobCreated,
 			ImageWorkRequest: ImageWorkRequest{
+				Image:    test.image,
 				WorkType: test.worktype,
 				Node:     &node,
+				Imagecache: &fledgedv1alpha2.ImageCache{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "foo",
+						Namespace: fledgedNameSpace,
+					},
+				},
 			},
 		}
 		imagemanager.handlePodStatusChange(&test.pod)
 
+		if test.expectVerifyJob {
+			// the verification is queued, and the verify job created by the verify worker
+			if iwres := imagemanager.imageworkstatus[test.pod.Labels["job-name"]]; !iwres.pendingVerification || imagemanager.verifyqueue.Len() != 1 {
+				t.Errorf("Test: %s failed: verification not queued", test.name)
+			}
+			if len(fakekubeclientset.Actions()) != 0 {
+				t.Errorf("Test: %s failed: verify job created before the verification was processed", test.name)
+			}
+			imagemanager.processNextVerifyItem()
+			if _, ok := imagemanager.imageworkstatus[test.pod.Labels["job-name"]]; ok {
+				t.Errorf("Test: %s failed: result of pull job not replaced by verify job", test.name)
+			}
+			for _, action := range fakekubeclientset.Actions() {
+				if action.GetVerb() == "create" && action.GetResource().Resource == "jobs" {
+					job := action.(core.CreateAction).GetObject().(*batchv1.Job)
+					if job.Labels[verifyLabel] != "true" {
+						t.Errorf("Test: %s failed: created job is not a verify job", test.name)
+					}
+				}
+			}
+			if len(fakekubeclientset.Actions()) == 0 {
+				t.Errorf("Test: %s failed: verify job not created", test.name)
+			}
+			continue
+		}
+		iwres := imagemanager.imageworkstatus[test.pod.Labels["job-name"]]
+		if iwres.Digest != test.expectedDigest {
+			t.Errorf("Test: %s failed: expectedDigest=%s, actualDigest=%s", test.name, test.expectedDigest, iwres.Digest)
+		}
+		if test.expectedReason != "" && iwres.Reason != test.expectedReason {
+			t.Errorf("Test: %s failed: expectedReason=%s, actualReason=%s", test.name, test.expectedReason, iwres.Reason)
+		}
 		if test.pod.Status.Phase == corev1.PodSucceeded {
 			if !(imagemanager.imageworkstatus[test.pod.Labels["job-name"]].Status == ImageWorkResultStatusSucceeded) {
 				t.Errorf("Test: %s failed: expectedWorkResult=%s, actualWorkResult=%s", test.name, ImageWorkResultStatusSucceeded, imagemanager.imageworkstatus[test.pod.Labels["job-name"]].Status)
@@ -348,6 +1111,7 @@ func TestUpdateImageCacheStatus(t *testing.T) {
 		jobDeleteErr        bool
 		expectError         bool
 		expectedErrorString string
+		expectStatusUpdate  bool
 	}{
 		{
 			name: "#1: Successful",
@@ -610,8 +1374,50 @@ func TestUpdateImageCacheStatus(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "#9: Create - Event listing keeps failing, partial status queued",
+			imageworkstatus: map[string]ImageWorkResult{
+				"fakejob": {
+					ImageWorkRequest: ImageWorkRequest{
+						Imagecache: &fledgedv1alpha2.ImageCache{
+							ObjectMeta: metav1.ObjectMeta{
+								Name: imageCacheName,
+							},
+						},
+						Node: &node,
+					},
+					Status: ImageWorkResultStatusJobCreated,
+				},
+			},
+			pods: []corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: fledgedNameSpace,
+						Labels:    map[string]string{"job-name": "fakejob"},
+					},
+					Status: corev1.PodStatus{
+						Phase: corev1.PodPending,
+						ContainerStatuses: []corev1.ContainerStatus{
+							{
+								State: corev1.ContainerState{
+									Waiting: &corev1.ContainerStateWaiting{
+										Reason:  "fakereason",
+										Message: "fakemessage",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			eventListErr:        true,
+			expectError:         true,
+			expectedErrorString: "Internal error occurred: fake error",
+			expectStatusUpdate:  true,
+		},
 	}
 
+	listRetryBackoff = wait.Backoff{Steps: 2, Duration: time.Millisecond, Factor: 1.0, Jitter: 0.1}
 	for _, test := range tests {
 		fakekubeclientset := &fakeclientset.Clientset{}
 		if test.eventListErr {
@@ -632,7 +1438,7 @@ func TestUpdateImageCacheStatus(t *testing.T) {
 		}
 		imagemanager.imageworkstatus = test.imageworkstatus
 		errCh := make(chan error)
-		go imagemanager.updateImageCacheStatus(imageCache, errCh)
+		go imagemanager.updateImageCacheStatus(context.TODO(), imageCache, errCh)
 		err := <-errCh
 		if err != nil {
 			t.Logf("err=%s", err.Error())
@@ -647,6 +1453,79 @@ func TestUpdateImageCacheStatus(t *testing.T) {
 		} else if err != nil {
 			t.Errorf("Test: %s failed. expectedError=nil, actualError=%s", test.name, err.Error())
 		}
+		if test.expectStatusUpdate {
+			if err := wait.PollImmediate(time.Millisecond, time.Second, func() (bool, error) {
+				return imagemanager.workqueue.Len() == 1, nil
+			}); err != nil {
+				t.Errorf("Test: %s failed: status update not queued", test.name)
+				continue
+			}
+			obj, _ := imagemanager.workqueue.Get()
+			wqKey := obj.(WorkQueueKey)
+			iwres := (*wqKey.Status)["fakejob"]
+			if wqKey.WorkType != ImageCacheStatusUpdate || iwres.Status != ImageWorkResultStatusFailed || iwres.Reason != "fakereason" {
+				t.Errorf("Test: %s failed: unexpected status update %+v", test.name, iwres)
+			}
+		}
+	}
+}
+
+func TestUpdatePendingImageWorkResultsUnlocked(t *testing.T) {
+	fakekubeclientset := &fakeclientset.Clientset{}
+	imagemanager, podInformer := newTestImageManager(fakekubeclientset, "IfNotPresent", "sa-kube-fledged", false, "priority-class-kube-fledged", false, "")
+	lockHeld := false
+	fakekubeclientset.AddReactor("list", "events", func(action core.Action) (handled bool, ret runtime.Object, err error) {
+		if !imagemanager.lock.TryLock() {
+			lockHeld = true
+		} else {
+			imagemanager.lock.Unlock()
+		}
+		return true, &corev1.EventList{Items: []corev1.Event{{Message: "fakeevent"}}}, nil
+	})
+	podInformer.Informer().GetIndexer().Add(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "fakepod",
+			Namespace: fledgedNameSpace,
+			Labels:    map[string]string{"job-name": "fakejob"},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					State: corev1.ContainerState{
+						Waiting: &corev1.ContainerStateWaiting{
+							Reason:  "fakereason",
+							Message: "fakemessage",
+						},
+					},
+				},
+			},
+		},
+	})
+	imagemanager.imageworkstatus = map[string]ImageWorkResult{
+		"fakejob": {
+			ImageWorkRequest: ImageWorkRequest{
+				Imagecache: &fledgedv1alpha2.ImageCache{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "foo",
+						Namespace: fledgedNameSpace,
+					},
+				},
+				Node: &node,
+			},
+			Status: ImageWorkResultStatusJobCreated,
+		},
+	}
+	if err := imagemanager.updatePendingImageWorkResults("foo"); err != nil {
+		t.Errorf("Test: events listed off the lock failed: expectedError=nil, actualError=%s", err.Error())
+	}
+	if lockHeld {
+		t.Errorf("Test: events listed off the lock failed: the lock was held while listing the events")
+	}
+	iwres := imagemanager.imageworkstatus["fakejob"]
+	if iwres.Status != ImageWorkResultStatusFailed || iwres.Reason != "fakereason" || iwres.Message != "fakemessage:fakeevent" {
+		t.Errorf("Test: events listed off the lock failed: expectedResult=%s/fakereason/fakemessage:fakeevent, actualResult=%s/%s/%s",
+			ImageWorkResultStatusFailed, iwres.Status, iwres.Reason, iwres.Message)
 	}
 }
 
@@ -928,3 +1807,785 @@ func TestProcessNextWorkItem(t *testing.T) {
 		}
 	}
 }
+
+func TestPullLimiter(t *testing.T) {
+	tests := []struct {
+		name          string
+		initial       int
+		max           int
+		outcomes      []bool
+		expectedLimit int
+	}{
+		{name: "#1: Initial limit", initial: 1, max: 8, outcomes: nil, expectedLimit: 1},
+		{name: "#2: Limit doubles on success", initial: 1, max: 8, outcomes: []bool{true, true}, expectedLimit: 4},
+		{name: "#3: Limit capped at max", initial: 2, max: 5, outcomes: []bool{true, true, true}, expectedLimit: 5},
+		{name: "#4: Limit halved on failure", initial: 1, max: 8, outcomes: []bool{true, true, true, false}, expectedLimit: 4},
+		{name: "#5: Limit not below initial", initial: 2, max: 8, outcomes: []bool{false, false}, expectedLimit: 2},
+		{name: "#6: Initial above max", initial: 10, max: 3, outcomes: nil, expectedLimit: 3},
+		{name: "#7: Fixed limit", initial: 2, max: 2, outcomes: []bool{true, false, true}, expectedLimit: 2},
+	}
+	node1 := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	node2 := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}}
+	for _, test := range tests {
+		l := newPullLimiter(test.initial, test.max, 0)
+		for _, succeeded := range test.outcomes {
+			if !l.acquire(node1) {
+				t.Fatalf("Test: %s failed: acquire returned false", test.name)
+			}
+			l.release("node1", succeeded)
+		}
+		if limit := l.limit("node1"); limit != test.expectedLimit {
+			t.Errorf("Test: %s failed: expectedLimit=%d, actualLimit=%d", test.name, test.expectedLimit, limit)
+		}
+		for i := 0; i < test.expectedLimit; i++ {
+			if !l.acquire(node1) {
+				t.Errorf("Test: %s failed: acquire %d of %d returned false", test.name, i+1, test.expectedLimit)
+			}
+		}
+		if l.acquire(node1) {
+			t.Errorf("Test: %s failed: acquire above the limit returned true", test.name)
+		}
+		if !l.acquire(node2) {
+			t.Errorf("Test: %s failed: limit of node1 applied to node2", test.name)
+		}
+	}
+	if newPullLimiter(1, 0, 0) != nil {
+		t.Errorf("Test: pull limiter with max 0 is not nil")
+	}
+}
+
+func TestPullLimiterCPUsPerPull(t *testing.T) {
+	tests := []struct {
+		name        string
+		max         int
+		cpus        string
+		expectedMax int
+	}{
+		{name: "#1: One pull per 2 cpus", cpus: "16", expectedMax: 8},
+		{name: "#2: Fractional cpus rounded down", cpus: "7500m", expectedMax: 3},
+		{name: "#3: At least one pull", cpus: "1", expectedMax: 1},
+		{name: "#4: Bounded by max", max: 4, cpus: "64", expectedMax: 4},
+		{name: "#5: Allocatable cpus unknown", cpus: "", expectedMax: 1},
+	}
+	for _, test := range tests {
+		l := newPullLimiter(1, test.max, 2)
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+		if test.cpus != "" {
+			node.Status.Allocatable = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(test.cpus)}
+		}
+		for i := 0; i < 10; i++ {
+			if !l.acquire(node) {
+				t.Fatalf("Test: %s failed: acquire returned false", test.name)
+			}
+			l.release(node.Name, true)
+		}
+		if limit := l.limit(node.Name); limit != test.expectedMax {
+			t.Errorf("Test: %s failed: expectedLimit=%d, actualLimit=%d", test.name, test.expectedMax, limit)
+		}
+	}
+}
+
+func TestDeleteLimiter(t *testing.T) {
+	imageCache := &fledgedv1alpha2.ImageCache{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: fledgedNameSpace}}
+	fakekubeclientset := &fakeclientset.Clientset{}
+	imagemanager, _ := newTestImageManager(fakekubeclientset, "IfNotPresent", "", false, "", false, "")
+	imagemanager.pullLimiter = newPullLimiter(1, 1, 0)
+	imagemanager.deleteLimiter = newPullLimiter(1, 1, 0)
+	testnode := node.DeepCopy()
+	testnode.Name = "bar"
+	imagemanager.deleteLimiter.acquire(testnode)
+
+	iwr := ImageWorkRequest{Image: "foo:v1", Node: testnode, ContainerRuntimeVersion: "containerd://1.6.0",
+		WorkType: ImageCachePurge, Imagecache: imageCache}
+	imagemanager.imageworkqueue.Add(iwr)
+	imagemanager.processNextWorkItem()
+	if len(fakekubeclientset.Actions()) != 0 {
+		t.Errorf("Test: delete job created on a node at its limit of concurrent deletes: %v", fakekubeclientset.Actions())
+	}
+	if !imagemanager.hasDeferredRequests(imageCache) {
+		t.Errorf("Test: delete on a node at its limit of concurrent deletes not deferred")
+	}
+
+	imagemanager.releaseJobSlot(ImageWorkResult{ImageWorkRequest: iwr}, true)
+	if !imagemanager.deleteLimiter.acquire(testnode) {
+		t.Errorf("Test: slot of a finished delete job not released")
+	}
+	if !imagemanager.pullLimiter.acquire(testnode) {
+		t.Errorf("Test: delete limit applied to pulls")
+	}
+}
+
+func TestDeleteUnsupportedRuntime(t *testing.T) {
+	imageCache := &fledgedv1alpha2.ImageCache{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: fledgedNameSpace}}
+	fakekubeclientset := &fakeclientset.Clientset{}
+	imagemanager, _ := newTestImageManager(fakekubeclientset, "IfNotPresent", "", false, "", false, "")
+	iwr := ImageWorkRequest{Image: "foo:v1", Node: &node, ContainerRuntimeVersion: "fakeruntime://1.0.0",
+		WorkType: ImageCachePurge, Imagecache: imageCache}
+	imagemanager.imageworkqueue.Add(iwr)
+	if !imagemanager.processNextWorkItem() {
+		t.Fatalf("Test: delete on a node with an unsupported runtime failed: work queue shut down")
+	}
+	if len(imagemanager.imageworkstatus) != 1 {
+		t.Fatalf("Test: delete on a node with an unsupported runtime failed: expectedResults=1, actualResults=%d", len(imagemanager.imageworkstatus))
+	}
+	for _, iwres := range imagemanager.imageworkstatus {
+		if iwres.Status != ImageWorkResultStatusFailed || iwres.Reason != fledgedv1alpha2.ImageCacheReasonUnsupportedRuntime {
+			t.Errorf("Test: delete on a node with an unsupported runtime failed: expectedReason=%s, actualResult=%+v",
+				fledgedv1alpha2.ImageCacheReasonUnsupportedRuntime, iwres)
+		}
+	}
+	if imagemanager.imageworkqueue.NumRequeues(iwr) != 0 {
+		t.Errorf("Test: delete on a node with an unsupported runtime failed: request requeued")
+	}
+}
+
+func TestDeleteLimiterTotal(t *testing.T) {
+	tests := []struct {
+		name     string
+		perNode  int
+		total    int
+		acquires []string
+		expected []bool
+	}{
+		{name: "#1: Total only", perNode: 0, total: 2, acquires: []string{"node1", "node1", "node2"}, expected: []bool{true, true, false}},
+		{name: "#2: Per node and total", perNode: 1, total: 2, acquires: []string{"node1", "node1", "node2", "node3"}, expected: []bool{true, false, true, false}},
+		{name: "#3: Per node only", perNode: 1, total: 0, acquires: []string{"node1", "node2", "node3"}, expected: []bool{true, true, true}},
+	}
+	for _, test := range tests {
+		l := newDeleteLimiter(test.perNode, test.total)
+		for i, name := range test.acquires {
+			if actual := l.acquire(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}); actual != test.expected[i] {
+				t.Errorf("Test: %s failed: acquire #%d on %s: expected=%t, actual=%t", test.name, i+1, name, test.expected[i], actual)
+			}
+		}
+		if test.total == 0 {
+			continue
+		}
+		if !l.atTotal() {
+			t.Errorf("Test: %s failed: expectedAtTotal=true, actualAtTotal=false", test.name)
+		}
+		// the slots of a finished delete job and of the jobs of a deleted node are given back to the total
+		l.release("node1", true)
+		if l.atTotal() {
+			t.Errorf("Test: %s failed: slot of a finished delete job not given back to the total", test.name)
+		}
+		l.acquire(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node4"}})
+		l.forget("node4")
+		if l.atTotal() {
+			t.Errorf("Test: %s failed: slots of a deleted node not given back to the total", test.name)
+		}
+	}
+	if newDeleteLimiter(0, 0) != nil {
+		t.Errorf("Test: newDeleteLimiter(0, 0) should return nil (no limit)")
+	}
+}
+
+func TestIsNodeReady(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []corev1.NodeCondition
+		expected   bool
+	}{
+		{name: "#1: Ready", conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}, expected: true},
+		{name: "#2: Not ready", conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionFalse}}, expected: false},
+		{name: "#3: Readiness unknown", conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionUnknown}}, expected: false},
+		{name: "#4: No Ready condition", conditions: []corev1.NodeCondition{{Type: corev1.NodeDiskPressure, Status: corev1.ConditionFalse}}, expected: true},
+	}
+	for _, test := range tests {
+		node := &corev1.Node{Status: corev1.NodeStatus{Conditions: test.conditions}}
+		if actual := IsNodeReady(node); actual != test.expected {
+			t.Errorf("Test: %s failed: expectedReady=%t, actualReady=%t", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestCheckFreeDisk(t *testing.T) {
+	diskPressure := corev1.NodeCondition{Type: corev1.NodeDiskPressure, Status: corev1.ConditionTrue}
+	tests := []struct {
+		name        string
+		minFreeDisk int64
+		conditions  []corev1.NodeCondition
+		freeDisk    int64
+		freeDiskErr error
+		expectError bool
+	}{
+		{name: "#1: Disk check disabled", minFreeDisk: 0, conditions: []corev1.NodeCondition{diskPressure}, freeDisk: 0, expectError: false},
+		{name: "#2: Sufficient free disk", minFreeDisk: 100, freeDisk: 200, expectError: false},
+		{name: "#3: Insufficient free disk", minFreeDisk: 100, freeDisk: 50, expectError: true},
+		{name: "#4: Node under disk pressure", minFreeDisk: 100, conditions: []corev1.NodeCondition{diskPressure}, freeDisk: 200, expectError: true},
+		{name: "#5: Free disk unknown", minFreeDisk: 100, freeDiskErr: fmt.Errorf("fake error"), expectError: false},
+	}
+	for _, test := range tests {
+		fakekubeclientset := &fakeclientset.Clientset{}
+		imagemanager, _ := newTestImageManager(fakekubeclientset, "IfNotPresent", "", false, "", false, "")
+		imagemanager.minFreeDisk = test.minFreeDisk
+		imagemanager.freeDisk = func(node *corev1.Node) (int64, error) {
+			return test.freeDisk, test.freeDiskErr
+		}
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "fakenode", Labels: map[string]string{"kubernetes.io/hostname": "fakenode"}},
+			Status:     corev1.NodeStatus{Conditions: test.conditions},
+		}
+		err := imagemanager.checkFreeDisk(node)
+		if test.expectError && !errors.Is(err, ErrInsufficientDisk) {
+			t.Errorf("Test: %s failed: expectedError=%v, actualError=%v", test.name, ErrInsufficientDisk, err)
+		}
+		if !test.expectError && err != nil {
+			t.Errorf("Test: %s failed: expectedError=nil, actualError=%v", test.name, err)
+		}
+	}
+}
+
+func TestCheckCacheBudget(t *testing.T) {
+	nodeImages := []corev1.ContainerImage{
+		{Names: []string{"docker.io/library/nginx:1.23", "docker.io/library/nginx@sha256:abc"}, SizeBytes: 60},
+		{Names: []string{"docker.io/library/redis:7"}, SizeBytes: 30},
+		{Names: []string{"quay.io/other/app:v1"}, SizeBytes: 500},
+	}
+	tests := []struct {
+		name                 string
+		image                string
+		maxCacheBytesPerNode int64
+		cachedImages         []string
+		cachedRepositories   []string
+		expectError          bool
+	}{
+		{name: "#1: Budget disabled", image: "busybox:1.35", maxCacheBytesPerNode: 0, cachedImages: []string{"nginx:1.23", "redis:7"}, expectError: false},
+		{name: "#2: Within budget", image: "busybox:1.35", maxCacheBytesPerNode: 100, cachedImages: []string{"nginx:1.23", "redis:7"}, expectError: false},
+		{name: "#3: Budget exceeded", image: "busybox:1.35", maxCacheBytesPerNode: 90, cachedImages: []string{"nginx:1.23", "redis:7"}, expectError: true},
+		{name: "#4: Budget exceeded by tags of a repository", image: "busybox:1.35", maxCacheBytesPerNode: 90, cachedImages: []string{"nginx:1.23"}, cachedRepositories: []string{"docker.io/library/redis"}, expectError: true},
+		{name: "#5: Images not cached are not counted", image: "busybox:1.35", maxCacheBytesPerNode: 100, cachedImages: []string{"nginx:1.23", "app:v2"}, cachedRepositories: []string{"quay.io/other/ap"}, expectError: false},
+		{name: "#6: Image already in node", image: "nginx:1.23", maxCacheBytesPerNode: 50, cachedImages: []string{"nginx:1.23", "redis:7"}, expectError: false},
+	}
+	for _, test := range tests {
+		imagemanager, _ := newTestImageManager(&fakeclientset.Clientset{}, "IfNotPresent", "", false, "", false, "")
+		imagemanager.maxCacheBytesPerNode = test.maxCacheBytesPerNode
+		imagemanager.cachedImages = func() ([]string, []string) {
+			return test.cachedImages, test.cachedRepositories
+		}
+		iwr := ImageWorkRequest{
+			Image: test.image,
+			Node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "fakenode", Labels: map[string]string{"kubernetes.io/hostname": "fakenode"}},
+				Status:     corev1.NodeStatus{Images: nodeImages},
+			},
+		}
+		err := imagemanager.checkCacheBudget(iwr)
+		if test.expectError && !errors.Is(err, ErrBudgetExceeded) {
+			t.Errorf("Test: %s failed: expectedError=%v, actualError=%v", test.name, ErrBudgetExceeded, err)
+		}
+		if !test.expectError && err != nil {
+			t.Errorf("Test: %s failed: expectedError=nil, actualError=%v", test.name, err)
+		}
+	}
+}
+
+func TestImageToPull(t *testing.T) {
+	imagecache := &fledgedv1alpha2.ImageCache{
+		Spec: fledgedv1alpha2.ImageCacheSpec{Mirrors: []string{"mirror1.example.com", "mirror2.example.com:5000"}},
+	}
+	tests := []struct {
+		name          string
+		image         string
+		mirror        int
+		expectedImage string
+	}{
+		{name: "#1: No mirror", image: "nginx:1.23", mirror: 0, expectedImage: "nginx:1.23"},
+		{name: "#2: Bare image on mirror", image: "nginx:1.23", mirror: 1, expectedImage: "mirror1.example.com/library/nginx:1.23"},
+		{name: "#3: Docker hub image on mirror", image: "bitnami/redis:7", mirror: 1, expectedImage: "mirror1.example.com/bitnami/redis:7"},
+		{name: "#4: Registry host replaced", image: "gcr.io/project/app:v1", mirror: 2, expectedImage: "mirror2.example.com:5000/project/app:v1"},
+		{name: "#5: Registry host with port replaced", image: "localhost:5000/app@sha256:abc", mirror: 2, expectedImage: "mirror2.example.com:5000/app@sha256:abc"},
+		{name: "#6: Mirror out of range", image: "nginx:1.23", mirror: 3, expectedImage: "nginx:1.23"},
+	}
+	for _, test := range tests {
+		iwr := ImageWorkRequest{Image: test.image, Imagecache: imagecache, mirror: test.mirror}
+		if image := imageToPull(iwr); image != test.expectedImage {
+			t.Errorf("Test: %s failed: expectedImage=%s, actualImage=%s", test.name, test.expectedImage, image)
+		}
+	}
+}
+
+func TestTargetImage(t *testing.T) {
+	imagecache := &fledgedv1alpha2.ImageCache{
+		Spec: fledgedv1alpha2.ImageCacheSpec{Mirrors: []string{"mirror1.example.com"}},
+	}
+	m := &ImageManager{registryRewrites: map[string]string{
+		"docker.io": "cache.example.com/docker.io",
+		"quay.io":   "cache.example.com:5000/quay/",
+	}}
+	tests := []struct {
+		name             string
+		image            string
+		mirror           int
+		expectedImage    string
+		expectedRegistry string
+	}{
+		{name: "#1: Bare image rewritten", image: "nginx:1.23", expectedImage: "cache.example.com/docker.io/library/nginx:1.23", expectedRegistry: "cache.example.com"},
+		{name: "#2: Docker hub image rewritten", image: "docker.io/bitnami/redis:7", expectedImage: "cache.example.com/docker.io/bitnami/redis:7", expectedRegistry: "cache.example.com"},
+		{name: "#3: Target with trailing slash", image: "quay.io/app:v2", expectedImage: "cache.example.com:5000/quay/app:v2", expectedRegistry: "cache.example.com:5000"},
+		{name: "#4: Registry without rule", image: "gcr.io/project/app:v1", expectedImage: "gcr.io/project/app:v1", expectedRegistry: "gcr.io"},
+		{name: "#5: Invalid image", image: "Nginx:1.23", expectedImage: "Nginx:1.23", expectedRegistry: "docker.io"},
+		{name: "#6: Mirror takes precedence", image: "nginx:1.23", mirror: 1, expectedImage: "mirror1.example.com/library/nginx:1.23", expectedRegistry: "mirror1.example.com"},
+	}
+	for _, test := range tests {
+		iwr := ImageWorkRequest{Image: test.image, Imagecache: imagecache, mirror: test.mirror}
+		if image := m.targetImage(iwr); image != test.expectedImage {
+			t.Errorf("Test: %s failed: expectedImage=%s, actualImage=%s", test.name, test.expectedImage, image)
+		}
+		if registry := m.pullRegistry(iwr); registry != test.expectedRegistry {
+			t.Errorf("Test: %s failed: expectedRegistry=%s, actualRegistry=%s", test.name, test.expectedRegistry, registry)
+		}
+	}
+}
+
+func TestIsImagePullFailing(t *testing.T) {
+	waiting := func(phase corev1.PodPhase, reason string) *corev1.Pod {
+		return &corev1.Pod{Status: corev1.PodStatus{
+			Phase: phase,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}}},
+			},
+		}}
+	}
+	tests := []struct {
+		name     string
+		pod      *corev1.Pod
+		expected bool
+	}{
+		{name: "#1: ErrImagePull", pod: waiting(corev1.PodPending, "ErrImagePull"), expected: true},
+		{name: "#2: ImagePullBackOff", pod: waiting(corev1.PodPending, "ImagePullBackOff"), expected: true},
+		{name: "#3: ContainerCreating", pod: waiting(corev1.PodPending, "ContainerCreating"), expected: false},
+		{name: "#4: Pod not pending", pod: waiting(corev1.PodFailed, "ErrImagePull"), expected: false},
+	}
+	for _, test := range tests {
+		if actual := isImagePullFailing(test.pod); actual != test.expected {
+			t.Errorf("Test: %s failed: expected=%t, actual=%t", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestImageNeedsToBePulled(t *testing.T) {
+	cachedNode := &corev1.Node{
+		Status: corev1.NodeStatus{
+			Images: []corev1.ContainerImage{{Names: []string{"docker.io/library/nginx:1.23", "nginx:latest"}}},
+		},
+	}
+	verify := &fledgedv1alpha2.ImageCache{Spec: fledgedv1alpha2.ImageCacheSpec{RefreshMode: fledgedv1alpha2.ImageCacheRefreshModeVerify}}
+	pull := &fledgedv1alpha2.ImageCache{Spec: fledgedv1alpha2.ImageCacheSpec{RefreshMode: fledgedv1alpha2.ImageCacheRefreshModePull}}
+	tests := []struct {
+		name            string
+		imagePullPolicy string
+		image           string
+		workType        WorkType
+		imageCache      *fledgedv1alpha2.ImageCache
+		expectedPull    bool
+	}{
+		{name: "#1: Refresh in pull mode pulls present image", imagePullPolicy: "Always", image: "nginx:1.23", workType: ImageCacheRefresh, imageCache: pull, expectedPull: true},
+		{name: "#2: Refresh in verify mode skips present image", imagePullPolicy: "Always", image: "nginx:1.23", workType: ImageCacheRefresh, imageCache: verify, expectedPull: false},
+		{name: "#3: Refresh in verify mode skips present latest image", imagePullPolicy: "IfNotPresent", image: "nginx:latest", workType: ImageCacheRefresh, imageCache: verify, expectedPull: false},
+		{name: "#4: Refresh in verify mode pulls missing image", imagePullPolicy: "Always", image: "redis:7", workType: ImageCacheRefresh, imageCache: verify, expectedPull: true},
+		{name: "#5: Create in verify mode follows pull policy", imagePullPolicy: "Always", image: "nginx:1.23", workType: ImageCacheCreate, imageCache: verify, expectedPull: true},
+		{name: "#6: Refresh without mode follows pull policy", imagePullPolicy: "IfNotPresent", image: "nginx:1.23", workType: ImageCacheRefresh, imageCache: &fledgedv1alpha2.ImageCache{}, expectedPull: false},
+	}
+	for _, test := range tests {
+		iwr := ImageWorkRequest{Image: test.image, Node: cachedNode, WorkType: test.workType, Imagecache: test.imageCache}
+		pull := imageNeedsToBePulled(test.imagePullPolicy, iwr, nil)
+		if pull != test.expectedPull {
+			t.Errorf("Test: %s failed: expectedPull=%t, actualPull=%t", test.name, test.expectedPull, pull)
+		}
+	}
+}
+
+func TestNodeImageIndex(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "fakenode", ResourceVersion: "1"},
+		Status: corev1.NodeStatus{
+			Images: []corev1.ContainerImage{{Names: []string{"docker.io/library/nginx:1.23", "docker.io/library/nginx@sha256:abc"}}},
+		},
+	}
+	index := newNodeImageIndex("")
+	tests := []struct {
+		name            string
+		image           string
+		expectedPresent bool
+	}{
+		{name: "#1: Fully qualified image", image: "docker.io/library/nginx:1.23", expectedPresent: true},
+		{name: "#2: Image without registry", image: "library/nginx:1.23", expectedPresent: true},
+		{name: "#3: Image without registry and namespace", image: "nginx:1.23", expectedPresent: true},
+		{name: "#4: Image by digest", image: "nginx@sha256:abc", expectedPresent: true},
+		{name: "#5: Image with a tag prefix of the tag", image: "nginx:1.2", expectedPresent: false},
+		{name: "#6: Missing image", image: "redis:7", expectedPresent: false},
+		{name: "#7: Image with the registry but not the namespace", image: "docker.io/nginx:1.23", expectedPresent: true},
+	}
+	for _, test := range tests {
+		if present := index.contains(node, test.image); present != test.expectedPresent {
+			t.Errorf("Test: %s failed: expectedPresent=%t, actualPresent=%t", test.name, test.expectedPresent, present)
+		}
+	}
+
+	updated := node.DeepCopy()
+	updated.ResourceVersion = "2"
+	updated.Status.Images = append(updated.Status.Images, corev1.ContainerImage{Names: []string{"docker.io/library/redis:7"}})
+	if !index.contains(updated, "redis:7") {
+		t.Errorf("Test: index not rebuilt on a new resource version of the node")
+	}
+	index.invalidate(node.Name)
+	if _, ok := index.nodes[node.Name]; ok {
+		t.Errorf("Test: entry of the node not dropped on invalidate")
+	}
+}
+
+func TestNormalizeImage(t *testing.T) {
+	tests := []struct {
+		name            string
+		image           string
+		defaultRegistry string
+		expected        string
+	}{
+		{name: "#1: Image name only", image: "nginx", expected: "docker.io/library/nginx:latest"},
+		{name: "#2: Image with namespace and tag", image: "bitnami/redis:7", expected: "docker.io/bitnami/redis:7"},
+		{name: "#3: Image with registry port", image: "registry:5000/foo", expected: "registry:5000/foo:latest"},
+		{name: "#4: Image by digest", image: "nginx@sha256:" + strings.Repeat("a", 64), expected: "docker.io/library/nginx@sha256:" + strings.Repeat("a", 64)},
+		{name: "#5: Default registry", image: "nginx:1.23", defaultRegistry: "quay.io", expected: "quay.io/nginx:1.23"},
+		{name: "#6: Default registry with an image with registry", image: "gcr.io/foo/bar", defaultRegistry: "quay.io", expected: "gcr.io/foo/bar:latest"},
+		{name: "#7: Invalid image", image: "Nginx", expected: "Nginx"},
+	}
+	for _, test := range tests {
+		if actual := normalizeImage(test.image, test.defaultRegistry); actual != test.expected {
+			t.Errorf("Test: %s failed: expectedImage=%s, actualImage=%s", test.name, test.expected, actual)
+		}
+	}
+
+	node := &corev1.Node{Status: corev1.NodeStatus{Images: []corev1.ContainerImage{
+		{Names: []string{"docker.io/library/nginx:latest-alpine", "docker.io/library/nginx:latest"}}}}}
+	pullTests := []struct {
+		name         string
+		image        string
+		expectedPull bool
+	}{
+		{name: "#1: Image without tag", image: "nginx", expectedPull: true},
+		{name: "#2: Image with a tag starting with latest", image: "nginx:latest-alpine", expectedPull: false},
+		{name: "#3: Image with registry port and without tag", image: "registry:5000/nginx", expectedPull: true},
+	}
+	for _, test := range pullTests {
+		if actual := checkIfImageNeedsToBePulled(string(corev1.PullIfNotPresent), test.image, node, nil); actual != test.expectedPull {
+			t.Errorf("Test: %s failed: expectedPull=%t, actualPull=%t", test.name, test.expectedPull, actual)
+		}
+	}
+}
+
+func TestHandleNodeDeletion(t *testing.T) {
+	imageCache := &fledgedv1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: fledgedNameSpace,
+		},
+	}
+	deletedNode := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{"kubernetes.io/hostname": "node1"}}}
+	otherNode := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2", Labels: map[string]string{"kubernetes.io/hostname": "node2"}}}
+	fakekubeclientset := &fakeclientset.Clientset{}
+	imagemanager, _ := newTestImageManager(fakekubeclientset, "IfNotPresent", "", false, "", true, "")
+	imagemanager.imageworkstatus = map[string]ImageWorkResult{
+		"job1": {ImageWorkRequest: ImageWorkRequest{Image: "foo:v1", Node: deletedNode, Imagecache: imageCache}, Status: ImageWorkResultStatusJobCreated},
+		"job2": {ImageWorkRequest: ImageWorkRequest{Image: "foo:v1", Node: otherNode, Imagecache: imageCache}, Status: ImageWorkResultStatusJobCreated},
+		"job3": {ImageWorkRequest: ImageWorkRequest{Image: "bar:v1", Node: deletedNode, Imagecache: imageCache}, Status: ImageWorkResultStatusSucceeded},
+	}
+
+	imagemanager.HandleNodeDeletion(deletedNode)
+
+	if _, ok := imagemanager.imageworkstatus["job1"]; ok {
+		t.Errorf("Test: result of job on deleted node not removed")
+	}
+	if iwres := imagemanager.imageworkstatus["job2"]; iwres.Status != ImageWorkResultStatusJobCreated {
+		t.Errorf("Test: job on other node: expectedStatus=%s, actualStatus=%s", ImageWorkResultStatusJobCreated, iwres.Status)
+	}
+	if iwres := imagemanager.imageworkstatus["job3"]; iwres.Status != ImageWorkResultStatusSucceeded {
+		t.Errorf("Test: completed job on deleted node: expectedStatus=%s, actualStatus=%s", ImageWorkResultStatusSucceeded, iwres.Status)
+	}
+	nodeDeleted := 0
+	for job, iwres := range imagemanager.imageworkstatus {
+		if iwres.Status == ImageWorkResultStatusNodeDeleted {
+			nodeDeleted++
+			if !strings.HasPrefix(job, fakeJobPrefix) || iwres.Reason != fledgedv1alpha2.ImageCacheReasonNodeDeleted {
+				t.Errorf("Test: abandoned job recorded as %s with reason %s", job, iwres.Reason)
+			}
+		}
+	}
+	if nodeDeleted != 1 {
+		t.Errorf("Test: expectedNodeDeletedResults=1, actualNodeDeletedResults=%d", nodeDeleted)
+	}
+	deleted := []string{}
+	for _, action := range fakekubeclientset.Actions() {
+		if action.GetVerb() == "delete" && action.GetResource().Resource == "jobs" {
+			deleted = append(deleted, action.(core.DeleteAction).GetName())
+		}
+	}
+	if len(deleted) != 1 || deleted[0] != "job1" {
+		t.Errorf("Test: expectedDeletedJobs=[job1], actualDeletedJobs=%v", deleted)
+	}
+
+	// jobs are retained with the retain job retention policy
+	fakekubeclientset = &fakeclientset.Clientset{}
+	imagemanager, _ = newTestImageManager(fakekubeclientset, "IfNotPresent", "", false, "", false, "")
+	imagemanager.imageworkstatus = map[string]ImageWorkResult{
+		"job1": {ImageWorkRequest: ImageWorkRequest{Image: "foo:v1", Node: deletedNode, Imagecache: imageCache}, Status: ImageWorkResultStatusJobCreated},
+	}
+	imagemanager.HandleNodeDeletion(deletedNode)
+	if _, ok := imagemanager.imageworkstatus["job1"]; ok {
+		t.Errorf("Test: retain: result of job on deleted node not removed")
+	}
+	if actions := fakekubeclientset.Actions(); len(actions) != 0 {
+		t.Errorf("Test: retain: expectedActions=[], actualActions=%v", actions)
+	}
+
+	// pulls of image lists retargeted on node deletion are returned and held back from the status update
+	retargetImageCache := imageCache.DeepCopy()
+	retargetImageCache.Spec.CacheSpec = []fledgedv1alpha2.CacheSpecImages{{Images: []string{"foo:v1"}}, {Images: []string{"bar:v1"}, RetargetOnNodeDeletion: true}}
+	imagemanager, _ = newTestImageManager(&fakeclientset.Clientset{}, "IfNotPresent", "", false, "", true, "")
+	imagemanager.imageworkstatus = map[string]ImageWorkResult{
+		"job1": {ImageWorkRequest: ImageWorkRequest{Image: "foo:v1", Node: deletedNode, Imagecache: retargetImageCache}, Status: ImageWorkResultStatusJobCreated},
+		"job2": {ImageWorkRequest: ImageWorkRequest{Image: "bar:v1", Node: deletedNode, Imagecache: retargetImageCache, ImageList: 1}, Status: ImageWorkResultStatusJobCreated},
+		"job3": {ImageWorkRequest: ImageWorkRequest{Image: "bar:v1", Node: deletedNode, Imagecache: retargetImageCache, ImageList: 1}, Status: ImageWorkResultStatusJobCreated},
+	}
+	retargets := imagemanager.HandleNodeDeletion(deletedNode)
+	if len(retargets) != 2 || retargets[0].Image != "bar:v1" || retargets[1].Image != "bar:v1" {
+		t.Fatalf("Test: retarget: expectedRetargets=2 pulls of bar:v1, actualRetargets=%+v", retargets)
+	}
+	if len(imagemanager.imageworkstatus) != 1 || !imagemanager.hasDeferredRequests(retargetImageCache) {
+		t.Errorf("Test: retarget: expectedResults=1 and deferred requests, actualResults=%d", len(imagemanager.imageworkstatus))
+	}
+	imagemanager.RetargetImageWork(retargets[0], otherNode)
+	if iwr, _ := imagemanager.imageworkqueue.Get(); iwr.(ImageWorkRequest).Node != otherNode || !iwr.(ImageWorkRequest).deferred {
+		t.Errorf("Test: retarget: expectedNode=%s, actualRequest=%+v", otherNode.Name, iwr)
+	}
+	imagemanager.RetargetImageWork(retargets[1], nil)
+	if nodes := imagemanager.NodesWithImageWork(retargetImageCache, "bar:v1"); !nodes[deletedNode.Name] || len(nodes) != 1 {
+		t.Errorf("Test: retarget: expectedNodesWithImageWork=[%s], actualNodesWithImageWork=%v", deletedNode.Name, nodes)
+	}
+	if imagemanager.deferredRequests[cacheKey(retargetImageCache)] != 1 {
+		t.Errorf("Test: retarget: expectedDeferredRequests=1, actualDeferredRequests=%d", imagemanager.deferredRequests[cacheKey(retargetImageCache)])
+	}
+}
+
+func TestPullsProgressing(t *testing.T) {
+	imageCache := &fledgedv1alpha2.ImageCache{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: fledgedNameSpace}}
+	now := time.Now()
+	event := func(reason string, age time.Duration) runtime.Object {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: fmt.Sprintf("pod1.%s", reason), Namespace: fledgedNameSpace},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "pod1", Namespace: fledgedNameSpace},
+			Reason:         reason,
+			LastTimestamp:  metav1.NewTime(now.Add(-age)),
+		}
+	}
+	tests := []struct {
+		name        string
+		workType    WorkType
+		phase       corev1.PodPhase
+		waiting     string
+		events      []runtime.Object
+		progressing bool
+	}{
+		{name: "#1: Image being pulled", phase: corev1.PodPending, waiting: "ContainerCreating",
+			events: []runtime.Object{event("Scheduled", time.Minute), event("Pulling", time.Second)}, progressing: true},
+		{name: "#2: Image pull backing off", phase: corev1.PodPending, waiting: "ImagePullBackOff",
+			events: []runtime.Object{event("Pulling", time.Second)}, progressing: false},
+		{name: "#3: Image pull failed after pulling", phase: corev1.PodPending, waiting: "ContainerCreating",
+			events: []runtime.Object{event("Pulling", time.Minute), event("Failed", time.Second)}, progressing: false},
+		{name: "#4: Pod running", phase: corev1.PodRunning,
+			events: []runtime.Object{event("Pulling", time.Second)}, progressing: false},
+		{name: "#5: Delete job", workType: ImageCachePurge, phase: corev1.PodPending, waiting: "ContainerCreating",
+			events: []runtime.Object{event("Pulling", time.Second)}, progressing: false},
+	}
+	for _, test := range tests {
+		imagemanager, podInformer := newTestImageManager(fakeclientset.NewSimpleClientset(test.events...), "IfNotPresent", "", false, "", true, "")
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: fledgedNameSpace, Labels: map[string]string{"job-name": "job1"}},
+			Status:     corev1.PodStatus{Phase: test.phase},
+		}
+		if test.waiting != "" {
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: test.waiting}}}}
+		}
+		podInformer.Informer().GetIndexer().Add(pod)
+		imagemanager.imageworkstatus = map[string]ImageWorkResult{
+			"job1": {ImageWorkRequest: ImageWorkRequest{Image: "foo:v1", Node: &node, WorkType: test.workType, Imagecache: imageCache}, Status: ImageWorkResultStatusJobCreated},
+		}
+		if progressing := imagemanager.pullsProgressing("foo"); progressing != test.progressing {
+			t.Errorf("Test: %s: expectedProgressing=%t, actualProgressing=%t", test.name, test.progressing, progressing)
+		}
+	}
+}
+
+func TestSizedPullDeadline(t *testing.T) {
+	deadlineSeconds := int64(60)
+	imageCache := func(jobDeadlineSeconds *int64) *fledgedv1alpha2.ImageCache {
+		return &fledgedv1alpha2.ImageCache{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: fledgedNameSpace},
+			Spec: fledgedv1alpha2.ImageCacheSpec{
+				CacheSpec: []fledgedv1alpha2.CacheSpecImages{{Images: []string{"foo:v1", "huge:v1", "bar:v1"},
+					ImageSizes: map[string]resource.Quantity{"foo:v1": resource.MustParse("600Mi"), "huge:v1": resource.MustParse("1Ti")}}},
+				JobDeadlineSeconds: jobDeadlineSeconds,
+			},
+		}
+	}
+	cachedNode := &corev1.Node{Status: corev1.NodeStatus{Images: []corev1.ContainerImage{
+		{Names: []string{"docker.io/library/bar:v1"}, SizeBytes: 20 * 1024 * 1024}}}}
+	tests := []struct {
+		name             string
+		bandwidth        int64
+		iwr              ImageWorkRequest
+		expectedDeadline time.Duration
+	}{
+		{name: "#1: Size from imageSizes", bandwidth: 10 * 1024 * 1024,
+			iwr: ImageWorkRequest{Image: "foo:v1", Node: &node, Imagecache: imageCache(nil)}, expectedDeadline: 2 * time.Minute},
+		{name: "#2: Size from the node status", bandwidth: 10 * 1024 * 1024,
+			iwr: ImageWorkRequest{Image: "bar:v1", Node: cachedNode, Imagecache: imageCache(nil)}, expectedDeadline: time.Minute + 2*time.Second},
+		{name: "#3: Deadline capped", bandwidth: 10 * 1024 * 1024,
+			iwr: ImageWorkRequest{Image: "huge:v1", Node: &node, Imagecache: imageCache(nil)}, expectedDeadline: maxSizedPullDeadline},
+		{name: "#4: Size not known", bandwidth: 10 * 1024 * 1024,
+			iwr: ImageWorkRequest{Image: "bar:v1", Node: &node, Imagecache: imageCache(nil)}, expectedDeadline: 0},
+		{name: "#5: Bandwidth not set", bandwidth: 0,
+			iwr: ImageWorkRequest{Image: "foo:v1", Node: &node, Imagecache: imageCache(nil)}, expectedDeadline: 0},
+		{name: "#6: Job deadline of the image cache", bandwidth: 10 * 1024 * 1024,
+			iwr: ImageWorkRequest{Image: "foo:v1", Node: &node, Imagecache: imageCache(&deadlineSeconds)}, expectedDeadline: 0},
+	}
+	imagemanager, _ := newTestImageManager(fakeclientset.NewSimpleClientset(), "IfNotPresent", "", false, "", true, "")
+	imagemanager.imagePullDeadlineBase = time.Minute
+	for _, test := range tests {
+		imagemanager.imagePullBandwidth = test.bandwidth
+		var actual time.Duration
+		before := time.Now()
+		if deadline := imagemanager.sizedPullDeadline(test.iwr); !deadline.IsZero() {
+			actual = deadline.Sub(before).Round(time.Second)
+		}
+		if actual != test.expectedDeadline {
+			t.Errorf("Test: %s failed: expectedDeadline=%s, actualDeadline=%s", test.name, test.expectedDeadline, actual)
+		}
+	}
+
+	// pulls past their sized deadline do not hold back the status update of the image cache
+	imagemanager.imageworkstatus = map[string]ImageWorkResult{
+		"job1": {ImageWorkRequest: ImageWorkRequest{Image: "foo:v1", Node: &node, Imagecache: imageCache(nil)},
+			Status: ImageWorkResultStatusJobCreated, deadline: time.Now().Add(-time.Second)},
+	}
+	if latest := imagemanager.latestSizedPullDeadline("foo"); time.Until(latest) > 0 {
+		t.Errorf("Test: latest sized deadline is in the future: %s", latest)
+	}
+	if imagemanager.waitSizedPullDeadlines(imageCache(nil), func() (bool, error) { return false, nil }) {
+		t.Errorf("Test: jobs past their sized deadline waited for")
+	}
+}
+
+func TestListTags(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			if r.URL.Query().Get("scope") != "repository:library/foo:pull" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprint(w, `{"token": "secret"}`)
+		case r.Header.Get("Authorization") != "Bearer secret":
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path != "/v2/library/foo/tags/list":
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Query().Get("last") == "":
+			w.Header().Set("Link", `</v2/library/foo/tags/list?last=v1&n=2>; rel="next"`)
+			fmt.Fprint(w, `{"name": "library/foo", "tags": ["v0", "v1"]}`)
+		default:
+			fmt.Fprint(w, `{"name": "library/foo", "tags": ["v2"]}`)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		repository   string
+		expectedTags []string
+		expectedErr  error
+	}{
+		{name: "#1: Paginated tags with a bearer token", repository: "library/foo", expectedTags: []string{"v0", "v1", "v2"}},
+		{name: "#2: Repository not found", repository: "library/bar", expectedErr: ErrTagsNotListable},
+	}
+	for _, test := range tests {
+		tags, err := listTags(server.Client(), server.URL, test.repository)
+		if test.expectedErr != nil {
+			if !errors.Is(err, test.expectedErr) {
+				t.Errorf("Test: %s failed: expectedError=%v, actualError=%v", test.name, test.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test: %s failed: expectedError=nil, actualError=%v", test.name, err)
+		}
+		if !reflect.DeepEqual(tags, test.expectedTags) {
+			t.Errorf("Test: %s failed: expectedTags=%v, actualTags=%v", test.name, test.expectedTags, tags)
+		}
+	}
+}
+
+func TestRepositoryName(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                     "library/nginx",
+		"senthilrch/kubefledged":    "senthilrch/kubefledged",
+		"docker.io/nginx":           "library/nginx",
+		"quay.io/coreos/etcd":       "coreos/etcd",
+		"localhost:5000/team/image": "team/image",
+	}
+	for repository, expected := range tests {
+		if name := repositoryName(repository); name != expected {
+			t.Errorf("Test: %s failed: expectedName=%s, actualName=%s", repository, expected, name)
+		}
+	}
+}
+
+func TestMatchTags(t *testing.T) {
+	tags := []string{"v2.0", "v1.1", "v1.0", "v1.2-rc", "latest"}
+	tests := []struct {
+		name           string
+		includeTags    []string
+		excludeTags    []string
+		expectedImages []string
+		expectErr      bool
+	}{
+		{name: "#1: All tags", expectedImages: []string{"foo:latest", "foo:v1.0", "foo:v1.1", "foo:v1.2-rc", "foo:v2.0"}},
+		{name: "#2: Included and excluded tags", includeTags: []string{"v1.*"}, excludeTags: []string{"*-rc"}, expectedImages: []string{"foo:v1.0", "foo:v1.1"}},
+		{name: "#3: No tags matching", includeTags: []string{"v3.*"}, expectedImages: []string{}},
+		{name: "#4: Bad pattern", includeTags: []string{"v1.["}, expectErr: true},
+	}
+	for _, test := range tests {
+		images, err := MatchTags("foo", tags, test.includeTags, test.excludeTags)
+		if (err != nil) != test.expectErr {
+			t.Errorf("Test: %s failed: expectErr=%t, actualError=%v", test.name, test.expectErr, err)
+			continue
+		}
+		if !test.expectErr && !reflect.DeepEqual(images, test.expectedImages) {
+			t.Errorf("Test: %s failed: expectedImages=%v, actualImages=%v", test.name, test.expectedImages, images)
+		}
+	}
+}
+
+func TestFailureCategory(t *testing.T) {
+	tests := []struct {
+		name             string
+		reason           string
+		message          string
+		expectedCategory string
+	}{
+		{name: "#1: Authentication required", reason: "ErrImagePull", message: "Failed to pull image: 401 Unauthorized", expectedCategory: ImagePullFailureAuth},
+		{name: "#2: Access denied", reason: "ImagePullBackOff", message: "rpc error: denied: access forbidden", expectedCategory: ImagePullFailureAuth},
+		{name: "#3: Manifest unknown", reason: "ErrImagePull", message: "manifest unknown: manifest tagged by \"v9\" is not found", expectedCategory: ImagePullFailureNotFound},
+		{name: "#4: Repository of docker.io not found", reason: "ErrImagePull", message: "pull access denied for typo, repository does not exist or may require 'docker login'", expectedCategory: ImagePullFailureNotFound},
+		{name: "#5: Invalid image name", reason: "InvalidImageName", message: "couldn't parse image reference", expectedCategory: ImagePullFailureNotFound},
+		{name: "#6: Insufficient disk", reason: fledgedv1alpha2.ImageCacheReasonInsufficientDisk, message: "insufficient disk", expectedCategory: ImagePullFailureDiskPressure},
+		{name: "#7: No space left", reason: "ErrImagePull", message: "write /var/lib/containerd/tmp: no space left on device", expectedCategory: ImagePullFailureDiskPressure},
+		{name: "#8: Other failure", reason: "DeadlineExceeded", message: "Job was active longer than specified deadline", expectedCategory: ImagePullFailureOther},
+	}
+	for _, test := range tests {
+		if category := FailureCategory(ImageWorkResult{Reason: test.reason, Message: test.message}); category != test.expectedCategory {
+			t.Errorf("Test: %s failed: expectedCategory=%s, actualCategory=%s", test.name, test.expectedCategory, category)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"github.com/golang/glog"
+	fledgedv1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// workLabel is the label of the jobs with the work they do: pull, delete or verify
+	workLabel = "kubefledged-work"
+	// jobWorkPull is the work of the jobs pulling an image
+	jobWorkPull = "pull"
+	// jobWorkDelete is the work of the jobs deleting an image
+	jobWorkDelete = "delete"
+	// jobWorkVerify is the work of the jobs verifying the digest of a pulled image
+	jobWorkVerify = "verify"
+)
+
+// adoptedJobKey identifies the work of an adopted job by the hash of its image cache, node and image
+type adoptedJobKey struct {
+	hash string
+	work string
+}
+
+// setJobWork labels the job with its work. Along with the hash of the work, the label is the progress of a
+// reconcile persisted in the cluster, from which a restarted controller tells which of the jobs to adopt
+func setJobWork(job *batchv1.Job, work string) {
+	if job.Labels == nil {
+		job.Labels = map[string]string{}
+	}
+	job.Labels[workLabel] = work
+}
+
+// IsResumableJob returns true if the job pulls or deletes an image and is labeled with the hash of its work,
+// so that it can be adopted by the reconcile resumed after a restart of the controller. Verify jobs are not
+// resumable, since their pull is not known to have succeeded in the resumed reconcile
+func IsResumableJob(job *batchv1.Job) bool {
+	work := job.Labels[workLabel]
+	return job.Labels[workHashLabel] != "" && (work == jobWorkPull || work == jobWorkDelete)
+}
+
+// AdoptJobs hands the jobs left over by the reconciles under processing when the controller stopped to the
+// image manager. The resumed reconciles claim the jobs of their work instead of creating them again
+func (m *ImageManager) AdoptJobs(jobs []batchv1.Job) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for i := range jobs {
+		key := adoptedJobKey{hash: jobs[i].Labels[workHashLabel], work: jobs[i].Labels[workLabel]}
+		m.adoptedJobs[key] = append(m.adoptedJobs[key], jobs[i].DeepCopy())
+		glog.Infof("Job %s adopted (%s), to be claimed by the resumed reconcile of image cache %s",
+			jobs[i].Name, key.work, jobs[i].Labels["imagecache"])
+	}
+}
+
+// claimAdoptedJob returns an adopted job doing the work for the image work request, if any, and removes it
+// from the adopted jobs
+func (m *ImageManager) claimAdoptedJob(iwr ImageWorkRequest, work string) *batchv1.Job {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	key := adoptedJobKey{hash: workHash(iwr), work: work}
+	jobs := m.adoptedJobs[key]
+	for i, job := range jobs {
+		if job.Namespace != iwr.Imagecache.Namespace {
+			continue
+		}
+		if m.adoptedJobs[key] = append(jobs[:i:i], jobs[i+1:]...); len(m.adoptedJobs[key]) == 0 {
+			delete(m.adoptedJobs, key)
+		}
+		return job
+	}
+	return nil
+}
+
+// releaseAdoptedJobs gives the adopted jobs of the image cache which were not claimed by its resumed
+// reconcile to the job reaper, unless jobs are retained
+func (m *ImageManager) releaseAdoptedJobs(imagecache *fledgedv1alpha2.ImageCache) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key, jobs := range m.adoptedJobs {
+		kept := jobs[:0]
+		for _, job := range jobs {
+			if job.Namespace != imagecache.Namespace || job.Labels["imagecache"] != imagecache.Name {
+				kept = append(kept, job)
+				continue
+			}
+			glog.Infof("Adopted job %s not claimed by the resumed reconcile of image cache %s", job.Name, imagecache.Name)
+			if m.canDeleteJob {
+				m.jobReaper.add(job.Namespace, job.Name)
+			}
+		}
+		if len(kept) == 0 {
+			delete(m.adoptedJobs, key)
+		} else {
+			m.adoptedJobs[key] = kept
+		}
+	}
+}
+
+// resolveAdoptedJob handles the completion of an adopted job whose pod completed before the controller
+// restarted, since no status change of the pod is then received
+func (m *ImageManager) resolveAdoptedJob(job *batchv1.Job) {
+	pods, err := m.podsLister.Pods(job.Namespace).List(labels.SelectorFromSet(labels.Set{"job-name": job.Name}))
+	if err != nil {
+		glog.Warningf("Error listing pods of adopted job %s: %v", job.Name, err)
+		return
+	}
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			m.handlePodStatusChange(pod)
+			return
+		}
+	}
+}
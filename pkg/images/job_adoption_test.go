@@ -0,0 +1,120 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"testing"
+
+	fledgedv1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAdoptJobs(t *testing.T) {
+	imageCache := &fledgedv1alpha2.ImageCache{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: fledgedNameSpace}}
+	iwr := func(image string, workType WorkType) ImageWorkRequest {
+		return ImageWorkRequest{Image: image, Node: &node, WorkType: workType, Imagecache: imageCache}
+	}
+	adoptedJob := func(name, work string, iwr ImageWorkRequest) batchv1.Job {
+		return batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: fledgedNameSpace, Labels: map[string]string{
+			"imagecache": imageCache.Name, workHashLabel: workHash(iwr), workLabel: work}}}
+	}
+	tests := []struct {
+		name           string
+		iwr            ImageWorkRequest
+		job            batchv1.Job
+		podPhase       corev1.PodPhase
+		expectedJob    string
+		expectedStatus string
+	}{
+		{name: "#1: Pull job completed before the restart claimed", iwr: iwr("foo:v1", ImageCacheRefresh),
+			job: adoptedJob("foo-pull", jobWorkPull, iwr("foo:v1", ImageCacheRefresh)), podPhase: corev1.PodSucceeded,
+			expectedJob: "foo-pull", expectedStatus: ImageWorkResultStatusSucceeded},
+		{name: "#2: Running delete job claimed", iwr: iwr("foo:v1", ImageCachePurge),
+			job: adoptedJob("foo-delete", jobWorkDelete, iwr("foo:v1", ImageCachePurge)), podPhase: corev1.PodRunning,
+			expectedJob: "foo-delete", expectedStatus: ImageWorkResultStatusJobCreated},
+		{name: "#3: Delete job not claimed by a pull", iwr: iwr("foo:v1", ImageCacheRefresh),
+			job: adoptedJob("foo-delete", jobWorkDelete, iwr("foo:v1", ImageCachePurge)), podPhase: corev1.PodRunning,
+			expectedJob: "", expectedStatus: ImageWorkResultStatusJobCreated},
+		{name: "#4: Pull job of another image not claimed", iwr: iwr("foo:v1", ImageCacheRefresh),
+			job: adoptedJob("bar-pull", jobWorkPull, iwr("bar:v1", ImageCacheRefresh)), podPhase: corev1.PodRunning,
+			expectedJob: "", expectedStatus: ImageWorkResultStatusJobCreated},
+	}
+	for _, test := range tests {
+		fakekubeclientset := fakeclientset.NewSimpleClientset()
+		imagemanager, podInformer := newTestImageManager(fakekubeclientset, "IfNotPresent", "sa-kube-fledged", false, "priority-class-kube-fledged", true, "")
+		podInformer.Informer().GetIndexer().Add(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: test.job.Name + "-abcde", Namespace: fledgedNameSpace, Labels: map[string]string{"job-name": test.job.Name}},
+			Status:     corev1.PodStatus{Phase: test.podPhase}})
+		imagemanager.AdoptJobs([]batchv1.Job{test.job})
+		imagemanager.imageworkqueue.Add(test.iwr)
+		imagemanager.processNextWorkItem()
+
+		created := 0
+		for _, action := range fakekubeclientset.Actions() {
+			if action.GetVerb() == "create" && action.GetResource().Resource == "jobs" {
+				created++
+			}
+		}
+		jobs := []string{}
+		for job := range imagemanager.imageworkstatus {
+			jobs = append(jobs, job)
+		}
+		if len(jobs) != 1 {
+			t.Fatalf("Test: %s failed: expectedResults=1, actualResults=%v", test.name, jobs)
+		}
+		if test.expectedJob != "" && (jobs[0] != test.expectedJob || created != 0) {
+			t.Errorf("Test: %s failed: expectedJob=%s, actualJob=%s, jobsCreated=%d", test.name, test.expectedJob, jobs[0], created)
+		}
+		if test.expectedJob == "" && (jobs[0] == test.job.Name || created != 1) {
+			t.Errorf("Test: %s failed: expectedJobsCreated=1, actualJobsCreated=%d", test.name, created)
+		}
+		if actual := imagemanager.imageworkstatus[jobs[0]].Status; actual != test.expectedStatus {
+			t.Errorf("Test: %s failed: expectedStatus=%s, actualStatus=%s", test.name, test.expectedStatus, actual)
+		}
+
+		// the adopted jobs left unclaimed are deleted once all the image work of the reconcile is processed
+		expectedReaped := 0
+		if test.expectedJob == "" {
+			expectedReaped = 1
+		}
+		imagemanager.releaseAdoptedJobs(imageCache)
+		if actual := imagemanager.jobReaper.pending(); actual != expectedReaped || len(imagemanager.adoptedJobs) != 0 {
+			t.Errorf("Test: %s failed: expectedReaped=%d, actualReaped=%d, adoptedJobs=%d", test.name, expectedReaped, actual, len(imagemanager.adoptedJobs))
+		}
+	}
+}
+
+func TestIsResumableJob(t *testing.T) {
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		expected bool
+	}{
+		{name: "#1: Pull job", labels: map[string]string{workHashLabel: "0123456789", workLabel: jobWorkPull}, expected: true},
+		{name: "#2: Delete job", labels: map[string]string{workHashLabel: "0123456789", workLabel: jobWorkDelete}, expected: true},
+		{name: "#3: Verify job", labels: map[string]string{workHashLabel: "0123456789", workLabel: jobWorkVerify}, expected: false},
+		{name: "#4: Job of a previous version", labels: map[string]string{workHashLabel: "0123456789"}, expected: false},
+	}
+	for _, test := range tests {
+		if actual := IsResumableJob(&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Labels: test.labels}}); actual != test.expected {
+			t.Errorf("Test: %s failed: expected=%t, actual=%t", test.name, test.expected, actual)
+		}
+	}
+}
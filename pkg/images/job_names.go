@@ -0,0 +1,93 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// workHashLabel is the label of the jobs and their pods with the hash of the image cache, node and image
+	// of their work, which is also encoded in their name
+	workHashLabel = "kubefledged-work-hash"
+	// nodeLabel is the label of the jobs and their pods with the hostname of their node
+	nodeLabel = "kubefledged-node"
+	// workHashLength is the no. of hex digits of the hash of the work of a job
+	workHashLength = 10
+	// jobNameSuffixLength is the no. of random characters ending the name of a job
+	jobNameSuffixLength = 5
+)
+
+// workHash returns the hash of the image cache, node and image of the image work request
+func workHash(iwr ImageWorkRequest) string {
+	var key strings.Builder
+	if iwr.Imagecache != nil {
+		key.WriteString(iwr.Imagecache.Namespace + "/" + iwr.Imagecache.Name)
+	}
+	key.WriteString("\n")
+	if iwr.Node != nil {
+		key.WriteString(iwr.Node.Labels["kubernetes.io/hostname"])
+	}
+	key.WriteString("\n" + iwr.Image)
+	sum := sha256.Sum256([]byte(key.String()))
+	return hex.EncodeToString(sum[:])[:workHashLength]
+}
+
+// jobName returns a name <prefix>-<hash>-<suffix> with a random suffix. The prefix is truncated for the name
+// to be a valid label value, since it is the job-name label of the pods of the job
+func jobName(prefix, hash string) string {
+	maxPrefix := validation.LabelValueMaxLength - len(hash) - jobNameSuffixLength - 2
+	if len(prefix) > maxPrefix {
+		prefix = prefix[:maxPrefix]
+	}
+	// a name part must end with an alphanumeric character
+	prefix = strings.TrimRight(prefix, "-.")
+	return prefix + "-" + hash + "-" + utilrand.String(jobNameSuffixLength)
+}
+
+// setJobName names the job of the image work request after its image cache and the hash of its work, and
+// labels the job and its pods with the hash and the hostname of the node. The jobs of the same image, node
+// and image cache thus share the hash, while the random suffix of their names tells them apart
+func setJobName(job *batchv1.Job, iwr ImageWorkRequest) {
+	hash := workHash(iwr)
+	job.GenerateName = ""
+	job.Name = jobName(iwr.Imagecache.Name, hash)
+	for _, labels := range []*map[string]string{&job.Labels, &job.Spec.Template.Labels} {
+		if *labels == nil {
+			*labels = map[string]string{}
+		}
+		(*labels)[workHashLabel] = hash
+		if iwr.Node != nil {
+			(*labels)[nodeLabel] = iwr.Node.Labels["kubernetes.io/hostname"]
+		}
+	}
+}
+
+// fakeJobName returns the name under which the result of an image work request with no job is recorded
+func fakeJobName(iwr ImageWorkRequest) string {
+	prefix := fakeJobPrefix
+	if iwr.Imagecache != nil {
+		prefix += iwr.Imagecache.Name
+	}
+	return jobName(prefix, workHash(iwr))
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"strings"
+	"testing"
+
+	fledgedv1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestSetJobName(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{"kubernetes.io/hostname": "node1"}}}
+	iwr := func(cacheName, image string) ImageWorkRequest {
+		return ImageWorkRequest{Image: image, Node: node,
+			Imagecache: &fledgedv1alpha2.ImageCache{ObjectMeta: metav1.ObjectMeta{Name: cacheName, Namespace: "kube-fledged"}}}
+	}
+	longName := strings.Repeat("a", 60) + "-b"
+	tests := []struct {
+		name           string
+		iwr            ImageWorkRequest
+		expectedPrefix string
+	}{
+		{name: "#1: Short image cache name", iwr: iwr("foo", "foo:v1"), expectedPrefix: "foo-"},
+		{name: "#2: Long image cache name", iwr: iwr(longName, "foo:v1"), expectedPrefix: strings.Repeat("a", 46) + "-"},
+		{name: "#3: Image cache name truncated at a dash", iwr: iwr(strings.Repeat("a", 45)+"-b", "foo:v1"), expectedPrefix: strings.Repeat("a", 45) + "-"},
+	}
+	for _, test := range tests {
+		job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{GenerateName: test.iwr.Imagecache.Name + "-"}}
+		setJobName(job, test.iwr)
+		hash := workHash(test.iwr)
+		if !strings.HasPrefix(job.Name, test.expectedPrefix+hash+"-") || job.GenerateName != "" {
+			t.Errorf("Test: %s failed: expectedPrefix=%s, actualName=%s", test.name, test.expectedPrefix+hash+"-", job.Name)
+		}
+		if errs := validation.IsDNS1123Label(job.Name); len(errs) > 0 {
+			t.Errorf("Test: %s failed: invalid name %s: %v", test.name, job.Name, errs)
+		}
+		if job.Labels[workHashLabel] != hash || job.Spec.Template.Labels[workHashLabel] != hash ||
+			job.Spec.Template.Labels[nodeLabel] != "node1" {
+			t.Errorf("Test: %s failed: expectedHash=%s, actualLabels=%v, actualPodLabels=%v", test.name, hash,
+				job.Labels, job.Spec.Template.Labels)
+		}
+	}
+
+	if workHash(iwr("foo", "foo:v1")) != workHash(iwr("foo", "foo:v1")) || workHash(iwr("foo", "foo:v1")) == workHash(iwr("foo", "foo:v2")) {
+		t.Errorf("Test: work hash is not deterministic and distinct per image")
+	}
+	if name1, name2 := jobName("foo", "0123456789"), jobName("foo", "0123456789"); name1 == name2 {
+		t.Errorf("Test: names of the jobs of the same work collide: %s", name1)
+	}
+	if name := fakeJobName(iwr("foo", "foo:v1")); !strings.HasPrefix(name, fakeJobPrefix+"foo-"+workHash(iwr("foo", "foo:v1"))) {
+		t.Errorf("Test: unexpected fake job name %s", name)
+	}
+}
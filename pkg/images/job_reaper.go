@@ -0,0 +1,86 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// jobReapInterval is the interval at which the completed jobs are deleted by the job reaper
+const jobReapInterval = 5 * time.Second
+
+// jobReaper deletes the completed jobs, whose results were read, in batches on a timer, so that the status
+// updates of the image caches do not wait for their jobs to be deleted one at a time
+type jobReaper struct {
+	lock sync.Mutex
+	// jobs has the namespace of each job to be deleted
+	jobs map[string]string
+}
+
+// newJobReaper returns a job reaper with no jobs to be deleted
+func newJobReaper() *jobReaper {
+	return &jobReaper{jobs: make(map[string]string)}
+}
+
+// add marks the job as completed, to be deleted by the next batch
+func (r *jobReaper) add(namespace, job string) {
+	r.lock.Lock()
+	r.jobs[job] = namespace
+	r.lock.Unlock()
+}
+
+// pending returns the no. of jobs to be deleted
+func (r *jobReaper) pending() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return len(r.jobs)
+}
+
+// reap deletes the jobs marked as completed. The jobs which could not be deleted, other than the ones not
+// found, are kept for the next batch
+func (r *jobReaper) reap(kubeclientset kubernetes.Interface) {
+	r.lock.Lock()
+	batch := r.jobs
+	r.jobs = make(map[string]string)
+	r.lock.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+	deletePropagation := metav1.DeletePropagationBackground
+	deleted := 0
+	for job, namespace := range batch {
+		err := kubeclientset.BatchV1().Jobs(namespace).
+			Delete(context.TODO(), job, metav1.DeleteOptions{PropagationPolicy: &deletePropagation})
+		switch {
+		case err == nil:
+			deleted++
+		case apierrors.IsNotFound(err):
+			glog.Warningf("Error deleting job %s: %s", job, "not found")
+		default:
+			glog.Errorf("Error deleting job %s: %v", job, err)
+			r.add(namespace, job)
+		}
+	}
+	glog.V(4).Infof("Job reaper deleted %d of %d completed jobs", deleted, len(batch))
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+)
+
+func TestJobReaper(t *testing.T) {
+	job := func(name string) *batchv1.Job {
+		return &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: fledgedNameSpace}}
+	}
+	fakekubeclientset := fakeclientset.NewSimpleClientset(job("job1"), job("job2"), job("job3"))
+	failDeletes := true
+	fakekubeclientset.PrependReactor("delete", "jobs", func(action core.Action) (bool, runtime.Object, error) {
+		if failDeletes && action.(core.DeleteAction).GetName() == "job3" {
+			return true, nil, apierrors.NewServerTimeout(batchv1.Resource("jobs"), "delete", 1)
+		}
+		return false, nil, nil
+	})
+
+	reaper := newJobReaper()
+	for _, name := range []string{"job1", "job2", "job3", "missing"} {
+		reaper.add(fledgedNameSpace, name)
+	}
+	reaper.reap(fakekubeclientset)
+	jobs, _ := fakekubeclientset.BatchV1().Jobs(fledgedNameSpace).List(context.TODO(), metav1.ListOptions{})
+	if len(jobs.Items) != 1 || jobs.Items[0].Name != "job3" || reaper.pending() != 1 {
+		t.Errorf("Test: first batch failed: expectedJobs=[job3], actualJobs=%d, expectedPending=1, actualPending=%d", len(jobs.Items), reaper.pending())
+	}
+
+	failDeletes = false
+	reaper.reap(fakekubeclientset)
+	jobs, _ = fakekubeclientset.BatchV1().Jobs(fledgedNameSpace).List(context.TODO(), metav1.ListOptions{})
+	if len(jobs.Items) != 0 || reaper.pending() != 0 {
+		t.Errorf("Test: retried batch failed: expectedJobs=0, actualJobs=%d, expectedPending=0, actualPending=%d", len(jobs.Items), reaper.pending())
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	fledgedv1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// jobTemplateOwnFields are the fields of the pod spec of a job which are never taken from the job template:
+// the containers and their volumes are those of the job
+var jobTemplateOwnFields = map[string]bool{
+	"InitContainers":      true,
+	"Containers":          true,
+	"EphemeralContainers": true,
+	"Volumes":             true,
+}
+
+// setJobTemplate applies the PodTemplate referenced by the jobTemplate of the image cache, if any, to the
+// pod of the job. It returns ErrJobTemplateNotFound if the PodTemplate does not exist
+func (m *ImageManager) setJobTemplate(ctx context.Context, job *batchv1.Job, imagecache *fledgedv1alpha2.ImageCache) error {
+	if imagecache.Spec.JobTemplate == nil || imagecache.Spec.JobTemplate.Name == "" {
+		return nil
+	}
+	name := imagecache.Spec.JobTemplate.Name
+	podTemplate, err := m.kubeclientset.CoreV1().PodTemplates(imagecache.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("%w: podtemplate %s/%s", ErrJobTemplateNotFound, imagecache.Namespace, name)
+	}
+	if err != nil {
+		return err
+	}
+	applyJobTemplate(job, &podTemplate.Template)
+	return nil
+}
+
+// applyJobTemplate uses the pod template as the base of the pod of the job. The labels and annotations of the
+// template are added to those of the pod, and its node selector, pod (anti-)affinity and image pull secrets to
+// those the job needs to run on its node. Its tolerations replace the default toleration of all taints. Every
+// other field of the pod spec the job leaves unset is taken from the template, except the containers and the
+// volumes, which are always those of the job
+func applyJobTemplate(job *batchv1.Job, template *corev1.PodTemplateSpec) {
+	template = template.DeepCopy()
+	podMeta := &job.Spec.Template.ObjectMeta
+	podMeta.Labels = withTemplateEntries(podMeta.Labels, template.Labels)
+	podMeta.Annotations = withTemplateEntries(podMeta.Annotations, template.Annotations)
+
+	podSpec := &job.Spec.Template.Spec
+	podSpec.NodeSelector = withTemplateEntries(podSpec.NodeSelector, template.Spec.NodeSelector)
+	if len(template.Spec.Tolerations) > 0 {
+		podSpec.Tolerations = template.Spec.Tolerations
+	}
+	if podSpec.Affinity != nil && template.Spec.Affinity != nil {
+		podSpec.Affinity.PodAffinity = template.Spec.Affinity.PodAffinity
+		podSpec.Affinity.PodAntiAffinity = template.Spec.Affinity.PodAntiAffinity
+	}
+	podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, template.Spec.ImagePullSecrets...)
+
+	spec, base := reflect.ValueOf(podSpec).Elem(), reflect.ValueOf(&template.Spec).Elem()
+	for i := 0; i < spec.NumField(); i++ {
+		if jobTemplateOwnFields[spec.Type().Field(i).Name] || !spec.Field(i).IsZero() {
+			continue
+		}
+		spec.Field(i).Set(base.Field(i))
+	}
+}
+
+// withTemplateEntries adds the entries of the template to the map, keeping the value of the keys it already has
+func withTemplateEntries(m, template map[string]string) map[string]string {
+	if len(template) == 0 {
+		return m
+	}
+	if m == nil {
+		m = make(map[string]string, len(template))
+	}
+	for key, value := range template {
+		if _, ok := m[key]; !ok {
+			m[key] = value
+		}
+	}
+	return m
+}
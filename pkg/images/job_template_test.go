@@ -0,0 +1,104 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	fledgedv1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSetJobTemplate(t *testing.T) {
+	ndots := "2"
+	podTemplate := &corev1.PodTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "jobs", Namespace: fledgedNameSpace},
+		Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:      map[string]string{"team": "platform", "app": "other"},
+				Annotations: map[string]string{"example.com/audit": "true"},
+			},
+			Spec: corev1.PodSpec{
+				Containers:        []corev1.Container{{Name: "ignored", Image: "ignored:v1"}},
+				NodeSelector:      map[string]string{"pool": "gpu"},
+				Tolerations:       []corev1.Toleration{{Key: "gpu", Operator: corev1.TolerationOpExists}},
+				Affinity:          &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{}},
+				DNSPolicy:         corev1.DNSNone,
+				DNSConfig:         &corev1.PodDNSConfig{Nameservers: []string{"10.0.0.10"}, Options: []corev1.PodDNSConfigOption{{Name: "ndots", Value: &ndots}}},
+				PriorityClassName: "template-priority",
+				ImagePullSecrets:  []corev1.LocalObjectReference{{Name: "template-secret"}},
+			},
+		},
+	}
+	m := &ImageManager{kubeclientset: fakeclientset.NewSimpleClientset(podTemplate)}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{"kubernetes.io/hostname": "node1"}}}
+	imagecache := &fledgedv1alpha2.ImageCache{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: fledgedNameSpace},
+		Spec: fledgedv1alpha2.ImageCacheSpec{
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "cache-secret"}},
+			JobTemplate:      &corev1.LocalObjectReference{Name: "jobs"},
+		},
+	}
+	job, _ := newImagePullJob(imagecache, "foo:v1", node, "IfNotPresent", "busybox:1.36", "", "jobs-priority", "",
+		corev1.PullIfNotPresent, false, nil)
+	if err := m.setJobTemplate(context.TODO(), job, imagecache); err != nil {
+		t.Fatalf("Test: job template failed: %v", err)
+	}
+	podSpec := job.Spec.Template.Spec
+	if len(podSpec.Containers) != 1 || podSpec.Containers[0].Name != "imagepuller" {
+		t.Errorf("Test: containers failed: expected=[imagepuller], actual=%v", podSpec.Containers)
+	}
+	if labels := job.Spec.Template.Labels; labels["app"] != "kubefledged" || labels["team"] != "platform" {
+		t.Errorf("Test: labels failed: actual=%v", labels)
+	}
+	if job.Spec.Template.Annotations["example.com/audit"] != "true" {
+		t.Errorf("Test: annotations failed: actual=%v", job.Spec.Template.Annotations)
+	}
+	expectedNodeSelector := map[string]string{"kubernetes.io/hostname": "node1", "pool": "gpu"}
+	if !reflect.DeepEqual(podSpec.NodeSelector, expectedNodeSelector) {
+		t.Errorf("Test: node selector failed: expected=%v, actual=%v", expectedNodeSelector, podSpec.NodeSelector)
+	}
+	if !reflect.DeepEqual(podSpec.Tolerations, podTemplate.Template.Spec.Tolerations) {
+		t.Errorf("Test: tolerations failed: expected=%v, actual=%v", podTemplate.Template.Spec.Tolerations, podSpec.Tolerations)
+	}
+	if podSpec.Affinity.NodeAffinity == nil || podSpec.Affinity.PodAntiAffinity == nil {
+		t.Errorf("Test: affinity failed: expected node affinity and pod anti-affinity, actual=%v", podSpec.Affinity)
+	}
+	if podSpec.DNSPolicy != corev1.DNSNone || podSpec.DNSConfig == nil || podSpec.DNSConfig.Nameservers[0] != "10.0.0.10" {
+		t.Errorf("Test: dns failed: expectedPolicy=None, actualPolicy=%s, actualConfig=%v", podSpec.DNSPolicy, podSpec.DNSConfig)
+	}
+	if podSpec.PriorityClassName != "jobs-priority" {
+		t.Errorf("Test: priority class failed: expected=jobs-priority, actual=%s", podSpec.PriorityClassName)
+	}
+	expectedSecrets := []corev1.LocalObjectReference{{Name: "cache-secret"}, {Name: "template-secret"}}
+	if !reflect.DeepEqual(podSpec.ImagePullSecrets, expectedSecrets) {
+		t.Errorf("Test: image pull secrets failed: expected=%v, actual=%v", expectedSecrets, podSpec.ImagePullSecrets)
+	}
+	if len(podTemplate.Template.Spec.ImagePullSecrets) != 1 || podTemplate.Template.Labels["app"] != "other" {
+		t.Errorf("Test: the pod template was modified")
+	}
+
+	imagecache.Spec.JobTemplate.Name = "missing"
+	if err := m.setJobTemplate(context.TODO(), job, imagecache); !errors.Is(err, ErrJobTemplateNotFound) {
+		t.Errorf("Test: missing job template failed: expectedErr=%v, actualErr=%v", ErrJobTemplateNotFound, err)
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+// managedImagesOf returns the images recorded in the managed images annotation of the node
+func managedImagesOf(node *corev1.Node, annotation string) []string {
+	value, ok := node.Annotations[annotation]
+	if !ok {
+		return nil
+	}
+	var images []string
+	if err := json.Unmarshal([]byte(value), &images); err != nil {
+		glog.Warningf("Ignoring annotation %s of node %s: %v", annotation, node.Name, err)
+		return nil
+	}
+	return images
+}
+
+// withManagedImage returns the sorted images with the image added or removed, and whether they changed
+func withManagedImage(images []string, image string, managed bool) ([]string, bool) {
+	updated := make([]string, 0, len(images)+1)
+	found := false
+	for _, i := range images {
+		if i == image {
+			found = true
+			if !managed {
+				continue
+			}
+		}
+		updated = append(updated, i)
+	}
+	if found == managed {
+		return images, false
+	}
+	if managed {
+		updated = append(updated, image)
+	}
+	sort.Strings(updated)
+	return updated, true
+}
+
+// recordManagedImage adds the image of the request to, or removes it from, the managed images annotation of
+// its node, once it has been pulled or deleted
+func (m *ImageManager) recordManagedImage(iwr ImageWorkRequest, managed bool) {
+	if m.managedImagesAnnotation == "" {
+		return
+	}
+	image := m.nodeImages.normalize(iwr.Image)
+	nodes := m.kubeclientset.CoreV1().Nodes()
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node, err := nodes.Get(context.TODO(), iwr.Node.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		images, changed := withManagedImage(managedImagesOf(node, m.managedImagesAnnotation), image, managed)
+		if !changed {
+			return nil
+		}
+		value, err := json.Marshal(images)
+		if err != nil {
+			return err
+		}
+		// the resource version makes the patch fail with a conflict if the annotation changed meanwhile
+		patch, err := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{
+			"resourceVersion": node.ResourceVersion,
+			"annotations":     map[string]string{m.managedImagesAnnotation: string(value)},
+		}})
+		if err != nil {
+			return err
+		}
+		_, err = nodes.Patch(context.TODO(), node.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+		return err
+	})
+	if err != nil {
+		glog.Errorf("Error recording managed image %s on node %s: %v", image, iwr.Node.Name, err)
+	}
+}
+
+// checkManagedImage returns an error if the image of the request is not recorded in the managed images
+// annotation of its node, so that only images pulled by kubefledged are deleted
+func (m *ImageManager) checkManagedImage(ctx context.Context, iwr ImageWorkRequest) error {
+	if m.managedImagesAnnotation == "" {
+		return nil
+	}
+	node, err := m.kubeclientset.CoreV1().Nodes().Get(ctx, iwr.Node.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	image := m.nodeImages.normalize(iwr.Image)
+	for _, i := range managedImagesOf(node, m.managedImagesAnnotation) {
+		if i == image {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s not pulled to node %s by kubefledged", ErrImageNotManaged, iwr.Image, iwr.Node.Name)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWithManagedImage(t *testing.T) {
+	tests := []struct {
+		name            string
+		images          []string
+		image           string
+		managed         bool
+		expectedImages  []string
+		expectedChanged bool
+	}{
+		{name: "#1: Add to no images", images: nil, image: "foo:v1", managed: true,
+			expectedImages: []string{"foo:v1"}, expectedChanged: true},
+		{name: "#2: Add in order", images: []string{"bar:v1", "foo:v1"}, image: "baz:v1", managed: true,
+			expectedImages: []string{"bar:v1", "baz:v1", "foo:v1"}, expectedChanged: true},
+		{name: "#3: Add a recorded image", images: []string{"foo:v1"}, image: "foo:v1", managed: true,
+			expectedImages: []string{"foo:v1"}, expectedChanged: false},
+		{name: "#4: Remove", images: []string{"bar:v1", "foo:v1"}, image: "bar:v1", managed: false,
+			expectedImages: []string{"foo:v1"}, expectedChanged: true},
+		{name: "#5: Remove an image not recorded", images: []string{"foo:v1"}, image: "bar:v1", managed: false,
+			expectedImages: []string{"foo:v1"}, expectedChanged: false},
+	}
+	for _, test := range tests {
+		images, changed := withManagedImage(test.images, test.image, test.managed)
+		if !reflect.DeepEqual(images, test.expectedImages) || changed != test.expectedChanged {
+			t.Errorf("Test: %s failed: expectedImages=%v, actualImages=%v, expectedChanged=%t, actualChanged=%t",
+				test.name, test.expectedImages, images, test.expectedChanged, changed)
+		}
+	}
+}
+
+func TestManagedImages(t *testing.T) {
+	const annotation = "kubefledged.io/managed-images"
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{"kubernetes.io/hostname": "node1"}}}
+	fakekubeclientset := fakeclientset.NewSimpleClientset(node)
+	imagemanager, _ := newTestImageManager(fakekubeclientset, "IfNotPresent", "", false, "", true, "")
+	iwr := ImageWorkRequest{Image: "foo:v1", Node: node, WorkType: ImageCachePurge}
+
+	// without the annotation, all images are deleted
+	if err := imagemanager.checkManagedImage(context.TODO(), iwr); err != nil {
+		t.Errorf("Test: delete without a managed images annotation failed: %v", err)
+	}
+
+	imagemanager.managedImagesAnnotation = annotation
+	if err := imagemanager.checkManagedImage(context.TODO(), iwr); !errors.Is(err, ErrImageNotManaged) {
+		t.Errorf("Test: delete of an image not pulled: expectedErr=%v, actualErr=%v", ErrImageNotManaged, err)
+	}
+
+	managedImages := func() []string {
+		n, err := fakekubeclientset.CoreV1().Nodes().Get(context.TODO(), "node1", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Test: error getting node: %v", err)
+		}
+		return managedImagesOf(n, annotation)
+	}
+	imagemanager.recordManagedImage(iwr, true)
+	imagemanager.recordManagedImage(ImageWorkRequest{Image: "bar:v1", Node: node}, true)
+	expected := []string{"docker.io/library/bar:v1", "docker.io/library/foo:v1"}
+	if actual := managedImages(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Test: pulled images: expectedManagedImages=%v, actualManagedImages=%v", expected, actual)
+	}
+	if err := imagemanager.checkManagedImage(context.TODO(), iwr); err != nil {
+		t.Errorf("Test: delete of a pulled image failed: %v", err)
+	}
+
+	imagemanager.recordManagedImage(iwr, false)
+	expected = []string{"docker.io/library/bar:v1"}
+	if actual := managedImages(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Test: deleted image: expectedManagedImages=%v, actualManagedImages=%v", expected, actual)
+	}
+}
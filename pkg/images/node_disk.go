@@ -0,0 +1,164 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes"
+)
+
+// nodeStatsSummary is the part of the kubelet stats summary having the free disk of the node
+type nodeStatsSummary struct {
+	Node struct {
+		Fs      *fsStats `json:"fs,omitempty"`
+		Runtime *struct {
+			ImageFs *fsStats `json:"imageFs,omitempty"`
+		} `json:"runtime,omitempty"`
+	} `json:"node"`
+}
+
+// fsStats has the available bytes of a filesystem
+type fsStats struct {
+	AvailableBytes *uint64 `json:"availableBytes,omitempty"`
+}
+
+// nodeFreeDisk returns the free bytes of the filesystem holding the images of the node, as reported
+// by the kubelet stats summary. The node filesystem is used if the runtime has no separate image filesystem
+func nodeFreeDisk(kubeclientset kubernetes.Interface, node *corev1.Node) (int64, error) {
+	raw, err := kubeclientset.CoreV1().RESTClient().Get().
+		AbsPath("/api/v1/nodes", node.Name, "proxy", "stats", "summary").DoRaw(context.TODO())
+	if err != nil {
+		return 0, err
+	}
+	var summary nodeStatsSummary
+	if err := json.Unmarshal(raw, &summary); err != nil {
+		return 0, err
+	}
+	if rt := summary.Node.Runtime; rt != nil && rt.ImageFs != nil && rt.ImageFs.AvailableBytes != nil {
+		return int64(*rt.ImageFs.AvailableBytes), nil
+	}
+	if fs := summary.Node.Fs; fs != nil && fs.AvailableBytes != nil {
+		return int64(*fs.AvailableBytes), nil
+	}
+	return 0, fmt.Errorf("no available bytes in stats summary of node %s", node.Name)
+}
+
+// hasDiskPressure returns true if the DiskPressure condition of the node is true
+func hasDiskPressure(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeDiskPressure {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// checkFreeDisk returns ErrInsufficientDisk if the node is under disk pressure or has less free disk
+// than the minimum. If the free disk of the node cannot be found, the check is skipped
+func (m *ImageManager) checkFreeDisk(node *corev1.Node) error {
+	if m.minFreeDisk <= 0 {
+		return nil
+	}
+	hostname := node.Labels["kubernetes.io/hostname"]
+	if hasDiskPressure(node) {
+		return fmt.Errorf("%w: %s is under disk pressure", ErrInsufficientDisk, hostname)
+	}
+	free, err := m.freeDisk(node)
+	if err != nil {
+		glog.Warningf("Error getting free disk of node %s, skipping disk check: %v", hostname, err)
+		return nil
+	}
+	if free < m.minFreeDisk {
+		return fmt.Errorf("%w: %s has %s free, at least %s required", ErrInsufficientDisk, hostname,
+			resource.NewQuantity(free, resource.BinarySI), resource.NewQuantity(m.minFreeDisk, resource.BinarySI))
+	}
+	return nil
+}
+
+// imageRefersTo returns true if name, as listed in the status of a node e.g. docker.io/library/nginx:1.23,
+// refers to image e.g. nginx:1.23
+func imageRefersTo(name, image string) bool {
+	return name == image || strings.HasSuffix(name, "/"+image)
+}
+
+// repositoryOf returns the image name without its tag and digest
+func repositoryOf(name string) string {
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	return name
+}
+
+// cachedBytes returns the total size of the images in the node status which are any of the images
+// or a tag of any of the repositories
+func cachedBytes(node *corev1.Node, images, repositories []string) int64 {
+	var total int64
+	for _, nodeImage := range node.Status.Images {
+		if nodeImageCached(nodeImage.Names, images, repositories) {
+			total += nodeImage.SizeBytes
+		}
+	}
+	return total
+}
+
+// nodeImageCached returns true if any of the names of an image in the node status refers to any of
+// the images or to a tag of any of the repositories
+func nodeImageCached(names, images, repositories []string) bool {
+	for _, name := range names {
+		for _, image := range images {
+			if imageRefersTo(name, image) {
+				return true
+			}
+		}
+		for _, repository := range repositories {
+			if imageRefersTo(repositoryOf(name), repository) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkCacheBudget returns ErrBudgetExceeded if the images of all the image caches already take up the
+// maximum bytes per node on the node. The sizes are those of the node status. Pulls of images already
+// present in the node are not checked, as they take up no more disk
+func (m *ImageManager) checkCacheBudget(iwr ImageWorkRequest) error {
+	if m.maxCacheBytesPerNode <= 0 || m.cachedImages == nil {
+		return nil
+	}
+	if cachedBytes(iwr.Node, []string{iwr.Image}, nil) > 0 {
+		return nil
+	}
+	images, repositories := m.cachedImages()
+	total := cachedBytes(iwr.Node, images, repositories)
+	if total >= m.maxCacheBytesPerNode {
+		return fmt.Errorf("%w: %s has %s of cached images, at most %s allowed", ErrBudgetExceeded,
+			iwr.Node.Labels["kubernetes.io/hostname"], resource.NewQuantity(total, resource.BinarySI),
+			resource.NewQuantity(m.maxCacheBytesPerNode, resource.BinarySI))
+	}
+	return nil
+}
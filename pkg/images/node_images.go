@@ -0,0 +1,137 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/docker/distribution/reference"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// defaultImageRegistry is the registry of the images whose name has no registry, unless a default registry is set
+const defaultImageRegistry = "docker.io"
+
+// nodeImageIndex indexes the names of the images in the status of the nodes, so that finding whether an
+// image is present in a node doesn't scan the node status for every image. The entry of a node is rebuilt
+// whenever the resource version of the node changes and dropped when the node is updated or deleted
+type nodeImageIndex struct {
+	lock            sync.RWMutex
+	nodes           map[string]indexedNodeImages
+	defaultRegistry string
+}
+
+// indexedNodeImages has the image names of a node at a resource version
+type indexedNodeImages struct {
+	resourceVersion string
+	names           map[string]bool
+}
+
+// newNodeImageIndex returns an empty node image index. Images without a registry are looked up in the
+// default registry, or in docker.io if it is empty
+func newNodeImageIndex(defaultRegistry string) *nodeImageIndex {
+	return &nodeImageIndex{nodes: make(map[string]indexedNodeImages), defaultRegistry: defaultRegistry}
+}
+
+// NormalizeImage returns the fully qualified reference of the image, with defaultRegistry as the registry of
+// an image without one. See normalizeImage
+func NormalizeImage(image, defaultRegistry string) string {
+	return normalizeImage(image, defaultRegistry)
+}
+
+// normalizeImage returns the fully qualified reference of the image e.g. docker.io/library/nginx:latest for
+// nginx: the default registry is added to an image without a registry, the library namespace to the images of
+// docker.io without a namespace, and the latest tag to an image without a tag or digest. Images which are not
+// valid references are returned as is
+func normalizeImage(image, defaultRegistry string) string {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return image
+	}
+	named = reference.TagNameOnly(named)
+	if defaultRegistry != "" && defaultRegistry != defaultImageRegistry && !hasRegistry(image) {
+		return defaultRegistry + "/" + reference.FamiliarString(named)
+	}
+	return named.String()
+}
+
+// hasRegistry returns true if the first path component of the image is a registry i.e. a host name with a
+// dot or port, or localhost
+func hasRegistry(image string) bool {
+	i := strings.Index(image, "/")
+	if i < 0 {
+		return false
+	}
+	return strings.ContainsAny(image[:i], ".:") || image[:i] == "localhost"
+}
+
+// normalize returns the fully qualified reference of the image with the default registry of the index
+func (x *nodeImageIndex) normalize(image string) string {
+	if x == nil {
+		return normalizeImage(image, "")
+	}
+	return normalizeImage(image, x.defaultRegistry)
+}
+
+// imageNames returns the names of the images in the status of the node. Besides each name e.g.
+// docker.io/library/nginx:1.23, the name without its leading path components i.e. library/nginx:1.23
+// and nginx:1.23 is included, so that images are found whether or not they are fully qualified. The
+// normalized name is included too, for the runtimes which report names which are not fully qualified
+func imageNames(node *corev1.Node) map[string]bool {
+	names := make(map[string]bool)
+	for _, nodeImage := range node.Status.Images {
+		for _, name := range nodeImage.Names {
+			names[normalizeImage(name, "")] = true
+			for {
+				names[name] = true
+				i := strings.Index(name, "/")
+				if i < 0 {
+					break
+				}
+				name = name[i+1:]
+			}
+		}
+	}
+	return names
+}
+
+// contains returns true if the image, as is or normalized, is present in the node. Nodes without a resource
+// version are not indexed
+func (x *nodeImageIndex) contains(node *corev1.Node, image string) bool {
+	if x == nil || node.ResourceVersion == "" {
+		names := imageNames(node)
+		return names[image] || names[x.normalize(image)]
+	}
+	x.lock.RLock()
+	entry, ok := x.nodes[node.Name]
+	x.lock.RUnlock()
+	if !ok || entry.resourceVersion != node.ResourceVersion {
+		entry = indexedNodeImages{resourceVersion: node.ResourceVersion, names: imageNames(node)}
+		x.lock.Lock()
+		x.nodes[node.Name] = entry
+		x.lock.Unlock()
+	}
+	return entry.names[image] || entry.names[x.normalize(image)]
+}
+
+// invalidate drops the entry of the node
+func (x *nodeImageIndex) invalidate(nodeName string) {
+	x.lock.Lock()
+	delete(x.nodes, nodeName)
+	x.lock.Unlock()
+}
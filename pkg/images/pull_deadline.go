@@ -0,0 +1,187 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/glog"
+	fledgedv1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// pullProgressCheckInterval is how often the pull jobs still running past the image pull deadline are
+// checked for progress, while the deadline is extended
+const pullProgressCheckInterval = 30 * time.Second
+
+// maxSizedPullDeadline caps the pull deadlines proportional to the size of the images at the active
+// deadline of the pull jobs
+const maxSizedPullDeadline = time.Hour
+
+// imageSizeHint returns the expected size of the image of the pull: its size in imageSizes of its image
+// list or, if the image is already in the node e.g. when it is refreshed, its size in the node status.
+// It is 0 if the size is not known
+func imageSizeHint(iwr ImageWorkRequest) int64 {
+	if iwr.Imagecache != nil && iwr.ImageList < len(iwr.Imagecache.Spec.CacheSpec) {
+		if size, ok := iwr.Imagecache.Spec.CacheSpec[iwr.ImageList].ImageSizes[iwr.Image]; ok {
+			return size.Value()
+		}
+	}
+	if iwr.Node != nil {
+		return cachedBytes(iwr.Node, []string{iwr.Image}, nil)
+	}
+	return 0
+}
+
+// sizedPullDeadline returns the time after which the pull is failed, when its deadline is proportional to
+// the size of its image: the image pull deadline base plus the time the image takes to be pulled at the
+// image pull bandwidth. It is the zero time if the bandwidth or the size of the image is not known, or if
+// the image cache sets jobDeadlineSeconds, in which case the image pull deadline applies
+func (m *ImageManager) sizedPullDeadline(iwr ImageWorkRequest) time.Time {
+	if m.imagePullBandwidth <= 0 || iwr.Imagecache.Spec.JobDeadlineSeconds != nil {
+		return time.Time{}
+	}
+	size := imageSizeHint(iwr)
+	if size <= 0 {
+		return time.Time{}
+	}
+	deadline := m.imagePullDeadlineBase + time.Duration(float64(size)/float64(m.imagePullBandwidth)*float64(time.Second))
+	if deadline > maxSizedPullDeadline {
+		deadline = maxSizedPullDeadline
+	}
+	return time.Now().Add(deadline)
+}
+
+// latestSizedPullDeadline returns the latest of the sized deadlines of the pending pulls of the image cache
+func (m *ImageManager) latestSizedPullDeadline(imageCacheName string) time.Time {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	var latest time.Time
+	for _, iwres := range m.imageworkstatus {
+		if iwres.ImageWorkRequest.Imagecache.Name == imageCacheName && iwres.Status == ImageWorkResultStatusJobCreated &&
+			iwres.deadline.After(latest) {
+			latest = iwres.deadline
+		}
+	}
+	return latest
+}
+
+// waitSizedPullDeadlines keeps waiting for the jobs of the image cache past its image pull deadline, until
+// the latest sized deadline of its pending pulls. It returns true if the jobs are done by then
+func (m *ImageManager) waitSizedPullDeadlines(imageCache *fledgedv1alpha2.ImageCache, jobsDone wait.ConditionFunc) bool {
+	for remaining := time.Until(m.latestSizedPullDeadline(imageCache.Name)); remaining > 0; remaining = time.Until(m.latestSizedPullDeadline(imageCache.Name)) {
+		glog.V(4).Infof("Waiting %s for the pulls of image cache %s with deadlines sized to their images", remaining, imageCache.Name)
+		if err := wait.Poll(time.Second, remaining, jobsDone); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// extendPullDeadline keeps waiting for the jobs of the image cache past the image pull deadline, as long
+// as some of its pulls are progressing, until the maximum image pull deadline. The deadline of an image
+// cache with jobDeadlineSeconds is not extended, since its jobs are stopped at that deadline. It returns
+// true if the jobs were done within the extended deadline
+func (m *ImageManager) extendPullDeadline(imageCache *fledgedv1alpha2.ImageCache, jobsDone wait.ConditionFunc) bool {
+	if imageCache.Spec.JobDeadlineSeconds != nil || m.imagePullDeadlineMax <= m.imagePullDeadlineDuration {
+		return false
+	}
+	deadline := time.Now().Add(m.imagePullDeadlineMax - m.imagePullDeadlineDuration)
+	for remaining := time.Until(deadline); remaining > 0; remaining = time.Until(deadline) {
+		if !m.pullsProgressing(imageCache.Name) {
+			return false
+		}
+		glog.Infof("Extending image pull deadline of image cache %s: images are still being pulled", imageCache.Name)
+		if remaining > pullProgressCheckInterval {
+			remaining = pullProgressCheckInterval
+		}
+		if err := wait.Poll(time.Second, remaining, jobsDone); err == nil {
+			return true
+		}
+	}
+	glog.Infof("Maximum image pull deadline of image cache %s reached", imageCache.Name)
+	return false
+}
+
+// pullsProgressing returns true if the image of any pending pull job of the image cache is being pulled
+func (m *ImageManager) pullsProgressing(imageCacheName string) bool {
+	var namespace string
+	var jobs []string
+	m.lock.RLock()
+	for job, iwres := range m.imageworkstatus {
+		if iwres.ImageWorkRequest.Imagecache.Name == imageCacheName && iwres.Status == ImageWorkResultStatusJobCreated &&
+			iwres.ImageWorkRequest.WorkType != ImageCachePurge && !iwres.verifying {
+			namespace = iwres.ImageWorkRequest.Imagecache.Namespace
+			jobs = append(jobs, job)
+		}
+	}
+	m.lock.RUnlock()
+	for _, job := range jobs {
+		pods, err := m.podsLister.Pods(namespace).List(labels.Set(map[string]string{"job-name": job}).AsSelector())
+		if err != nil {
+			glog.Errorf("Error listing pods of job %s: %v", job, err)
+			continue
+		}
+		for _, pod := range pods {
+			if m.podPullingImage(pod) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// podPullingImage returns true if the kubelet is pulling the image of the pod: the latest event of the
+// pending pod is a Pulling event. A stuck pull is reported by Failed and BackOff events instead
+func (m *ImageManager) podPullingImage(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodPending || isImagePullFailing(pod) {
+		return false
+	}
+	fieldSelector := fields.Set{
+		"involvedObject.kind":      "Pod",
+		"involvedObject.name":      pod.Name,
+		"involvedObject.namespace": pod.Namespace,
+	}.AsSelector().String()
+	eventlist, err := m.kubeclientset.CoreV1().Events(pod.Namespace).List(context.TODO(), metav1.ListOptions{FieldSelector: fieldSelector})
+	if err != nil {
+		glog.Errorf("Error listing events for pod (%s): %v", pod.Name, err)
+		return false
+	}
+	var latest *corev1.Event
+	for i := range eventlist.Items {
+		if latest == nil || eventTime(&eventlist.Items[i]).After(eventTime(latest)) {
+			latest = &eventlist.Items[i]
+		}
+	}
+	return latest != nil && latest.Reason == "Pulling"
+}
+
+// eventTime returns the time the event last occurred
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
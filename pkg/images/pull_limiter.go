@@ -0,0 +1,177 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// pullLimiter limits the number of image pull jobs running concurrently on a node. The limit of
+// a node starts at the initial value, doubles on every successful pull until it reaches the maximum
+// and is halved (but not below the initial value) on every failed pull, similar to TCP slow-start
+// With the initial value equal to the maximum, the limit is fixed, as for the delete jobs of purges.
+// With cpusPerPull, the maximum of a node is one pull per cpusPerPull of its allocatable cpus, bounded
+// by the maximum if it is positive. With total, the jobs in flight across all the nodes are capped as well
+type pullLimiter struct {
+	initial     int
+	max         int
+	cpusPerPull int
+	total       int
+	inFlight    int
+	nodes       map[string]*nodePullWindow
+	lock        sync.Mutex
+}
+
+// nodePullWindow is the current limit, the bounds of the limit and the no. of pull jobs in flight on a node
+type nodePullWindow struct {
+	limit    int
+	initial  int
+	max      int
+	inFlight int
+}
+
+// newPullLimiter returns a limiter with the given bounds. It returns nil (no limit) if neither max
+// nor cpusPerPull is positive
+func newPullLimiter(initial, max, cpusPerPull int) *pullLimiter {
+	if max <= 0 && cpusPerPull <= 0 {
+		return nil
+	}
+	if initial <= 0 {
+		initial = 1
+	}
+	if max > 0 && initial > max {
+		initial = max
+	}
+	return &pullLimiter{
+		initial:     initial,
+		max:         max,
+		cpusPerPull: cpusPerPull,
+		nodes:       make(map[string]*nodePullWindow),
+	}
+}
+
+// newDeleteLimiter returns a limiter of the delete jobs of purges with a fixed limit per node, if perNode is
+// positive, and at most total delete jobs in flight across all the nodes, if total is positive. It returns
+// nil (no limit) if neither is positive
+func newDeleteLimiter(perNode, total int) *pullLimiter {
+	if total <= 0 {
+		return newPullLimiter(perNode, perNode, 0)
+	}
+	if perNode < 0 {
+		perNode = 0
+	}
+	return &pullLimiter{
+		initial: perNode,
+		max:     perNode,
+		total:   total,
+		nodes:   make(map[string]*nodePullWindow),
+	}
+}
+
+// nodeMax returns the maximum limit of the node: one pull per cpusPerPull of its allocatable cpus,
+// and at least one, bounded by the maximum. Without cpusPerPull, it is the maximum
+func (l *pullLimiter) nodeMax(node *corev1.Node) int {
+	if l.cpusPerPull <= 0 {
+		return l.max
+	}
+	cpus := node.Status.Allocatable.Cpu().MilliValue() / 1000
+	max := int(cpus) / l.cpusPerPull
+	if max < 1 {
+		max = 1
+	}
+	if l.max > 0 && max > l.max {
+		max = l.max
+	}
+	return max
+}
+
+// acquire returns true and takes a slot if the node has not reached its limit, nor the limiter its total.
+// A node without a maximum has no limit of its own
+func (l *pullLimiter) acquire(node *corev1.Node) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.total > 0 && l.inFlight >= l.total {
+		return false
+	}
+	w, ok := l.nodes[node.Name]
+	if !ok {
+		w = &nodePullWindow{initial: l.initial, max: l.nodeMax(node)}
+		if w.initial > w.max {
+			w.initial = w.max
+		}
+		w.limit = w.initial
+		l.nodes[node.Name] = w
+	}
+	if w.max > 0 && w.inFlight >= w.limit {
+		return false
+	}
+	w.inFlight++
+	l.inFlight++
+	return true
+}
+
+// release gives back the slot of a finished pull and adapts the limit of the node to its outcome
+func (l *pullLimiter) release(node string, succeeded bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	w, ok := l.nodes[node]
+	if !ok {
+		return
+	}
+	if w.inFlight > 0 {
+		w.inFlight--
+		l.inFlight--
+	}
+	if succeeded {
+		if w.limit *= 2; w.limit > w.max {
+			w.limit = w.max
+		}
+	} else {
+		if w.limit /= 2; w.limit < w.initial {
+			w.limit = w.initial
+		}
+	}
+}
+
+// forget drops the limit and the pull jobs in flight of a node which was deleted
+func (l *pullLimiter) forget(node string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if w, ok := l.nodes[node]; ok {
+		l.inFlight -= w.inFlight
+	}
+	delete(l.nodes, node)
+}
+
+// limit returns the current limit of the node
+func (l *pullLimiter) limit(node string) int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if w, ok := l.nodes[node]; ok {
+		return w.limit
+	}
+	return l.initial
+}
+
+// atTotal returns true if the jobs in flight across all the nodes have reached the total
+func (l *pullLimiter) atTotal() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.total > 0 && l.inFlight >= l.total
+}
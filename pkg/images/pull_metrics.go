@@ -0,0 +1,63 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/senthilrch/kube-fledged/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// podDuration returns the wall-clock duration of the pod, from its start to the end of its last container.
+// It returns false if the pod has no start time or no terminated container
+func podDuration(pod *corev1.Pod) (time.Duration, bool) {
+	if pod.Status.StartTime == nil {
+		return 0, false
+	}
+	var finished time.Time
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Terminated != nil && status.State.Terminated.FinishedAt.Time.After(finished) {
+			finished = status.State.Terminated.FinishedAt.Time
+		}
+	}
+	if finished.IsZero() || finished.Before(pod.Status.StartTime.Time) {
+		return 0, false
+	}
+	return finished.Sub(pod.Status.StartTime.Time), true
+}
+
+// recordPullMetrics records the duration of the succeeded pull pod and, if the size of its image is known,
+// the throughput of the pull, by the registry the image was pulled from. The throughput is an estimate: the
+// duration includes the scheduling of the pod on the node and the run of its init containers, and the layers
+// of the image already in the node are not downloaded again
+func (m *ImageManager) recordPullMetrics(pod *corev1.Pod, iwr ImageWorkRequest) {
+	duration, ok := podDuration(pod)
+	if !ok {
+		return
+	}
+	registry := m.pullRegistry(iwr)
+	metrics.PullDuration.WithLabelValues(registry).Observe(duration.Seconds())
+	size := imageSizeHint(iwr)
+	if size <= 0 || duration <= 0 {
+		return
+	}
+	throughput := float64(size) / duration.Seconds()
+	metrics.PullThroughput.WithLabelValues(registry).Observe(throughput)
+	glog.V(4).Infof("Pull of %s (%d bytes) from %s took %s (%.0f bytes/s)", iwr.Image, size, registry, duration, throughput)
+}
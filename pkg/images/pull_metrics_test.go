@@ -0,0 +1,87 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	fledgedv1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	"github.com/senthilrch/kube-fledged/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodDuration(t *testing.T) {
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	pod := func(startTime *metav1.Time, finished ...time.Time) *corev1.Pod {
+		p := &corev1.Pod{Status: corev1.PodStatus{StartTime: startTime}}
+		for _, f := range finished {
+			p.Status.ContainerStatuses = append(p.Status.ContainerStatuses, corev1.ContainerStatus{
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{FinishedAt: metav1.NewTime(f)}}})
+		}
+		return p
+	}
+	tests := []struct {
+		name             string
+		pod              *corev1.Pod
+		expectedDuration time.Duration
+		expectedOk       bool
+	}{
+		{name: "#1: No start time", pod: pod(nil, start.Add(time.Minute)), expectedOk: false},
+		{name: "#2: No terminated container", pod: pod(&metav1.Time{Time: start}), expectedOk: false},
+		{name: "#3: Last container", pod: pod(&metav1.Time{Time: start}, start.Add(10*time.Second), start.Add(40*time.Second)),
+			expectedDuration: 40 * time.Second, expectedOk: true},
+		{name: "#4: Finished before start", pod: pod(&metav1.Time{Time: start}, start.Add(-time.Second)), expectedOk: false},
+	}
+	for _, test := range tests {
+		duration, ok := podDuration(test.pod)
+		if duration != test.expectedDuration || ok != test.expectedOk {
+			t.Errorf("Test: %s failed: expectedDuration=%s, actualDuration=%s, expectedOk=%t, actualOk=%t",
+				test.name, test.expectedDuration, duration, test.expectedOk, ok)
+		}
+	}
+}
+
+func TestRecordPullMetrics(t *testing.T) {
+	start := time.Now().Add(-time.Minute)
+	pod := &corev1.Pod{Status: corev1.PodStatus{
+		StartTime: &metav1.Time{Time: start},
+		ContainerStatuses: []corev1.ContainerStatus{{State: corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{FinishedAt: metav1.NewTime(start.Add(20 * time.Second))}}}},
+	}}
+	imagecache := &fledgedv1alpha2.ImageCache{Spec: fledgedv1alpha2.ImageCacheSpec{CacheSpec: []fledgedv1alpha2.CacheSpecImages{{
+		Images:     []string{"registry1.example.com/foo:v1", "registry2.example.com/bar:v1"},
+		ImageSizes: map[string]resource.Quantity{"registry1.example.com/foo:v1": resource.MustParse("200Mi")},
+	}}}}
+	m := &ImageManager{}
+	durations, throughputs := testutil.CollectAndCount(metrics.PullDuration), testutil.CollectAndCount(metrics.PullThroughput)
+	m.recordPullMetrics(pod, ImageWorkRequest{Image: "registry1.example.com/foo:v1", Imagecache: imagecache})
+	if actual := testutil.CollectAndCount(metrics.PullThroughput); actual != throughputs+1 {
+		t.Errorf("Test: known size failed: expectedThroughputSeries=%d, actualThroughputSeries=%d", throughputs+1, actual)
+	}
+	// the throughput of a pull of an image with an unknown size is not known
+	m.recordPullMetrics(pod, ImageWorkRequest{Image: "registry2.example.com/bar:v1", Imagecache: imagecache})
+	if actual := testutil.CollectAndCount(metrics.PullThroughput); actual != throughputs+1 {
+		t.Errorf("Test: unknown size failed: expectedThroughputSeries=%d, actualThroughputSeries=%d", throughputs+1, actual)
+	}
+	if actual := testutil.CollectAndCount(metrics.PullDuration); actual != durations+2 {
+		t.Errorf("Test: durations failed: expectedDurationSeries=%d, actualDurationSeries=%d", durations+2, actual)
+	}
+}
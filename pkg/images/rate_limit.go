@@ -0,0 +1,170 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	fledgedv1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// rateLimitMaxRetries is the no. of times a pull rate-limited by the registry is retried after a backoff,
+// before its failure is reported
+const rateLimitMaxRetries = 3
+
+// rateLimitMessages are the messages of image pulls rate-limited by the registry, in lower case
+var rateLimitMessages = []string{"toomanyrequests", "too many requests", "rate limit"}
+
+// isRateLimited returns true if the message of a failed image pull says the registry rate-limited it
+func isRateLimited(message string) bool {
+	message = strings.ToLower(message)
+	for _, m := range rateLimitMessages {
+		if strings.Contains(message, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// podRateLimitMessage returns the message of the pull job pod if its pull was rate-limited by the registry:
+// the message of its container waiting to pull the image, or of its terminated container
+func podRateLimitMessage(pod *corev1.Pod) (string, bool) {
+	for _, status := range pod.Status.ContainerStatuses {
+		var message string
+		switch {
+		case status.State.Waiting != nil:
+			message = status.State.Waiting.Message
+		case status.State.Terminated != nil:
+			message = status.State.Terminated.Message
+		}
+		if isRateLimited(message) {
+			return message, true
+		}
+	}
+	return "", false
+}
+
+// pullRegistry returns the registry host the image of the request is pulled from
+func (m *ImageManager) pullRegistry(iwr ImageWorkRequest) string {
+	if mirror := mirrorOf(iwr); mirror != "" {
+		return mirror
+	}
+	return registryHost(m.targetImage(iwr))
+}
+
+// registryPause returns how long pulls from the registry are still paused after it rate-limited a pull
+func (m *ImageManager) registryPause(registry string) time.Duration {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return time.Until(m.registryPausedUntil[registry])
+}
+
+// backoffRateLimitedPull replaces the pull job of the pod, whose pull was rate-limited by the registry, with
+// a job created after the rate limit backoff, which doubles on every retry. Pulls from the registry are paused
+// for the rate limit pause. The result of the pull job stays pending during the backoff, so that the status
+// update of the image cache waits for the retry. It returns false if the pull is not retried
+func (m *ImageManager) backoffRateLimitedPull(pod *corev1.Pod) bool {
+	if m.rateLimitBackoff <= 0 {
+		return false
+	}
+	message, ok := podRateLimitMessage(pod)
+	if !ok {
+		return false
+	}
+	pullJob := pod.Labels["job-name"]
+	m.lock.Lock()
+	iwres, ok := m.imageworkstatus[pullJob]
+	if !ok || iwres.Status != ImageWorkResultStatusJobCreated || iwres.verifying || iwres.backingOff ||
+		iwres.ImageWorkRequest.WorkType == ImageCachePurge || iwres.ImageWorkRequest.rateLimitRetries >= rateLimitMaxRetries {
+		m.lock.Unlock()
+		return false
+	}
+	iwres.backingOff = true
+	m.imageworkstatus[pullJob] = iwres
+	registry := m.pullRegistry(iwres.ImageWorkRequest)
+	if m.rateLimitPause > 0 {
+		m.registryPausedUntil[registry] = time.Now().Add(m.rateLimitPause)
+	}
+	m.lock.Unlock()
+
+	iwr := iwres.ImageWorkRequest
+	backoff := m.rateLimitBackoff << iwr.rateLimitRetries
+	if pause := m.registryPause(registry); pause > backoff {
+		backoff = pause
+	}
+	iwr.rateLimitRetries++
+	glog.Warningf("Job %s rate-limited by registry %s (pull:- %s --> %s), retrying in %s: %s",
+		pullJob, registry, iwr.Image, iwr.Node.Labels["kubernetes.io/hostname"], backoff, message)
+	// the kubelet would otherwise keep retrying the pull, compounding the rate limit. It is left in
+	// place for debugging if RetentionPolicy is Retain
+	if m.canDeleteJob {
+		deletePropagation := metav1.DeletePropagationBackground
+		if err := m.kubeclientset.BatchV1().Jobs(iwr.Imagecache.Namespace).
+			Delete(context.TODO(), pullJob, metav1.DeleteOptions{PropagationPolicy: &deletePropagation}); err != nil {
+			glog.Warningf("Error deleting job %s: %v", pullJob, err)
+		}
+	}
+	time.AfterFunc(backoff, func() {
+		m.retryRateLimitedPull(pullJob, iwr)
+	})
+	return true
+}
+
+// retryRateLimitedPull creates the job retrying a rate-limited pull in place of the pull job. Nothing is
+// done if the result of the pull job was resolved in the meantime, e.g. on the image pull deadline
+func (m *ImageManager) retryRateLimitedPull(pullJob string, iwr ImageWorkRequest) {
+	m.lock.RLock()
+	iwres, ok := m.imageworkstatus[pullJob]
+	m.lock.RUnlock()
+	if !ok || iwres.Status != ImageWorkResultStatusJobCreated {
+		return
+	}
+	job, err := m.pullImage(iwr)
+	m.lock.Lock()
+	if _, ok := m.imageworkstatus[pullJob]; !ok {
+		m.lock.Unlock()
+		// the retry is not needed any more. It is deleted without holding the lock, so that a slow API
+		// server does not hold back the other users of the lock
+		if err == nil && m.canDeleteJob {
+			deletePropagation := metav1.DeletePropagationBackground
+			m.kubeclientset.BatchV1().Jobs(iwr.Imagecache.Namespace).
+				Delete(context.TODO(), job.Name, metav1.DeleteOptions{PropagationPolicy: &deletePropagation})
+		}
+		return
+	}
+	defer m.lock.Unlock()
+	delete(m.imageworkstatus, pullJob)
+	if err != nil {
+		// the retry holds the pull slot of the rate-limited job until it completes
+		m.releaseJobSlot(iwres, false)
+		glog.Errorf("Error creating job to retry the rate-limited pull of %s: %v", iwr.Image, err)
+		m.imageworkstatus[pullJob] = ImageWorkResult{
+			ImageWorkRequest: iwr,
+			Status:           ImageWorkResultStatusFailed,
+			Reason:           imageWorkFailureReason(err, fledgedv1alpha2.ImageCacheReasonImagePullFailedForSomeImages),
+			Message:          err.Error(),
+		}
+		return
+	}
+	glog.Infof("Job %s created (pull:- %s --> %s, rate limit retry: %d)", job.Name, iwr.Image, iwr.Node.Labels["kubernetes.io/hostname"], iwr.rateLimitRetries)
+	m.imageworkstatus[job.Name] = ImageWorkResult{ImageWorkRequest: iwr, Status: ImageWorkResultStatusJobCreated, deadline: m.sizedPullDeadline(iwr)}
+}
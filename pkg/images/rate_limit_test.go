@@ -0,0 +1,135 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"testing"
+	"time"
+
+	fledgedv1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+)
+
+func TestPodRateLimitMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    corev1.ContainerState
+		expected bool
+	}{
+		{name: "#1: Waiting with toomanyrequests", state: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+			Reason: "ErrImagePull", Message: "429 Too Many Requests - Server message: toomanyrequests: You have reached your pull rate limit"}}, expected: true},
+		{name: "#2: Terminated with rate limit", state: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			Message: "Rate limit exceeded"}}, expected: true},
+		{name: "#3: Image not found", state: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+			Reason: "ErrImagePull", Message: "manifest unknown"}}, expected: false},
+		{name: "#4: Digest containing 429", state: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+			Reason: "ErrImagePull", Message: "foo@sha256:4291c0ffee not found"}}, expected: false},
+	}
+	for _, test := range tests {
+		pod := &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{State: test.state}}}}
+		if _, actual := podRateLimitMessage(pod); actual != test.expected {
+			t.Errorf("Test: %s failed: expected=%t, actual=%t", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestBackoffRateLimitedPull(t *testing.T) {
+	imageCache := &fledgedv1alpha2.ImageCache{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: fledgedNameSpace}}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{"kubernetes.io/hostname": "node1"}}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "job1-abcde", Labels: map[string]string{"job-name": "job1"}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{State: corev1.ContainerState{
+			Waiting: &corev1.ContainerStateWaiting{Reason: "ErrImagePull", Message: "toomanyrequests: pull rate limit"}}}}},
+	}
+	iwr := ImageWorkRequest{Image: "docker.io/library/foo:v1", Node: node, Imagecache: imageCache, WorkType: ImageCacheCreate}
+
+	fakekubeclientset := fakeclientset.NewSimpleClientset()
+	imagemanager, _ := newTestImageManager(fakekubeclientset, "IfNotPresent", "", false, "", true, "")
+	imagemanager.imageworkstatus = map[string]ImageWorkResult{
+		"job1": {ImageWorkRequest: iwr, Status: ImageWorkResultStatusJobCreated},
+	}
+
+	// retries are disabled without a backoff
+	if imagemanager.backoffRateLimitedPull(pod) {
+		t.Errorf("Test: rate-limited pull retried without a backoff")
+	}
+
+	// the backoff outlasts the test, so the retry is run below
+	imagemanager.rateLimitBackoff = time.Hour
+	imagemanager.rateLimitPause = 2 * time.Hour
+	if !imagemanager.backoffRateLimitedPull(pod) {
+		t.Fatalf("Test: rate-limited pull not retried")
+	}
+	if iwres := imagemanager.imageworkstatus["job1"]; !iwres.backingOff || iwres.Status != ImageWorkResultStatusJobCreated {
+		t.Errorf("Test: expectedBackingOff=true, actualBackingOff=%t, actualStatus=%s", iwres.backingOff, iwres.Status)
+	}
+	if pause := imagemanager.registryPause("docker.io"); pause <= time.Hour {
+		t.Errorf("Test: registry not paused: actualPause=%s", pause)
+	}
+	if imagemanager.backoffRateLimitedPull(pod) {
+		t.Errorf("Test: pull backing off retried twice")
+	}
+	deleted := 0
+	for _, action := range fakekubeclientset.Actions() {
+		if action.GetVerb() == "delete" && action.GetResource().Resource == "jobs" && action.(core.DeleteAction).GetName() == "job1" {
+			deleted++
+		}
+	}
+	if deleted != 1 {
+		t.Errorf("Test: expectedDeletedJobs=1, actualDeletedJobs=%d", deleted)
+	}
+
+	imagemanager.retryRateLimitedPull("job1", ImageWorkRequest{Image: iwr.Image, Node: node, Imagecache: imageCache,
+		WorkType: ImageCacheCreate, rateLimitRetries: 1})
+	if _, ok := imagemanager.imageworkstatus["job1"]; ok {
+		t.Errorf("Test: result of the rate-limited job not replaced")
+	}
+	retried := 0
+	for _, iwres := range imagemanager.imageworkstatus {
+		if iwres.Status == ImageWorkResultStatusJobCreated && iwres.ImageWorkRequest.rateLimitRetries == 1 && !iwres.backingOff {
+			retried++
+		}
+	}
+	if retried != 1 {
+		t.Errorf("Test: expectedRetriedJobs=1, actualRetriedJobs=%d", retried)
+	}
+
+	// a retry which could not be created is failed with the reason of its error
+	imagemanager.imageworkstatus = map[string]ImageWorkResult{
+		"job1": {ImageWorkRequest: iwr, Status: ImageWorkResultStatusJobCreated, backingOff: true},
+	}
+	imagemanager.minFreeDisk = 100
+	imagemanager.freeDisk = func(*corev1.Node) (int64, error) { return 10, nil }
+	imagemanager.retryRateLimitedPull("job1", iwr)
+	if iwres := imagemanager.imageworkstatus["job1"]; iwres.Status != ImageWorkResultStatusFailed ||
+		iwres.Reason != fledgedv1alpha2.ImageCacheReasonInsufficientDisk {
+		t.Errorf("Test: expectedReason=%s, actualStatus=%s, actualReason=%s", fledgedv1alpha2.ImageCacheReasonInsufficientDisk, iwres.Status, iwres.Reason)
+	}
+	imagemanager.minFreeDisk = 0
+
+	// pulls are not retried after the maximum no. of retries
+	imagemanager.imageworkstatus = map[string]ImageWorkResult{
+		"job1": {ImageWorkRequest: ImageWorkRequest{Image: iwr.Image, Node: node, Imagecache: imageCache,
+			WorkType: ImageCacheCreate, rateLimitRetries: rateLimitMaxRetries}, Status: ImageWorkResultStatusJobCreated},
+	}
+	if imagemanager.backoffRateLimitedPull(pod) {
+		t.Errorf("Test: rate-limited pull retried after %d retries", rateLimitMaxRetries)
+	}
+}
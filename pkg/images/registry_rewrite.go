@@ -0,0 +1,53 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"strings"
+
+	"github.com/docker/distribution/reference"
+)
+
+// rewriteImage returns the image on the target of the rewrite rule of its registry, followed by the path of the
+// image in the registry e.g. mirror.example.com/docker.io/library/nginx:1.23 for nginx:1.23 with the rule
+// docker.io=mirror.example.com/docker.io. Images of registries without a rule, and images which are not valid
+// references, are returned as is
+func rewriteImage(image string, rewrites map[string]string) string {
+	if len(rewrites) == 0 {
+		return image
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return image
+	}
+	domain := reference.Domain(named)
+	target, ok := rewrites[domain]
+	if !ok {
+		return image
+	}
+	return strings.TrimSuffix(target, "/") + "/" + strings.TrimPrefix(named.String(), domain+"/")
+}
+
+// targetImage returns the image the pull and verify jobs of the work request refer to: the image on the mirror
+// of the image cache being tried, or else the image rewritten by the registry rewrite rules of the controller.
+// The results of the work request keep the original image
+func (m *ImageManager) targetImage(iwr ImageWorkRequest) string {
+	if mirrorOf(iwr) != "" {
+		return imageToPull(iwr)
+	}
+	return rewriteImage(iwr.Image, m.registryRewrites)
+}
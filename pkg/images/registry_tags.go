@@ -0,0 +1,215 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxTagsListPages bounds the no. of pages of the tags API followed for a repository
+const maxTagsListPages = 100
+
+// registryClient is used to query the tags API of the registries
+var registryClient = &http.Client{Timeout: 30 * time.Second}
+
+// ListTags returns the tags of a repository e.g. "docker.io/library/nginx", following the pagination of
+// the tags API of its registry. Repositories are read anonymously, getting a bearer token if the registry
+// asks for one. ErrTagsNotListable is returned if the registry does not support listing the tags
+func ListTags(repository string) ([]string, error) {
+	host, name := registryHost(repository), repositoryName(repository)
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	return listTags(registryClient, "https://"+host, name)
+}
+
+// listTags returns the tags of the repository name on the registry at baseURL
+func listTags(client *http.Client, baseURL, name string) ([]string, error) {
+	next, err := url.Parse(baseURL + "/v2/" + name + "/tags/list")
+	if err != nil {
+		return nil, err
+	}
+	token := ""
+	tags := []string{}
+	for page := 0; next != nil; page++ {
+		if page == maxTagsListPages {
+			return nil, fmt.Errorf("more than %d pages of tags for repository %s", maxTagsListPages, name)
+		}
+		resp, err := getTags(client, next.String(), token)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && token == "" {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+			if token, err = bearerToken(client, challenge, name); err != nil {
+				return nil, err
+			}
+			if resp, err = getTags(client, next.String(), token); err != nil {
+				return nil, err
+			}
+		}
+		var tagsList struct {
+			Tags []string `json:"tags"`
+		}
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			err = json.NewDecoder(resp.Body).Decode(&tagsList)
+		case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+			err = fmt.Errorf("listing tags of repository %s: %s", name, resp.Status)
+		default:
+			err = fmt.Errorf("%w: repository %s: %s", ErrTagsNotListable, name, resp.Status)
+		}
+		link := resp.Header.Get("Link")
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tagsList.Tags...)
+		if next, err = nextPage(next, link); err != nil {
+			return nil, err
+		}
+	}
+	return tags, nil
+}
+
+// getTags gets a page of the tags API, authorized by the bearer token if there is one
+func getTags(client *http.Client, pageURL, token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return client.Do(req)
+}
+
+// bearerToken gets an anonymous token to pull the repository from the realm of a bearer challenge
+func bearerToken(client *http.Client, challenge, name string) (string, error) {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return "", fmt.Errorf("%w: repository %s: unsupported authentication challenge %q", ErrTagsNotListable, name, challenge)
+	}
+	params := map[string]string{}
+	for _, param := range strings.Split(challenge[len("bearer "):], ",") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(param), "="); ok {
+			params[k] = strings.Trim(v, `"`)
+		}
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("%w: repository %s: no realm in authentication challenge %q", ErrTagsNotListable, name, challenge)
+	}
+	query := realm.Query()
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	query.Set("scope", "repository:"+name+":pull")
+	realm.RawQuery = query.Encode()
+
+	resp, err := client.Get(realm.String())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: repository %s: getting token: %s", ErrTagsNotListable, name, resp.Status)
+	}
+	var tokenResponse struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return "", err
+	}
+	if tokenResponse.Token != "" {
+		return tokenResponse.Token, nil
+	}
+	return tokenResponse.AccessToken, nil
+}
+
+// nextPage returns the url of the next page in the Link header of a page of the tags API, or nil
+// for the last page
+func nextPage(page *url.URL, link string) (*url.URL, error) {
+	if link == "" || !strings.Contains(link, `rel="next"`) {
+		return nil, nil
+	}
+	start, end := strings.Index(link, "<"), strings.Index(link, ">")
+	if start == -1 || end < start {
+		return nil, fmt.Errorf("malformed Link header of tags list: %s", link)
+	}
+	return page.Parse(link[start+1 : end])
+}
+
+// repositoryName returns the name of the repository on its registry. Repositories without a
+// namespace on docker.io are in the library namespace
+func repositoryName(repository string) string {
+	i := strings.Index(repository, "/")
+	if i == -1 {
+		return "library/" + repository
+	}
+	if host := repository[:i]; strings.ContainsAny(host, ".:") || host == "localhost" {
+		name := repository[i+1:]
+		if host == "docker.io" && !strings.Contains(name, "/") {
+			return "library/" + name
+		}
+		return name
+	}
+	return repository
+}
+
+// MatchTags returns the images of the repository for the tags matching any of the include patterns
+// (all tags if there are none) and none of the exclude patterns. Patterns are as in path.Match
+func MatchTags(repository string, tags, includeTags, excludeTags []string) ([]string, error) {
+	matchesAny := func(tag string, patterns []string) (bool, error) {
+		for _, pattern := range patterns {
+			matched, err := path.Match(pattern, tag)
+			if err != nil || matched {
+				return matched, err
+			}
+		}
+		return false, nil
+	}
+	images := []string{}
+	for _, tag := range tags {
+		if len(includeTags) > 0 {
+			included, err := matchesAny(tag, includeTags)
+			if err != nil {
+				return nil, err
+			}
+			if !included {
+				continue
+			}
+		}
+		excluded, err := matchesAny(tag, excludeTags)
+		if err != nil {
+			return nil, err
+		}
+		if !excluded {
+			images = append(images, repository+":"+tag)
+		}
+	}
+	sort.Strings(images)
+	return images, nil
+}
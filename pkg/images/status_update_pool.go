@@ -0,0 +1,38 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+// statusUpdatePool bounds the no. of image caches whose jobs are polled for their status update at the same
+// time. The status updates past its size wait for a slot. A nil pool does not bound them
+type statusUpdatePool chan struct{}
+
+// newStatusUpdatePool returns a pool of the given size, or nil if the size is not positive
+func newStatusUpdatePool(size int) statusUpdatePool {
+	if size <= 0 {
+		return nil
+	}
+	return make(statusUpdatePool, size)
+}
+
+// run calls update once a slot of the pool is free, and frees it after
+func (p statusUpdatePool) run(update func()) {
+	if p != nil {
+		p <- struct{}{}
+		defer func() { <-p }()
+	}
+	update()
+}
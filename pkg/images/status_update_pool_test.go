@@ -0,0 +1,93 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	fledgedv1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStatusUpdatePool(t *testing.T) {
+	tests := []struct {
+		name        string
+		size        int
+		updates     int
+		expectedMax int
+	}{
+		{name: "#1: Unbounded pool", size: 0, updates: 5, expectedMax: 5},
+		{name: "#2: Pool of 2", size: 2, updates: 5, expectedMax: 2},
+		{name: "#3: Pool larger than the updates", size: 10, updates: 3, expectedMax: 3},
+	}
+	for _, test := range tests {
+		pool := newStatusUpdatePool(test.size)
+		var lock sync.Mutex
+		running, max := 0, 0
+		started := make(chan struct{}, test.updates)
+		release := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := 0; i < test.updates; i++ {
+			wg.Add(1)
+			go pool.run(func() {
+				defer wg.Done()
+				lock.Lock()
+				running++
+				if running > max {
+					max = running
+				}
+				lock.Unlock()
+				started <- struct{}{}
+				<-release
+				lock.Lock()
+				running--
+				lock.Unlock()
+			})
+		}
+		// wait for the updates which get a slot, then let all of them run to completion
+		for i := 0; i < test.expectedMax; i++ {
+			<-started
+		}
+		close(release)
+		wg.Wait()
+		if max != test.expectedMax {
+			t.Errorf("Test: %s failed: expectedMaxConcurrent=%d, actualMaxConcurrent=%d", test.name, test.expectedMax, max)
+		}
+	}
+}
+
+func TestStatusUpdatePoolFrees(t *testing.T) {
+	imagemanager, _ := newTestImageManager(fakeclientset.NewSimpleClientset(), "IfNotPresent", "", false, "", false, "")
+	imagemanager.statusUpdates = newStatusUpdatePool(1)
+	// more status updates than the size of the pool are started, each of which frees its slot once done
+	updates := 3
+	for i := 0; i < updates; i++ {
+		imageCache := &fledgedv1alpha2.ImageCache{ObjectMeta: metav1.ObjectMeta{Name: string(rune('a' + i)), Namespace: fledgedNameSpace}}
+		imagemanager.imageworkqueue.Add(ImageWorkRequest{Imagecache: imageCache})
+		imagemanager.processNextWorkItem()
+	}
+	err := wait.Poll(100*time.Millisecond, 10*time.Second, func() (bool, error) {
+		return imagemanager.workqueue.Len() == updates, nil
+	})
+	if err != nil {
+		t.Errorf("Test: expectedStatusUpdates=%d, actualStatusUpdates=%d", updates, imagemanager.workqueue.Len())
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// verifyRequest is an item of the verify queue: the digest of the image pulled by the pull job is to be
+// verified on the node
+type verifyRequest struct {
+	pullJob string
+}
+
+// newVerifyQueue returns the queue of the verifications of the digests of the pulled images
+func newVerifyQueue() workqueue.RateLimitingInterface {
+	return workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "ImageVerify")
+}
+
+// queueImageVerification places the verification of the image pulled by a succeeded pull job on the verify
+// queue. Verifications are run by their own worker within the verify limiter, so that a slow verification
+// neither holds a pull slot nor delays the next pulls. The result of the pull job waits for the verification
+func (m *ImageManager) queueImageVerification(pullJob string, iwres ImageWorkResult) {
+	iwres.verifying = true
+	iwres.pendingVerification = true
+	iwres.deadline = time.Time{}
+	m.lock.Lock()
+	m.imageworkstatus[pullJob] = iwres
+	m.lock.Unlock()
+	glog.V(4).Infof("Verification of %s --> %s queued", iwres.ImageWorkRequest.Image, iwres.ImageWorkRequest.Node.Labels["kubernetes.io/hostname"])
+	m.verifyqueue.Add(verifyRequest{pullJob: pullJob})
+}
+
+// runVerifyWorker processes the verify queue until it is shut down
+func (m *ImageManager) runVerifyWorker() {
+	for m.processNextVerifyItem() {
+	}
+}
+
+// processNextVerifyItem starts the verification of the next item of the verify queue. Verifications of a node
+// at the limit of the verify limiter are requeued
+func (m *ImageManager) processNextVerifyItem() bool {
+	obj, shutdown := m.verifyqueue.Get()
+	if shutdown {
+		return false
+	}
+	defer m.verifyqueue.Done(obj)
+	m.verifyqueue.Forget(obj)
+	req := obj.(verifyRequest)
+	m.lock.RLock()
+	iwres, ok := m.imageworkstatus[req.pullJob]
+	m.lock.RUnlock()
+	// the result may have been resolved meanwhile, at the deadline of the image cache or on deletion of the node
+	if !ok || !iwres.pendingVerification || iwres.Status != ImageWorkResultStatusJobCreated {
+		return true
+	}
+	node := iwres.ImageWorkRequest.Node
+	if m.verifyLimiter != nil && !m.verifyLimiter.acquire(node) {
+		glog.V(4).Infof("Verification of %s deferred, node %s is at its limit of %d concurrent verifications",
+			iwres.ImageWorkRequest.Image, node.Labels["kubernetes.io/hostname"], m.verifyLimiter.limit(node.Name))
+		m.verifyqueue.AddAfter(obj, pullLimiterRequeueDelay)
+		return true
+	}
+	iwres.pendingVerification = false
+	if err := m.startImageVerification(req.pullJob, iwres); err != nil && m.verifyLimiter != nil {
+		m.verifyLimiter.release(node.Name, false)
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"testing"
+
+	fledgedv1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestProcessNextVerifyItem(t *testing.T) {
+	imageCache := &fledgedv1alpha2.ImageCache{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: fledgedNameSpace}}
+	pulled := func(image string) ImageWorkResult {
+		return ImageWorkResult{ImageWorkRequest: ImageWorkRequest{Image: image, Node: &node, Imagecache: imageCache,
+			ContainerRuntimeVersion: "containerd://1.6.0", WorkType: ImageCacheCreate}, Status: ImageWorkResultStatusJobCreated}
+	}
+	fakekubeclientset := fakeclientset.NewSimpleClientset()
+	imagemanager, _ := newTestImageManager(fakekubeclientset, "IfNotPresent", "", false, "", true, "")
+	imagemanager.verifyLimiter = newPullLimiter(1, 1, 0)
+	imagemanager.queueImageVerification("job1", pulled("foo@sha256:1234"))
+	imagemanager.queueImageVerification("job2", pulled("bar@sha256:5678"))
+
+	verifyJobs := func() int {
+		created := 0
+		for _, action := range fakekubeclientset.Actions() {
+			if action.GetVerb() == "create" && action.GetResource().Resource == "jobs" {
+				created++
+			}
+		}
+		return created
+	}
+	// the second verification is held back by the limit of one verification per node
+	imagemanager.processNextVerifyItem()
+	imagemanager.processNextVerifyItem()
+	if created := verifyJobs(); created != 1 {
+		t.Errorf("Test: expectedVerifyJobs=1, actualVerifyJobs=%d", created)
+	}
+	if iwres := imagemanager.imageworkstatus["job2"]; !iwres.pendingVerification {
+		t.Errorf("Test: verification over the limit not left pending")
+	}
+	if _, ok := imagemanager.imageworkstatus["job1"]; ok {
+		t.Errorf("Test: result of pull job not replaced by verify job")
+	}
+
+	// the slot of a finished verify job is given back
+	var verifyJob string
+	for job, iwres := range imagemanager.imageworkstatus {
+		if iwres.verifying && !iwres.pendingVerification {
+			verifyJob = job
+		}
+	}
+	imagemanager.handlePodStatusChange(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"job-name": verifyJob, verifyLabel: "true"}},
+		Status:     corev1.PodStatus{Phase: corev1.PodSucceeded},
+	})
+	if !imagemanager.verifyLimiter.acquire(&node) {
+		t.Errorf("Test: slot of a finished verify job not released")
+	}
+	imagemanager.verifyLimiter.release(node.Name, true)
+
+	// a verification still queued at the deadline of the image cache fails
+	if err := imagemanager.updatePendingImageWorkResults("foo"); err != nil {
+		t.Fatalf("Test: unexpected error resolving pending results: %v", err)
+	}
+	if iwres := imagemanager.imageworkstatus["job2"]; iwres.Status != ImageWorkResultStatusFailed ||
+		iwres.Reason != fledgedv1alpha2.ImageCacheReasonImageDigestVerificationFailed {
+		t.Errorf("Test: expectedStatus=%s, actualStatus=%s, expectedReason=%s, actualReason=%s", ImageWorkResultStatusFailed,
+			iwres.Status, fledgedv1alpha2.ImageCacheReasonImageDigestVerificationFailed, iwres.Reason)
+	}
+	// the verification of a resolved result is dropped
+	imagemanager.verifyqueue.Add(verifyRequest{pullJob: "job2"})
+	imagemanager.processNextVerifyItem()
+	if created := verifyJobs(); created != 1 {
+		t.Errorf("Test: verify job created for a resolved result: expectedVerifyJobs=1, actualVerifyJobs=%d", created)
+	}
+}
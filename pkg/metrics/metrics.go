@@ -0,0 +1,124 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics defines the prometheus metrics exposed by kube-fledged
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// CacheHits counts the images of scheduled pods which were already cached on the node by an image cache
+	CacheHits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubefledged_cache_hits_total",
+			Help: "Number of images of scheduled pods that were already cached on the node by an image cache",
+		},
+		[]string{"namespace", "imagecache"},
+	)
+	// CacheImages has the no. of images of an image cache by their status in the latest reconcile.
+	// The cache label is the namespace/name of the image cache
+	CacheImages = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kubefledged_cache_images_total",
+			Help: "Number of images of the image cache by status (cached, failed) in the latest reconcile",
+		},
+		[]string{"cache", "status"},
+	)
+	// CacheNodesCovered has the no. of nodes on which all the images of an image cache were cached
+	// in the latest reconcile
+	CacheNodesCovered = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kubefledged_cache_nodes_covered",
+			Help: "Number of nodes on which all the images of the image cache were cached in the latest reconcile",
+		},
+		[]string{"cache"},
+	)
+	// CacheStale is 1 if the last successful refresh of an image cache is older than its stale threshold, and 0
+	// otherwise. It is only set on the image caches which were refreshed successfully
+	CacheStale = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kubefledged_cache_stale",
+			Help: "Whether the last successful refresh of the image cache is older than its stale threshold (1) or not (0)",
+		},
+		[]string{"cache"},
+	)
+	// DeadlineTimeouts counts the times the image pull deadline of an image cache was reached with some of
+	// its jobs still pending
+	DeadlineTimeouts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubefledged_deadline_timeouts_total",
+			Help: "Number of times the image pull deadline of the image cache was reached before all its jobs were done",
+		},
+		[]string{"cache"},
+	)
+	// ImageFlaps counts the times an image of an image cache was cached on a node after having failed, or
+	// failed after having been cached, in consecutive reconciles
+	ImageFlaps = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubefledged_image_flaps_total",
+			Help: "Number of times an image of the image cache changed between cached and failed on a node in consecutive reconciles",
+		},
+		[]string{"cache", "image", "node"},
+	)
+	// PullDuration has the wall-clock durations of the succeeded image pull jobs, from the start of their
+	// pod to the end of its last container, by the registry the images were pulled from
+	PullDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "kubefledged_pull_duration_seconds",
+			Help:    "Wall-clock duration of the succeeded image pull jobs, from pod start to completion",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+		},
+		[]string{"registry"},
+	)
+	// PullThroughput has the estimated throughputs of the succeeded image pull jobs of images with a known
+	// size: the size of the image divided by the duration of the job
+	PullThroughput = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "kubefledged_pull_throughput_bytes_per_second",
+			Help:    "Estimated throughput of the succeeded image pull jobs: image size divided by the pull duration",
+			Buckets: prometheus.ExponentialBuckets(256*1024, 2, 12),
+		},
+		[]string{"registry"},
+	)
+	// WatchdogStalls counts the times the watchdog found the workers of the controller making no progress
+	// while image caches were waiting in the workqueue
+	WatchdogStalls = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "kubefledged_watchdog_stalls_total",
+			Help: "Number of times the controller workers made no progress within the watchdog window while the workqueue was not empty",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(CacheHits, CacheImages, CacheNodesCovered, CacheStale, DeadlineTimeouts, ImageFlaps, PullDuration, PullThroughput, WatchdogStalls)
+}
+
+// Serve exposes the metrics on the given address at /metrics. It blocks until the server fails
+func Serve(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	glog.Infof("Serving metrics on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		glog.Errorf("Error serving metrics: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// webhookCertNotAfter is the expiry of the certificate loaded by the webhook server, in unix seconds
+var webhookCertNotAfter atomic.Int64
+
+// webhookCertExpiry has the seconds until the certificate loaded by the webhook server expires. It is
+// negative once the certificate has expired
+var webhookCertExpiry = prometheus.NewGaugeFunc(
+	prometheus.GaugeOpts{
+		Name: "kubefledged_webhook_cert_expiry_seconds",
+		Help: "Seconds until the certificate loaded by the webhook server expires",
+	},
+	func() float64 {
+		return time.Until(time.Unix(webhookCertNotAfter.Load(), 0)).Seconds()
+	},
+)
+
+// RegisterWebhookMetrics registers the metrics of the webhook server
+func RegisterWebhookMetrics() {
+	prometheus.MustRegister(webhookCertExpiry)
+}
+
+// SetWebhookCertExpiry records the expiry of the certificate loaded by the webhook server
+func SetWebhookCertExpiry(notAfter time.Time) {
+	webhookCertNotAfter.Store(notAfter.Unix())
+}
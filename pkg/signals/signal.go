@@ -41,3 +41,24 @@ func SetupSignalHandler() (stopCh <-chan struct{}) {
 
 	return stop
 }
+
+// SetupDumpHandler calls dump on each of the dump signals (SIGUSR1) until stopCh is closed. There are
+// no dump signals on windows
+func SetupDumpHandler(stopCh <-chan struct{}, dump func()) {
+	if len(dumpSignals) == 0 {
+		return
+	}
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, dumpSignals...)
+	go func() {
+		defer signal.Stop(c)
+		for {
+			select {
+			case <-c:
+				dump()
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+}
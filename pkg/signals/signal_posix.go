@@ -25,3 +25,5 @@ import (
 )
 
 var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+var dumpSignals = []os.Signal{syscall.SIGUSR1}
@@ -21,3 +21,5 @@ import (
 )
 
 var shutdownSignals = []os.Signal{os.Interrupt}
+
+var dumpSignals []os.Signal
@@ -0,0 +1,104 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing exports OpenTelemetry traces of the reconciles of kube-fledged. Spans are no-ops until
+// Setup is called
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/senthilrch/kube-fledged"
+
+// Attribute keys of the spans
+const (
+	CacheKey    = attribute.Key("kubefledged.imagecache")
+	ImageKey    = attribute.Key("kubefledged.image")
+	NodeKey     = attribute.Key("kubefledged.node")
+	WorkTypeKey = attribute.Key("kubefledged.worktype")
+)
+
+// Setup exports the spans of the service via OTLP over gRPC to the endpoint (host:port) of a collector.
+// The returned func flushes the spans not yet exported and stops the export
+func Setup(ctx context.Context, serviceName, endpoint string, insecure bool) (func(context.Context) error, error) {
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint)}
+	if insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
+
+// Start starts a span of the reconcile path, as a child of the span of ctx if any
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records the error, if any, on the span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Parent identifies the span of a reconcile which queued work done later, e.g. by the workers of the image
+// manager. Unlike trace.SpanContext it is comparable, so that it can be part of the items of a workqueue
+type Parent struct {
+	traceID    trace.TraceID
+	spanID     trace.SpanID
+	traceFlags trace.TraceFlags
+}
+
+// ParentOf returns the Parent identifying the span of ctx. It is the zero Parent if ctx has no span
+func ParentOf(ctx context.Context) Parent {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return Parent{}
+	}
+	return Parent{traceID: sc.TraceID(), spanID: sc.SpanID(), traceFlags: sc.TraceFlags()}
+}
+
+// Context returns a context carrying the span identified by the Parent, so that spans started from it are its
+// children. It is context.Background() for the zero Parent
+func (p Parent) Context() context.Context {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: p.traceID, SpanID: p.spanID, TraceFlags: p.traceFlags, Remote: true})
+	if !sc.IsValid() {
+		return context.Background()
+	}
+	return trace.ContextWithRemoteSpanContext(context.Background(), sc)
+}
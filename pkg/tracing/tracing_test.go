@@ -0,0 +1,63 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestParent(t *testing.T) {
+	if p := ParentOf(context.Background()); p != (Parent{}) {
+		t.Errorf("Test: context without span: expectedParent=zero, actualParent=%+v", p)
+	}
+	if ctx := (Parent{}).Context(); ctx != context.Background() {
+		t.Errorf("Test: zero parent: context is not context.Background()")
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	ctx, reconcile := Start(context.Background(), "reconcile", CacheKey.String("kube-fledged/foo"))
+	// the parent is carried by a workqueue item, so it must be comparable
+	items := map[interface{}]bool{ParentOf(ctx): true}
+	_, pull := Start(ParentOf(ctx).Context(), "pull", ImageKey.String("foo:v1"))
+	End(pull, errors.New("pull failed"))
+	End(reconcile, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 || len(items) != 1 {
+		t.Fatalf("Test: expectedSpans=2, actualSpans=%d", len(spans))
+	}
+	if spans[0].Parent().SpanID() != spans[1].SpanContext().SpanID() || spans[0].SpanContext().TraceID() != spans[1].SpanContext().TraceID() {
+		t.Errorf("Test: span started from the parent is not a child of the reconcile span")
+	}
+	if spans[0].Status().Code != codes.Error || spans[1].Status().Code != codes.Unset {
+		t.Errorf("Test: expectedStatus=[Error Unset], actualStatus=[%s %s]", spans[0].Status().Code, spans[1].Status().Code)
+	}
+	if attrs := spans[0].Attributes(); len(attrs) != 1 || attrs[0] != ImageKey.String("foo:v1") {
+		t.Errorf("Test: expectedAttributes=[%v], actualAttributes=%v", ImageKey.String("foo:v1"), attrs)
+	}
+}
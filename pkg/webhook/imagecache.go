@@ -17,14 +17,27 @@ limitations under the License.
 package webhook
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"path"
 	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/golang/glog"
 	fledgedv1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	"github.com/senthilrch/kube-fledged/pkg/images"
 	v1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
 )
 
 const (
@@ -36,6 +49,340 @@ const (
      ]`
 )
 
+// MaxImagesPerCache is the maximum number of images an image cache may list across
+// all its image lists. Zero means there is no limit
+var MaxImagesPerCache int
+
+// KubeClient is used to look up the objects referenced by an image cache
+var KubeClient kubernetes.Interface
+
+// FailurePolicy defines how a failed lookup to the api server is handled
+type FailurePolicy string
+
+// Failure policies
+const (
+	// FailurePolicyFail rejects the image cache if a lookup fails
+	FailurePolicyFail FailurePolicy = "Fail"
+	// FailurePolicyIgnore admits the image cache, skipping the validation that needed the lookup
+	FailurePolicyIgnore FailurePolicy = "Ignore"
+)
+
+// LookupFailurePolicy is applied when a lookup keeps failing after the retries are exhausted
+var LookupFailurePolicy = FailurePolicyFail
+
+// lookupBackoff is the backoff used to retry lookups failing with transient errors
+var lookupBackoff = wait.Backoff{
+	Steps:    4,
+	Duration: 50 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.2,
+}
+
+// lookup runs fn, retrying transient api server errors. If fn keeps failing and the failure policy is
+// Ignore, the error is logged and ignored. Errors other than transient ones are always returned
+func lookup(what string, fn func() error) error {
+	err := retry.OnError(lookupBackoff, images.IsTransientAPIError, fn)
+	if err != nil && images.IsTransientAPIError(err) && LookupFailurePolicy == FailurePolicyIgnore {
+		glog.Warningf("Error getting %s, ignoring as failure policy is %s: %v", what, FailurePolicyIgnore, err)
+		return nil
+	}
+	return err
+}
+
+// specRule is a mutual-exclusivity or co-requirement constraint among the fields of an image
+// cache spec. It returns one error, with the path of the field, for every place in the spec
+// violating the constraint
+type specRule func(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) field.ErrorList
+
+// specRules are checked on every image cache created or updated. A combination of spec fields
+// which the controller can't sensibly act on should be rejected by adding a rule here
+var specRules = []specRule{
+	// an image list needs images, imagesFrom or repositories
+	func(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) (errs field.ErrorList) {
+		for k, i := range spec.CacheSpec {
+			if len(i.Images) == 0 && i.ImagesFrom == nil && len(i.Repositories) == 0 {
+				errs = append(errs, field.Required(specPath.Child("cacheSpec").Index(k).Child("images"), "No images specified within image list"))
+			}
+		}
+		return
+	},
+	// imagesFrom needs both the name and the key of the configmap
+	func(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) (errs field.ErrorList) {
+		for k, i := range spec.CacheSpec {
+			if i.ImagesFrom == nil {
+				continue
+			}
+			imagesFromPath := specPath.Child("cacheSpec").Index(k).Child("imagesFrom")
+			if i.ImagesFrom.Name == "" {
+				errs = append(errs, field.Required(imagesFromPath.Child("name"), "Name of the configmap must be specified in imagesFrom"))
+			}
+			if i.ImagesFrom.Key == "" {
+				errs = append(errs, field.Required(imagesFromPath.Child("key"), "Key of the configmap must be specified in imagesFrom"))
+			}
+		}
+		return
+	},
+	// a repository has neither a tag nor a digest, and its tag patterns are well-formed
+	func(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) (errs field.ErrorList) {
+		for k, i := range spec.CacheSpec {
+			for j, r := range i.Repositories {
+				repositoryPath := specPath.Child("cacheSpec").Index(k).Child("repositories").Index(j)
+				if r.Repository == "" || strings.Contains(r.Repository, "@") ||
+					strings.Contains(r.Repository[strings.LastIndex(r.Repository, "/")+1:], ":") {
+					errs = append(errs, field.Invalid(repositoryPath.Child("repository"), r.Repository, "Repository must be specified without a tag or digest"))
+				}
+				for _, tags := range []struct {
+					name     string
+					patterns []string
+				}{{"includeTags", r.IncludeTags}, {"excludeTags", r.ExcludeTags}} {
+					for t, pattern := range tags.patterns {
+						if _, err := path.Match(pattern, ""); err != nil {
+							errs = append(errs, field.Invalid(repositoryPath.Child(tags.name).Index(t), pattern, fmt.Sprintf("Tag pattern is not valid: %v", err)))
+						}
+					}
+				}
+			}
+		}
+		return
+	},
+	// workloadRef needs the name of a deployment, statefulset or daemonset
+	func(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) (errs field.ErrorList) {
+		for k, i := range spec.CacheSpec {
+			if i.WorkloadRef == nil {
+				continue
+			}
+			workloadRefPath := specPath.Child("cacheSpec").Index(k).Child("workloadRef")
+			switch i.WorkloadRef.Kind {
+			case "Deployment", "StatefulSet", "DaemonSet":
+			default:
+				errs = append(errs, field.NotSupported(workloadRefPath.Child("kind"), i.WorkloadRef.Kind, []string{"Deployment", "StatefulSet", "DaemonSet"}))
+			}
+			if i.WorkloadRef.Name == "" {
+				errs = append(errs, field.Required(workloadRefPath.Child("name"), "Name of the workload must be specified in workloadRef"))
+			}
+		}
+		return
+	},
+	// the names of nodeNames are not empty, and listed once
+	func(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) (errs field.ErrorList) {
+		for k, i := range spec.CacheSpec {
+			nodeNamesPath := specPath.Child("cacheSpec").Index(k).Child("nodeNames")
+			for m, name := range i.NodeNames {
+				if name == "" {
+					errs = append(errs, field.Required(nodeNamesPath.Index(m), "Name of the node must not be empty in nodeNames"))
+					continue
+				}
+				for p := 0; p < m; p++ {
+					if i.NodeNames[p] == name {
+						errs = append(errs, field.Duplicate(nodeNamesPath.Index(m), name))
+					}
+				}
+			}
+		}
+		return
+	},
+	// the sizes of imageSizes are positive
+	func(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) (errs field.ErrorList) {
+		for k, i := range spec.CacheSpec {
+			images := make([]string, 0, len(i.ImageSizes))
+			for image := range i.ImageSizes {
+				images = append(images, image)
+			}
+			sort.Strings(images)
+			for _, image := range images {
+				if size := i.ImageSizes[image]; size.Sign() <= 0 {
+					errs = append(errs, field.Invalid(specPath.Child("cacheSpec").Index(k).Child("imageSizes").Key(image),
+						size.String(), "Size of the image must be positive"))
+				}
+			}
+		}
+		return
+	},
+	// caBundle needs both the name and the key of the configmap
+	func(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) (errs field.ErrorList) {
+		if spec.CABundle == nil {
+			return nil
+		}
+		if spec.CABundle.Name == "" {
+			errs = append(errs, field.Required(specPath.Child("caBundle", "name"), "Name of the configmap must be specified in caBundle"))
+		}
+		if spec.CABundle.Key == "" {
+			errs = append(errs, field.Required(specPath.Child("caBundle", "key"), "Key of the configmap must be specified in caBundle"))
+		}
+		return
+	},
+	// a mirror is a registry host, listed once
+	func(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) (errs field.ErrorList) {
+		for m, mirror := range spec.Mirrors {
+			if mirror == "" || strings.Contains(mirror, "/") {
+				errs = append(errs, field.Invalid(specPath.Child("mirrors").Index(m), mirror, "Mirror is not a registry host"))
+			}
+			for p := 0; p < m; p++ {
+				if spec.Mirrors[p] == mirror {
+					errs = append(errs, field.Duplicate(specPath.Child("mirrors").Index(m), mirror))
+				}
+			}
+		}
+		return
+	},
+	// refreshMode is pull or verify
+	func(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) field.ErrorList {
+		switch spec.RefreshMode {
+		case "", fledgedv1alpha2.ImageCacheRefreshModePull, fledgedv1alpha2.ImageCacheRefreshModeVerify:
+			return nil
+		}
+		return field.ErrorList{field.NotSupported(specPath.Child("refreshMode"), spec.RefreshMode,
+			[]string{string(fledgedv1alpha2.ImageCacheRefreshModePull), string(fledgedv1alpha2.ImageCacheRefreshModeVerify)})}
+	},
+	// reconcileMode is additive or declarative
+	func(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) field.ErrorList {
+		switch spec.ReconcileMode {
+		case "", fledgedv1alpha2.ImageCacheReconcileModeAdditive, fledgedv1alpha2.ImageCacheReconcileModeDeclarative:
+			return nil
+		}
+		return field.ErrorList{field.NotSupported(specPath.Child("reconcileMode"), spec.ReconcileMode,
+			[]string{string(fledgedv1alpha2.ImageCacheReconcileModeAdditive), string(fledgedv1alpha2.ImageCacheReconcileModeDeclarative)})}
+	},
+	// failurePolicy is all or partial
+	func(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) field.ErrorList {
+		switch spec.FailurePolicy {
+		case "", fledgedv1alpha2.ImageCacheFailurePolicyAll, fledgedv1alpha2.ImageCacheFailurePolicyPartial:
+			return nil
+		}
+		return field.ErrorList{field.NotSupported(specPath.Child("failurePolicy"), spec.FailurePolicy,
+			[]string{string(fledgedv1alpha2.ImageCacheFailurePolicyAll), string(fledgedv1alpha2.ImageCacheFailurePolicyPartial)})}
+	},
+	// the job deadline and TTL are positive
+	func(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) (errs field.ErrorList) {
+		if spec.JobDeadlineSeconds != nil && *spec.JobDeadlineSeconds <= 0 {
+			errs = append(errs, field.Invalid(specPath.Child("jobDeadlineSeconds"), *spec.JobDeadlineSeconds, "must be positive"))
+		}
+		if spec.JobTTLSeconds != nil && *spec.JobTTLSeconds <= 0 {
+			errs = append(errs, field.Invalid(specPath.Child("jobTTLSeconds"), *spec.JobTTLSeconds, "must be positive"))
+		}
+		return
+	},
+	// minCoveragePercent is a percentage
+	func(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) field.ErrorList {
+		if spec.MinCoveragePercent != nil && (*spec.MinCoveragePercent < 0 || *spec.MinCoveragePercent > 100) {
+			return field.ErrorList{field.Invalid(specPath.Child("minCoveragePercent"), *spec.MinCoveragePercent, "must be between 0 and 100")}
+		}
+		return nil
+	},
+	// maxImageAge and refreshFrequency are positive
+	func(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) (errs field.ErrorList) {
+		if spec.MaxImageAge != nil && spec.MaxImageAge.Duration <= 0 {
+			errs = append(errs, field.Invalid(specPath.Child("maxImageAge"), spec.MaxImageAge.Duration.String(), "must be positive"))
+		}
+		if spec.RefreshFrequency != nil && spec.RefreshFrequency.Duration <= 0 {
+			errs = append(errs, field.Invalid(specPath.Child("refreshFrequency"), spec.RefreshFrequency.Duration.String(), "must be positive"))
+		}
+		return
+	},
+	// preWarm needs the name of a CronJob, and its lead and purge delay are positive
+	func(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) (errs field.ErrorList) {
+		if spec.PreWarm == nil {
+			return nil
+		}
+		preWarmPath := specPath.Child("preWarm")
+		if spec.PreWarm.CronJob == "" {
+			errs = append(errs, field.Required(preWarmPath.Child("cronJob"), "Name of the CronJob must be specified in preWarm"))
+		}
+		if spec.PreWarm.LeadMinutes != nil && *spec.PreWarm.LeadMinutes <= 0 {
+			errs = append(errs, field.Invalid(preWarmPath.Child("leadMinutes"), *spec.PreWarm.LeadMinutes, "must be positive"))
+		}
+		if spec.PreWarm.PurgeAfterMinutes != nil && *spec.PreWarm.PurgeAfterMinutes <= 0 {
+			errs = append(errs, field.Invalid(preWarmPath.Child("purgeAfterMinutes"), *spec.PreWarm.PurgeAfterMinutes, "must be positive"))
+		}
+		return
+	},
+	// jobTemplate needs the name of a PodTemplate
+	func(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) field.ErrorList {
+		if spec.JobTemplate != nil && spec.JobTemplate.Name == "" {
+			return field.ErrorList{field.Required(specPath.Child("jobTemplate").Child("name"), "Name of the PodTemplate must be specified in jobTemplate")}
+		}
+		return nil
+	},
+	// schedulerName is a valid scheduler name, as for the schedulerName of a pod
+	func(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) (errs field.ErrorList) {
+		if spec.SchedulerName == "" {
+			return nil
+		}
+		for _, msg := range validation.IsDNS1123Subdomain(spec.SchedulerName) {
+			errs = append(errs, field.Invalid(specPath.Child("schedulerName"), spec.SchedulerName, msg))
+		}
+		return
+	},
+	// an image pull secret needs a name
+	func(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) (errs field.ErrorList) {
+		for k, s := range spec.ImagePullSecrets {
+			if s.Name == "" {
+				errs = append(errs, field.Required(specPath.Child("imagePullSecrets").Index(k).Child("name"), "Name of the secret must be specified in imagePullSecrets"))
+			}
+		}
+		return
+	},
+}
+
+// validateSpecRules checks the spec against all the spec rules. The errors of all the
+// violated rules are returned together, so that they can be fixed in one go
+func validateSpecRules(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for _, rule := range specRules {
+		errs = append(errs, rule(spec, specPath)...)
+	}
+	return errs
+}
+
+// validateImageLists checks the no. of images of the image cache, and that no image list has
+// duplicate images
+func validateImageLists(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if MaxImagesPerCache > 0 {
+		noOfImages := 0
+		for _, i := range spec.CacheSpec {
+			noOfImages += len(i.Images)
+		}
+		if noOfImages > MaxImagesPerCache {
+			errs = append(errs, field.Forbidden(specPath.Child("cacheSpec"), fmt.Sprintf(
+				"No. of images (%d) exceeds the maximum allowed per image cache (%d). Split the images into multiple image caches",
+				noOfImages, MaxImagesPerCache)))
+		}
+	}
+	for k, i := range spec.CacheSpec {
+		for m := range i.Images {
+			for p := 0; p < m; p++ {
+				if i.Images[p] == i.Images[m] {
+					errs = append(errs, field.Duplicate(specPath.Child("cacheSpec").Index(k).Child("images").Index(m), i.Images[m]))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// validateImageCacheUpdate checks that an update of the image cache changes neither the no. of its
+// image lists, nor the node selector, workload ref or node names of any image list
+func validateImageCacheUpdate(spec, oldSpec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path) field.ErrorList {
+	if len(oldSpec.CacheSpec) != len(spec.CacheSpec) {
+		return field.ErrorList{field.Forbidden(specPath.Child("cacheSpec"), "Mismatch in no. of image lists")}
+	}
+	var errs field.ErrorList
+	for k := range oldSpec.CacheSpec {
+		imageListPath := specPath.Child("cacheSpec").Index(k)
+		if !reflect.DeepEqual(oldSpec.CacheSpec[k].NodeSelector, spec.CacheSpec[k].NodeSelector) {
+			errs = append(errs, field.Forbidden(imageListPath.Child("nodeSelector"), "Mismatch in node selector"))
+		}
+		if !reflect.DeepEqual(oldSpec.CacheSpec[k].WorkloadRef, spec.CacheSpec[k].WorkloadRef) {
+			errs = append(errs, field.Forbidden(imageListPath.Child("workloadRef"), "Mismatch in workload ref"))
+		}
+		if !reflect.DeepEqual(oldSpec.CacheSpec[k].NodeNames, spec.CacheSpec[k].NodeNames) {
+			errs = append(errs, field.Forbidden(imageListPath.Child("nodeNames"), "Mismatch in node names"))
+		}
+	}
+	return errs
+}
+
 // MutateImageCache modifies image cache resource
 /*
 func MutateImageCache(ar v1.AdmissionReview) *v1.AdmissionResponse {
@@ -98,53 +445,75 @@ func ValidateImageCache(ar v1.AdmissionReview) *v1.AdmissionResponse {
 		}
 	}
 
-	cacheSpec := imageCache.Spec.CacheSpec
-	glog.V(4).Infof("cacheSpec: %+v", cacheSpec)
+	glog.V(4).Infof("cacheSpec: %+v", imageCache.Spec.CacheSpec)
 
-	for _, i := range cacheSpec {
-		if len(i.Images) == 0 {
-			glog.Error("No images specified within image list")
-			return toV1AdmissionResponse(fmt.Errorf("No images specified within image list"))
+	specPath := field.NewPath("spec")
+	errs := validateImageLists(&imageCache.Spec, specPath)
+	errs = append(errs, validateSpecRules(&imageCache.Spec, specPath)...)
+	if ar.Request.Operation == v1.Update {
+		errs = append(errs, validateImageCacheUpdate(&imageCache.Spec, &oldImageCache.Spec, specPath)...)
+	}
+	if len(errs) > 0 {
+		glog.Errorf("Image cache spec is not valid: %v", errs.ToAggregate())
+		return toV1InvalidAdmissionResponse(&imageCache, errs)
+	}
+
+	policy := ImageRegistryPolicy
+	if RegistryPolicyConfigMap.Name != "" && KubeClient != nil {
+		var configMap *corev1.ConfigMap
+		// the failure policy is not applied: a registry policy which can't be read denies all the registries
+		err := retry.OnError(lookupBackoff, images.IsTransientAPIError, func() (err error) {
+			configMap, err = KubeClient.CoreV1().ConfigMaps(RegistryPolicyConfigMap.Namespace).Get(context.TODO(), RegistryPolicyConfigMap.Name, metav1.GetOptions{})
+			return
+		})
+		if err != nil {
+			glog.Errorf("Error getting ConfigMap %s of the registry policy: %v", RegistryPolicyConfigMap.Name, err)
+			return toV1AdmissionResponse(fmt.Errorf("Error getting ConfigMap %s of the registry policy: %v", RegistryPolicyConfigMap.Name, err))
 		}
+		if policy, err = policy.withConfigMap(configMap); err != nil {
+			glog.Errorf("Registry policy is not valid: %v", err)
+			return toV1AdmissionResponse(fmt.Errorf("Registry policy is not valid: %v", err))
+		}
+	}
+	if errs := validateImageRegistries(&imageCache.Spec, specPath, policy); len(errs) > 0 {
+		glog.Errorf("Image cache refers to denied registries: %v", errs.ToAggregate())
+		return toV1InvalidAdmissionResponse(&imageCache, errs)
+	}
 
-		for m := range i.Images {
-			for p := 0; p < m; p++ {
-				if i.Images[p] == i.Images[m] {
-					glog.Errorf("Duplicate image names within image list: %s", i.Images[m])
-					return toV1AdmissionResponse(fmt.Errorf("Duplicate image names within image list: %s", i.Images[m]))
-				}
+	if runtimeClassName := imageCache.Spec.RuntimeClassName; runtimeClassName != nil && *runtimeClassName != "" && KubeClient != nil {
+		err := lookup("RuntimeClass "+*runtimeClassName, func() error {
+			_, err := KubeClient.NodeV1().RuntimeClasses().Get(context.TODO(), *runtimeClassName, metav1.GetOptions{})
+			return err
+		})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				glog.Errorf("RuntimeClass %s not found", *runtimeClassName)
+				return toV1InvalidAdmissionResponse(&imageCache, field.ErrorList{field.NotFound(specPath.Child("runtimeClassName"), *runtimeClassName)})
 			}
+			glog.Errorf("Error getting RuntimeClass %s: %v", *runtimeClassName, err)
+			return toV1AdmissionResponse(fmt.Errorf("Error getting RuntimeClass %s: %v", *runtimeClassName, err))
 		}
-		/*
-			if len(i.NodeSelector) > 0 {
-				if nodes, err = c.nodesLister.List(labels.Set(i.NodeSelector).AsSelector()); err != nil {
-					glog.Errorf("Error listing nodes using nodeselector %+v: %v", i.NodeSelector, err)
-					return err
-				}
-			} else {
-				if nodes, err = c.nodesLister.List(labels.Everything()); err != nil {
-					glog.Errorf("Error listing nodes using nodeselector labels.Everything(): %v", err)
-					return err
-				}
-			}
-			glog.V(4).Infof("No. of nodes in %+v is %d", i.NodeSelector, len(nodes))
-			if len(nodes) == 0 {
-				glog.Errorf("NodeSelector %s did not match any nodes.", labels.Set(i.NodeSelector).String())
-				return fmt.Errorf("NodeSelector %s did not match any nodes", labels.Set(i.NodeSelector).String())
-			}
-		*/
 	}
 
-	if ar.Request.Operation == v1.Update {
-		if len(oldImageCache.Spec.CacheSpec) != len(imageCache.Spec.CacheSpec) {
-			glog.Errorf("Mismatch in no. of image lists")
-			return toV1AdmissionResponse(fmt.Errorf("Mismatch in no. of image lists"))
+	if caBundle := imageCache.Spec.CABundle; caBundle != nil && KubeClient != nil && (caBundle.Optional == nil || !*caBundle.Optional) {
+		var configMap *corev1.ConfigMap
+		err := lookup("ConfigMap "+caBundle.Name, func() (err error) {
+			configMap, err = KubeClient.CoreV1().ConfigMaps(imageCache.Namespace).Get(context.TODO(), caBundle.Name, metav1.GetOptions{})
+			return
+		})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				glog.Errorf("ConfigMap %s of caBundle not found", caBundle.Name)
+				return toV1InvalidAdmissionResponse(&imageCache, field.ErrorList{field.NotFound(specPath.Child("caBundle", "name"), caBundle.Name)})
+			}
+			glog.Errorf("Error getting ConfigMap %s of caBundle: %v", caBundle.Name, err)
+			return toV1AdmissionResponse(fmt.Errorf("Error getting ConfigMap %s of caBundle: %v", caBundle.Name, err))
 		}
-
-		for i := range oldImageCache.Spec.CacheSpec {
-			if !reflect.DeepEqual(oldImageCache.Spec.CacheSpec[i].NodeSelector, imageCache.Spec.CacheSpec[i].NodeSelector) {
-				glog.Errorf("Mismatch in node selector")
-				return toV1AdmissionResponse(fmt.Errorf("Mismatch in node selector"))
+		// configMap is nil if the lookup failed and was ignored
+		if configMap != nil {
+			if _, ok := configMap.Data[caBundle.Key]; !ok {
+				glog.Errorf("Key %s not found in ConfigMap %s of caBundle", caBundle.Key, caBundle.Name)
+				return toV1InvalidAdmissionResponse(&imageCache, field.ErrorList{field.NotFound(specPath.Child("caBundle", "key"), caBundle.Key)})
 			}
 		}
 	}
@@ -153,6 +522,15 @@ func ValidateImageCache(ar v1.AdmissionReview) *v1.AdmissionResponse {
 	return &reviewResponse
 }
 
+// toV1InvalidAdmissionResponse rejects the image cache with the errors of its fields, as the api server
+// rejects an invalid object
+func toV1InvalidAdmissionResponse(imageCache *fledgedv1alpha2.ImageCache, errs field.ErrorList) *v1.AdmissionResponse {
+	err := apierrors.NewInvalid(fledgedv1alpha2.Kind("ImageCache"), imageCache.Name, errs)
+	return &v1.AdmissionResponse{
+		Result: &err.ErrStatus,
+	}
+}
+
 func toV1AdmissionResponse(err error) *v1.AdmissionResponse {
 	return &v1.AdmissionResponse{
 		Result: &metav1.Status{
@@ -0,0 +1,220 @@
+/*
+Copyright 2026 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	fledgedv1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	v1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestValidateImageCacheFieldPaths(t *testing.T) {
+	images := func(images ...string) fledgedv1alpha2.CacheSpecImages {
+		return fledgedv1alpha2.CacheSpecImages{Images: images}
+	}
+	zero := int64(0)
+	overPercent := int32(101)
+	tests := []struct {
+		name           string
+		spec           fledgedv1alpha2.ImageCacheSpec
+		oldSpec        *fledgedv1alpha2.ImageCacheSpec
+		maxImages      int
+		expectedFields []string
+		// expectedMessage is the message of the first cause, if set
+		expectedMessage string
+	}{
+		{
+			name:           "#1: Valid image cache",
+			spec:           fledgedv1alpha2.ImageCacheSpec{CacheSpec: []fledgedv1alpha2.CacheSpecImages{images("foo:v1")}},
+			expectedFields: nil,
+		},
+		{
+			name: "#2: Duplicate image",
+			spec: fledgedv1alpha2.ImageCacheSpec{CacheSpec: []fledgedv1alpha2.CacheSpecImages{
+				images("foo:v1"), images("foo:v1", "bar:v1", "foo:v1")}},
+			expectedFields: []string{"spec.cacheSpec[1].images[2]"},
+		},
+		{
+			name: "#3: Empty image list and repository with a tag",
+			spec: fledgedv1alpha2.ImageCacheSpec{CacheSpec: []fledgedv1alpha2.CacheSpecImages{
+				images("foo:v1"), images(),
+				{Repositories: []fledgedv1alpha2.CacheSpecRepository{{Repository: "foo"}, {Repository: "bar:v1", ExcludeTags: []string{"v1", "["}}}}}},
+			expectedFields: []string{"spec.cacheSpec[1].images", "spec.cacheSpec[2].repositories[1].repository",
+				"spec.cacheSpec[2].repositories[1].excludeTags[1]"},
+		},
+		{
+			name: "#4: Spec fields",
+			spec: fledgedv1alpha2.ImageCacheSpec{
+				CacheSpec: []fledgedv1alpha2.CacheSpecImages{{Images: []string{"foo:v1"},
+					WorkloadRef: &fledgedv1alpha2.CacheSpecWorkloadRef{Kind: "Job"}}},
+				Mirrors:            []string{"mirror.io", "mirror.io/foo", "mirror.io"},
+				JobDeadlineSeconds: &zero,
+				RefreshMode:        "check",
+				ReconcileMode:      "strict",
+				MinCoveragePercent: &overPercent,
+				JobTemplate:        &corev1.LocalObjectReference{},
+				FailurePolicy:      "none",
+				MaxImageAge:        &metav1.Duration{},
+				RefreshFrequency:   &metav1.Duration{Duration: -time.Minute},
+			},
+			expectedFields: []string{"spec.cacheSpec[0].workloadRef.kind", "spec.cacheSpec[0].workloadRef.name",
+				"spec.mirrors[1]", "spec.mirrors[2]", "spec.refreshMode", "spec.reconcileMode", "spec.failurePolicy",
+				"spec.jobDeadlineSeconds", "spec.minCoveragePercent", "spec.maxImageAge", "spec.refreshFrequency", "spec.jobTemplate.name"},
+		},
+		{
+			name:           "#5: Too many images",
+			spec:           fledgedv1alpha2.ImageCacheSpec{CacheSpec: []fledgedv1alpha2.CacheSpecImages{images("foo:v1"), images("bar:v1")}},
+			maxImages:      1,
+			expectedFields: []string{"spec.cacheSpec"},
+			expectedMessage: "Forbidden: No. of images (2) exceeds the maximum allowed per image cache (1). " +
+				"Split the images into multiple image caches",
+		},
+		{
+			name: "#6: Node selector changed by update",
+			spec: fledgedv1alpha2.ImageCacheSpec{CacheSpec: []fledgedv1alpha2.CacheSpecImages{
+				images("foo:v1"), {Images: []string{"bar:v2"}, NodeSelector: map[string]string{"pool": "gpu"}}}},
+			oldSpec: &fledgedv1alpha2.ImageCacheSpec{CacheSpec: []fledgedv1alpha2.CacheSpecImages{
+				images("foo:v1"), images("bar:v1")}},
+			expectedFields: []string{"spec.cacheSpec[1].nodeSelector"},
+		},
+		{
+			name: "#7: Empty and duplicate node names",
+			spec: fledgedv1alpha2.ImageCacheSpec{CacheSpec: []fledgedv1alpha2.CacheSpecImages{
+				{Images: []string{"foo:v1"}, NodeNames: []string{"node1", "", "node2", "node1"}}}},
+			expectedFields: []string{"spec.cacheSpec[0].nodeNames[1]", "spec.cacheSpec[0].nodeNames[3]"},
+		},
+		{
+			name: "#8: Node names changed by update",
+			spec: fledgedv1alpha2.ImageCacheSpec{CacheSpec: []fledgedv1alpha2.CacheSpecImages{
+				{Images: []string{"foo:v1"}, NodeNames: []string{"node1", "node2"}}}},
+			oldSpec: &fledgedv1alpha2.ImageCacheSpec{CacheSpec: []fledgedv1alpha2.CacheSpecImages{
+				{Images: []string{"foo:v1"}, NodeNames: []string{"node1"}}}},
+			expectedFields: []string{"spec.cacheSpec[0].nodeNames"},
+		},
+		{
+			name: "#9: Image sizes not positive",
+			spec: fledgedv1alpha2.ImageCacheSpec{CacheSpec: []fledgedv1alpha2.CacheSpecImages{
+				{Images: []string{"foo:v1", "bar:v1", "baz:v1"}, ImageSizes: map[string]resource.Quantity{
+					"foo:v1": resource.MustParse("6Gi"), "bar:v1": resource.MustParse("0"), "baz:v1": resource.MustParse("-1Mi")}}}},
+			expectedFields: []string{"spec.cacheSpec[0].imageSizes[bar:v1]", "spec.cacheSpec[0].imageSizes[baz:v1]"},
+		},
+		{
+			name: "#10: Scheduler name not valid",
+			spec: fledgedv1alpha2.ImageCacheSpec{CacheSpec: []fledgedv1alpha2.CacheSpecImages{images("foo:v1")},
+				SchedulerName: "Batch_Scheduler"},
+			expectedFields: []string{"spec.schedulerName"},
+		},
+		{
+			name: "#11: Valid scheduler name",
+			spec: fledgedv1alpha2.ImageCacheSpec{CacheSpec: []fledgedv1alpha2.CacheSpecImages{images("foo:v1")},
+				SchedulerName: "batch-scheduler"},
+			expectedFields: nil,
+		},
+	}
+	defer func() { MaxImagesPerCache = 0 }()
+	for _, test := range tests {
+		MaxImagesPerCache = test.maxImages
+		ar := v1.AdmissionReview{Request: &v1.AdmissionRequest{Operation: v1.Create}}
+		ar.Request.Object = rawImageCache(t, test.spec)
+		if test.oldSpec != nil {
+			ar.Request.Operation = v1.Update
+			ar.Request.OldObject = rawImageCache(t, *test.oldSpec)
+		}
+		response := ValidateImageCache(ar)
+		if test.expectedFields == nil {
+			if !response.Allowed {
+				t.Errorf("Test: %s failed: image cache rejected: %s", test.name, response.Result.Message)
+			}
+			continue
+		}
+		if response.Allowed || response.Result == nil || response.Result.Reason != metav1.StatusReasonInvalid || response.Result.Details == nil {
+			t.Errorf("Test: %s failed: image cache not rejected as invalid: %+v", test.name, response)
+			continue
+		}
+		var fields []string
+		for _, cause := range response.Result.Details.Causes {
+			fields = append(fields, cause.Field)
+		}
+		if !reflect.DeepEqual(fields, test.expectedFields) {
+			t.Errorf("Test: %s failed: expectedFields=%v, actualFields=%v (%s)", test.name, test.expectedFields, fields, response.Result.Message)
+		}
+		if test.expectedMessage != "" && response.Result.Details.Causes[0].Message != test.expectedMessage {
+			t.Errorf("Test: %s failed: expectedMessage=%q, actualMessage=%q", test.name, test.expectedMessage, response.Result.Details.Causes[0].Message)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	defer func(policy FailurePolicy, backoff wait.Backoff) {
+		LookupFailurePolicy, lookupBackoff = policy, backoff
+	}(LookupFailurePolicy, lookupBackoff)
+	lookupBackoff = wait.Backoff{Steps: 3, Duration: time.Millisecond, Factor: 1.0}
+	timeout := apierrors.NewServerTimeout(corev1.Resource("configmaps"), "get", 1)
+	notFound := apierrors.NewNotFound(corev1.Resource("configmaps"), "foo")
+	tests := []struct {
+		name          string
+		policy        FailurePolicy
+		errs          []error
+		expectedErr   error
+		expectedCalls int
+	}{
+		{name: "#1: Fail - Successful", policy: FailurePolicyFail, errs: []error{nil}, expectedErr: nil, expectedCalls: 1},
+		{name: "#2: Fail - Transient error retried then successful", policy: FailurePolicyFail, errs: []error{timeout, nil}, expectedErr: nil, expectedCalls: 2},
+		{name: "#3: Fail - Transient error retries exhausted", policy: FailurePolicyFail, errs: []error{timeout, timeout, timeout}, expectedErr: timeout, expectedCalls: 3},
+		{name: "#4: Fail - Not found not retried", policy: FailurePolicyFail, errs: []error{notFound}, expectedErr: notFound, expectedCalls: 1},
+		{name: "#5: Ignore - Successful", policy: FailurePolicyIgnore, errs: []error{nil}, expectedErr: nil, expectedCalls: 1},
+		{name: "#6: Ignore - Transient error retried then successful", policy: FailurePolicyIgnore, errs: []error{timeout, nil}, expectedErr: nil, expectedCalls: 2},
+		{name: "#7: Ignore - Transient error retries exhausted, ignored", policy: FailurePolicyIgnore, errs: []error{timeout, timeout, timeout}, expectedErr: nil, expectedCalls: 3},
+		{name: "#8: Ignore - Not found not ignored", policy: FailurePolicyIgnore, errs: []error{notFound}, expectedErr: notFound, expectedCalls: 1},
+	}
+	for _, test := range tests {
+		LookupFailurePolicy = test.policy
+		calls := 0
+		err := lookup("ConfigMap foo", func() error {
+			if calls >= len(test.errs) {
+				return fmt.Errorf("unexpected call #%d", calls+1)
+			}
+			calls++
+			return test.errs[calls-1]
+		})
+		if err != test.expectedErr {
+			t.Errorf("Test: %s failed: expectedError=%v, actualError=%v", test.name, test.expectedErr, err)
+		}
+		if calls != test.expectedCalls {
+			t.Errorf("Test: %s failed: expectedCalls=%d, actualCalls=%d", test.name, test.expectedCalls, calls)
+		}
+	}
+}
+
+func rawImageCache(t *testing.T, spec fledgedv1alpha2.ImageCacheSpec) runtime.RawExtension {
+	raw, err := json.Marshal(fledgedv1alpha2.ImageCache{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "kube-fledged"}, Spec: spec})
+	if err != nil {
+		t.Fatalf("Error marshalling image cache: %v", err)
+	}
+	return runtime.RawExtension{Raw: raw}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2018 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	fledgedv1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	"github.com/senthilrch/kube-fledged/pkg/images"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Keys of the registry policy ConfigMap, each with one regular expression per line
+const (
+	allowedRegistriesKey = "allowed-registries"
+	deniedRegistriesKey  = "denied-registries"
+)
+
+// RegistryPolicy has the regular expressions of the registries from which the images of the image caches may
+// be cached. A registry matching a denied expression is denied. If there are allowed expressions, a registry
+// matching none of them is denied too. The expressions match the whole registry host e.g. `.*\.example\.com`
+type RegistryPolicy struct {
+	Allowed []*regexp.Regexp
+	Denied  []*regexp.Regexp
+}
+
+// ImageRegistryPolicy is the registry policy of the flags of the webhook server
+var ImageRegistryPolicy RegistryPolicy
+
+// RegistryPolicyConfigMap is the namespace and name of a ConfigMap extending the registry policy, read on every
+// admission so that its changes apply right away. No ConfigMap is read if the name is empty
+var RegistryPolicyConfigMap struct {
+	Namespace string
+	Name      string
+}
+
+// ParseRegistryPatterns compiles the regular expressions of registries, anchored to match the whole
+// registry host. Empty expressions and lines starting with # are skipped
+func ParseRegistryPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var regexps []*regexp.Regexp
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid registry pattern %q: %v", pattern, err)
+		}
+		regexps = append(regexps, re)
+	}
+	return regexps, nil
+}
+
+// registryPattern returns the regular expression of the registries as it was configured, without its anchors
+func registryPattern(re *regexp.Regexp) string {
+	return strings.TrimSuffix(strings.TrimPrefix(re.String(), "^(?:"), ")$")
+}
+
+// withConfigMap returns the policy extended with the expressions of the ConfigMap
+func (p RegistryPolicy) withConfigMap(configMap *corev1.ConfigMap) (RegistryPolicy, error) {
+	allowed, err := ParseRegistryPatterns(strings.Split(configMap.Data[allowedRegistriesKey], "\n"))
+	if err != nil {
+		return p, fmt.Errorf("ConfigMap %s/%s: %v", configMap.Namespace, configMap.Name, err)
+	}
+	denied, err := ParseRegistryPatterns(strings.Split(configMap.Data[deniedRegistriesKey], "\n"))
+	if err != nil {
+		return p, fmt.Errorf("ConfigMap %s/%s: %v", configMap.Namespace, configMap.Name, err)
+	}
+	return RegistryPolicy{
+		Allowed: append(append([]*regexp.Regexp{}, p.Allowed...), allowed...),
+		Denied:  append(append([]*regexp.Regexp{}, p.Denied...), denied...),
+	}, nil
+}
+
+// registryDenied returns why the policy denies the registry, or an empty string if the registry is allowed
+func (p RegistryPolicy) registryDenied(registry string) string {
+	for _, re := range p.Denied {
+		if re.MatchString(registry) {
+			return fmt.Sprintf("Registry %s is denied by the registry policy (%s)", registry, registryPattern(re))
+		}
+	}
+	if len(p.Allowed) == 0 {
+		return ""
+	}
+	for _, re := range p.Allowed {
+		if re.MatchString(registry) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("Registry %s is not allowed by the registry policy", registry)
+}
+
+// validateImageRegistries checks the registries of the images and repositories of the image lists against
+// the policy. Images without a registry are on docker.io
+func validateImageRegistries(spec *fledgedv1alpha2.ImageCacheSpec, specPath *field.Path, policy RegistryPolicy) field.ErrorList {
+	if len(policy.Allowed) == 0 && len(policy.Denied) == 0 {
+		return nil
+	}
+	var errs field.ErrorList
+	for k, i := range spec.CacheSpec {
+		imageListPath := specPath.Child("cacheSpec").Index(k)
+		for m, image := range i.Images {
+			if reason := policy.registryDenied(images.RegistryHost(image)); reason != "" {
+				errs = append(errs, field.Forbidden(imageListPath.Child("images").Index(m), reason))
+			}
+		}
+		for j, r := range i.Repositories {
+			if reason := policy.registryDenied(images.RegistryHost(r.Repository)); reason != "" {
+				errs = append(errs, field.Forbidden(imageListPath.Child("repositories").Index(j).Child("repository"), reason))
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2018 The kube-fledged authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	fledgedv1alpha2 "github.com/senthilrch/kube-fledged/pkg/apis/kubefledged/v1alpha2"
+	v1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+)
+
+func TestValidateImageRegistries(t *testing.T) {
+	mustParse := func(patterns ...string) RegistryPolicy {
+		regexps, err := ParseRegistryPatterns(patterns)
+		if err != nil {
+			t.Fatalf("Test: parsing registry patterns failed: %v", err)
+		}
+		return RegistryPolicy{Allowed: regexps}
+	}
+	spec := fledgedv1alpha2.ImageCacheSpec{CacheSpec: []fledgedv1alpha2.CacheSpecImages{
+		{Images: []string{"nginx:1.23", "registry.example.com/app:v1", "quay.io/prometheus/node-exporter:v1.5.0"}},
+		{Repositories: []fledgedv1alpha2.CacheSpecRepository{{Repository: "ghcr.io/org/app"}}},
+	}}
+	tests := []struct {
+		name           string
+		allowed        []string
+		denied         []string
+		expectedFields []string
+	}{
+		{name: "#1: No registry policy", expectedFields: nil},
+		{name: "#2: Allowed registries", allowed: []string{"registry.example.com", "quay.io"},
+			expectedFields: []string{"spec.cacheSpec[0].images[0]", "spec.cacheSpec[1].repositories[0].repository"}},
+		{name: "#3: Denied registries", denied: []string{"docker.io", "ghcr\\.io"},
+			expectedFields: []string{"spec.cacheSpec[0].images[0]", "spec.cacheSpec[1].repositories[0].repository"}},
+		{name: "#4: Denied registry among the allowed ones", allowed: []string{".*\\.io", ".*\\.com"}, denied: []string{"quay.io"},
+			expectedFields: []string{"spec.cacheSpec[0].images[2]"}},
+		{name: "#5: Patterns match the whole registry host", allowed: []string{"example.com", "# comment", ".*"}, denied: []string{"registry"},
+			expectedFields: nil},
+	}
+	for _, test := range tests {
+		policy := mustParse(test.allowed...)
+		policy.Denied = mustParse(test.denied...).Allowed
+		var fields []string
+		for _, err := range validateImageRegistries(&spec, field.NewPath("spec"), policy) {
+			fields = append(fields, err.Field)
+		}
+		if !reflect.DeepEqual(fields, test.expectedFields) {
+			t.Errorf("Test: %s failed: expectedFields=%v, actualFields=%v", test.name, test.expectedFields, fields)
+		}
+	}
+
+	if _, err := ParseRegistryPatterns([]string{"registry[.example.com"}); err == nil {
+		t.Errorf("Test: invalid registry pattern failed: expectedError=<error>, actualError=nil")
+	}
+}
+
+func TestValidateImageCacheRegistryPolicy(t *testing.T) {
+	defer func() {
+		ImageRegistryPolicy, KubeClient = RegistryPolicy{}, nil
+		RegistryPolicyConfigMap.Namespace, RegistryPolicyConfigMap.Name = "", ""
+	}()
+	denied, _ := ParseRegistryPatterns([]string{"docker.io"})
+	ImageRegistryPolicy = RegistryPolicy{Denied: denied}
+	RegistryPolicyConfigMap.Namespace, RegistryPolicyConfigMap.Name = "kube-fledged", "registry-policy"
+	KubeClient = fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "registry-policy", Namespace: "kube-fledged"},
+		Data:       map[string]string{allowedRegistriesKey: "# trusted registries\nregistry.example.com\n.*\\.azurecr\\.io\n"},
+	})
+	tests := []struct {
+		name     string
+		images   []string
+		allowed  bool
+		expected string
+	}{
+		{name: "#1: Allowed registries", images: []string{"registry.example.com/app:v1", "team.azurecr.io/app:v1"}, allowed: true},
+		{name: "#2: Registry denied by the flags", images: []string{"nginx:1.23"}, allowed: false,
+			expected: "Registry docker.io is denied by the registry policy (docker.io)"},
+		{name: "#3: Registry not allowed by the ConfigMap", images: []string{"quay.io/app:v1"}, allowed: false,
+			expected: "Registry quay.io is not allowed by the registry policy"},
+	}
+	for _, test := range tests {
+		ar := v1.AdmissionReview{Request: &v1.AdmissionRequest{Operation: v1.Create}}
+		ar.Request.Object = rawImageCache(t, fledgedv1alpha2.ImageCacheSpec{CacheSpec: []fledgedv1alpha2.CacheSpecImages{{Images: test.images}}})
+		response := ValidateImageCache(ar)
+		if response.Allowed != test.allowed {
+			t.Errorf("Test: %s failed: expectedAllowed=%t, actualAllowed=%t", test.name, test.allowed, response.Allowed)
+			continue
+		}
+		if test.allowed {
+			continue
+		}
+		if response.Result == nil || response.Result.Details == nil || len(response.Result.Details.Causes) != 1 ||
+			response.Result.Details.Causes[0].Message != "Forbidden: "+test.expected {
+			t.Errorf("Test: %s failed: expectedMessage=%q, actualResult=%+v", test.name, test.expected, response.Result)
+		}
+	}
+
+	RegistryPolicyConfigMap.Name = "missing"
+	ar := v1.AdmissionReview{Request: &v1.AdmissionRequest{Operation: v1.Create}}
+	ar.Request.Object = rawImageCache(t, fledgedv1alpha2.ImageCacheSpec{CacheSpec: []fledgedv1alpha2.CacheSpecImages{{Images: []string{"registry.example.com/app:v1"}}}})
+	if response := ValidateImageCache(ar); response.Allowed {
+		t.Errorf("Test: missing registry policy ConfigMap failed: expectedAllowed=false, actualAllowed=true")
+	}
+
+	// a registry policy which can't be read denies all the registries, even if lookup failures are ignored
+	defer func(policy FailurePolicy, backoff wait.Backoff) {
+		LookupFailurePolicy, lookupBackoff = policy, backoff
+	}(LookupFailurePolicy, lookupBackoff)
+	LookupFailurePolicy = FailurePolicyIgnore
+	lookupBackoff = wait.Backoff{Steps: 2, Duration: time.Millisecond, Factor: 1.0}
+	RegistryPolicyConfigMap.Name = "registry-policy"
+	fakeClient := fake.NewSimpleClientset()
+	fakeClient.PrependReactor("get", "configmaps", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewServerTimeout(corev1.Resource("configmaps"), "get", 1)
+	})
+	KubeClient = fakeClient
+	if response := ValidateImageCache(ar); response.Allowed {
+		t.Errorf("Test: registry policy ConfigMap timing out failed: expectedAllowed=false, actualAllowed=true")
+	}
+}